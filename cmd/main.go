@@ -1,14 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/mail"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"scraper/internal/dashboard"
+	"scraper/internal/export"
 	"scraper/internal/notification"
+	"scraper/internal/report"
 	"scraper/internal/scraper"
 	"scraper/internal/storage"
 )
@@ -18,17 +29,41 @@ func main() {
 	var (
 		testConnection = flag.Bool("test", false, "Test connection to the website")
 		testEmail      = flag.Bool("test-email", false, "Test email configuration")
+		sample         = flag.String("sample", "", "With --test-email, also send a sample of every notification built from a fake contract (fake) or the latest stored one (latest)")
+		sampleOutput   = flag.String("sample-output", "", "With --test-email, write the sample emails as HTML files into this directory instead of sending anything")
 		scrapeSelenium = flag.Bool("scrape-selenium", false, "Run the Selenium-based scraper (requires Selenium server)")
 		scrapeCLI      = flag.Bool("scrape-cli", false, "Run the CLI-only scraper (headless Selenium, requires Selenium server)")
 		debugSelenium  = flag.Bool("debug-selenium", false, "Debug Selenium page structure (navigates to page and analyzes it)")
 		serve          = flag.Bool("serve", false, "Start the web dashboard")
-		dbPath         = flag.String("db", "contracts.db", "Database file path")
+		dbPath         = flag.String("db", "contracts.db", "Database file path (or DSN when --db-driver is mysql)")
+		dbDriver       = flag.String("db-driver", "sqlite3", "Database driver: sqlite3 or mysql")
 		port           = flag.String("port", "8080", "Dashboard port")
+		bindAddress    = flag.String("bind", "", "Dashboard address to listen on, e.g. 127.0.0.1 behind a reverse proxy (default: every interface)")
+		basePath       = flag.String("base-path", "", "URL prefix the dashboard is served under behind a reverse proxy, e.g. /licitaciones")
+		purgeDeleted   = flag.Bool("purge-deleted", false, "Permanently remove soft-deleted contracts older than --purge-after")
+		exportDir      = flag.String("export", "", "Export contracts and status changes as gzipped CSV into this directory")
+		reportPath     = flag.String("report", "", "Write an Excel report (active contracts, status changes, upcoming deadlines) to this path")
+		emailReport    = flag.Bool("email-report", false, "Email the Excel report to TO_EMAIL")
+		backupPath     = flag.String("backup", "", "Write a consistent snapshot of the SQLite database to this path")
+		restorePath    = flag.String("restore", "", "Replace the SQLite database with the backup at this path")
+		backupDir      = flag.String("backup-dir", "backups", "Directory for scheduled backups and pre-restore snapshots")
+		backupInterval = flag.Duration("backup-interval", 0, "With --serve, back up the database into --backup-dir at this interval (e.g. 24h)")
+		devAssets      = flag.String("dev-assets", "", "With --serve, reload the dashboard templates and static files from this directory on every request (e.g. internal/dashboard)")
+		prune          = flag.Bool("prune", false, "Remove data older than the retention policy (see RETENTION_* environment variables)")
+		purgeAfter     = flag.Duration("purge-after", 30*24*time.Hour, "How long soft-deleted contracts are kept before --purge-deleted removes them")
+		profileName    = flag.String("profile", storage.DefaultProfile, "Search profile to scrape into; created on first use")
+		cpvCode        = flag.String("cpv", "", "CPV code searched by --profile, saved with the profile")
+		listProfiles   = flag.Bool("list-profiles", false, "List the search profiles and their number of contracts")
+		deleteProfile  = flag.String("delete-profile", "", "Permanently delete a search profile and all of its contracts")
+		desktopNotify  = flag.Bool("desktop-notify", false, "Also show new and watched contracts as desktop notifications (notify-send, osascript or a Windows toast)")
+		createToken    = flag.String("create-token", "", "Create an API token with this name for scripts calling the dashboard API")
+		listTokens     = flag.Bool("list-tokens", false, "List the API tokens")
+		revokeToken    = flag.String("revoke-token", "", "Revoke the API token with this name")
 	)
 	flag.Parse()
 
 	// Initialize storage
-	store, err := storage.NewStorage(*dbPath)
+	store, err := openStore(*dbDriver, *dbPath)
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
@@ -41,8 +76,86 @@ func main() {
 		os.Getenv("SMTP_USERNAME"),
 		os.Getenv("SMTP_PASSWORD"),
 		os.Getenv("FROM_EMAIL"),
-		[]string{os.Getenv("TO_EMAIL")}, // You can add multiple emails separated by comma
+		envList("TO_EMAIL"), // You can add multiple emails separated by comma
 	)
+	defer notifier.Close()
+	notifier.SetTimeouts(envDuration("SMTP_DIAL_TIMEOUT", notification.DefaultSMTPDialTimeout),
+		envDuration("SMTP_TIMEOUT", notification.DefaultSMTPTimeout))
+	skipVerify, _ := strconv.ParseBool(os.Getenv("SMTP_TLS_SKIP_VERIFY"))
+	if err := notifier.SetTLS(os.Getenv("SMTP_TLS"), skipVerify, os.Getenv("SMTP_TLS_CA_FILE")); err != nil {
+		log.Fatalf("Failed to configure SMTP TLS: %v", err)
+	}
+	if clientID := os.Getenv("SMTP_OAUTH2_CLIENT_ID"); clientID != "" {
+		err := notifier.SetOAuth2(notification.OAuth2Config{
+			TokenURL:     os.Getenv("SMTP_OAUTH2_TOKEN_URL"),
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("SMTP_OAUTH2_CLIENT_SECRET"),
+			RefreshToken: os.Getenv("SMTP_OAUTH2_REFRESH_TOKEN"),
+			Scope:        os.Getenv("SMTP_OAUTH2_SCOPE"),
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure SMTP OAuth2: %v", err)
+		}
+	}
+	if dir := os.Getenv("EMAIL_TEMPLATES_DIR"); dir != "" {
+		if err := notifier.LoadTemplates(dir); err != nil {
+			log.Fatalf("Failed to load email templates: %v", err)
+		}
+	}
+	pliegoMaxMB := 5
+	if value := os.Getenv("EMAIL_PLIEGO_MAX_MB"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("Warning: Invalid EMAIL_PLIEGO_MAX_MB=%q, using %d", value, pliegoMaxMB)
+		} else {
+			pliegoMaxMB = parsed
+		}
+	}
+	if err := notifier.SetPliegoMode(os.Getenv("EMAIL_PLIEGO"), int64(pliegoMaxMB)<<20); err != nil {
+		log.Printf("Warning: Invalid EMAIL_PLIEGO, the Pliego is left out of emails: %v", err)
+	}
+	if replyTo := os.Getenv("EMAIL_REPLY_TO"); replyTo != "" {
+		if err := notifier.SetReplyTo(replyTo); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	// EMAIL_HEADERS lists extra headers separated by ";" or newlines, e.g. "X-Priority: 1"
+	for _, line := range strings.FieldsFunc(os.Getenv("EMAIL_HEADERS"), func(r rune) bool { return r == ';' || r == '\n' }) {
+		name, value, _ := strings.Cut(line, ":")
+		if err := notifier.AddHeader(name, value); err != nil {
+			log.Printf("Warning: Skipping email header: %v", err)
+		}
+	}
+	calendarInvites, _ := strconv.ParseBool(os.Getenv("EMAIL_CALENDAR"))
+	notifier.SetCalendarInvites(calendarInvites)
+	addChannels(notifier, *profileName)
+	if *desktopNotify {
+		notifier.AddChannel(notification.NewDesktopChannel())
+	}
+	channels := loadDashboardChannels(store, notifier)
+	notifier.SetDeliveryLog(store)
+	config := loadRuntimeConfig(store, notifier)
+	notifier.SetDashboardURL(os.Getenv("DASHBOARD_URL"))
+	if lang := os.Getenv("NOTIFY_LANGUAGE"); lang != "" {
+		if err := notifier.SetLanguage(lang); err != nil {
+			log.Printf("Warning: Invalid NOTIFY_LANGUAGE, notifying in English: %v", err)
+		}
+	}
+	for _, setting := range envList("NOTIFY_LANGUAGES") {
+		target, lang, ok := strings.Cut(setting, "=")
+		if !ok {
+			log.Printf("Warning: Invalid NOTIFY_LANGUAGES entry %q, expected target=language", setting)
+			continue
+		}
+		if err := notifier.SetTargetLanguage(strings.TrimSpace(target), strings.TrimSpace(lang)); err != nil {
+			log.Printf("Warning: Invalid language for %s: %v", target, err)
+		}
+	}
+	if os.Getenv("NOTIFY_DEDUP_WINDOW") == "0" {
+		notifier.SetDedupWindow(0)
+	} else {
+		notifier.SetDedupWindow(envDuration("NOTIFY_DEDUP_WINDOW", notification.DefaultDedupWindow))
+	}
 
 	// Handle different commands
 	switch {
@@ -61,59 +174,49 @@ func main() {
 		fmt.Println("✅ Connection test successful!")
 
 	case *testEmail:
+		if *sampleOutput != "" {
+			writeSampleEmails(notifier, sampleContract(store, *sample), *sampleOutput)
+			break
+		}
+
 		if err := notifier.TestConnection(); err != nil {
 			log.Fatalf("Email test failed: %v", err)
 		}
 		fmt.Println("✅ Email configuration test successful!")
 
+		if *sample != "" {
+			if err := notifier.SendSample(sampleContract(store, *sample)); err != nil {
+				log.Fatalf("Failed to send sample notifications: %v", err)
+			}
+			fmt.Println("✅ Sample notifications sent!")
+		}
+
 	case *scrapeSelenium:
 		fmt.Println("🔍 Starting unified scraper (Selenium mode)...")
+		profile := loadProfile(store, *profileName, *cpvCode)
+		run := startRun(store, scraper.ScraperTypeSelenium, profile.Name)
 		
 		// Use the unified scraping function with Selenium mode
-		contracts, err := scraper.ScrapeContracts(scraper.ScraperTypeSelenium)
+		contracts, err := scraper.ScrapeContracts(scraper.ScraperTypeSelenium, profile.CPVCode)
 		if err != nil {
-			log.Fatalf("Selenium scraping failed: %v", err)
+			failRun(store, notifier, run, "Selenium scraping failed", err)
 		}
+		run.PagesProcessed++
+		assignProfile(contracts, profile)
 
 		fmt.Printf("📊 Found %d contracts with Selenium\n", len(contracts))
-		processContracts(contracts, store, notifier)
+		if err := processContracts(contracts, store, notifier, run); err != nil {
+			failRun(store, notifier, run, "Failed to process contracts", err)
+		}
+		finishRun(store, notifier, run, nil)
 
 	case *scrapeCLI:
 		fmt.Println("🔍 Starting unified scraper (CLI mode)...")
-		
-		// Create CLI scraper instance
-		cliScraper, err := scraper.NewScraper(scraper.ScraperTypeCLI)
-		if err != nil {
-			log.Fatalf("Failed to create CLI scraper: %v", err)
-		}
-		defer cliScraper.Close()
-
-		// Use the unified scraping workflow
-		contracts, err := scraper.ScrapeContractsWithScraper(cliScraper)
-		if err != nil {
+		profile := loadProfile(store, *profileName, *cpvCode)
+		if _, err := scrapeWithCLI(store, notifier, profile, nil); err != nil {
 			log.Fatalf("CLI scraping failed: %v", err)
 		}
 
-		// Extract ALL contracts for status change detection
-		allContracts, err := cliScraper.ExtractAllContracts()
-		if err != nil {
-			log.Printf("Warning: Failed to extract all contracts for status checking: %v", err)
-			allContracts = []scraper.Contract{} // Empty slice if failed
-		}
-
-		// Enhance contracts with document links (Pliego and Anuncio)
-		fmt.Println("📄 Enhancing contracts with document links...")
-		coreScraper := scraper.NewCoreScraper()
-		enhancedContracts, err := coreScraper.EnhanceContractsWithDocumentLinks(contracts, cliScraper, store)
-		if err != nil {
-			log.Printf("Warning: Failed to enhance contracts with document links: %v", err)
-			enhancedContracts = contracts // Use original contracts if enhancement fails
-		}
-
-		fmt.Printf("📊 Found %d contracts with CLI scraper\n", len(enhancedContracts))
-		fmt.Printf("📋 Found %d total contracts for status change detection\n", len(allContracts))
-		processContractsWithStatusCheck(enhancedContracts, allContracts, store, notifier)
-
 	case *debugSelenium:
 		fmt.Println("🔍 Starting Selenium debug mode...")
 		
@@ -174,9 +277,171 @@ func main() {
 
 		fmt.Println("✅ Debug mode completed. Check the logs and screenshots for details.")
 
+	case *purgeDeleted:
+		purged, err := store.PurgeDeletedContracts(*purgeAfter, cliActor())
+		if err != nil {
+			log.Fatalf("Failed to purge deleted contracts: %v", err)
+		}
+		fmt.Printf("🗑️ Purged %d contracts deleted more than %s ago\n", purged, *purgeAfter)
+
+	case *listProfiles:
+		profiles, err := store.GetProfiles()
+		if err != nil {
+			log.Fatalf("Failed to list profiles: %v", err)
+		}
+		for _, profile := range profiles {
+			cpv := profile.CPVCode
+			if cpv == "" {
+				cpv = scraper.NewCoreScraper().GetCPVCode()
+			}
+			fmt.Printf("🔎 %s (CPV %s): %d contracts\n", profile.Name, cpv, profile.Contracts)
+		}
+
+	case *deleteProfile != "":
+		deleted, err := store.DeleteProfile(*deleteProfile, cliActor())
+		if err != nil {
+			log.Fatalf("Failed to delete profile: %v", err)
+		}
+		fmt.Printf("🗑️ Deleted profile %s and its %d contracts\n", *deleteProfile, deleted)
+
+	case *createToken != "":
+		token, _, err := store.CreateAPIToken(*createToken)
+		if err != nil {
+			log.Fatalf("Failed to create API token: %v", err)
+		}
+		fmt.Printf("🔑 Created API token %s. Copy it now, it is not shown again:\n%s\n", *createToken, token)
+		fmt.Println("Send it as \"Authorization: Bearer <token>\" to the dashboard's /api/ endpoints")
+
+	case *listTokens:
+		tokens, err := store.GetAPITokens()
+		if err != nil {
+			log.Fatalf("Failed to list API tokens: %v", err)
+		}
+		for _, token := range tokens {
+			lastUsed := "never used"
+			if token.LastUsedAt != nil {
+				lastUsed = "last used " + token.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("🔑 %s (%s…): created %s, %s\n", token.Name, token.Hint, token.CreatedAt.Local().Format("2006-01-02 15:04"), lastUsed)
+		}
+
+	case *revokeToken != "":
+		if err := store.RevokeAPIToken(*revokeToken); err != nil {
+			log.Fatalf("Failed to revoke API token: %v", err)
+		}
+		fmt.Printf("🗑️ Revoked API token %s\n", *revokeToken)
+
+	case *exportDir != "":
+		files, err := export.ToDirectory(store, *exportDir)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		for _, file := range files {
+			fmt.Printf("📦 Exported %s\n", file)
+		}
+
+	case *reportPath != "":
+		file, err := os.Create(*reportPath)
+		if err != nil {
+			log.Fatalf("Failed to create report file: %v", err)
+		}
+		if err := report.Write(store, file, nil); err != nil {
+			file.Close()
+			log.Fatalf("Failed to generate report: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			log.Fatalf("Failed to write report file: %v", err)
+		}
+		fmt.Printf("📊 Report written to %s\n", *reportPath)
+
+	case *emailReport:
+		var buf bytes.Buffer
+		if err := report.Write(store, &buf, notifyExcludedTags()); err != nil {
+			log.Fatalf("Failed to generate report: %v", err)
+		}
+		attachment := notification.Attachment{Filename: report.FileName, ContentType: report.ContentType, Data: buf.Bytes()}
+		if err := notifier.SendReport(attachment); err != nil {
+			log.Fatalf("Failed to email report: %v", err)
+		}
+		fmt.Println("📧 Report emailed")
+
+	case *backupPath != "":
+		if err := store.Backup(*backupPath); err != nil {
+			log.Fatalf("Backup failed: %v", err)
+		}
+		fmt.Printf("💾 Database backed up to %s\n", *backupPath)
+
+	case *restorePath != "":
+		// Keep a snapshot of the current data in case the wrong backup is restored
+		snapshot, err := store.BackupToDir(*backupDir)
+		if err != nil {
+			log.Fatalf("Failed to snapshot current database before restore: %v", err)
+		}
+		fmt.Printf("💾 Current database saved to %s\n", snapshot)
+
+		if err := store.Restore(*restorePath); err != nil {
+			log.Fatalf("Restore failed: %v", err)
+		}
+		fmt.Printf("✅ Database restored from %s\n", *restorePath)
+
+	case *prune:
+		result, err := store.Prune(retentionPolicyFromEnv(), cliActor())
+		if err != nil {
+			log.Fatalf("Prune failed: %v", err)
+		}
+		fmt.Printf("🧹 Pruned %d status changes, %d revisions, %d archived and %d deleted contracts, %d raw pages\n",
+			result.StatusChanges, result.Revisions, result.ArchivedContracts, result.DeletedContracts, result.RawPages)
+
+		if keep := envDays("RETENTION_SCREENSHOTS_DAYS", 14); keep > 0 {
+			screenshots, err := scraper.PruneScreenshots(keep)
+			if err != nil {
+				log.Fatalf("Failed to prune screenshots: %v", err)
+			}
+			fmt.Printf("🧹 Deleted %d old screenshots\n", screenshots)
+		}
+
 	case *serve:
+		if *backupInterval > 0 {
+			go runScheduledBackups(store, *backupDir, *backupInterval)
+		}
+		go runNotificationRetries(store, notifier, notificationRetryInterval)
+
 		fmt.Printf("🌐 Starting dashboard on port %s...\n", *port)
 		dashboard := dashboard.NewDashboard(store, *port)
+		if err := setupDashboardAuth(dashboard); err != nil {
+			log.Fatalf("Failed to configure dashboard authentication: %v", err)
+		}
+		if err := setupDashboardTLS(dashboard); err != nil {
+			log.Fatalf("Failed to configure dashboard HTTPS: %v", err)
+		}
+		if err := dashboard.SetRequestLog(os.Getenv("DASHBOARD_REQUEST_LOG")); err != nil {
+			log.Fatalf("Failed to configure dashboard request log: %v", err)
+		}
+		if err := dashboard.SetCORSOrigins(envList("DASHBOARD_CORS_ORIGINS")); err != nil {
+			log.Fatalf("Failed to configure dashboard CORS: %v", err)
+		}
+		if err := dashboard.SetTrustedProxies(envList("DASHBOARD_TRUSTED_PROXIES")); err != nil {
+			log.Fatalf("Failed to configure dashboard trusted proxies: %v", err)
+		}
+		if err := setupDashboardRateLimits(dashboard); err != nil {
+			log.Fatalf("Failed to configure dashboard rate limits: %v", err)
+		}
+		if err := dashboard.SetBindAddress(*bindAddress); err != nil {
+			log.Fatalf("Failed to configure dashboard address: %v", err)
+		}
+		if err := dashboard.SetBasePath(*basePath); err != nil {
+			log.Fatalf("Failed to configure dashboard base path: %v", err)
+		}
+		dashboard.SetScrapeRunner(dashboardScrapeRunner(store, notifier, *profileName, *cpvCode))
+		dashboard.SetNotifier(notifier)
+		dashboard.SetConfigurer(config)
+		dashboard.SetChannelManager(channels)
+		if *devAssets != "" {
+			if err := dashboard.SetAssetsDir(*devAssets); err != nil {
+				log.Fatalf("Failed to load dashboard assets: %v", err)
+			}
+			fmt.Printf("🛠️ Reloading dashboard templates and static files from %s\n", *devAssets)
+		}
 		if err := dashboard.Start(); err != nil {
 			log.Fatalf("Failed to start dashboard: %v", err)
 		}
@@ -186,16 +451,78 @@ func main() {
 		fmt.Println("Usage:")
 		fmt.Println("  --test            Test connection to the website")
 		fmt.Println("  --test-email      Test email configuration")
+		fmt.Println("  --sample SOURCE   With --test-email, send sample notifications of a fake or the latest contract")
+		fmt.Println("  --sample-output DIR  With --test-email, write the sample emails to DIR instead of sending them")
 		fmt.Println("  --scrape-selenium Run the Selenium-based scraper (requires Selenium server)")
 		fmt.Println("  --scrape-cli      Run the CLI-only scraper (headless Selenium, requires Selenium server)")
 		fmt.Println("  --debug-selenium  Debug Selenium page structure (navigates to page and analyzes it)")
 		fmt.Println("  --serve           Start the web dashboard")
 		fmt.Println("  --db PATH         Database file path (default: contracts.db)")
+		fmt.Println("  --db-driver NAME  Database driver: sqlite3 or mysql (default: sqlite3)")
+		fmt.Println("                    With mysql, --db is a DSN like user:pass@tcp(localhost:3306)/contracts")
 		fmt.Println("  --port PORT       Dashboard port (default: 8080)")
+		fmt.Println("  --bind ADDR       Dashboard address to listen on, e.g. 127.0.0.1 (default: every interface)")
+		fmt.Println("  --base-path PATH  Serve the dashboard under a URL prefix, e.g. /licitaciones, behind a reverse proxy")
+		fmt.Println("  --export DIR      Export contracts and status changes as gzipped CSV into DIR")
+		fmt.Println("  --report PATH     Write an Excel report (active contracts, status changes, upcoming deadlines)")
+		fmt.Println("  --email-report    Email the Excel report to TO_EMAIL")
+		fmt.Println("  --backup PATH     Snapshot the SQLite database to PATH (safe while in use)")
+		fmt.Println("  --restore PATH    Restore the SQLite database from PATH (current data is saved to --backup-dir first)")
+		fmt.Println("  --backup-interval D  With --serve, back up into --backup-dir every D (e.g. 24h)")
+		fmt.Println("  --dev-assets DIR  With --serve, reload the dashboard templates and static files from DIR (e.g. internal/dashboard)")
+		fmt.Println("  --prune           Remove data older than the retention policy")
+		fmt.Println("  --purge-deleted   Permanently remove soft-deleted contracts")
+		fmt.Println("  --purge-after D   Keep soft-deleted contracts this long before purging (default: 720h)")
+		fmt.Println("  --profile NAME    Search profile to scrape into (default: default); each keeps its own contracts")
+		fmt.Println("  --cpv CODE        CPV code searched by --profile (saved with the profile)")
+		fmt.Println("  --list-profiles   List the search profiles")
+		fmt.Println("  --delete-profile NAME  Permanently delete a profile and its contracts")
+		fmt.Println("  --desktop-notify  Also show new and watched contracts as desktop notifications")
+		fmt.Println("  --create-token NAME  Create an API token for the dashboard API (shown once)")
+		fmt.Println("  --list-tokens     List the API tokens")
+		fmt.Println("  --revoke-token NAME  Revoke an API token")
 		fmt.Println()
 		fmt.Println("Environment variables needed for email:")
 		fmt.Println("  SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD")
-		fmt.Println("  FROM_EMAIL, TO_EMAIL")
+		fmt.Println("  FROM_EMAIL, TO_EMAIL (comma separated)")
+		fmt.Println("  SMTP_DIAL_TIMEOUT (10s), SMTP_TIMEOUT (30s)")
+		fmt.Println("  SMTP_TLS (auto, starttls, tls or none), SMTP_TLS_SKIP_VERIFY, SMTP_TLS_CA_FILE (optional)")
+		fmt.Println("  SMTP_OAUTH2_TOKEN_URL, SMTP_OAUTH2_CLIENT_ID, SMTP_OAUTH2_CLIENT_SECRET,")
+		fmt.Println("  SMTP_OAUTH2_REFRESH_TOKEN, SMTP_OAUTH2_SCOPE (optional, XOAUTH2 instead of SMTP_PASSWORD)")
+		fmt.Println("  EMAIL_TEMPLATES_DIR, EMAIL_PLIEGO (button or attach), EMAIL_PLIEGO_MAX_MB (optional)")
+		fmt.Println("  EMAIL_CALENDAR (optional, attach deadlines as .ics)")
+		fmt.Println("  EMAIL_REPLY_TO, EMAIL_HEADERS (optional, e.g. \"X-Priority: 1;List-Unsubscribe: <mailto:...>\")")
+		fmt.Println("  TELEGRAM_BOT_TOKEN, TELEGRAM_CHAT_IDS (optional)")
+		fmt.Println("  SLACK_WEBHOOK_URL, SLACK_WEBHOOK_URL_<PROFILE> (optional)")
+		fmt.Println("  WEBHOOK_URL, WEBHOOK_SECRET, WEBHOOK_RETRIES (optional)")
+		fmt.Println("  TEAMS_WEBHOOKS (optional)")
+		fmt.Println("  NTFY_TOPIC, NTFY_SERVER, NTFY_TOKEN or NTFY_USERNAME/NTFY_PASSWORD (optional)")
+		fmt.Println("  PUSHOVER_TOKEN, PUSHOVER_USER (optional)")
+		fmt.Println("  SIGNAL_API_URL, SIGNAL_NUMBER, SIGNAL_RECIPIENTS, SIGNAL_RULE (optional)")
+		fmt.Println("  MATRIX_HOMESERVER, MATRIX_ACCESS_TOKEN, MATRIX_ROOM_IDS (optional)")
+		fmt.Println("  GOTIFY_URL, GOTIFY_TOKEN, GOTIFY_PRIORITIES (optional, e.g. new_contracts=2,deadline=9)")
+		fmt.Println("  NOTIFY_QUIET_HOURS (e.g. 22:00-07:00), NOTIFY_QUIET_WEEKENDS, NOTIFY_MAX_PER_HOUR (optional)")
+		fmt.Println("  NOTIFY_KEYWORDS (e.g. pantalla,videowall), NOTIFY_MIN_AMOUNT (euros) (optional, new contracts notified)")
+		fmt.Println("  TO_EMAIL and the NOTIFY_ filters and schedule above can also be changed on the dashboard /settings page")
+		fmt.Println("  More SMTP, Telegram, Slack and webhook channels can be added on the /settings page too")
+		fmt.Println("  NOTIFY_DEDUP_WINDOW (24h, 0 to notify repeated runs again)")
+		fmt.Println("  DASHBOARD_URL (optional, e.g. https://contratos.example.com, linked from every alert)")
+		fmt.Println("  DASHBOARD_TLS_CERT, DASHBOARD_TLS_KEY (optional, serve the dashboard over HTTPS)")
+		fmt.Println("  DASHBOARD_AUTOCERT_HOSTS, DASHBOARD_AUTOCERT_DIR (autocert), DASHBOARD_AUTOCERT_EMAIL (optional, Let's Encrypt)")
+		fmt.Println("  DASHBOARD_USERS (e.g. ana:secret,luis:hunter2), DASHBOARD_AUTH (login or basic, default: login)")
+		fmt.Println("  DASHBOARD_PUBLIC (optional, true to show the contracts read-only without login)")
+		fmt.Println("  DASHBOARD_REQUEST_LOG (all, errors or off, default: all)")
+		fmt.Println("  DASHBOARD_RATE_LIMIT, DASHBOARD_RATE_LIMIT_DESTRUCTIVE (API requests per client, default: 300/m and 10/m, or off)")
+		fmt.Println("  DASHBOARD_TRUSTED_PROXIES (optional, e.g. 127.0.0.1,10.0.0.0/8, reverse proxies whose X-Forwarded-For is believed)")
+		fmt.Println("  DASHBOARD_CORS_ORIGINS (optional, e.g. https://app.example.com, origins allowed to call /api/v1 from a browser)")
+		fmt.Println("  NOTIFY_LANGUAGE (en or es), NOTIFY_LANGUAGES (per target, e.g. email=es,slack=en)")
+		fmt.Println("  RUN_SUMMARY_TARGETS (optional, e.g. email,teams#2), RUN_INTERVAL (e.g. 1h, for the next run time)")
+		fmt.Println("  SCRAPE_ALERT_AFTER (2 failed runs in a row, 0 to never alert), SCRAPE_ALERT_TARGETS (all by default)")
+		fmt.Println()
+		fmt.Println("Retention for --prune, in days (0 keeps data forever):")
+		fmt.Println("  RETENTION_STATUS_CHANGES_DAYS (180), RETENTION_REVISIONS_DAYS (180)")
+		fmt.Println("  RETENTION_ARCHIVED_DAYS (730), RETENTION_DELETED_DAYS (30), RETENTION_SCREENSHOTS_DAYS (14)")
+		fmt.Println("  RETENTION_RAW_PAGES_DAYS (365)")
 		fmt.Println()
 		fmt.Println("For Selenium scraper, you need to:")
 		fmt.Println("  1. Install Selenium server: docker run -d -p 4444:4444 selenium/standalone-chrome")
@@ -203,32 +530,1046 @@ func main() {
 	}
 }
 
-// processContracts handles the common logic for processing scraped contracts
-func processContracts(contracts []scraper.Contract, store *storage.Storage, notifier *notification.Notifier) {
+// openStore opens the database, encrypted with SQLCipher when DB_PASSPHRASE is set
+func openStore(driver, dsn string) (*storage.Storage, error) {
+	passphrase := os.Getenv("DB_PASSPHRASE")
+	if passphrase == "" {
+		return storage.Open(driver, dsn)
+	}
+
+	if driver != "" && driver != "sqlite" && driver != "sqlite3" {
+		return nil, fmt.Errorf("DB_PASSPHRASE is only supported for SQLite databases")
+	}
+	return storage.NewEncryptedStorage(dsn, passphrase)
+}
+
+// retentionPolicyFromEnv builds the retention policy from RETENTION_* environment variables,
+// falling back to storage.DefaultRetentionPolicy
+func retentionPolicyFromEnv() storage.RetentionPolicy {
+	defaults := storage.DefaultRetentionPolicy
+	return storage.RetentionPolicy{
+		StatusChanges:     envDays("RETENTION_STATUS_CHANGES_DAYS", int(defaults.StatusChanges.Hours()/24)),
+		Revisions:         envDays("RETENTION_REVISIONS_DAYS", int(defaults.Revisions.Hours()/24)),
+		ArchivedContracts: envDays("RETENTION_ARCHIVED_DAYS", int(defaults.ArchivedContracts.Hours()/24)),
+		DeletedContracts:  envDays("RETENTION_DELETED_DAYS", int(defaults.DeletedContracts.Hours()/24)),
+		RawPages:          envDays("RETENTION_RAW_PAGES_DAYS", int(defaults.RawPages.Hours()/24)),
+	}
+}
+
+// notifyWatchlist emails the status changes of watched contracts recorded since the run started and
+// a reminder for watched contracts closing within WATCH_DEADLINE_DAYS (3 by default)
+func notifyWatchlist(store storage.Store, notifier *notification.Notifier, since time.Time) error {
+	changes, err := store.GetWatchedStatusChangesSince(since)
+	if err != nil {
+		return err
+	}
+
+	var updates []notification.StatusUpdate
+	for _, change := range changes {
+		contract, err := store.GetContractByID(change.ContractID)
+		if err != nil {
+			return err
+		}
+		if contract == nil {
+			continue
+		}
+		updates = append(updates, notification.StatusUpdate{Contract: *contract, OldStatus: change.OldStatus, NewStatus: change.NewStatus})
+	}
+
+	revisions, err := store.GetWatchedRevisionsSince(since)
+	if err != nil {
+		return err
+	}
+
+	var modified []notification.FieldUpdate
+	for _, revision := range revisions {
+		contract, err := store.GetContractByID(revision.ContractID)
+		if err != nil {
+			return err
+		}
+		if contract == nil {
+			continue
+		}
+		modified = append(modified, notification.FieldUpdate{Contract: *contract, Field: revision.Field, OldValue: revision.OldValue, NewValue: revision.NewValue})
+	}
+
+	// Reminders are scheduled ahead and kept in the database, so one that fell due while the
+	// scraper was not running is still sent on the next run
+	if _, err := store.ScheduleDeadlineReminders(envDays("WATCH_DEADLINE_DAYS", 3), storage.ReminderChannelEmail); err != nil {
+		return err
+	}
+	reminders, err := store.GetDueReminders(storage.ReminderChannelEmail)
+	if err != nil {
+		return err
+	}
+
+	var deadlines []scraper.Contract
+	for _, reminder := range reminders {
+		contract, err := store.GetContractByID(reminder.ContractID)
+		if err != nil {
+			return err
+		}
+		if contract != nil {
+			deadlines = append(deadlines, *contract)
+		}
+	}
+
+	if len(updates) == 0 && len(modified) == 0 && len(deadlines) == 0 {
+		return nil
+	}
+
+	if err := notifier.SendWatchlistNotification(updates, modified, deadlines); err != nil {
+		return err
+	}
+	fmt.Printf("👀 Watchlist notification sent (%d status changes, %d modifications, %d deadlines)\n", len(updates), len(modified), len(deadlines))
+
+	return store.MarkRemindersSent(reminders)
+}
+
+// addChannels registers the chat and push channels configured in the environment with the notifier.
+// Channels that can be set per search profile use the variable suffixed with the profile name if set.
+func addChannels(notifier *notification.Notifier, profile string) {
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		chatIDs := envList("TELEGRAM_CHAT_IDS")
+		if len(chatIDs) == 0 {
+			log.Printf("Warning: TELEGRAM_BOT_TOKEN is set but TELEGRAM_CHAT_IDS is empty, Telegram notifications are disabled")
+		} else {
+			notifier.AddChannel(notification.NewTelegramChannel(token, chatIDs))
+		}
+	}
+
+	if webhookURL := profileEnv("SLACK_WEBHOOK_URL", profile); webhookURL != "" {
+		notifier.AddChannel(notification.NewSlackChannel(webhookURL))
+	}
+
+	if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		retries := 3
+		if value := os.Getenv("WEBHOOK_RETRIES"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				log.Printf("Warning: Invalid WEBHOOK_RETRIES=%q, using %d", value, retries)
+			} else {
+				retries = parsed
+			}
+		}
+		notifier.AddChannel(notification.NewWebhookChannel(webhookURL, os.Getenv("WEBHOOK_SECRET"), retries))
+	}
+
+	// TEAMS_WEBHOOKS lists routes separated by ";" or newlines: a webhook URL optionally followed by
+	// a rule selecting the alerts it receives, e.g. "https://... events=new_contracts,min_amount=50000"
+	for _, route := range strings.FieldsFunc(os.Getenv("TEAMS_WEBHOOKS"), func(r rune) bool { return r == ';' || r == '\n' }) {
+		webhookURL, ruleText, _ := strings.Cut(strings.TrimSpace(route), " ")
+		if webhookURL == "" {
+			continue
+		}
+
+		rule, err := notification.ParseRule(ruleText)
+		if err != nil {
+			log.Printf("Warning: Skipping Teams webhook: %v", err)
+			continue
+		}
+		notifier.AddChannel(notification.WithRule(notification.NewTeamsChannel(webhookURL), rule))
+	}
+
+	if topic := os.Getenv("NTFY_TOPIC"); topic != "" {
+		notifier.AddChannel(notification.NewNtfyChannel(os.Getenv("NTFY_SERVER"), topic,
+			os.Getenv("NTFY_TOKEN"), os.Getenv("NTFY_USERNAME"), os.Getenv("NTFY_PASSWORD")))
+	}
+
+	if token := os.Getenv("PUSHOVER_TOKEN"); token != "" {
+		if user := os.Getenv("PUSHOVER_USER"); user == "" {
+			log.Printf("Warning: PUSHOVER_TOKEN is set but PUSHOVER_USER is empty, Pushover notifications are disabled")
+		} else {
+			notifier.AddChannel(notification.NewPushoverChannel(token, user))
+		}
+	}
+
+	if apiURL := os.Getenv("SIGNAL_API_URL"); apiURL != "" {
+		number, recipients := os.Getenv("SIGNAL_NUMBER"), envList("SIGNAL_RECIPIENTS")
+		if number == "" || len(recipients) == 0 {
+			log.Printf("Warning: SIGNAL_API_URL is set but SIGNAL_NUMBER or SIGNAL_RECIPIENTS is empty, Signal notifications are disabled")
+		} else if rule, err := notification.ParseRule(os.Getenv("SIGNAL_RULE")); err != nil {
+			log.Printf("Warning: Signal notifications are disabled: %v", err)
+		} else {
+			notifier.AddChannel(notification.WithRule(notification.NewSignalChannel(apiURL, number, recipients), rule))
+		}
+	}
+
+	if homeserver := os.Getenv("MATRIX_HOMESERVER"); homeserver != "" {
+		token, roomIDs := os.Getenv("MATRIX_ACCESS_TOKEN"), envList("MATRIX_ROOM_IDS")
+		if token == "" || len(roomIDs) == 0 {
+			log.Printf("Warning: MATRIX_HOMESERVER is set but MATRIX_ACCESS_TOKEN or MATRIX_ROOM_IDS is empty, Matrix notifications are disabled")
+		} else {
+			notifier.AddChannel(notification.NewMatrixChannel(homeserver, token, roomIDs))
+		}
+	}
+
+	if server := os.Getenv("GOTIFY_URL"); server != "" {
+		priorities, err := notification.ParseGotifyPriorities(os.Getenv("GOTIFY_PRIORITIES"))
+		if err != nil {
+			log.Printf("Warning: Invalid GOTIFY_PRIORITIES, using the defaults: %v", err)
+			priorities = notification.DefaultGotifyPriorities
+		}
+		if token := os.Getenv("GOTIFY_TOKEN"); token == "" {
+			log.Printf("Warning: GOTIFY_URL is set but GOTIFY_TOKEN is empty, Gotify notifications are disabled")
+		} else {
+			notifier.AddChannel(notification.NewGotifyChannel(server, token, priorities))
+		}
+	}
+}
+
+// profileEnv reads NAME_PROFILE (the profile name upper-cased, other characters than letters and
+// digits replaced by "_"), falling back to NAME
+func profileEnv(name, profile string) string {
+	suffix := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, profile)
+
+	if value := os.Getenv(name + "_" + suffix); value != "" {
+		return value
+	}
+	return os.Getenv(name)
+}
+
+// setupDashboardAuth requires the users in DASHBOARD_USERS to log in to the dashboard, with a login page
+// or HTTP basic auth as DASHBOARD_AUTH says, except to read the contracts with DASHBOARD_PUBLIC=true
+func setupDashboardAuth(d *dashboard.Dashboard) error {
+	users, err := dashboard.ParseUsers(os.Getenv("DASHBOARD_USERS"))
+	if err != nil {
+		return fmt.Errorf("failed to parse DASHBOARD_USERS: %w", err)
+	}
+	if err := d.SetAuth(users, os.Getenv("DASHBOARD_AUTH")); err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		fmt.Printf("🔒 Dashboard login required for %d user(s)\n", len(users))
+	}
+
+	if value := os.Getenv("DASHBOARD_PUBLIC"); value != "" {
+		public, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid DASHBOARD_PUBLIC %q, expected true or false", value)
+		}
+		d.SetPublic(public)
+		if public {
+			fmt.Println("🌍 Dashboard contracts readable without login (read-only)")
+		}
+	}
+	return nil
+}
+
+// dashboardScrapeRunner runs the scrapes started from the dashboard with the CLI scraper. They scrape
+// defaultProfile (with cpvCode, as --scrape-cli would) unless the dashboard asks for an existing profile.
+func dashboardScrapeRunner(store storage.Store, notifier *notification.Notifier, defaultProfile, cpvCode string) dashboard.ScrapeRunner {
+	return func(name string, progress func(step string)) (*storage.ScrapeRun, error) {
+		var profile *storage.Profile
+		var err error
+		if name == "" || name == defaultProfile {
+			profile, err = store.SaveProfile(defaultProfile, cpvCode)
+		} else {
+			profile, err = store.GetProfile(name)
+			if err == nil && profile == nil {
+				err = fmt.Errorf("profile %s not found", name)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile: %w", err)
+		}
+
+		fmt.Printf("🔍 Starting a scrape of profile %s from the dashboard...\n", profile.Name)
+		return scrapeWithCLI(store, notifier, profile, progress)
+	}
+}
+
+// setupDashboardRateLimits limits the API requests of each client to DASHBOARD_RATE_LIMIT, and its
+// deletes and scrapes to DASHBOARD_RATE_LIMIT_DESTRUCTIVE
+func setupDashboardRateLimits(d *dashboard.Dashboard) error {
+	api, destructive := dashboard.DefaultAPIRateLimit, dashboard.DefaultDestructiveRateLimit
+	var err error
+	if value := os.Getenv("DASHBOARD_RATE_LIMIT"); value != "" {
+		if api, err = dashboard.ParseRateLimit(value); err != nil {
+			return fmt.Errorf("failed to parse DASHBOARD_RATE_LIMIT: %w", err)
+		}
+	}
+	if value := os.Getenv("DASHBOARD_RATE_LIMIT_DESTRUCTIVE"); value != "" {
+		if destructive, err = dashboard.ParseRateLimit(value); err != nil {
+			return fmt.Errorf("failed to parse DASHBOARD_RATE_LIMIT_DESTRUCTIVE: %w", err)
+		}
+	}
+	d.SetRateLimits(api, destructive)
+	return nil
+}
+
+// setupDashboardTLS serves the dashboard over HTTPS with the certificate in DASHBOARD_TLS_CERT and
+// DASHBOARD_TLS_KEY, or with certificates from Let's Encrypt for DASHBOARD_AUTOCERT_HOSTS
+func setupDashboardTLS(d *dashboard.Dashboard) error {
+	certFile, keyFile := os.Getenv("DASHBOARD_TLS_CERT"), os.Getenv("DASHBOARD_TLS_KEY")
+	hosts := envList("DASHBOARD_AUTOCERT_HOSTS")
+
+	switch {
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("DASHBOARD_TLS_CERT and DASHBOARD_TLS_KEY must be set together")
+		}
+		if len(hosts) > 0 {
+			return fmt.Errorf("DASHBOARD_AUTOCERT_HOSTS cannot be combined with DASHBOARD_TLS_CERT")
+		}
+		if err := d.SetTLS(certFile, keyFile); err != nil {
+			return err
+		}
+		fmt.Printf("🔐 Serving the dashboard over HTTPS with %s\n", certFile)
+
+	case len(hosts) > 0:
+		cacheDir := os.Getenv("DASHBOARD_AUTOCERT_DIR")
+		if cacheDir == "" {
+			cacheDir = "autocert"
+		}
+		if err := d.SetAutocert(hosts, cacheDir, os.Getenv("DASHBOARD_AUTOCERT_EMAIL")); err != nil {
+			return err
+		}
+		fmt.Printf("🔐 Serving the dashboard over HTTPS with Let's Encrypt certificates for %s\n", strings.Join(hosts, ", "))
+	}
+	return nil
+}
+
+// envList reads a comma-separated list from an environment variable, skipping empty entries
+func envList(name string) []string {
+	return splitList(os.Getenv(name))
+}
+
+// splitList splits a comma-separated list, skipping empty entries
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// notifyExcludedTags returns the tags whose contracts are left out of emailed reports
+// (NOTIFY_EXCLUDE_TAGS, comma separated, "ignore" by default)
+func notifyExcludedTags() []string {
+	value, ok := os.LookupEnv("NOTIFY_EXCLUDE_TAGS")
+	if !ok {
+		return []string{storage.TagIgnore}
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = storage.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// envDays reads a number of days from an environment variable
+func envDays(name string, defaultDays int) time.Duration {
+	days := defaultDays
+	if value := os.Getenv(name); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Warning: Invalid %s=%q, using %d days", name, value, defaultDays)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// envDuration reads a duration such as "30s" from an environment variable
+func envDuration(name string, defaultDuration time.Duration) time.Duration {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("Warning: Invalid %s=%q, using %s", name, value, defaultDuration)
+		} else {
+			return parsed
+		}
+	}
+	return defaultDuration
+}
+
+// cliActor names the user of a command-line run for the audit log
+func cliActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "cli:" + user
+	}
+	return "cli"
+}
+
+// runScheduledBackups backs up the database into dir every interval until the process exits
+func runScheduledBackups(store *storage.Storage, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		path, err := store.BackupToDir(dir)
+		if err != nil {
+			log.Printf("Warning: Scheduled backup failed: %v", err)
+			continue
+		}
+		log.Printf("💾 Scheduled backup written to %s", path)
+	}
+}
+
+// notificationRetryInterval is how often --serve looks for failed notifications whose retry is due
+const notificationRetryInterval = time.Minute
+
+// runNotificationRetries retries the failed notifications every interval until the process exits
+func runNotificationRetries(store storage.Store, notifier *notification.Notifier, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := retryNotifications(store, notifier); err != nil {
+			log.Printf("Warning: Failed to retry notifications: %v", err)
+		}
+		if err := releaseHeldNotifications(store, notifier); err != nil {
+			log.Printf("Warning: Failed to send held notifications: %v", err)
+		}
+	}
+}
+
+// retryNotifications sends again the failed notifications whose retry is due. A notification that
+// keeps failing is retried with a doubling delay until storage.MaxNotificationAttempts is reached.
+func retryNotifications(store storage.Store, notifier *notification.Notifier) error {
+	entries, err := store.GetDueNotifications()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sendErr := notifier.Redeliver(entry.Target, entry.Event, entry.Payload)
+		if err := store.RecordNotificationRetry(entry, sendErr); err != nil {
+			return err
+		}
+
+		switch {
+		case sendErr == nil:
+			fmt.Printf("📧 Resent the %s notification to %s\n", entry.Event, entry.Target)
+		case entry.Attempts+1 >= storage.MaxNotificationAttempts:
+			log.Printf("Warning: Giving up the %s notification to %s after %d attempts: %v", entry.Event, entry.Target, entry.Attempts+1, sendErr)
+		default:
+			log.Printf("Warning: Retry of the %s notification to %s failed: %v", entry.Event, entry.Target, sendErr)
+		}
+	}
+	return nil
+}
+
+// sampleContract returns the contract sample notifications are built from: a made-up one for "fake"
+// or an empty source, or the most recently scraped one for "latest"
+func sampleContract(store storage.Store, source string) scraper.Contract {
+	switch source {
+	case "", "fake":
+		return notification.SampleContract()
+	case "latest":
+		contracts, _, err := store.GetContractsPage(storage.ContractFilter{}, storage.DefaultContractSort, 1, 0)
+		if err != nil {
+			log.Fatalf("Failed to load the latest contract: %v", err)
+		}
+		if len(contracts) == 0 {
+			log.Fatalf("No contracts stored yet, use --sample fake")
+		}
+		return contracts[0]
+	}
+	log.Fatalf("Unknown sample source %q, use fake or latest", source)
+	return scraper.Contract{}
+}
+
+// writeSampleEmails renders every email template with samples built around contract into dir
+func writeSampleEmails(notifier *notification.Notifier, contract scraper.Contract, dir string) {
+	emails, err := notifier.RenderSample(contract)
+	if err != nil {
+		log.Fatalf("Failed to render sample emails: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Failed to create %s: %v", dir, err)
+	}
+
+	for _, email := range emails {
+		path := filepath.Join(dir, email.Template)
+		if err := os.WriteFile(path, []byte(email.Body), 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		fmt.Printf("📝 %s: %s\n", path, email.Subject)
+	}
+	fmt.Printf("✅ Rendered %d sample emails of %s, nothing was sent\n", len(emails), contract.ID)
+}
+
+// releaseHeldNotifications sends the notifications held during quiet hours or over the hourly limit,
+// one digest per target and event, to the targets the schedule allows sending to again
+func releaseHeldNotifications(store storage.Store, notifier *notification.Notifier) error {
+	entries, err := store.GetHeldNotifications()
+	if err != nil {
+		return err
+	}
+
+	type digestKey struct{ target, event string }
+	var keys []digestKey
+	held := make(map[digestKey][]storage.NotificationLogEntry)
+	for _, entry := range entries {
+		key := digestKey{entry.Target, entry.Event}
+		if _, ok := held[key]; !ok {
+			keys = append(keys, key)
+		}
+		held[key] = append(held[key], entry)
+	}
+
+	for _, key := range keys {
+		if !notifier.CanSend(key.target) {
+			continue
+		}
+
+		var payloads [][]byte
+		for _, entry := range held[key] {
+			payloads = append(payloads, entry.Payload)
+		}
+		digest, sendErr := notifier.SendDigest(key.target, key.event, payloads)
+		if err := store.ReleaseHeldNotifications(held[key], digest, sendErr); err != nil {
+			return err
+		}
+
+		if sendErr != nil {
+			log.Printf("Warning: Failed to send the %s digest to %s, retrying later: %v", key.event, key.target, sendErr)
+		} else {
+			fmt.Printf("📬 Sent %d held %s notifications to %s\n", len(payloads), key.event, key.target)
+		}
+	}
+	return nil
+}
+
+// runtimeConfigNames are the settings that can be changed on the dashboard settings page without a
+// restart. Once saved there, they override the environment variables of the same name.
+var runtimeConfigNames = []string{"TO_EMAIL", "NOTIFY_KEYWORDS", "NOTIFY_MIN_AMOUNT", "NOTIFY_QUIET_HOURS",
+	"NOTIFY_QUIET_WEEKENDS", "NOTIFY_MAX_PER_HOUR"}
+
+// runtimeConfig applies the runtime settings to the notifier, as the dashboard.Configurer
+type runtimeConfig struct {
+	notifier *notification.Notifier
+	mu       sync.Mutex
+	values   map[string]string // Settings in effect, by name
+}
+
+// loadRuntimeConfig applies to notifier the runtime settings saved from the dashboard, or else the
+// environment variables. Invalid settings are skipped with a warning.
+func loadRuntimeConfig(store storage.Store, notifier *notification.Notifier) *runtimeConfig {
+	saved, err := store.GetConfig()
+	if err != nil {
+		log.Printf("Warning: Failed to load the dashboard settings, using the environment: %v", err)
+	}
+
+	values := make(map[string]string)
+	for _, name := range runtimeConfigNames {
+		if value, ok := saved[name]; ok {
+			values[name] = value
+		} else {
+			values[name] = os.Getenv(name)
+		}
+	}
+
+	settings, err := parseRuntimeConfig(values)
+	if err != nil {
+		log.Printf("Warning: Ignoring invalid settings: %v", err)
+	}
+	settings.apply(notifier)
+	return &runtimeConfig{notifier: notifier, values: values}
+}
+
+// Config returns the runtime settings in effect
+func (c *runtimeConfig) Config() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make(map[string]string, len(c.values))
+	for name, value := range c.values {
+		values[name] = value
+	}
+	return values
+}
+
+// ApplyConfig changes runtime settings. If any of them is invalid none is applied.
+func (c *runtimeConfig) ApplyConfig(changes map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string]string, len(c.values))
+	for name, value := range c.values {
+		values[name] = value
+	}
+	for name, value := range changes {
+		if _, ok := values[name]; !ok {
+			return fmt.Errorf("unknown setting %s", name)
+		}
+		values[name] = strings.TrimSpace(value)
+	}
+
+	settings, err := parseRuntimeConfig(values)
+	if err != nil {
+		return err
+	}
+	settings.apply(c.notifier)
+	c.values = values
+	return nil
+}
+
+// notificationSettings are the parsed runtime settings
+type notificationSettings struct {
+	recipients []string
+	filter     notification.Rule
+	schedule   notification.Schedule
+}
+
+// parseRuntimeConfig parses the runtime settings. Invalid ones are left unset and reported together in the error.
+func parseRuntimeConfig(values map[string]string) (notificationSettings, error) {
+	var settings notificationSettings
+	var errs []error
+
+	for _, address := range splitList(values["TO_EMAIL"]) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			errs = append(errs, fmt.Errorf("invalid TO_EMAIL address %q", address))
+			continue
+		}
+		settings.recipients = append(settings.recipients, address)
+	}
+
+	settings.filter.Keywords = splitList(strings.ToLower(values["NOTIFY_KEYWORDS"]))
+	if value := values["NOTIFY_MIN_AMOUNT"]; value != "" {
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil || amount < 0 {
+			errs = append(errs, fmt.Errorf("invalid NOTIFY_MIN_AMOUNT %q, expected an amount in euros such as 50000", value))
+		} else {
+			settings.filter.MinAmount = amount
+		}
+	}
+
+	if value := values["NOTIFY_QUIET_HOURS"]; value != "" {
+		from, to, err := notification.ParseQuietHours(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid NOTIFY_QUIET_HOURS: %w", err))
+		} else {
+			settings.schedule.QuietFrom, settings.schedule.QuietTo = from, to
+		}
+	}
+	if value := values["NOTIFY_QUIET_WEEKENDS"]; value != "" {
+		quiet, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid NOTIFY_QUIET_WEEKENDS %q, expected true or false", value))
+		}
+		settings.schedule.QuietWeekends = quiet
+	}
+	if value := values["NOTIFY_MAX_PER_HOUR"]; value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Errorf("invalid NOTIFY_MAX_PER_HOUR %q, expected a number, 0 for no limit", value))
+		} else {
+			settings.schedule.MaxPerHour = parsed
+		}
+	}
+
+	return settings, errors.Join(errs...)
+}
+
+// apply sets the recipients, new contract filter and schedule of notifier
+func (s notificationSettings) apply(notifier *notification.Notifier) {
+	notifier.SetRecipients(s.recipients)
+	notifier.SetFilter(s.filter)
+	notifier.SetSchedule(s.schedule)
+}
+
+// dashboardChannels builds the notification channels added on the dashboard settings page, as the
+// dashboard.ChannelManager
+type dashboardChannels struct {
+	notifier *notification.Notifier
+}
+
+// loadDashboardChannels adds to notifier the channels saved from the dashboard. Invalid channels are
+// skipped with a warning.
+func loadDashboardChannels(store storage.Store, notifier *notification.Notifier) *dashboardChannels {
+	manager := &dashboardChannels{notifier: notifier}
+	saved, err := store.GetNotificationChannels()
+	if err != nil {
+		log.Printf("Warning: Failed to load the dashboard notification channels: %v", err)
+		return manager
+	}
+
+	var channels []notification.DashboardChannel
+	for _, stored := range saved {
+		channel, err := buildChannel(stored)
+		if err != nil {
+			log.Printf("Warning: Skipping %s channel #%d: %v", stored.Kind, stored.ID, err)
+			continue
+		}
+		channels = append(channels, notification.DashboardChannel{Target: stored.Target(), Channel: channel})
+	}
+	notifier.SetDashboardChannels(channels)
+	return manager
+}
+
+// ApplyChannels replaces the channels of the dashboard. If any of them is invalid none is applied.
+func (m *dashboardChannels) ApplyChannels(stored []storage.NotificationChannel) error {
+	channels := make([]notification.DashboardChannel, 0, len(stored))
+	for _, settings := range stored {
+		channel, err := buildChannel(settings)
+		if err != nil {
+			return err
+		}
+		channels = append(channels, notification.DashboardChannel{Target: settings.Target(), Channel: channel})
+	}
+	m.notifier.SetDashboardChannels(channels)
+	return nil
+}
+
+// TestChannel sends a sample new contract alert through a channel
+func (m *dashboardChannels) TestChannel(stored storage.NotificationChannel) error {
+	channel, err := buildChannel(stored)
+	if err != nil {
+		return err
+	}
+	return m.notifier.SendTest(notification.DashboardChannel{Target: stored.Target(), Channel: channel})
+}
+
+// buildChannel creates the channel of a kind offered on the dashboard settings page from its settings,
+// which are named as there
+func buildChannel(stored storage.NotificationChannel) (notification.Channel, error) {
+	settings := stored.Settings
+	required := func(names ...string) error {
+		for _, name := range names {
+			if strings.TrimSpace(settings[name]) == "" {
+				return fmt.Errorf("missing setting %q of the %s channel", name, stored.Kind)
+			}
+		}
+		return nil
+	}
+
+	switch stored.Kind {
+	case "smtp":
+		if err := required("host", "from", "to"); err != nil {
+			return nil, err
+		}
+		port := settings["port"]
+		if port == "" {
+			port = "587"
+		} else if number, err := strconv.Atoi(port); err != nil || number <= 0 || number > 65535 {
+			return nil, fmt.Errorf("invalid SMTP port %q", port)
+		}
+		if _, err := mail.ParseAddress(settings["from"]); err != nil {
+			return nil, fmt.Errorf("invalid from address %q", settings["from"])
+		}
+		recipients := splitList(settings["to"])
+		for _, address := range recipients {
+			if _, err := mail.ParseAddress(address); err != nil {
+				return nil, fmt.Errorf("invalid recipient %q", address)
+			}
+		}
+		return notification.NewEmailChannel(settings["host"], port, settings["username"], settings["password"],
+			settings["from"], recipients), nil
+
+	case "telegram":
+		if err := required("token", "chat_ids"); err != nil {
+			return nil, err
+		}
+		return notification.NewTelegramChannel(settings["token"], splitList(settings["chat_ids"])), nil
+
+	case "slack":
+		if err := required("webhook_url"); err != nil {
+			return nil, err
+		}
+		if err := checkWebURL(settings["webhook_url"]); err != nil {
+			return nil, err
+		}
+		return notification.NewSlackChannel(settings["webhook_url"]), nil
+
+	case "webhook":
+		if err := required("url"); err != nil {
+			return nil, err
+		}
+		if err := checkWebURL(settings["url"]); err != nil {
+			return nil, err
+		}
+		return notification.NewWebhookChannel(settings["url"], settings["secret"], 3), nil
+	}
+	return nil, fmt.Errorf("unknown kind of channel %q", stored.Kind)
+}
+
+// checkWebURL fails unless address is an absolute http or https URL
+func checkWebURL(address string) error {
+	parsed, err := url.Parse(address)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid URL %q, expected http:// or https://", address)
+	}
+	return nil
+}
+
+// loadProfile returns the search profile to scrape into, creating it or saving its CPV code as needed
+func loadProfile(store storage.Store, name, cpvCode string) *storage.Profile {
+	profile, err := store.SaveProfile(name, cpvCode)
+	if err != nil {
+		log.Fatalf("Failed to load profile %s: %v", name, err)
+	}
+	fmt.Printf("🔎 Profile: %s\n", profile.Name)
+	return profile
+}
+
+// assignProfile marks scraped contracts as found by the given profile
+func assignProfile(contracts []scraper.Contract, profile *storage.Profile) {
+	for i := range contracts {
+		contracts[i].ProfileID = profile.ID
+	}
+}
+
+// startRun records the start of a scrape. If the run cannot be stored the scrape still goes ahead
+// with an unsaved run.
+func startRun(store storage.Store, scraperType scraper.ScraperType, profile string) *storage.ScrapeRun {
+	run, err := store.StartScrapeRun(string(scraperType), profile)
+	if err != nil {
+		log.Printf("Warning: Failed to record scrape run: %v", err)
+		return &storage.ScrapeRun{StartedAt: time.Now(), ScraperType: string(scraperType), Profile: profile}
+	}
+	return run
+}
+
+// finishRun stores the outcome of a scrape and sends its summary to the RUN_SUMMARY_TARGETS
+func finishRun(store storage.Store, notifier *notification.Notifier, run *storage.ScrapeRun, runErr error) {
+	if run.ID == 0 {
+		return
+	}
+	if err := store.FinishScrapeRun(run, runErr); err != nil {
+		log.Printf("Warning: Failed to record scrape run result: %v", err)
+		return
+	}
+	fmt.Printf("📈 Run %d %s in %s: %d found, %d new, %d changed, %d errors\n",
+		run.ID, run.Status, run.Duration().Round(time.Second), run.ContractsFound, run.ContractsNew, run.ContractsChanged, len(run.Errors))
+
+	if targets := envList("RUN_SUMMARY_TARGETS"); len(targets) > 0 {
+		if err := notifier.SendRunSummary(targets, runSummary(run)); err != nil {
+			log.Printf("Warning: Failed to send run summary: %v", err)
+		}
+	}
+	alertScrapeFailures(store, notifier, run)
+}
+
+// alertScrapeFailures alerts the SCRAPE_ALERT_TARGETS (every target by default) when the runs of
+// the profile have failed SCRAPE_ALERT_AFTER times in a row, and once more when a run succeeds again
+func alertScrapeFailures(store storage.Store, notifier *notification.Notifier, run *storage.ScrapeRun) {
+	threshold := 2
+	if value := os.Getenv("SCRAPE_ALERT_AFTER"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Warning: Invalid SCRAPE_ALERT_AFTER=%q, using %d", value, threshold)
+		} else {
+			threshold = parsed
+		}
+	}
+	if threshold == 0 {
+		return
+	}
+
+	runs, err := store.GetScrapeRuns(100)
+	if err != nil {
+		log.Printf("Warning: Failed to check for repeated scrape failures: %v", err)
+		return
+	}
+
+	// Failed runs of the profile in a row before this one, newest first
+	var failed []storage.ScrapeRun
+	for _, previous := range runs {
+		if previous.ID >= run.ID || previous.Profile != run.Profile || previous.Status == storage.RunStatusRunning {
+			continue
+		}
+		if previous.Status != storage.RunStatusFailed {
+			break
+		}
+		failed = append(failed, previous)
+	}
+
+	alert := notification.FailureAlert{Profile: run.Profile}
+	switch {
+	case run.Status == storage.RunStatusFailed && len(failed)+1 == threshold:
+		alert.Failures = threshold
+		alert.Since = run.StartedAt
+		if len(failed) > 0 {
+			alert.Since = failed[len(failed)-1].StartedAt
+		}
+		if len(run.Errors) > 0 {
+			alert.Error = run.Errors[len(run.Errors)-1]
+		}
+		alert.Screenshot = failureScreenshot(run.StartedAt)
+	case run.Status != storage.RunStatusFailed && len(failed) >= threshold:
+		alert.Failures = len(failed)
+		alert.Since = failed[len(failed)-1].StartedAt
+		alert.Recovered = true
+	default:
+		return
+	}
+
+	if err := notifier.SendFailureAlert(envList("SCRAPE_ALERT_TARGETS"), alert); err != nil {
+		log.Printf("Warning: Failed to send scrape failure alert: %v", err)
+		return
+	}
+	if alert.Recovered {
+		fmt.Println("✅ Scraping recovered, alert sent")
+	} else {
+		fmt.Printf("🚨 Scraping failed %d times in a row, alert sent\n", alert.Failures)
+	}
+}
+
+// failureScreenshot returns the newest screenshot taken since the run started, nil if there is none
+func failureScreenshot(since time.Time) *notification.Attachment {
+	path, err := scraper.LatestScreenshot(since)
+	if err != nil {
+		log.Printf("Warning: Failed to find the last screenshot: %v", err)
+		return nil
+	}
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Warning: Failed to read screenshot: %v", err)
+		return nil
+	}
+	return &notification.Attachment{Filename: filepath.Base(path), ContentType: "image/png", Data: data}
+}
+
+// runSummary returns the summary of a finished run. The next run is expected RUN_INTERVAL after
+// this one started, if set to the interval the scraper is scheduled at.
+func runSummary(run *storage.ScrapeRun) notification.RunSummary {
+	summary := notification.RunSummary{
+		RunID:            run.ID,
+		Profile:          run.Profile,
+		ScraperType:      run.ScraperType,
+		Status:           run.Status,
+		StartedAt:        run.StartedAt,
+		FinishedAt:       *run.FinishedAt,
+		ContractsFound:   run.ContractsFound,
+		ContractsNew:     run.ContractsNew,
+		ContractsChanged: run.ContractsChanged,
+		Errors:           run.Errors,
+	}
+	if interval := envDuration("RUN_INTERVAL", 0); interval > 0 {
+		nextRun := run.StartedAt.Add(interval)
+		summary.NextRunAt = &nextRun
+	}
+	return summary
+}
+
+// scrapeWithCLI scrapes a profile with the CLI scraper, stores and notifies the results and records
+// the run. progress, if set, is called with the scraper.Step* constants as the scrape goes on.
+func scrapeWithCLI(store storage.Store, notifier *notification.Notifier, profile *storage.Profile, progress func(step string)) (*storage.ScrapeRun, error) {
+	run := startRun(store, scraper.ScraperTypeCLI, profile.Name)
+
+	// Create CLI scraper instance
+	cliScraper, err := scraper.NewScraper(scraper.ScraperTypeCLI)
+	if err != nil {
+		finishRun(store, notifier, run, err)
+		return run, fmt.Errorf("failed to create CLI scraper: %w", err)
+	}
+	defer cliScraper.Close()
+
+	// Use the unified scraping workflow
+	coreScraper := scraper.NewCoreScraper()
+	coreScraper.SetCPVCode(profile.CPVCode)
+	coreScraper.SetProgress(progress)
+	contracts, err := coreScraper.ScrapeLEDContracts(cliScraper)
+	if err != nil {
+		finishRun(store, notifier, run, err)
+		return run, err
+	}
+	run.PagesProcessed++
+	assignProfile(contracts, profile)
+
+	// Extract ALL contracts for status change detection
+	allContracts, err := cliScraper.ExtractAllContracts()
+	if err != nil {
+		log.Printf("Warning: Failed to extract all contracts for status checking: %v", err)
+		run.AddError(fmt.Errorf("failed to extract all contracts: %w", err))
+		allContracts = []scraper.Contract{} // Empty slice if failed
+	}
+	assignProfile(allContracts, profile)
+
+	// Enhance contracts with document links (Pliego and Anuncio)
+	fmt.Println("📄 Enhancing contracts with document links...")
+	enhancedContracts, err := coreScraper.EnhanceContractsWithDocumentLinks(contracts, cliScraper, store)
+	if err != nil {
+		log.Printf("Warning: Failed to enhance contracts with document links: %v", err)
+		run.AddError(fmt.Errorf("failed to enhance contracts with document links: %w", err))
+		enhancedContracts = contracts // Use original contracts if enhancement fails
+	}
+
+	fmt.Printf("📊 Found %d contracts with CLI scraper\n", len(enhancedContracts))
+	fmt.Printf("📋 Found %d total contracts for status change detection\n", len(allContracts))
+	if err := processContractsWithStatusCheck(enhancedContracts, allContracts, store, notifier, run); err != nil {
+		finishRun(store, notifier, run, err)
+		return run, err
+	}
+	finishRun(store, notifier, run, nil)
+	return run, nil
+}
+
+// failRun records a failed scrape and exits
+func failRun(store storage.Store, notifier *notification.Notifier, run *storage.ScrapeRun, message string, err error) {
+	finishRun(store, notifier, run, err)
+	log.Fatalf("%s: %v", message, err)
+}
+
+// processContracts handles the common logic for processing scraped contracts. It only fails
+// when the contracts cannot be stored.
+func processContracts(contracts []scraper.Contract, store storage.Store, notifier *notification.Notifier, run *storage.ScrapeRun) error {
+	run.ContractsFound = len(contracts)
+
+	// Notifications that failed on an earlier run go out before the new ones
+	if err := retryNotifications(store, notifier); err != nil {
+		log.Printf("Warning: Failed to retry notifications: %v", err)
+		run.AddError(err)
+	}
+	if err := releaseHeldNotifications(store, notifier); err != nil {
+		log.Printf("Warning: Failed to send held notifications: %v", err)
+		run.AddError(err)
+	}
+
 	if len(contracts) > 0 {
 		// Get new contracts
 		newContracts, err := store.GetNewContracts(contracts)
 		if err != nil {
-			log.Fatalf("Failed to check for new contracts: %v", err)
+			return fmt.Errorf("failed to check for new contracts: %w", err)
 		}
+		run.ContractsNew = len(newContracts)
 
 		fmt.Printf("🆕 Found %d new contracts\n", len(newContracts))
 
 		// Save all contracts (this will also detect status changes)
 		if err := store.SaveContracts(contracts); err != nil {
-			log.Fatalf("Failed to save contracts: %v", err)
+			return fmt.Errorf("failed to save contracts: %w", err)
+		}
+
+		// Move closed and expired contracts out of the active view
+		if _, err := store.ArchiveContracts(storage.DefaultArchivePolicy); err != nil {
+			log.Printf("Warning: Failed to archive contracts: %v", err)
+			run.AddError(err)
 		}
 
 		// Send notification for new contracts
 		if len(newContracts) > 0 {
 			if err := notifier.SendNewContractsNotification(newContracts); err != nil {
 				log.Printf("Warning: Failed to send notification: %v", err)
+				run.AddError(err)
 			} else {
 				fmt.Println("📧 Notification sent for new contracts")
 			}
 		}
 	}
 
+	// Watched contracts always get their status changes and deadline reminders
+	if err := notifyWatchlist(store, notifier, run.StartedAt); err != nil {
+		log.Printf("Warning: Failed to send watchlist notification: %v", err)
+		run.AddError(err)
+	}
+
 	// Show total count
 	count, err := store.GetContractCount()
 	if err != nil {
@@ -236,19 +1577,23 @@ func processContracts(contracts []scraper.Contract, store *storage.Storage, noti
 	} else {
 		fmt.Printf("💾 Total contracts in database: %d\n", count)
 	}
+	return nil
 }
 
 // processContractsWithStatusCheck handles contracts and status changes
-func processContractsWithStatusCheck(contracts []scraper.Contract, allContracts []scraper.Contract, store *storage.Storage, notifier *notification.Notifier) {
-	// First, check for status changes in existing contracts
+func processContractsWithStatusCheck(contracts []scraper.Contract, allContracts []scraper.Contract, store storage.Store, notifier *notification.Notifier, run *storage.ScrapeRun) error {
+	// First, check for status and field changes in existing contracts
 	if len(allContracts) > 0 {
-		if err := store.CheckAndUpdateStatusChanges(allContracts); err != nil {
-			log.Printf("Warning: Failed to check status changes: %v", err)
+		if err := store.CheckAndUpdateChanges(allContracts); err != nil {
+			log.Printf("Warning: Failed to check contract changes: %v", err)
+			run.AddError(err)
 		}
 	}
 
 	// Then process new contracts
-	processContracts(contracts, store, notifier)
+	if err := processContracts(contracts, store, notifier, run); err != nil {
+		return err
+	}
 
 	// Check for status changes
 	statusChanges, err := store.GetRecentStatusChanges()
@@ -260,4 +1605,40 @@ func processContractsWithStatusCheck(contracts []scraper.Contract, allContracts
 			fmt.Printf("   • %s: %s → %s (%s)\n", change.ContractID, change.OldStatus, change.NewStatus, change.ChangedAt)
 		}
 	}
+
+	if err := notifyStatusChanges(store, notifier, run.StartedAt); err != nil {
+		log.Printf("Warning: Failed to send status change notification: %v", err)
+		run.AddError(err)
+	}
+	return nil
+}
+
+// notifyStatusChanges emails the status changes recorded since the run started. Watched contracts
+// are left out, as notifyWatchlist already reports their changes.
+func notifyStatusChanges(store storage.Store, notifier *notification.Notifier, since time.Time) error {
+	changes, err := store.GetUnwatchedStatusChangesSince(since)
+	if err != nil {
+		return err
+	}
+
+	var updates []notification.StatusUpdate
+	for _, change := range changes {
+		contract, err := store.GetContractByID(change.ContractID)
+		if err != nil {
+			return err
+		}
+		if contract == nil {
+			continue
+		}
+		updates = append(updates, notification.StatusUpdate{Contract: *contract, OldStatus: change.OldStatus, NewStatus: change.NewStatus})
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := notifier.SendStatusChangeNotification(updates); err != nil {
+		return err
+	}
+	fmt.Printf("📧 Status change notification sent (%d changes)\n", len(updates))
+	return nil
 } 
\ No newline at end of file
@@ -0,0 +1,142 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"scraper/internal/scraper"
+)
+
+// FileName is the suggested name for attached and downloaded calendars
+const FileName = "deadlines.ics"
+
+// ContentType is the MIME type of the generated calendar
+const ContentType = "text/calendar; charset=utf-8"
+
+// icsTime is the UTC date-time format of iCalendar
+const icsTime = "20060102T150405Z"
+
+// icsLineLimit is the longest content line in octets; longer lines are folded (RFC 5545 3.1)
+const icsLineLimit = 75
+
+// Options names the calendar and its events, so they can be translated
+type Options struct {
+	Name  string // Calendar name shown by clients that support it
+	Label string // Start of every event summary, e.g. "Deadline"
+}
+
+// Write writes an iCalendar with one event at the submission deadline of every contract that has
+// one, with a reminder the day before. Events are identified by the contract, so importing a newer
+// calendar moves a postponed deadline instead of adding a second event.
+func Write(w io.Writer, contracts []scraper.Contract, opts Options) error {
+	now := time.Now().UTC().Format(icsTime)
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//LED Screen Contract Scraper//Deadlines//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	if opts.Name != "" {
+		lines = append(lines, "X-WR-CALNAME:"+escapeText(opts.Name))
+	}
+
+	for _, contract := range WithDeadlines(contracts) {
+		deadline := contract.Deadline.UTC().Format(icsTime)
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+escapeText(contract.ID)+"@led-contracts",
+			"DTSTAMP:"+now,
+			"DTSTART:"+deadline,
+			"DTEND:"+deadline,
+			"SUMMARY:"+escapeText(summary(contract, opts.Label)),
+			"DESCRIPTION:"+escapeText(description(contract)),
+		)
+		if link := contractLink(contract); link != "" {
+			lines = append(lines, "URL:"+link)
+		}
+		lines = append(lines,
+			"BEGIN:VALARM",
+			"ACTION:DISPLAY",
+			"DESCRIPTION:"+escapeText(summary(contract, opts.Label)),
+			"TRIGGER:-P1D",
+			"END:VALARM",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, fold(line)+"\r\n"); err != nil {
+			return fmt.Errorf("failed to write calendar: %w", err)
+		}
+	}
+	return nil
+}
+
+// WithDeadlines returns the contracts with a known deadline, each once
+func WithDeadlines(contracts []scraper.Contract) []scraper.Contract {
+	var result []scraper.Contract
+	seen := make(map[string]bool)
+	for _, contract := range contracts {
+		if contract.Deadline == nil || seen[contract.ID] {
+			continue
+		}
+		seen[contract.ID] = true
+		result = append(result, contract)
+	}
+	return result
+}
+
+// summary is the title of the event of a contract
+func summary(contract scraper.Contract, label string) string {
+	if label == "" {
+		label = "Deadline"
+	}
+	return fmt.Sprintf("%s: %s - %s", label, contract.ID, contract.Description)
+}
+
+// description is the body of the event of a contract
+func description(contract scraper.Contract) string {
+	parts := []string{contract.Description}
+	for _, field := range []string{contract.ContractingBody, contract.Amount, contract.Status, contract.Link} {
+		if field != "" {
+			parts = append(parts, field)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// contractLink returns the dashboard page of a contract if it is linked, otherwise the portal
+func contractLink(contract scraper.Contract) string {
+	if contract.DashboardLink != "" {
+		return contract.DashboardLink
+	}
+	return contract.Link
+}
+
+// escapeText escapes a TEXT value (RFC 5545 3.3.11)
+func escapeText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(text)
+}
+
+// fold splits a content line longer than icsLineLimit octets into continuation lines starting with
+// a space, without splitting a UTF-8 character
+func fold(line string) string {
+	var b strings.Builder
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = icsLineLimit - 1 // The leading space counts
+	}
+	b.WriteString(line)
+	return b.String()
+}
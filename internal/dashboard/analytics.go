@@ -0,0 +1,132 @@
+package dashboard
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"scraper/internal/storage"
+)
+
+// analyticsMonths is how many months the contracts per month chart shows
+const analyticsMonths = 24
+
+// analyticsBodies is how many contracting bodies the budget chart shows
+const analyticsBodies = 15
+
+// chartBar is one bar of a chart on the analytics page
+type chartBar struct {
+	Label   string
+	Count   int
+	Value   string  // Estimated value in euros, formatted
+	Percent float64 // Length of the bar relative to the longest one
+}
+
+// handleAnalytics serves the analytics page: contracts per month, budget by contracting body,
+// status funnel and time to adjudication
+func (d *Dashboard) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	stats, err := d.store.GetStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	funnel, err := d.store.GetStatusFunnel()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	adjudication, err := d.store.GetAdjudicationTimes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	months := stats.ByMonth
+	if len(months) > analyticsMonths {
+		months = months[len(months)-analyticsMonths:]
+	}
+
+	bodies := append([]storage.StatGroup(nil), stats.ByContractingBody...)
+	sort.SliceStable(bodies, func(i, j int) bool { return bodies[i].Value > bodies[j].Value })
+	if len(bodies) > analyticsBodies {
+		bodies = bodies[:analyticsBodies]
+	}
+
+	funnelBars := make([]chartBar, len(funnel))
+	funnelSizes := make([]float64, len(funnel))
+	for i, status := range funnel {
+		funnelBars[i] = chartBar{Label: status.Status, Count: status.Count}
+		funnelSizes[i] = float64(status.Count)
+	}
+
+	data := struct {
+		page
+		Total        int
+		TotalValue   string
+		Months       []chartBar
+		Bodies       []chartBar
+		Funnel       []chartBar
+		Adjudication *storage.AdjudicationTimes
+		AverageDays  string
+		MedianDays   string
+	}{
+		page:         d.page(r),
+		Total:        stats.Total,
+		TotalValue:   formatEuros(stats.TotalValue),
+		Months:       groupBars(months, func(g storage.StatGroup) float64 { return float64(g.Count) }),
+		Bodies:       groupBars(bodies, func(g storage.StatGroup) float64 { return g.Value }),
+		Funnel:       scaleBars(funnelBars, funnelSizes),
+		Adjudication: adjudication,
+		AverageDays:  strconv.FormatFloat(adjudication.AverageDays, 'f', 1, 64),
+		MedianDays:   strconv.FormatFloat(adjudication.MedianDays, 'f', 1, 64),
+	}
+
+	d.renderPage(w, r, http.StatusOK, "analytics.html", data)
+}
+
+// groupBars turns stat groups into bars sized by size
+func groupBars(groups []storage.StatGroup, size func(storage.StatGroup) float64) []chartBar {
+	bars := make([]chartBar, len(groups))
+	sizes := make([]float64, len(groups))
+	for i, group := range groups {
+		label := group.Key
+		if label == "" {
+			label = "Unknown"
+		}
+		bars[i] = chartBar{Label: label, Count: group.Count, Value: formatEuros(group.Value)}
+		sizes[i] = size(group)
+	}
+	return scaleBars(bars, sizes)
+}
+
+// scaleBars sets the Percent of every bar from its size, the largest bar being 100
+func scaleBars(bars []chartBar, sizes []float64) []chartBar {
+	var largest float64
+	for _, size := range sizes {
+		if size > largest {
+			largest = size
+		}
+	}
+	if largest > 0 {
+		for i := range bars {
+			bars[i].Percent = sizes[i] / largest * 100
+		}
+	}
+	return bars
+}
+
+// formatEuros formats an amount in whole euros with Spanish thousands separators, e.g. "1.250.000 €"
+func formatEuros(amount float64) string {
+	digits := strconv.FormatFloat(amount, 'f', 0, 64)
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+	return grouped.String() + " €"
+}
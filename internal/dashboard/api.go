@@ -0,0 +1,609 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"scraper/internal/storage"
+)
+
+// The versioned API under /api/v1 is the one meant for integrations. Every response is a JSON
+// envelope, {"data": ...} on success (with "meta" for paged listings) and
+// {"error": {"status": ..., "code": ..., "message": ...}} on failure, sent with a matching status
+// code. Field names are snake_case and only change with a new version. The unversioned /api/
+// endpoints are what the dashboard page uses and may change with it.
+
+// apiPrefix is the path all versioned endpoints live under
+const apiPrefix = "/api/v1/"
+
+// apiEnvelope is the body of every /api/v1 response
+type apiEnvelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Meta  *apiMeta    `json:"meta,omitempty"`
+	Error *apiError   `json:"error,omitempty"`
+}
+
+// apiMeta describes the page of a listing
+type apiMeta struct {
+	Total  int `json:"total"` // Items matching the request, on every page
+	Limit  int `json:"limit"` // 0 when every item was returned
+	Offset int `json:"offset"`
+}
+
+// apiError is a failed /api/v1 request
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"` // Stable, machine readable version of the status, see apiErrorCodes
+	Message string `json:"message"`
+}
+
+// apiErrorCodes are the error codes sent for each status
+var apiErrorCodes = map[int]string{
+	http.StatusBadRequest:          "invalid_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusMethodNotAllowed:    "method_not_allowed",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "rate_limited",
+	http.StatusInternalServerError: "internal_error",
+	http.StatusBadGateway:          "bad_gateway",
+	http.StatusServiceUnavailable:  "unavailable",
+}
+
+// registerAPIRoutes registers the /api/v1 endpoints. Contracts are referred to by uid or id.
+func (d *Dashboard) registerAPIRoutes() {
+	mux := d.mux
+	mux.HandleFunc("GET /api/v1/contracts", d.apiListContracts)
+	mux.HandleFunc("DELETE /api/v1/contracts", d.apiDeleteAllContracts)
+	mux.HandleFunc("POST /api/v1/contracts/seen", d.apiMarkSeen)
+	mux.HandleFunc("POST /api/v1/contracts/delete", d.apiDeleteContracts)
+	mux.HandleFunc("POST /api/v1/contracts/archive", d.apiArchiveContracts)
+	mux.HandleFunc("POST /api/v1/contracts/tag", d.apiTagContracts)
+	mux.HandleFunc("GET /api/v1/contracts/{id}", d.apiGetContract)
+	mux.HandleFunc("DELETE /api/v1/contracts/{id}", d.apiDeleteContract)
+	mux.HandleFunc("POST /api/v1/contracts/{id}/unarchive", d.apiUnarchiveContract)
+	mux.HandleFunc("PUT /api/v1/contracts/{id}/watch", d.apiWatchContract)
+	mux.HandleFunc("DELETE /api/v1/contracts/{id}/watch", d.apiUnwatchContract)
+	mux.HandleFunc("PUT /api/v1/contracts/{id}/tags/{tag}", d.apiAddTag)
+	mux.HandleFunc("DELETE /api/v1/contracts/{id}/tags/{tag}", d.apiRemoveTag)
+	mux.HandleFunc("GET /api/v1/contracts/{id}/notes", d.apiListNotes)
+	mux.HandleFunc("POST /api/v1/contracts/{id}/notes", d.apiAddNote)
+	mux.HandleFunc("GET /api/v1/contracts/{id}/revisions", d.apiContractRevisions)
+	mux.HandleFunc("GET /api/v1/contracts/{id}/status-changes", d.apiContractStatusChanges)
+	mux.HandleFunc("PATCH /api/v1/notes/{id}", d.apiUpdateNote)
+	mux.HandleFunc("DELETE /api/v1/notes/{id}", d.apiDeleteNote)
+	mux.HandleFunc("GET /api/v1/deleted-contracts", d.apiListDeletedContracts)
+	mux.HandleFunc("POST /api/v1/deleted-contracts/restore", d.apiRestoreAllContracts)
+	mux.HandleFunc("POST /api/v1/deleted-contracts/{id}/restore", d.apiRestoreContract)
+	mux.HandleFunc("GET /api/v1/status-changes", d.apiListStatusChanges)
+	mux.HandleFunc("POST /api/v1/status-changes/{id}/ack", d.apiAckStatusChange)
+	mux.HandleFunc("GET /api/v1/revisions", d.apiRecentRevisions)
+	mux.HandleFunc("GET /api/v1/stats", d.apiStats)
+	mux.HandleFunc("GET /api/v1/statuses", d.apiStatuses)
+	mux.HandleFunc("GET /api/v1/tags", d.apiTags)
+	mux.HandleFunc("GET /api/v1/cpv-codes", d.apiCPVCodes)
+	mux.HandleFunc("GET /api/v1/profiles", d.apiProfiles)
+	mux.HandleFunc("GET /api/v1/scrape-runs", d.apiScrapeRuns)
+	mux.HandleFunc("GET /api/v1/audit-log", d.apiAuditLog)
+	mux.HandleFunc("GET /api/v1/channels", d.apiListChannels)
+	mux.HandleFunc("POST /api/v1/channels", d.apiAddChannel)
+	mux.HandleFunc("DELETE /api/v1/channels/{id}", d.apiDeleteChannel)
+	mux.HandleFunc("POST /api/v1/channels/{id}/test", d.apiTestChannel)
+	mux.HandleFunc("GET /api/v1/me", d.apiMe)
+	mux.HandleFunc("GET /api/v1/scrape", d.apiScrapeStatus)
+	mux.HandleFunc("POST /api/v1/scrape", d.apiStartScrape)
+	mux.HandleFunc("GET /api/v1/openapi.yaml", d.handleOpenAPISpec)
+	mux.HandleFunc(apiPrefix, d.handleUnknownAPI)
+}
+
+// writeAPIData sends a successful /api/v1 response
+func writeAPIData(w http.ResponseWriter, status int, data interface{}, meta *apiMeta) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiEnvelope{Data: data, Meta: meta})
+}
+
+// writeAPIError sends a failed /api/v1 response
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	code, ok := apiErrorCodes[status]
+	if !ok {
+		code = "error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiEnvelope{Error: &apiError{Status: status, Code: code, Message: message}})
+}
+
+// writeAPIStoreError answers a request the store failed. Internal errors are logged rather than
+// sent, as they may describe the database.
+func writeAPIStoreError(w http.ResponseWriter, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Warning: API request failed: %v", err)
+		writeAPIError(w, status, "Internal server error")
+		return
+	}
+	writeAPIError(w, status, err.Error())
+}
+
+// writeAPIQueryError answers a request whose contract query is invalid, like writeQueryError
+func writeAPIQueryError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, errProfileNotFound) {
+		status = http.StatusNotFound
+	}
+	writeAPIError(w, status, err.Error())
+}
+
+// writeAPIList sends a listing, as an empty array rather than null when there are no items
+func writeAPIList[T any](w http.ResponseWriter, items []T, meta *apiMeta) {
+	if items == nil {
+		items = []T{}
+	}
+	writeAPIData(w, http.StatusOK, items, meta)
+}
+
+// apiContractID resolves the {id} path value of a request to the id the contract is stored under
+func (d *Dashboard) apiContractID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, err := d.store.ResolveContractID(r.PathValue("id"))
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return "", false
+	}
+	return id, true
+}
+
+// apiNumericID reads a numeric {id} path value, e.g. of a note
+func apiNumericID(w http.ResponseWriter, r *http.Request, what string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeAPIError(w, http.StatusBadRequest, "Invalid "+what+" ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeAPIBody decodes the JSON body of a request into v
+func decodeAPIBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// handleUnknownAPI answers the API requests no endpoint matches, with 405 when the path exists for
+// other methods
+func (d *Dashboard) handleUnknownAPI(w http.ResponseWriter, r *http.Request) {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := d.mux.Handler(probe); pattern != "/api/" && pattern != apiPrefix {
+			allowed = append(allowed, method)
+		}
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeRequestError(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeRequestError(w, r, "No such endpoint", http.StatusNotFound)
+}
+
+// apiListContracts lists the contracts matching the filters, sort and page read by contractQuery
+func (d *Dashboard) apiListContracts(w http.ResponseWriter, r *http.Request) {
+	query, err := d.contractQuery(r.URL.Query())
+	if err != nil {
+		writeAPIQueryError(w, err)
+		return
+	}
+
+	contracts, total, err := d.store.GetContractsPage(query.filter, query.sort, query.limit, query.offset)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, contracts, &apiMeta{Total: total, Limit: query.limit, Offset: query.offset})
+}
+
+// apiGetContract returns one contract, whether active or archived
+func (d *Dashboard) apiGetContract(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.apiContractID(w, r)
+	if !ok {
+		return
+	}
+
+	contract, err := d.store.GetContractByID(id)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	if contract == nil {
+		writeAPIError(w, http.StatusNotFound, "contract "+r.PathValue("id")+" not found")
+		return
+	}
+	writeAPIData(w, http.StatusOK, contract, nil)
+}
+
+// apiDeleteAllContracts soft-deletes every contract. It needs ?confirm=all so a stray DELETE on the
+// collection cannot empty it.
+func (d *Dashboard) apiDeleteAllContracts(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("confirm") != "all" {
+		writeAPIError(w, http.StatusBadRequest, "Deleting every contract needs ?confirm=all")
+		return
+	}
+	d.apiAction(w, d.store.DeleteAllContracts(requestActor(r)))
+}
+
+// apiDeleteContract soft-deletes a contract
+func (d *Dashboard) apiDeleteContract(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, func(id string) error { return d.store.DeleteContract(id, requestActor(r)) })
+}
+
+// apiUnarchiveContract moves an archived contract back to the active list
+func (d *Dashboard) apiUnarchiveContract(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, d.store.UnarchiveContract)
+}
+
+// apiWatchContract puts a contract on the watchlist
+func (d *Dashboard) apiWatchContract(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, d.store.WatchContract)
+}
+
+// apiUnwatchContract removes a contract from the watchlist
+func (d *Dashboard) apiUnwatchContract(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, d.store.UnwatchContract)
+}
+
+// apiAddTag labels a contract with the {tag}
+func (d *Dashboard) apiAddTag(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, func(id string) error { return d.store.AddTag(id, r.PathValue("tag")) })
+}
+
+// apiRemoveTag removes the {tag} from a contract
+func (d *Dashboard) apiRemoveTag(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, func(id string) error { return d.store.RemoveTag(id, r.PathValue("tag")) })
+}
+
+// apiContractAction applies action to the {id} contract
+func (d *Dashboard) apiContractAction(w http.ResponseWriter, r *http.Request, action func(contractID string) error) {
+	id, ok := d.apiContractID(w, r)
+	if !ok {
+		return
+	}
+	d.apiAction(w, action(id))
+}
+
+// apiAction answers a request that returns nothing: 204 on success
+func (d *Dashboard) apiAction(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiMarkSeen clears the unseen flag of the contracts in {"ids": [...]}, or of every contract with {"all": true}
+func (d *Dashboard) apiMarkSeen(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		IDs []string `json:"ids"`
+		All bool     `json:"all"`
+	}
+	if !decodeAPIBody(w, r, &request) {
+		return
+	}
+
+	var seen int64
+	var err error
+	if request.All {
+		seen, err = d.store.MarkAllContractsSeen()
+	} else {
+		ids := make([]string, 0, len(request.IDs))
+		for _, ref := range request.IDs {
+			id, err := d.store.ResolveContractID(ref)
+			if err != nil {
+				writeAPIStoreError(w, err)
+				return
+			}
+			ids = append(ids, id)
+		}
+		seen, err = d.store.MarkContractsSeen(ids)
+	}
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIData(w, http.StatusOK, map[string]int64{"seen": seen}, nil)
+}
+
+// apiListNotes lists the notes of a contract
+func (d *Dashboard) apiListNotes(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.apiContractID(w, r)
+	if !ok {
+		return
+	}
+
+	notes, err := d.store.GetNotes(id)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, notes, nil)
+}
+
+// apiAddNote attaches the note {"body": ..., "author": ...} to a contract. The author is the
+// logged in user when there is one.
+func (d *Dashboard) apiAddNote(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.apiContractID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Author string `json:"author"`
+		Body   string `json:"body"`
+	}
+	if !decodeAPIBody(w, r, &request) {
+		return
+	}
+	if user := authenticatedUser(r); user != "" {
+		request.Author = user
+	}
+
+	note, err := d.store.AddNote(id, request.Author, request.Body)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIData(w, http.StatusCreated, note, nil)
+}
+
+// apiUpdateNote replaces the text of a note with {"body": ...}
+func (d *Dashboard) apiUpdateNote(w http.ResponseWriter, r *http.Request) {
+	noteID, ok := apiNumericID(w, r, "note")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Body string `json:"body"`
+	}
+	if !decodeAPIBody(w, r, &request) {
+		return
+	}
+	d.apiAction(w, d.store.UpdateNote(noteID, request.Body))
+}
+
+// apiDeleteNote removes a note
+func (d *Dashboard) apiDeleteNote(w http.ResponseWriter, r *http.Request) {
+	noteID, ok := apiNumericID(w, r, "note")
+	if !ok {
+		return
+	}
+	d.apiAction(w, d.store.DeleteNote(noteID))
+}
+
+// apiContractRevisions lists the field revisions of a contract
+func (d *Dashboard) apiContractRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.apiContractID(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := d.store.GetContractRevisions(id)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, revisions, nil)
+}
+
+// apiContractStatusChanges lists the status changes of a contract, newest first, acknowledged ones included
+func (d *Dashboard) apiContractStatusChanges(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.apiContractID(w, r)
+	if !ok {
+		return
+	}
+
+	changes, err := d.store.GetStatusChanges(id)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, changes, nil)
+}
+
+// apiMe tells a client who it is authenticated as and what it may do, so an app can check the
+// credentials it was given before showing the contracts
+func (d *Dashboard) apiMe(w http.ResponseWriter, r *http.Request) {
+	writeAPIData(w, http.StatusOK, map[string]interface{}{
+		"user":       authenticatedUser(r), // "token:<name>" for API tokens, empty without a login
+		"can_scrape": d.scrape != nil,
+	}, nil)
+}
+
+// apiRecentRevisions lists the recent field revisions of all contracts
+func (d *Dashboard) apiRecentRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := d.store.GetRecentRevisions()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, revisions, nil)
+}
+
+// apiListDeletedContracts lists the soft-deleted contracts that can still be restored
+func (d *Dashboard) apiListDeletedContracts(w http.ResponseWriter, r *http.Request) {
+	contracts, err := d.store.GetDeletedContracts()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, contracts, nil)
+}
+
+// apiRestoreContract restores a soft-deleted contract
+func (d *Dashboard) apiRestoreContract(w http.ResponseWriter, r *http.Request) {
+	d.apiContractAction(w, r, func(id string) error { return d.store.RestoreContract(id, requestActor(r)) })
+}
+
+// apiRestoreAllContracts restores every soft-deleted contract
+func (d *Dashboard) apiRestoreAllContracts(w http.ResponseWriter, r *http.Request) {
+	restored, err := d.store.RestoreAllContracts(requestActor(r))
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIData(w, http.StatusOK, map[string]int64{"restored": restored}, nil)
+}
+
+// apiListStatusChanges lists the recent status changes, acknowledged ones only with ?acknowledged=1
+func (d *Dashboard) apiListStatusChanges(w http.ResponseWriter, r *http.Request) {
+	changes, err := d.store.GetRecentStatusChanges()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("acknowledged") != "1" {
+		pending := []storage.StatusChange{}
+		for _, change := range changes {
+			if change.AcknowledgedAt == nil {
+				pending = append(pending, change)
+			}
+		}
+		changes = pending
+	}
+	writeAPIList(w, changes, nil)
+}
+
+// apiAckStatusChange acknowledges a status change for every dashboard user
+func (d *Dashboard) apiAckStatusChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiNumericID(w, r, "status change")
+	if !ok {
+		return
+	}
+	d.apiAction(w, d.store.AcknowledgeStatusChange(id, requestActor(r)))
+}
+
+// apiStats returns the dashboard statistics
+func (d *Dashboard) apiStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := d.collectStats()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIData(w, http.StatusOK, stats, nil)
+}
+
+// apiStatuses counts the contracts by status, with the filters of /api/v1/contracts except status
+func (d *Dashboard) apiStatuses(w http.ResponseWriter, r *http.Request) {
+	query, err := d.contractQuery(r.URL.Query())
+	if err != nil {
+		writeAPIQueryError(w, err)
+		return
+	}
+
+	statuses, err := d.store.GetStatusCounts(query.filter)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, statuses, nil)
+}
+
+// apiTags lists the tags in use with their number of contracts
+func (d *Dashboard) apiTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := d.store.GetTags()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, tags, nil)
+}
+
+// apiCPVCodes lists the CPV codes listed by contracts with their number of contracts
+func (d *Dashboard) apiCPVCodes(w http.ResponseWriter, r *http.Request) {
+	codes, err := d.store.GetCPVCodes()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, codes, nil)
+}
+
+// apiProfiles lists the search profiles with their number of contracts
+func (d *Dashboard) apiProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := d.store.GetProfiles()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, profiles, nil)
+}
+
+// apiScrapeRuns lists the most recent scrape runs (?limit=N, 20 by default)
+func (d *Dashboard) apiScrapeRuns(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r.URL.Query(), 20)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	runs, err := d.store.GetScrapeRuns(limit)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, runs, nil)
+}
+
+// apiAuditLog lists the most recent audit log entries (?limit=N, 100 by default)
+func (d *Dashboard) apiAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r.URL.Query(), 100)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := d.store.GetAuditLog(limit)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, entries, nil)
+}
+
+// apiScrapeStatus returns the progress of the running or last scrape started from the dashboard
+func (d *Dashboard) apiScrapeStatus(w http.ResponseWriter, r *http.Request) {
+	if d.scrape == nil {
+		writeAPIError(w, http.StatusNotFound, "Scraping from the dashboard is not enabled")
+		return
+	}
+	writeAPIData(w, http.StatusOK, d.scrape.status(), nil)
+}
+
+// apiStartScrape starts a scrape of the optional ?profile=, answering 202 with its progress, or
+// 409 while another scrape is running
+func (d *Dashboard) apiStartScrape(w http.ResponseWriter, r *http.Request) {
+	if d.scrape == nil {
+		writeAPIError(w, http.StatusNotFound, "Scraping from the dashboard is not enabled")
+		return
+	}
+
+	actor := requestActor(r)
+	job, started := d.scrape.start(strings.TrimSpace(r.FormValue("profile")), actor)
+	if !started {
+		writeAPIError(w, http.StatusConflict, "A scrape is already running")
+		return
+	}
+	log.Printf("Scrape started through the API by %s", actor)
+	writeAPIData(w, http.StatusAccepted, job, nil)
+}
@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// The page templates (templates/*.html), the files served under /static/ (static/*) and the API
+// specification (openapi.yaml) are built into the binary. With SetAssetsDir they are read from disk instead, for frontend development.
+//
+//go:embed templates/*.html static/* openapi.yaml
+var assetFiles embed.FS
+
+// SetAssetsDir reads the templates and static files from dir (the internal/dashboard directory of a
+// checkout) on every request rather than from the binary, so frontend edits show on reload
+func (d *Dashboard) SetAssetsDir(dir string) error {
+	if dir == "" {
+		d.assetsDir = ""
+		return nil
+	}
+	if _, err := d.parsePageTemplates(os.DirFS(dir)); err != nil {
+		return err
+	}
+	d.assetsDir = dir
+	return nil
+}
+
+// parsePageTemplates parses the templates/*.html files of assets, named after their file, e.g.
+// "dashboard.html". They link to other pages with {{url "/history"}}, which adds the base path, and
+// give their text in English with {{t "Total Contracts"}}, translated when the page is rendered.
+func (d *Dashboard) parsePageTemplates(assets fs.FS) (*template.Template, error) {
+	funcs := template.FuncMap{"url": d.url, "t": translator(languageEnglish)}
+	templates, err := template.New("").Funcs(funcs).ParseFS(assets, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard templates: %w", err)
+	}
+	return templates, nil
+}
+
+// assets returns the file system the templates and static files are read from
+func (d *Dashboard) assets() fs.FS {
+	if d.assetsDir != "" {
+		return os.DirFS(d.assetsDir)
+	}
+	return assetFiles
+}
+
+// page holds what every page template needs besides its own data
+type page struct {
+	Theme    string // themeDark or themeLight
+	Lang     string // Language of the page, languageSpanish or languageEnglish
+	ReadOnly bool   // A visitor of a public dashboard, shown no buttons that change anything
+}
+
+// page returns the common page data for a request
+func (d *Dashboard) page(r *http.Request) page {
+	return page{Theme: d.theme(r), Lang: d.language(r), ReadOnly: d.readOnly(r)}
+}
+
+// renderPage executes the page template name with data, in the language of the request, and sends
+// it with the given status code
+func (d *Dashboard) renderPage(w http.ResponseWriter, r *http.Request, status int, name string, data interface{}) {
+	templates := d.pages
+	if d.assetsDir != "" {
+		var err error
+		if templates, err = d.parsePageTemplates(d.assets()); err != nil {
+			log.Printf("Warning: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	templates, err := templates.Clone()
+	if err != nil {
+		log.Printf("Warning: Failed to render %s: %v", name, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	templates.Funcs(template.FuncMap{"t": translator(d.language(r))})
+
+	// Render into a buffer so a failing template still gets an error page
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Printf("Warning: Failed to render %s: %v", name, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// handleStatic serves the CSS and JavaScript files, the icons and the web app manifest
+func (d *Dashboard) handleStatic(w http.ResponseWriter, r *http.Request) {
+	d.serveStatic(w, r, strings.TrimPrefix(r.URL.Path, "/static/"))
+}
+
+// serveStatic serves the static file name. Browsers check for changes on every load and only
+// download a file again when its content changed.
+func (d *Dashboard) serveStatic(w http.ResponseWriter, r *http.Request, name string) {
+	content, err := fs.ReadFile(d.assets(), "static/"+name)
+	if name == "" || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(content)))
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
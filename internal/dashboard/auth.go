@@ -0,0 +1,337 @@
+package dashboard
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authentication modes, see SetAuth
+const (
+	AuthLogin = "login" // Login page with a session cookie; basic auth is accepted too
+	AuthBasic = "basic" // HTTP basic auth only
+)
+
+// sessionCookie is the cookie holding the session token after logging in
+const sessionCookie = "dashboard_session"
+
+// sessionDuration is how long a login lasts
+const sessionDuration = 24 * time.Hour
+
+// loginFailureDelay slows down password guessing
+const loginFailureDelay = time.Second
+
+// auth holds the users allowed into the dashboard and their sessions
+type auth struct {
+	users    map[string]string // Passwords by user name
+	mode     string
+	mu       sync.Mutex
+	sessions map[string]session // Sessions by token
+}
+
+// session is a logged in user
+type session struct {
+	user    string
+	expires time.Time
+}
+
+// userKey is the context key of the authenticated user of a request
+type userKey struct{}
+
+// ParseUsers parses the users allowed into the dashboard from "name:password" pairs separated by
+// commas, e.g. "ana:secret,luis:hunter2"
+func ParseUsers(value string) (map[string]string, error) {
+	users := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, password, ok := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || password == "" {
+			return nil, fmt.Errorf("invalid user %q, expected name:password", strings.TrimSpace(name))
+		}
+		users[name] = password
+	}
+	return users, nil
+}
+
+// SetAuth requires one of the users to log in before using the dashboard, with a login page
+// (AuthLogin, the default) or HTTP basic auth (AuthBasic). Without users the dashboard is open.
+func (d *Dashboard) SetAuth(users map[string]string, mode string) error {
+	switch mode {
+	case "":
+		mode = AuthLogin
+	case AuthLogin, AuthBasic:
+	default:
+		return fmt.Errorf("unknown authentication mode %q (use %s or %s)", mode, AuthLogin, AuthBasic)
+	}
+
+	if len(users) == 0 {
+		d.auth = nil
+		return nil
+	}
+	d.auth = &auth{users: users, mode: mode, sessions: make(map[string]session)}
+	return nil
+}
+
+// requireAuth lets only authenticated requests through to next. Pages redirect to the login page,
+// the API answers 401 with a basic auth challenge so scripts and calendar apps can log in. API
+// requests with a bearer token are checked against the API tokens, whether or not users are set.
+// A public dashboard lets the requests of isPublicRead through without a user, see SetPublic.
+// Requests from other sites that would change something are refused, see isCrossSite.
+func (d *Dashboard) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := bearerToken(r); ok {
+			d.authenticateToken(w, r, token, next)
+			return
+		}
+		if d.auth == nil {
+			if d.public && !isPublicRead(r) {
+				writeRequestError(w, r, "The dashboard is read-only", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if d.auth.mode == AuthLogin && (r.URL.Path == "/login" || r.URL.Path == "/logout") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := d.auth.sessionUser(r)
+		if user == "" {
+			if name, password, ok := r.BasicAuth(); ok {
+				if d.auth.checkPassword(name, password) {
+					user = name
+				} else {
+					// As on the login page, since limitRequests only sees authenticated requests
+					log.Printf("Warning: Failed dashboard basic auth for %q from %s", name, r.RemoteAddr)
+					time.Sleep(loginFailureDelay)
+				}
+			}
+		}
+		if user == "" && d.public && isPublicRead(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if user == "" {
+			// The API documentation is a page, although it lives under /api/, while the feed is read
+			// by apps like the API
+			isAPI := (strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/api/docs") || r.URL.Path == feedPath
+			if d.auth.mode == AuthLogin && !isAPI {
+				http.Redirect(w, r, d.url("/login")+"?next="+template.URLQueryEscaper(r.URL.RequestURI()), http.StatusSeeOther)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="Contracts Dashboard", charset="UTF-8"`)
+			writeRequestError(w, r, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Browsers send the session cookie and the basic auth credentials along with the requests of
+		// other sites too, so a form elsewhere could otherwise act as the user
+		if d.isCrossSite(r) {
+			log.Printf("Warning: Refused %s %s from %s for %q", r.Method, r.URL.Path, r.Header.Get("Origin"), user)
+			writeRequestError(w, r, "Cross-site request refused", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// isCrossSite reports whether a request that may change something was made by a page of another
+// site than the dashboard, other than the origins SetCORSOrigins allows to call /api/v1. Sec-Fetch-Site tells it in
+// current browsers; older ones are checked by their Origin header, which behind a reverse proxy
+// only matches if the proxy passes the Host header on. Requests without either, e.g. from curl,
+// are not made by a browser.
+func (d *Dashboard) isCrossSite(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if d.corsOrigins[origin] && strings.HasPrefix(r.URL.Path, apiPrefix) {
+		return false
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return false
+	case "":
+	default:
+		return true
+	}
+	if origin == "" {
+		return false
+	}
+	parsed, err := url.Parse(origin)
+	return err != nil || parsed.Host != r.Host
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// authenticateToken serves an API request made with a bearer token, as the user "token:<name>"
+func (d *Dashboard) authenticateToken(w http.ResponseWriter, r *http.Request, token string, next http.Handler) {
+	if !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != feedPath {
+		writeRequestError(w, r, "API tokens only give access to /api/ and "+feedPath, http.StatusForbidden)
+		return
+	}
+
+	apiToken, err := d.store.AuthenticateAPIToken(token)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		writeRequestError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if apiToken == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Contracts Dashboard", error="invalid_token"`)
+		writeRequestError(w, r, "Invalid API token", http.StatusUnauthorized)
+		return
+	}
+
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, "token:"+apiToken.Name)))
+}
+
+// writeRequestError answers a request that failed before reaching its handler, in the /api/v1 error
+// format for versioned API requests
+func writeRequestError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	if strings.HasPrefix(r.URL.Path, apiPrefix) {
+		writeAPIError(w, status, message)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// authenticatedUser returns the user who made a request, empty if the dashboard is open
+func authenticatedUser(r *http.Request) string {
+	user, _ := r.Context().Value(userKey{}).(string)
+	return user
+}
+
+// checkPassword reports whether password is the password of user, in constant time
+func (a *auth) checkPassword(user, password string) bool {
+	expected, ok := a.users[user]
+	got, want := sha256.Sum256([]byte(password)), sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(got[:], want[:]) == 1 && ok
+}
+
+// sessionUser returns the user of the session cookie of a request, empty if there is no valid session
+func (a *auth) sessionUser(r *http.Request) string {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[cookie.Value]
+	if !ok || time.Now().After(s.expires) {
+		delete(a.sessions, cookie.Value)
+		return ""
+	}
+	return s.user
+}
+
+// startSession creates a session for user and returns its token, dropping the expired ones
+func (a *auth) startSession(user string) (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for key, s := range a.sessions {
+		if now.After(s.expires) {
+			delete(a.sessions, key)
+		}
+	}
+	key := hex.EncodeToString(token)
+	a.sessions[key] = session{user: user, expires: now.Add(sessionDuration)}
+	return key, nil
+}
+
+// handleLogin shows the login form and logs the user in when it is posted
+func (d *Dashboard) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = "/"
+	}
+	if d.auth == nil || d.auth.mode != AuthLogin {
+		http.Redirect(w, r, d.url(next), http.StatusSeeOther)
+		return
+	}
+
+	var loginError string
+	if r.Method == http.MethodPost {
+		user := strings.TrimSpace(r.FormValue("username"))
+		if d.auth.checkPassword(user, r.FormValue("password")) {
+			token, err := d.auth.startSession(user)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     sessionCookie,
+				Value:    token,
+				Path:     d.url("/"),
+				MaxAge:   int(sessionDuration.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+				SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, r, d.url(next), http.StatusSeeOther)
+			return
+		}
+
+		log.Printf("Warning: Failed dashboard login for %q from %s", user, r.RemoteAddr)
+		time.Sleep(loginFailureDelay)
+		loginError = "Wrong user name or password"
+	}
+
+	status := http.StatusOK
+	if loginError != "" {
+		status = http.StatusUnauthorized
+	}
+	d.renderPage(w, r, status, "login.html", struct {
+		page
+		Next  string
+		Error string
+	}{page: d.page(r), Next: next, Error: loginError})
+}
+
+// handleLogout ends the session of the user
+func (d *Dashboard) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if d.auth != nil {
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			d.auth.mu.Lock()
+			delete(d.auth.sessions, cookie.Value)
+			d.auth.mu.Unlock()
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: d.url("/"), MaxAge: -1, HttpOnly: true})
+	http.Redirect(w, r, d.url("/login"), http.StatusSeeOther)
+}
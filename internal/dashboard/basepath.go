@@ -0,0 +1,105 @@
+package dashboard
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetBindAddress makes the dashboard listen on one address only, e.g. "127.0.0.1" to be reachable
+// through a reverse proxy on the same machine alone. Empty listens on every interface.
+func (d *Dashboard) SetBindAddress(host string) error {
+	host = strings.Trim(strings.TrimSpace(host), "[]")
+	if host != "" && host != "localhost" && net.ParseIP(host) == nil {
+		return fmt.Errorf("invalid bind address %q, expected an IP address such as 127.0.0.1", host)
+	}
+	d.host = host
+	return nil
+}
+
+// SetTrustedProxies lists the reverse proxies, as IP addresses or CIDR ranges such as 10.0.0.0/8,
+// whose X-Forwarded-For header tells the client address of their requests for the rate limits and the
+// request log. The header of any other client is ignored, as it could be made up.
+func (d *Dashboard) SetTrustedProxies(proxies []string) error {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q, expected an IP address or a CIDR range", proxy)
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q, expected an IP address or a CIDR range", proxy)
+		}
+		networks = append(networks, network)
+	}
+	d.trustedProxies = networks
+	return nil
+}
+
+// trustedProxy reports whether addr is one of the trusted proxies
+func (d *Dashboard) trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range d.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBasePath serves the dashboard under a URL prefix, e.g. "/licitaciones" for
+// https://example.com/licitaciones/, so it can share a reverse proxy with other apps. The proxy must
+// pass the prefix on; links, redirects and the session cookie include it.
+func (d *Dashboard) SetBasePath(path string) error {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		d.basePath = ""
+		return nil
+	}
+	if strings.ContainsAny(path, "?#%\\ ") || strings.Contains(path, "//") {
+		return fmt.Errorf("invalid base path %q, expected e.g. /licitaciones", path)
+	}
+	d.basePath = "/" + path
+	return nil
+}
+
+// url returns the address of a dashboard path such as "/history" under the base path
+func (d *Dashboard) url(path string) string {
+	return d.basePath + path
+}
+
+// stripBasePath removes the base path from requests before routing them, and answers 404 to the
+// requests outside it
+func (d *Dashboard) stripBasePath(next http.Handler) http.Handler {
+	if d.basePath == "" {
+		return next
+	}
+	strip := http.StripPrefix(d.basePath, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == d.basePath:
+			target := d.url("/")
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, d.basePath+"/"):
+			strip.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// maxBulkContracts bounds the contracts a bulk action applies to in one request
+const maxBulkContracts = 1000
+
+// bulkRequest is the body of a bulk action, the contracts being referred to by uid or id
+type bulkRequest struct {
+	IDs []string `json:"ids"`
+	Tag string   `json:"tag"` // For tagging
+}
+
+// bulkAction applies an action to contracts and returns how many it changed
+type bulkAction func(r *http.Request, contractIDs []string, request bulkRequest) (int64, error)
+
+// bulkDelete soft-deletes the contracts, each one restorable with "Restore Deleted"
+func (d *Dashboard) bulkDelete(r *http.Request, contractIDs []string, _ bulkRequest) (int64, error) {
+	return d.store.DeleteContracts(contractIDs, requestActor(r))
+}
+
+// bulkArchive moves the contracts to the archive
+func (d *Dashboard) bulkArchive(_ *http.Request, contractIDs []string, _ bulkRequest) (int64, error) {
+	return d.store.ArchiveContractsByID(contractIDs)
+}
+
+// bulkTag labels the contracts with the tag of the request
+func (d *Dashboard) bulkTag(_ *http.Request, contractIDs []string, request bulkRequest) (int64, error) {
+	return d.store.TagContracts(contractIDs, request.Tag)
+}
+
+// handleDeleteContracts soft-deletes the contracts selected on the dashboard, {"ids": [...]}
+func (d *Dashboard) handleDeleteContracts(w http.ResponseWriter, r *http.Request) {
+	d.handleBulkAction(w, r, "deleted", d.bulkDelete)
+}
+
+// handleArchiveContracts archives the contracts selected on the dashboard, {"ids": [...]}
+func (d *Dashboard) handleArchiveContracts(w http.ResponseWriter, r *http.Request) {
+	d.handleBulkAction(w, r, "archived", d.bulkArchive)
+}
+
+// handleTagContracts tags the contracts selected on the dashboard, {"ids": [...], "tag": "ignore"}
+func (d *Dashboard) handleTagContracts(w http.ResponseWriter, r *http.Request) {
+	d.handleBulkAction(w, r, "tagged", d.bulkTag)
+}
+
+// handleBulkAction decodes a bulk request, applies action to its contracts and answers how many it
+// changed under the name counted, e.g. {"success": true, "deleted": 12}
+func (d *Dashboard) handleBulkAction(w http.ResponseWriter, r *http.Request, counted string, action bulkAction) {
+	var request bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ids, status, err := d.bulkContractIDs(request.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	changed, err := action(r, ids, request)
+	if err != nil {
+		d.writeResult(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		counted:   changed,
+	})
+}
+
+// apiDeleteContracts soft-deletes contracts, {"ids": [...]}
+func (d *Dashboard) apiDeleteContracts(w http.ResponseWriter, r *http.Request) {
+	d.apiBulkAction(w, r, "deleted", d.bulkDelete)
+}
+
+// apiArchiveContracts archives contracts, {"ids": [...]}
+func (d *Dashboard) apiArchiveContracts(w http.ResponseWriter, r *http.Request) {
+	d.apiBulkAction(w, r, "archived", d.bulkArchive)
+}
+
+// apiTagContracts tags contracts, {"ids": [...], "tag": "ignore"}
+func (d *Dashboard) apiTagContracts(w http.ResponseWriter, r *http.Request) {
+	d.apiBulkAction(w, r, "tagged", d.bulkTag)
+}
+
+// apiBulkAction is handleBulkAction for /api/v1, answering {"data": {"deleted": 12}}
+func (d *Dashboard) apiBulkAction(w http.ResponseWriter, r *http.Request, counted string, action bulkAction) {
+	var request bulkRequest
+	if !decodeAPIBody(w, r, &request) {
+		return
+	}
+
+	ids, status, err := d.bulkContractIDs(request.IDs)
+	if err != nil {
+		writeAPIError(w, status, err.Error())
+		return
+	}
+
+	changed, err := action(r, ids, request)
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIData(w, http.StatusOK, map[string]int64{counted: changed}, nil)
+}
+
+// bulkContractIDs resolves the contract references of a bulk request to the ids they are stored
+// under. On failure it also returns the HTTP status to answer.
+func (d *Dashboard) bulkContractIDs(refs []string) ([]string, int, error) {
+	if len(refs) == 0 {
+		return nil, http.StatusBadRequest, errors.New("no contracts selected")
+	}
+	if len(refs) > maxBulkContracts {
+		return nil, http.StatusBadRequest, fmt.Errorf("at most %d contracts can be changed at once", maxBulkContracts)
+	}
+
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		id, err := d.store.ResolveContractID(ref)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			return nil, http.StatusInternalServerError, errors.New("failed to resolve contracts")
+		}
+		ids = append(ids, id)
+	}
+	return ids, http.StatusOK, nil
+}
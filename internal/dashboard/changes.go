@@ -0,0 +1,275 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// maxDiffCells bounds the work of a word diff; longer texts are shown as replaced as a whole
+const maxDiffCells = 250000
+
+// changeFields labels the fields of a contract change, in the order they are shown. Key fields are
+// what a rectification most often alters and bidders must not miss, so they come first.
+var changeFields = []struct {
+	name  string
+	label string
+	key   bool
+	link  bool
+}{
+	{"status", "Status", true, false},
+	{"amount", "Amount", true, false},
+	{"submission_date", "Submission Date", true, false},
+	{"pliego_link", "Pliego", true, true},
+	{"anuncio_link", "Anuncio", true, true},
+	{"description", "Description", false, false},
+	{"contract_type", "Type", false, false},
+	{"contracting_body", "Contracting Body", false, false},
+	{"link", "Link", false, true},
+}
+
+// contractChange is what one scrape changed in a contract, such as a rectification
+type contractChange struct {
+	ChangedAt string
+	Fields    []fieldChange
+}
+
+// fieldChange is the old and new value of a field, with the words that changed marked
+type fieldChange struct {
+	Label   string // English name of the field, translated by the template
+	Key     bool   // Amount, deadline, documents or status
+	Link    bool   // The values are URLs, shown as links rather than diffed
+	Old     []diffPart
+	New     []diffPart
+	OldText string
+	NewText string
+	Delta   string // How much the amount moved, e.g. "+5.000 € (+10%)", empty for other fields
+	Days    int    // Days the deadline moved, 0 for other fields
+	order   int    // Position of the field in changeFields
+}
+
+// statusStep is a status change of a contract as its timeline shows it
+type statusStep struct {
+	storage.StatusChange
+	OldClass string // Classes of the status badges, see statusClass
+	NewClass string
+}
+
+// diffPart is a piece of a diffed value, Changed when it was removed from the old value or added to the new one
+type diffPart struct {
+	Text    string
+	Changed bool
+}
+
+// handleContractPage serves the page of a contract, ?id= being its uid or id: its current details,
+// the timeline of its status changes and what each scrape changed in it, newest first
+func (d *Dashboard) handleContractPage(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.contractID(w, r.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+	contract, err := d.store.GetContractByID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get contract: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if contract == nil {
+		http.Error(w, "Contract not found", http.StatusNotFound)
+		return
+	}
+
+	revisions, err := d.store.GetContractRevisions(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	statusChanges, err := d.store.GetStatusChanges(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The status the contract was first seen with is the old status of its first change
+	timeline := make([]statusStep, len(statusChanges))
+	for i, change := range statusChanges {
+		timeline[i] = statusStep{StatusChange: change, OldClass: statusClass(change.OldStatus), NewClass: statusClass(change.NewStatus)}
+	}
+	firstStatus := contract.Status
+	if n := len(statusChanges); n > 0 {
+		firstStatus = statusChanges[n-1].OldStatus
+	}
+
+	var pliegoURL, anuncioURL string
+	if contract.PliegoLink != "" {
+		pliegoURL = d.documentURL(*contract, storage.DocumentPliego)
+	}
+	if contract.AnuncioLink != "" {
+		anuncioURL = d.documentURL(*contract, storage.DocumentAnuncio)
+	}
+
+	d.renderPage(w, r, http.StatusOK, "contract.html", struct {
+		page
+		Contract         scraper.Contract
+		Timeline         []statusStep
+		FirstStatus      string
+		FirstStatusClass string
+		Changes          []contractChange
+		PliegoURL        string // Download addresses of the documents, empty without a link
+		AnuncioURL       string
+	}{
+		page:             d.page(r),
+		Contract:         *contract,
+		Timeline:         timeline,
+		FirstStatus:      firstStatus,
+		FirstStatusClass: statusClass(firstStatus),
+		Changes:          groupChanges(revisions, statusChanges),
+		PliegoURL:        pliegoURL,
+		AnuncioURL:       anuncioURL,
+	})
+}
+
+// statusClass returns the class of the badge of a status, as getStatusClass does in dashboard.js, or
+// "" for a status without its own colors
+func statusClass(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "publicada":
+		return "publicada"
+	case "adjudicada":
+		return "adjudicada"
+	case "anulada":
+		return "anulada"
+	case "evaluación previa", "evaluacion previa":
+		return "evaluacion"
+	}
+	return ""
+}
+
+// groupChanges groups the field and status changes of a contract by when they were recorded, so
+// the changes of one scrape show together, newest first
+func groupChanges(revisions []storage.ContractRevision, statusChanges []storage.StatusChange) []contractChange {
+	var changes []contractChange
+	index := make(map[string]int)
+	add := func(changedAt string, field fieldChange) {
+		i, ok := index[changedAt]
+		if !ok {
+			i = len(changes)
+			index[changedAt] = i
+			changes = append(changes, contractChange{ChangedAt: changedAt})
+		}
+		changes[i].Fields = append(changes[i].Fields, field)
+	}
+
+	for _, change := range statusChanges {
+		add(change.ChangedAt, diffField("status", change.OldStatus, change.NewStatus))
+	}
+	for _, revision := range revisions {
+		add(revision.ChangedAt, diffField(revision.Field, revision.OldValue, revision.NewValue))
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].ChangedAt > changes[j].ChangedAt })
+	for _, change := range changes {
+		fields := change.Fields
+		sort.SliceStable(fields, func(i, j int) bool { return fields[i].order < fields[j].order })
+	}
+	return changes
+}
+
+// diffField describes the change of a field from before to after
+func diffField(name, before, after string) fieldChange {
+	change := fieldChange{Label: name, OldText: before, NewText: after, order: len(changeFields)}
+	for i, field := range changeFields {
+		if field.name == name {
+			change.Label, change.Key, change.Link, change.order = field.label, field.key, field.link, i
+		}
+	}
+	if !change.Link {
+		change.Old, change.New = diffWords(before, after)
+	}
+
+	switch name {
+	case "amount":
+		oldAmount, oldOK := scraper.ParseAmount(before)
+		newAmount, newOK := scraper.ParseAmount(after)
+		if oldOK && newOK && oldAmount != newAmount {
+			change.Delta = signedEuros(newAmount - oldAmount)
+			if oldAmount > 0 {
+				change.Delta += fmt.Sprintf(" (%+.0f%%)", (newAmount-oldAmount)/oldAmount*100)
+			}
+		}
+	case "submission_date":
+		oldDeadline, oldOK := scraper.ParseDeadline(before)
+		newDeadline, newOK := scraper.ParseDeadline(after)
+		if oldOK && newOK {
+			change.Days = int(math.Round(newDeadline.Sub(oldDeadline).Hours() / 24))
+		}
+	}
+	return change
+}
+
+// signedEuros formats an amount of euros with its sign, e.g. "+5.000 €" or "-250 €"
+func signedEuros(amount float64) string {
+	if amount < 0 {
+		return "-" + formatEuros(-amount)
+	}
+	return "+" + formatEuros(amount)
+}
+
+// diffTokens splits text into words and the spaces between them
+var diffTokens = regexp.MustCompile(`\s+|\S+`)
+
+// diffWords compares two texts word by word and returns both split into unchanged and changed parts
+func diffWords(before, after string) ([]diffPart, []diffPart) {
+	a, b := diffTokens.FindAllString(before, -1), diffTokens.FindAllString(after, -1)
+	if len(a)*len(b) > maxDiffCells {
+		return []diffPart{{Text: before, Changed: true}}, []diffPart{{Text: after, Changed: true}}
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var oldParts, newParts []diffPart
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			oldParts = appendPart(oldParts, a[i], false)
+			newParts = appendPart(newParts, b[j], false)
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			oldParts = appendPart(oldParts, a[i], true)
+			i++
+		default:
+			newParts = appendPart(newParts, b[j], true)
+			j++
+		}
+	}
+	return oldParts, newParts
+}
+
+// appendPart adds text to parts, joining it with the last part when both are changed or both are not
+func appendPart(parts []diffPart, text string, changed bool) []diffPart {
+	if n := len(parts); n > 0 && parts[n-1].Changed == changed {
+		parts[n-1].Text += text
+		return parts
+	}
+	return append(parts, diffPart{Text: text, Changed: changed})
+}
@@ -0,0 +1,356 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"scraper/internal/storage"
+)
+
+// ChannelManager sets up the notification channels added on the settings page, see SetChannelManager
+type ChannelManager interface {
+	// ApplyChannels makes the notifier deliver to exactly these channels on top of those of the
+	// environment. It applies none of them if one is invalid, e.g. misses a setting.
+	ApplyChannels(channels []storage.NotificationChannel) error
+	// TestChannel sends a sample alert through a channel
+	TestChannel(channel storage.NotificationChannel) error
+}
+
+// SetChannelManager lets dashboard users add, test and remove notification channels on the settings
+// page through manager, without a restart. Without one the channels are only set in the environment.
+func (d *Dashboard) SetChannelManager(manager ChannelManager) {
+	d.channels = manager
+}
+
+// maskedSecret replaces the passwords and tokens of the channels shown by the dashboard and the API
+const maskedSecret = "••••••"
+
+// channelSetting is a setting of a kind of channel, in English for the templates to translate
+type channelSetting struct {
+	Name        string
+	Label       string
+	Placeholder string
+	Secret      bool // A password or token, masked once saved
+}
+
+// channelKind is a kind of channel that can be added on the settings page
+type channelKind struct {
+	Kind     string
+	Label    string
+	Settings []channelSetting
+}
+
+// channelKinds are the kinds of channel the settings page offers, in its order
+var channelKinds = []channelKind{
+	{"smtp", "Email (SMTP)", []channelSetting{
+		{"host", "SMTP server", "smtp.example.com", false},
+		{"port", "Port", "587", false},
+		{"username", "Username", "", false},
+		{"password", "Password", "", true},
+		{"from", "From address", "alerts@example.com", false},
+		{"to", "Recipients", "ana@example.com, luis@example.com", false},
+	}},
+	{"telegram", "Telegram", []channelSetting{
+		{"token", "Bot token", "123456:ABC-DEF…", true},
+		{"chat_ids", "Chat IDs", "-1001234567890, 987654321", false},
+	}},
+	{"slack", "Slack", []channelSetting{
+		{"webhook_url", "Webhook URL", "https://hooks.slack.com/services/…", true},
+	}},
+	{"webhook", "Webhook", []channelSetting{
+		{"url", "URL", "https://example.com/hooks/contracts", false},
+		{"secret", "Signing secret", "", true},
+	}},
+}
+
+// findChannelKind returns the kind of channel named kind, or nil
+func findChannelKind(kind string) *channelKind {
+	for i := range channelKinds {
+		if channelKinds[i].Kind == kind {
+			return &channelKinds[i]
+		}
+	}
+	return nil
+}
+
+// channelView is a stored channel as the settings page and the API show it, its secrets masked
+type channelView struct {
+	ID        int64             `json:"id"`
+	Kind      string            `json:"kind"`
+	Label     string            `json:"label"`  // English name of the kind, translated by the template
+	Target    string            `json:"target"` // Name of the channel in the delivery log and NOTIFY_LANGUAGES
+	Settings  map[string]string `json:"settings"`
+	Summary   string            `json:"-"` // The settings that are not secret, for the settings page
+	CreatedAt time.Time         `json:"created_at"`
+	CreatedBy string            `json:"created_by,omitempty"`
+}
+
+// newChannelView masks the secrets of a stored channel
+func newChannelView(channel storage.NotificationChannel) channelView {
+	view := channelView{
+		ID:        channel.ID,
+		Kind:      channel.Kind,
+		Label:     channel.Kind,
+		Target:    channel.Target(),
+		Settings:  make(map[string]string, len(channel.Settings)),
+		CreatedAt: channel.CreatedAt,
+		CreatedBy: channel.CreatedBy,
+	}
+	for name, value := range channel.Settings {
+		view.Settings[name] = value
+	}
+
+	kind := findChannelKind(channel.Kind)
+	if kind == nil {
+		return view
+	}
+	view.Label = kind.Label
+	var summary []string
+	for _, setting := range kind.Settings {
+		value := channel.Settings[setting.Name]
+		if value == "" {
+			continue
+		}
+		if setting.Secret {
+			view.Settings[setting.Name] = maskedSecret
+		} else {
+			summary = append(summary, value)
+		}
+	}
+	view.Summary = strings.Join(summary, " · ")
+	return view
+}
+
+// channelRequest is the body of a request adding a channel, {"kind": "slack", "settings":
+// {"webhook_url": "https://..."}}
+type channelRequest struct {
+	Kind     string            `json:"kind"`
+	Settings map[string]string `json:"settings"`
+}
+
+// invalidChannelError is a channel the channel manager rejected, answered with 400 like storage.ErrInvalid
+type invalidChannelError struct {
+	err error
+}
+
+func (e *invalidChannelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidChannelError) Is(target error) bool {
+	return target == storage.ErrInvalid
+}
+
+// channelTestError is a test alert the service of a channel refused, answered with 502 Bad Gateway
+// as the request itself was fine
+type channelTestError struct {
+	kind string
+	err  error
+}
+
+func (e *channelTestError) Error() string {
+	return fmt.Sprintf("failed to send the test to the %s channel: %v", e.kind, e.err)
+}
+
+// channelViews lists the stored channels with their secrets masked
+func (d *Dashboard) channelViews() ([]channelView, error) {
+	channels, err := d.store.GetNotificationChannels()
+	if err != nil {
+		return nil, err
+	}
+	views := make([]channelView, len(channels))
+	for i, channel := range channels {
+		views[i] = newChannelView(channel)
+	}
+	return views, nil
+}
+
+// addChannel checks a channel by applying it with the stored ones, then saves it. Settings the kind
+// does not have are dropped, so a typo cannot hide a missing setting.
+func (d *Dashboard) addChannel(request channelRequest, actor string) (*storage.NotificationChannel, error) {
+	kind := findChannelKind(strings.TrimSpace(request.Kind))
+	if kind == nil {
+		return nil, &invalidChannelError{fmt.Errorf("unknown kind of channel %q", request.Kind)}
+	}
+	channel := storage.NotificationChannel{Kind: kind.Kind, Settings: make(map[string]string)}
+	for _, setting := range kind.Settings {
+		if value := strings.TrimSpace(request.Settings[setting.Name]); value != "" {
+			channel.Settings[setting.Name] = value
+		}
+	}
+
+	d.channelsMu.Lock()
+	defer d.channelsMu.Unlock()
+	stored, err := d.store.GetNotificationChannels()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.channels.ApplyChannels(append(stored, channel)); err != nil {
+		return nil, &invalidChannelError{err}
+	}
+	if err := d.store.AddNotificationChannel(&channel, actor); err != nil {
+		// Back to the channels that are saved, so what is notified matches what is listed
+		if applyErr := d.channels.ApplyChannels(stored); applyErr != nil {
+			log.Printf("Warning: Failed to restore the notification channels: %v", applyErr)
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// deleteChannel removes a stored channel and stops notifying it
+func (d *Dashboard) deleteChannel(id int64, actor string) error {
+	d.channelsMu.Lock()
+	defer d.channelsMu.Unlock()
+	if err := d.store.DeleteNotificationChannel(id, actor); err != nil {
+		return err
+	}
+	stored, err := d.store.GetNotificationChannels()
+	if err != nil {
+		return fmt.Errorf("channel removed but still notified until restart: %w", err)
+	}
+	if err := d.channels.ApplyChannels(stored); err != nil {
+		return fmt.Errorf("channel removed but still notified until restart: %w", err)
+	}
+	return nil
+}
+
+// testChannel sends a sample alert through a stored channel
+func (d *Dashboard) testChannel(id int64) error {
+	channels, err := d.store.GetNotificationChannels()
+	if err != nil {
+		return err
+	}
+	for _, channel := range channels {
+		if channel.ID == id {
+			if err := d.channels.TestChannel(channel); err != nil {
+				return &channelTestError{kind: channel.Kind, err: err}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("channel %d %w", id, storage.ErrNotFound)
+}
+
+// channelID parses the {id} path value of a channel request, answering 400 if it is invalid
+func channelID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// channelsEnabled answers 503 to a channel request without a channel manager
+func (d *Dashboard) channelsEnabled(w http.ResponseWriter) bool {
+	if d.channels == nil {
+		http.Error(w, "Managing notification channels is not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// handleAddChannel adds the channel of the settings page form, {"kind": ..., "settings": {...}}
+func (d *Dashboard) handleAddChannel(w http.ResponseWriter, r *http.Request) {
+	if !d.channelsEnabled(w) {
+		return
+	}
+	var request channelRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	_, err := d.addChannel(request, requestActor(r))
+	d.writeResult(w, err)
+}
+
+// handleDeleteChannel removes a channel from the settings page
+func (d *Dashboard) handleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := channelID(w, r)
+	if !ok || !d.channelsEnabled(w) {
+		return
+	}
+	d.writeResult(w, d.deleteChannel(id, requestActor(r)))
+}
+
+// handleTestChannel sends a sample alert through a channel, for the "Send test" button of the settings page
+func (d *Dashboard) handleTestChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := channelID(w, r)
+	if !ok || !d.channelsEnabled(w) {
+		return
+	}
+	var testErr *channelTestError
+	if err := d.testChannel(id); errors.As(err, &testErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": testErr.Error()})
+	} else {
+		d.writeResult(w, err)
+	}
+}
+
+// apiChannelsEnabled answers 503 to a /api/v1 channel request without a channel manager
+func (d *Dashboard) apiChannelsEnabled(w http.ResponseWriter) bool {
+	if d.channels == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "Managing notification channels is not enabled")
+		return false
+	}
+	return true
+}
+
+// apiListChannels lists the channels added on the dashboard, their passwords and tokens masked
+func (d *Dashboard) apiListChannels(w http.ResponseWriter, r *http.Request) {
+	views, err := d.channelViews()
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIList(w, views, nil)
+}
+
+// apiAddChannel adds a channel, {"kind": ..., "settings": {...}}, once it is checked to be complete
+func (d *Dashboard) apiAddChannel(w http.ResponseWriter, r *http.Request) {
+	if !d.apiChannelsEnabled(w) {
+		return
+	}
+	var request channelRequest
+	if !decodeAPIBody(w, r, &request) {
+		return
+	}
+	channel, err := d.addChannel(request, requestActor(r))
+	if err != nil {
+		writeAPIStoreError(w, err)
+		return
+	}
+	writeAPIData(w, http.StatusCreated, newChannelView(*channel), nil)
+}
+
+// apiDeleteChannel removes a channel added on the dashboard
+func (d *Dashboard) apiDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiNumericID(w, r, "channel")
+	if !ok || !d.apiChannelsEnabled(w) {
+		return
+	}
+	d.apiAction(w, d.deleteChannel(id, requestActor(r)))
+}
+
+// apiTestChannel sends a sample alert through a channel, answering 502 if the service refuses it
+func (d *Dashboard) apiTestChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiNumericID(w, r, "channel")
+	if !ok || !d.apiChannelsEnabled(w) {
+		return
+	}
+	var testErr *channelTestError
+	if err := d.testChannel(id); errors.As(err, &testErr) {
+		writeAPIError(w, http.StatusBadGateway, testErr.Error())
+	} else {
+		d.apiAction(w, err)
+	}
+}
@@ -0,0 +1,207 @@
+package dashboard
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the smallest response worth compressing; below it the headers outweigh the savings
+const compressMinSize = 1024
+
+// compressibleTypes are the content types compressed when the browser accepts it. Downloads that are
+// compressed already, such as .csv.gz and .xlsx, are sent as they are.
+var compressibleTypes = []string{"text/", "application/json", "application/javascript", "application/yaml", "image/svg+xml"}
+
+// Compressors are reused across responses, as each one allocates large buffers. HTTP's deflate
+// encoding is the zlib format, not a raw deflate stream.
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() interface{} { return zlib.NewWriter(io.Discard) }}
+)
+
+// compressResponses compresses the text responses of requests accepting gzip or deflate
+func compressResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		next.ServeHTTP(cw, r)
+		// Not deferred: after a panic, nothing buffered is sent so the panic can still be answered with a 500
+		cw.close()
+	})
+}
+
+// acceptedEncoding returns the encoding to compress with, gzip preferred, or "" if the client takes neither
+func acceptedEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		// "gzip;q=0" refuses gzip
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	switch {
+	case accepted["gzip"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter holds back the start of a response until it knows whether to compress it: it must
+// be of a compressible type and at least compressMinSize bytes long
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	status     int    // Status set by the handler, 0 for 200
+	buf        []byte // Response held back until the decision
+	decided    bool
+	compressor io.WriteCloser // Nil unless compressing
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.decided || c.status != 0 {
+		if c.decided {
+			c.ResponseWriter.WriteHeader(status)
+		}
+		return
+	}
+	c.status = status
+	if !bodyAllowed(status) {
+		c.decide(false)
+	}
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if !c.decided {
+		c.buf = append(c.buf, b...)
+		if len(c.buf) < compressMinSize {
+			return len(b), nil
+		}
+		if err := c.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if c.compressor != nil {
+		return c.compressor.Write(b)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Flush sends what was written so far, compressed if it is large enough
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.decide(len(c.buf) >= compressMinSize)
+	}
+	if flusher, ok := c.compressor.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// decide sends the header, compressing the response when large is set and its type and status
+// allow it, and then the held back start of the body
+func (c *compressWriter) decide(large bool) error {
+	c.decided = true
+	status := c.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := c.Header()
+	if header.Get("Content-Type") == "" && len(c.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(c.buf))
+	}
+	if large && bodyAllowed(status) && status != http.StatusPartialContent && header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", c.encoding)
+		header.Del("Content-Length")
+		// The compressed bytes differ, so a strong validator no longer applies to them
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		c.compressor = c.newCompressor()
+	}
+
+	c.ResponseWriter.WriteHeader(status)
+	if len(c.buf) == 0 {
+		return nil
+	}
+	buf := c.buf
+	c.buf = nil
+	var err error
+	if c.compressor != nil {
+		_, err = c.compressor.Write(buf)
+	} else {
+		_, err = c.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// newCompressor takes a compressor for the encoding from its pool, writing to the response
+func (c *compressWriter) newCompressor() io.WriteCloser {
+	if c.encoding == "gzip" {
+		gz := gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(c.ResponseWriter)
+		return gz
+	}
+	zw := zlibWriters.Get().(*zlib.Writer)
+	zw.Reset(c.ResponseWriter)
+	return zw
+}
+
+// close sends what is held back and finishes the compressed stream
+func (c *compressWriter) close() {
+	if !c.decided {
+		c.decide(false)
+	}
+	if c.compressor == nil {
+		return
+	}
+	c.compressor.Close()
+	switch compressor := c.compressor.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(compressor)
+	case *zlib.Writer:
+		zlibWriters.Put(compressor)
+	}
+	c.compressor = nil
+}
+
+// bodyAllowed reports whether a response with the status may have a body
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// compressible reports whether responses of the content type are worth compressing
+func compressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
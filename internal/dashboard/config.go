@@ -0,0 +1,153 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// Configurer applies the runtime configuration edited on the settings page, see SetConfigurer
+type Configurer interface {
+	Config() map[string]string                   // The settings in effect, by name
+	ApplyConfig(changes map[string]string) error // Applies none of the changes if one is invalid
+}
+
+// SetConfigurer lets dashboard users change the notification recipients, filters and schedule on the
+// settings page through configurer, without a restart. Without one only the profiles can be edited.
+func (d *Dashboard) SetConfigurer(configurer Configurer) {
+	d.configurer = configurer
+}
+
+// configField is a runtime setting shown on the settings page, named after the environment variable
+// it overrides
+type configField struct {
+	Section string // English, like Label and Help, translated by the template
+	Name    string
+	Label   string
+	Help    string
+	Toggle  bool // A true/false setting, shown as a checkbox
+}
+
+// configFields are the runtime settings in the order the settings page shows them
+var configFields = []configField{
+	{"Recipients", "TO_EMAIL", "Email recipients", "Addresses the emails are sent to, separated by commas", false},
+	{"Filters", "NOTIFY_KEYWORDS", "Keywords", "Only new contracts whose description contains one of these words are notified, separated by commas. Empty notifies every contract.", false},
+	{"Filters", "NOTIFY_MIN_AMOUNT", "Minimum amount (€)", "New contracts with a lower estimated amount are not notified. Empty for any amount.", false},
+	{"Schedule", "NOTIFY_QUIET_HOURS", "Quiet hours", "Alerts are held until they end and then sent in one digest, e.g. 22:00-07:00. Empty for none.", false},
+	{"Schedule", "NOTIFY_QUIET_WEEKENDS", "Quiet weekends", "Hold the alerts on Saturdays and Sundays", true},
+	{"Schedule", "NOTIFY_MAX_PER_HOUR", "Messages per hour", "Most messages sent to the email or a channel per hour; the rest wait for the next digest. 0 for no limit.", false},
+}
+
+// configFieldView is a runtime setting with its value in effect
+type configFieldView struct {
+	configField
+	Value string
+	On    bool // Value is true, for toggles
+}
+
+// handleSettingsPage serves the settings page: the CPV code of each search profile, with a configurer
+// the notification recipients, filters and schedule, and with a channel manager the notification channels
+func (d *Dashboard) handleSettingsPage(w http.ResponseWriter, r *http.Request) {
+	profiles, err := d.store.GetProfiles()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get profiles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var fields []configFieldView
+	if d.configurer != nil {
+		config := d.configurer.Config()
+		for _, field := range configFields {
+			on, _ := strconv.ParseBool(config[field.Name])
+			fields = append(fields, configFieldView{configField: field, Value: config[field.Name], On: on})
+		}
+	}
+
+	var channels []channelView
+	if d.channels != nil {
+		if channels, err = d.channelViews(); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get notification channels: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	d.renderPage(w, r, http.StatusOK, "settings.html", struct {
+		page
+		Fields         []configFieldView
+		Profiles       []storage.Profile
+		ManageChannels bool
+		Channels       []channelView
+		ChannelKinds   []channelKind
+	}{
+		page:           d.page(r),
+		Fields:         fields,
+		Profiles:       profiles,
+		ManageChannels: d.channels != nil,
+		Channels:       channels,
+		ChannelKinds:   channelKinds,
+	})
+}
+
+// handleSaveSettings saves the settings page, {"settings": {"TO_EMAIL": "..."}, "profiles": [{"name":
+// "default", "cpv_code": "32351200"}]}. Settings apply at once and are kept over the environment on
+// the next start; a profile not yet existing is created.
+func (d *Dashboard) handleSaveSettings(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Settings map[string]string `json:"settings"`
+		Profiles []struct {
+			Name    string `json:"name"`
+			CPVCode string `json:"cpv_code"`
+		} `json:"profiles"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for _, profile := range request.Profiles {
+		if strings.TrimSpace(profile.Name) == "" {
+			http.Error(w, "Every profile needs a name", http.StatusBadRequest)
+			return
+		}
+		if code := strings.TrimSpace(profile.CPVCode); code != "" && scraper.NormalizeCPVCode(code) == "" {
+			http.Error(w, fmt.Sprintf("Invalid CPV code %q of profile %s", code, profile.Name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(request.Settings) > 0 {
+		if d.configurer == nil {
+			http.Error(w, "Changing the notification settings is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+		if err := d.configurer.ApplyConfig(request.Settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Saved as applied, so the next start picks up what is in effect now
+		config := d.configurer.Config()
+		saved := make(map[string]string, len(request.Settings))
+		for name := range request.Settings {
+			saved[name] = config[name]
+		}
+		if err := d.store.SetConfig(saved, requestActor(r)); err != nil {
+			d.writeResult(w, fmt.Errorf("settings applied but not saved, they will be lost on restart: %w", err))
+			return
+		}
+	}
+
+	for _, profile := range request.Profiles {
+		if _, err := d.store.SaveProfile(profile.Name, profile.CPVCode); err != nil {
+			d.writeResult(w, err)
+			return
+		}
+	}
+
+	d.writeResult(w, nil)
+}
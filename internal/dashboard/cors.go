@@ -0,0 +1,81 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsMaxAge is how long browsers may cache the answer to a preflight request
+const corsMaxAge = 10 * time.Minute
+
+// corsMethods and corsHeaders are what cross-origin requests to /api/v1 may use
+const (
+	corsMethods = "GET, POST, PUT, PATCH, DELETE"
+	corsHeaders = "Authorization, Content-Type, X-Actor"
+)
+
+// SetCORSOrigins lets pages and browser extensions served from the given origins, e.g.
+// "https://app.example.com" or "chrome-extension://<id>", call /api/v1. "*" allows every origin, but
+// then browsers send no cookies, so requests need an API token. Without origins, only the
+// dashboard's own pages can call the API from a browser.
+func (d *Dashboard) SetCORSOrigins(origins []string) error {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+		if origin != "*" {
+			parsed, err := url.Parse(origin)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" || strings.TrimSuffix(parsed.Path, "/") != "" || parsed.RawQuery != "" {
+				return fmt.Errorf("invalid origin %q, expected scheme://host[:port]", origin)
+			}
+			origin = parsed.Scheme + "://" + parsed.Host
+		}
+		allowed[origin] = true
+	}
+
+	if len(allowed) == 0 {
+		allowed = nil
+	}
+	d.corsOrigins = allowed
+	return nil
+}
+
+// allowCORS adds the CORS headers to /api/v1 responses for the allowed origins, and answers their
+// preflight requests itself since browsers send those without credentials
+func (d *Dashboard) allowCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if d.corsOrigins == nil || origin == "" || !strings.HasPrefix(r.URL.Path, apiPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := d.corsOrigins[origin]
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else if d.corsOrigins["*"] {
+			allowed = true
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !allowed {
+				writeAPIError(w, http.StatusForbidden, "Origin not allowed")
+				return
+			}
+			w.Header().Add("Vary", "Access-Control-Request-Method, Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", corsMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,32 +1,91 @@
 package dashboard
 
 import (
+	"crypto/tls"
+	"html/template"
 	"log"
+	"net"
 	"net/http"
+	"sync"
+
+	"golang.org/x/crypto/acme/autocert"
 
 	"scraper/internal/storage"
 )
 
 // Dashboard handles the web interface
 type Dashboard struct {
-	store *storage.Storage
-	port  string
+	store              storage.Store
+	mux                *http.ServeMux // Routes of this dashboard, see registerRoutes
+	port               string
+	host               string             // Address to listen on, every interface when empty, see SetBindAddress
+	basePath           string             // URL prefix the dashboard is served under, e.g. "/licitaciones", see SetBasePath
+	trustedProxies     []*net.IPNet       // Proxies whose X-Forwarded-For header is believed, see SetTrustedProxies
+	pages              *template.Template // Page templates, whose links go through url
+	auth               *auth              // Users allowed in, nil if the dashboard is open, see SetAuth
+	public             bool               // Visitors who are not logged in may read the contracts, see SetPublic
+	tlsConfig          *tls.Config        // Serves HTTPS when set, see SetTLS and SetAutocert
+	autocert           *autocert.Manager  // Obtains the certificates from Let's Encrypt, see SetAutocert
+	scrape             *scrapeJobs        // Runs the scrapes started from the page, nil if disabled, see SetScrapeRunner
+	notifier           Notifier           // Resends failed notifications, nil if disabled, see SetNotifier
+	configurer         Configurer         // Applies the settings page, nil to only edit profiles, see SetConfigurer
+	channels           ChannelManager     // Applies the channels of the settings page, nil if disabled, see SetChannelManager
+	channelsMu         sync.Mutex         // Serializes adding and removing channels, so the notifier gets the stored ones
+	assetsDir          string             // Templates and static files are read from here when set, see SetAssetsDir
+	requestLog         string             // Which requests are logged, see SetRequestLog
+	corsOrigins        map[string]bool    // Origins allowed to call /api/v1 from a browser, nil for none, see SetCORSOrigins
+	apiLimiter         *rateLimiter       // Limits API requests per client, nil for no limit, see SetRateLimits
+	destructiveLimiter *rateLimiter       // Limits deletes and scrapes per client, nil for no limit
 }
 
 // NewDashboard creates a new dashboard instance
-func NewDashboard(store *storage.Storage, port string) *Dashboard {
-	return &Dashboard{
-		store: store,
-		port:  port,
+func NewDashboard(store storage.Store, port string) *Dashboard {
+	d := &Dashboard{
+		store:              store,
+		port:               port,
+		mux:                http.NewServeMux(),
+		requestLog:         RequestLogAll,
+		apiLimiter:         newRateLimiter(DefaultAPIRateLimit),
+		destructiveLimiter: newRateLimiter(DefaultDestructiveRateLimit),
 	}
+	d.pages = template.Must(d.parsePageTemplates(assetFiles))
+	d.registerRoutes()
+	return d
+}
+
+// Handler returns the dashboard with its logging, compression, CORS, authentication and rate
+// limiting, e.g. to serve it from an httptest.Server or under another server's mux. Call it after
+// SetBasePath.
+func (d *Dashboard) Handler() http.Handler {
+	return d.logRequests(d.stripBasePath(compressResponses(d.allowCORS(d.requireAuth(d.limitRequests(d.mux))))))
 }
 
 // Start starts the web server
 func (d *Dashboard) Start() error {
-	// Register all routes
-	d.registerRoutes()
+	switch {
+	case d.auth == nil && d.public:
+		log.Printf("The dashboard is public and read-only; only API tokens can change contracts")
+	case d.auth == nil:
+		log.Printf("Warning: The dashboard has no authentication; anyone who can reach it can delete contracts")
+	case d.public:
+		log.Printf("The dashboard is public; visitors who are not logged in can read the contracts")
+	}
 
-	addr := ":" + d.port
-	log.Printf("Dashboard starting on http://localhost%s", addr)
-	return http.ListenAndServe(addr, nil)
-} 
\ No newline at end of file
+	addr := net.JoinHostPort(d.host, d.port)
+	handler := d.Handler()
+	host := d.host
+	if host == "" {
+		host = "localhost"
+	}
+	if d.tlsConfig == nil {
+		log.Printf("Dashboard starting on http://%s%s", net.JoinHostPort(host, d.port), d.url("/"))
+		return http.ListenAndServe(addr, handler)
+	}
+
+	if d.autocert != nil {
+		go d.serveACMEChallenges()
+	}
+	server := &http.Server{Addr: addr, Handler: handler, TLSConfig: d.tlsConfig}
+	log.Printf("Dashboard starting on https://%s%s", net.JoinHostPort(host, d.port), d.url("/"))
+	return server.ListenAndServeTLS("", "")
+}
\ No newline at end of file
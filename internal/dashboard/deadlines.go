@@ -0,0 +1,134 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"scraper/internal/storage"
+)
+
+// calendarBusyDay is the number of deadlines from which a day is highlighted as busy
+const calendarBusyDay = 3
+
+// calendarLocation is the time zone of the calendar days, the one deadlines are published in
+var calendarLocation = loadCalendarLocation()
+
+// loadCalendarLocation returns Spanish peninsular time, or UTC if the zone database is unavailable
+func loadCalendarLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// calendarDay is one cell of the deadline calendar
+type calendarDay struct {
+	Day       int
+	InMonth   bool // False for the days of the previous and next months that fill the first and last weeks
+	Today     bool
+	Deadlines []calendarDeadline
+}
+
+// calendarDeadline is a contract shown on the day of its deadline
+type calendarDeadline struct {
+	UID         string
+	ID          string
+	Description string
+	Body        string
+	Time        string // Time of day, empty for deadlines at the end of the day
+	Watched     bool
+}
+
+// handleDeadlineCalendar serves the deadline calendar: the submission deadlines of the active
+// contracts on a month grid, ?month=2025-11 (the current month by default), only the watched ones
+// with ?watched=1
+func (d *Dashboard) handleDeadlineCalendar(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().In(calendarLocation)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, calendarLocation)
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, calendarLocation)
+		if err != nil {
+			http.Error(w, "Invalid month, expected e.g. 2025-11", http.StatusBadRequest)
+			return
+		}
+		month = parsed
+	}
+	watched := r.URL.Query().Get("watched") == "1"
+
+	// Weeks start on Monday, and the grid shows whole weeks
+	first := month.AddDate(0, 0, -((int(month.Weekday()) + 6) % 7))
+	next := month.AddDate(0, 1, 0)
+	last := next.AddDate(0, 0, (7-(int(next.Weekday())+6)%7)%7)
+
+	contracts, _, err := d.store.GetContractsPage(storage.ContractFilter{
+		DeadlineFrom: first,
+		DeadlineTo:   last,
+		Watched:      watched,
+	}, storage.ContractSort{Field: storage.SortByDeadline}, 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	byDay := make(map[string][]calendarDeadline)
+	inMonth := 0
+	for _, contract := range contracts {
+		if contract.Deadline == nil {
+			continue
+		}
+		deadline := contract.Deadline.In(calendarLocation)
+		entry := calendarDeadline{
+			UID:         contract.UID,
+			ID:          contract.ID,
+			Description: contract.Description,
+			Body:        contract.ContractingBody,
+			Watched:     contract.Watched,
+		}
+		if deadline.Hour() != 23 || deadline.Minute() != 59 {
+			entry.Time = deadline.Format("15:04")
+		}
+		key := deadline.Format("2006-01-02")
+		byDay[key] = append(byDay[key], entry)
+		if deadline.Month() == month.Month() {
+			inMonth++
+		}
+	}
+
+	var weeks [][]calendarDay
+	for day := first; day.Before(last); day = day.AddDate(0, 0, 7) {
+		week := make([]calendarDay, 7)
+		for i := range week {
+			date := day.AddDate(0, 0, i)
+			week[i] = calendarDay{
+				Day:       date.Day(),
+				InMonth:   date.Month() == month.Month(),
+				Today:     date.Format("2006-01-02") == now.Format("2006-01-02"),
+				Deadlines: byDay[date.Format("2006-01-02")],
+			}
+		}
+		weeks = append(weeks, week)
+	}
+
+	d.renderPage(w, r, http.StatusOK, "calendar.html", struct {
+		page
+		Month     string
+		Deadlines int
+		Weeks     [][]calendarDay
+		Previous  string
+		Next      string
+		Current   string
+		Watched   bool
+		BusyDay   int
+	}{
+		page:      d.page(r),
+		Month:     formatMonth(d.language(r), month),
+		Deadlines: inMonth,
+		Weeks:     weeks,
+		Previous:  month.AddDate(0, -1, 0).Format("2006-01"),
+		Next:      next.Format("2006-01"),
+		Current:   month.Format("2006-01"),
+		Watched:   watched,
+		BusyDay:   calendarBusyDay,
+	})
+}
@@ -0,0 +1,40 @@
+package dashboard
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+)
+
+// swaggerUIURL is where the API documentation page loads Swagger UI from. It is not built into the
+// binary, so the page needs the browser to reach the CDN; the specification itself does not.
+const swaggerUIURL = "https://unpkg.com/swagger-ui-dist@5.17.14"
+
+// handleOpenAPISpec serves the OpenAPI description of /api/v1
+func (d *Dashboard) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	spec, err := fs.ReadFile(d.assets(), "openapi.yaml")
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// The server address in the specification is absolute, so it must include the base path
+	if d.basePath != "" {
+		spec = bytes.Replace(spec, []byte("  - url: /api/v1\n"), []byte("  - url: "+d.url("/api/v1")+"\n"), 1)
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(spec)
+}
+
+// handleAPIDocs serves the interactive API documentation
+func (d *Dashboard) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	d.renderPage(w, r, http.StatusOK, "apidocs.html", struct {
+		SwaggerUI string
+		SpecURL   string
+	}{
+		SwaggerUI: swaggerUIURL,
+		SpecURL:   d.url(apiPrefix + "openapi.yaml"),
+	})
+}
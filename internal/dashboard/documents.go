@@ -0,0 +1,239 @@
+package dashboard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// DocumentsRoot is the directory holding the downloaded contract documents, one folder per contract
+const DocumentsRoot = "documents"
+
+// maxDocumentSize bounds a document fetched from the portal, in bytes
+const maxDocumentSize = 50 << 20
+
+// documentClient fetches documents from the portal. Its timeout covers reading the whole document.
+var documentClient = &http.Client{Timeout: 2 * time.Minute}
+
+// errDocumentNotStored is a document findDocument would have to download but may not
+var errDocumentNotStored = errors.New("document is not stored yet")
+
+// unsafePathChars are the characters replaced in the file names of stored documents
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// documentURL returns the dashboard address downloading a document of a contract, docType being
+// storage.DocumentPliego or storage.DocumentAnuncio
+func (d *Dashboard) documentURL(contract scraper.Contract, docType string) string {
+	ref := contract.UID
+	if ref == "" {
+		ref = contract.ID
+	}
+	return d.url("/api/contracts/" + url.PathEscape(ref) + "/documents/" + docType + "/download")
+}
+
+// handleDownloadDocument serves a document of a contract through the dashboard, so the download
+// does not depend on the portal link still working. {docID} is "pliego" or "anuncio" for the latest
+// copy, downloaded first if there is none yet or the contract now links another file, or the ID of
+// a stored copy. Visitors of a public dashboard only get the copies already stored, and are sent to
+// the portal for the others.
+func (d *Dashboard) handleDownloadDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.contractID(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	contract, err := d.store.GetContractByID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get contract: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if contract == nil {
+		http.Error(w, "Contract not found", http.StatusNotFound)
+		return
+	}
+
+	doc, status, err := d.findDocument(*contract, r.PathValue("docID"), !d.readOnly(r))
+	if errors.Is(err, errDocumentNotStored) {
+		http.Redirect(w, r, documentLink(*contract, r.PathValue("docID")), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	file, err := os.Open(doc.LocalPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to open document: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	filename := doc.Type + "-" + strings.Trim(unsafePathChars.ReplaceAllString(contract.ID, "_"), "_") + filepath.Ext(doc.LocalPath)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, filename, doc.DownloadedAt, file)
+}
+
+// findDocument returns the stored copy of a document of a contract, as handleDownloadDocument names
+// it, downloading the latest one when needed if fetch is set. On failure it also returns the HTTP
+// status to answer.
+func (d *Dashboard) findDocument(contract scraper.Contract, docID string, fetch bool) (*storage.Document, int, error) {
+	if docID == storage.DocumentPliego || docID == storage.DocumentAnuncio {
+		docType := docID
+		link := documentLink(contract, docType)
+
+		latest, err := d.store.GetLatestDocument(contract.ID, docType)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if latest != nil && (link == "" || latest.URL == link) && fileExists(latest.LocalPath) {
+			return latest, http.StatusOK, nil
+		}
+		if link == "" {
+			return nil, http.StatusNotFound, fmt.Errorf("contract has no %s", docType)
+		}
+		if !fetch {
+			return nil, http.StatusNotFound, errDocumentNotStored
+		}
+
+		doc, err := d.fetchDocument(contract.ID, docType, link, latest)
+		if err != nil {
+			return nil, http.StatusBadGateway, err
+		}
+		return doc, http.StatusOK, nil
+	}
+
+	docNumber, err := strconv.ParseInt(docID, 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid document %q, expected pliego, anuncio or a document ID", docID)
+	}
+	docs, err := d.store.GetDocuments(contract.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	for _, doc := range docs {
+		if doc.ID == docNumber {
+			if !fileExists(doc.LocalPath) {
+				return nil, http.StatusGone, fmt.Errorf("the file of document %d is no longer stored", doc.ID)
+			}
+			return &doc, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusNotFound, errors.New("document not found")
+}
+
+// documentLink returns the portal link of a document of a contract, docType being
+// storage.DocumentPliego or storage.DocumentAnuncio
+func documentLink(contract scraper.Contract, docType string) string {
+	if docType == storage.DocumentAnuncio {
+		return contract.AnuncioLink
+	}
+	return contract.PliegoLink
+}
+
+// fetchDocument downloads a document of a contract from the portal to DocumentsRoot and records it,
+// unless it is the same file as latest, the copy stored last
+func (d *Dashboard) fetchDocument(contractID, docType, link string, latest *storage.Document) (*storage.Document, error) {
+	resp, err := documentClient.Get(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", docType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", docType, resp.Status)
+	}
+	// An expired portal link answers with an HTML error page rather than the document
+	contentType := resp.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "text/") {
+		return nil, fmt.Errorf("failed to download %s: the portal returned %s instead of a document", docType, contentType)
+	}
+	if resp.ContentLength > maxDocumentSize {
+		return nil, fmt.Errorf("failed to download %s: %d bytes is over the %d byte limit", docType, resp.ContentLength, maxDocumentSize)
+	}
+
+	dir := filepath.Join(DocumentsRoot, strings.Trim(unsafePathChars.ReplaceAllString(contractID, "_"), "_"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create documents directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, docType+"-*.part")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", docType, err)
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once the file is renamed
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(resp.Body, maxDocumentSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", docType, err)
+	}
+	if size > maxDocumentSize {
+		return nil, fmt.Errorf("failed to download %s: over the %d byte limit", docType, maxDocumentSize)
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if latest != nil && latest.SHA256 == digest && fileExists(latest.LocalPath) {
+		return latest, nil
+	}
+
+	doc := &storage.Document{
+		ContractID: contractID,
+		Type:       docType,
+		URL:        link,
+		LocalPath:  filepath.Join(dir, docType+"-"+digest[:16]+documentExtension(contentType, resp.Header.Get("Content-Disposition"))),
+		SHA256:     digest,
+		Size:       size,
+	}
+	if err := os.Rename(tmp.Name(), doc.LocalPath); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", docType, err)
+	}
+	if err := d.store.SaveDocument(doc); err != nil {
+		// The file is still served, it is only downloaded again next time
+		log.Printf("Warning: %v", err)
+		doc.DownloadedAt = time.Now()
+	}
+	return doc, nil
+}
+
+// documentExtension returns the file extension of a downloaded document, from the file name the
+// portal gives it or else its content type. Documents are PDFs unless told otherwise.
+func documentExtension(contentType, disposition string) string {
+	if _, params, err := mime.ParseMediaType(disposition); err == nil {
+		if ext := strings.ToLower(filepath.Ext(params["filename"])); ext != "" && !unsafePathChars.MatchString(ext[1:]) {
+			return ext
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "application/octet-stream" {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ".pdf"
+}
+
+// fileExists reports whether path names a stored file
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+
+	"scraper/internal/feed"
+	"scraper/internal/storage"
+)
+
+// feedPath is where the feed is served. Like the API it answers 401 with a basic auth challenge
+// instead of redirecting to the login page, and accepts API tokens, as feed readers cannot log in.
+const feedPath = "/feed.xml"
+
+// defaultFeedEntries is how many of the contracts discovered last the feed holds without ?limit=
+const defaultFeedEntries = 50
+
+// maxFeedEntries bounds ?limit= of the feed
+const maxFeedEntries = 500
+
+// handleFeed serves the contracts discovered last as an Atom feed, newest first, so they can be
+// followed from a feed reader without setting up email. It takes the filters of /api/contracts,
+// e.g. ?tag=to%20bid or ?profile=screens, and ?limit= entries.
+func (d *Dashboard) handleFeed(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query, err := d.contractQuery(params)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	if d.readOnly(r) {
+		hideTeamFilters(&query)
+	}
+	query.sort = storage.ContractSort{Field: storage.SortByFirstSeenAt, Descending: true}
+	if query.limit == 0 {
+		query.limit = defaultFeedEntries
+	}
+	query.limit = min(query.limit, maxFeedEntries)
+
+	contracts, _, err := d.store.GetContractsPage(query.filter, query.sort, query.limit, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Feed readers need absolute links, so they point to the address the feed was fetched from
+	origin := requestOrigin(r)
+	for i, contract := range contracts {
+		ref := contract.UID
+		if ref == "" {
+			ref = contract.ID
+		}
+		contracts[i].DashboardLink = origin + d.url("/contract") + "?id=" + url.QueryEscape(ref)
+	}
+
+	title := "New LED screen contracts"
+	switch {
+	case params.Get("tag") != "":
+		title += " tagged " + params.Get("tag")
+	case params.Get("profile") != "":
+		title += " of profile " + params.Get("profile")
+	}
+	selfURL := origin + d.url(feedPath)
+	if r.URL.RawQuery != "" {
+		selfURL += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Content-Type", feed.ContentType)
+	if err := feed.Write(w, contracts, feed.Options{Title: title, SelfURL: selfURL, SiteURL: origin + d.url("/")}); err != nil {
+		log.Printf("Failed to write feed: %v", err)
+	}
+}
+
+// requestOrigin returns the scheme and host a request was made to, e.g. "https://example.com",
+// taking the scheme from X-Forwarded-Proto behind a reverse proxy
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
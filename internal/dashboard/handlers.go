@@ -1,89 +1,654 @@
 package dashboard
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
+	"html"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"scraper/internal/calendar"
+	"scraper/internal/export"
+	"scraper/internal/report"
+	"scraper/internal/scraper"
 	"scraper/internal/storage"
 )
 
 // handleHome serves the main dashboard page
 func (d *Dashboard) handleHome(w http.ResponseWriter, r *http.Request) {
-	tmplParsed, err := template.New("dashboard").Parse(MainTemplate)
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	common := d.page(r)
+	d.renderPage(w, r, http.StatusOK, "dashboard.html", struct {
+		page
+		User       string
+		LogoutLink bool
+		LoginLink  bool // A visitor of a public dashboard, who can log in to change contracts
+		CanScrape  bool
+		Messages   map[string]string // Translations of the text of dashboard.js
+	}{
+		page:       common,
+		User:       authenticatedUser(r),
+		LogoutLink: d.auth != nil && d.auth.mode == AuthLogin && !common.ReadOnly,
+		LoginLink:  d.auth != nil && d.auth.mode == AuthLogin && common.ReadOnly,
+		CanScrape:  d.scrape != nil && !common.ReadOnly,
+		Messages:   translations[common.Lang],
+	})
+}
+
+// handleAPIContracts returns contracts as JSON. A single contract is selected with ?id=; otherwise
+// the contracts are filtered, sorted and paged by the parameters read by contractQuery, and the
+// number of matching contracts is sent in the X-Total-Count header.
+func (d *Dashboard) handleAPIContracts(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("id") != "" {
+		// A single contract, e.g. linked from a notification, whether active or archived
+		id, ok := d.contractID(w, r.URL.Query().Get("id"))
+		if !ok {
+			return
+		}
+		contract, err := d.store.GetContractByID(id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get contract: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if contract == nil {
+			http.Error(w, "Contract not found", http.StatusNotFound)
+			return
+		}
+		if d.readOnly(r) {
+			hideTeamData(contract)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]scraper.Contract{*contract})
 		return
 	}
 
-	tmplParsed.Execute(w, nil)
-}
+	query, err := d.contractQuery(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	if d.readOnly(r) {
+		hideTeamFilters(&query)
+	}
 
-// handleAPIContracts returns contracts as JSON
-func (d *Dashboard) handleAPIContracts(w http.ResponseWriter, r *http.Request) {
-	contracts, err := d.store.GetContracts()
+	contracts, total, err := d.store.GetContractsPage(query.filter, query.sort, query.limit, query.offset)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get contracts: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if contracts == nil {
+		contracts = []scraper.Contract{}
+	}
+	if d.readOnly(r) {
+		for i := range contracts {
+			hideTeamData(&contracts[i])
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(contracts)
 }
 
+// searchResult is a contract found by /api/search
+type searchResult struct {
+	scraper.Contract
+	Score        float64 `json:"score"`
+	SnippetField string  `json:"snippet_field"` // description, contracting_body or id
+	Snippet      string  `json:"snippet"`       // HTML, with the matching words in <mark>
+}
+
+// handleAPISearch searches the words of ?q= in the full-text index of the contracts, most relevant
+// first, with the filters and page read by contractQuery. The number of matches is sent in the
+// X-Total-Count header.
+func (d *Dashboard) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	query, err := d.contractQuery(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	query.filter.Text = ""
+	query.filter.Search = r.URL.Query().Get("q")
+	if d.readOnly(r) {
+		hideTeamFilters(&query)
+	}
+
+	results, total, err := d.store.SearchContracts(query.filter, query.limit, query.offset)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to search contracts: %v", err), errorStatus(err))
+		return
+	}
+
+	found := make([]searchResult, len(results))
+	for i, result := range results {
+		if d.readOnly(r) {
+			hideTeamData(&result.Contract)
+		}
+		found[i] = searchResult{
+			Contract:     result.Contract,
+			Score:        result.Score,
+			SnippetField: result.Field,
+			Snippet:      snippetHTML(result.Snippet),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(found)
+}
+
+// snippetHTML escapes a search snippet for a page, marking its matching words with <mark>
+func snippetHTML(parts []storage.SnippetPart) string {
+	var snippet strings.Builder
+	for _, part := range parts {
+		if part.Match {
+			snippet.WriteString("<mark>" + html.EscapeString(part.Text) + "</mark>")
+		} else {
+			snippet.WriteString(html.EscapeString(part.Text))
+		}
+	}
+	return snippet.String()
+}
+
+// errProfileNotFound is returned by contractQuery for an unknown ?profile=
+var errProfileNotFound = errors.New("profile not found")
+
+// writeQueryError answers a request whose contract query is invalid
+func writeQueryError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, errProfileNotFound) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// contractListQuery is a contract listing requested from /api/contracts
+type contractListQuery struct {
+	filter        storage.ContractFilter
+	sort          storage.ContractSort
+	limit, offset int
+}
+
+// contractQuery reads the filters, sort and page of a contract listing:
+//
+//	archived=1, watching=1, unseen=1   archived, watched or not yet seen contracts
+//	tag, profile, cpv (comma separated) contracts with a tag, of a profile or with a CPV code
+//	status (comma separated), body, q   status, contracting body, or text in the ID, description or body
+//	search                              words starting words of the ID, description or body, see SearchContracts
+//	min_amount, max_amount              estimated amount in euros
+//	deadline_from, deadline_to          submission deadline, YYYY-MM-DD (inclusive) or RFC 3339
+//	scraped_from, scraped_to            last time scraped, YYYY-MM-DD (inclusive) or RFC 3339
+//	sort, order                         scraped_at, first_seen_at, archived_at, status, amount, deadline or id; asc or desc
+//	limit, offset                       page of the results; every contract without a limit
+func (d *Dashboard) contractQuery(params url.Values) (contractListQuery, error) {
+	query := contractListQuery{sort: storage.DefaultContractSort}
+	filter := &query.filter
+
+	switch {
+	case params.Get("archived") == "1":
+		filter.Archive = storage.ArchiveOnly
+		query.sort = storage.ContractSort{Field: storage.SortByArchivedAt, Descending: true}
+	case params.Get("watching") == "1":
+		filter.Archive = storage.ArchiveInclude
+	}
+	filter.Watched = params.Get("watching") == "1"
+	if params.Get("unseen") == "1" {
+		filter.Unseen = true
+		query.sort = storage.ContractSort{Field: storage.SortByFirstSeenAt, Descending: true}
+	}
+
+	if tag := params.Get("tag"); tag != "" {
+		filter.Tags = []string{tag}
+	}
+	filter.CPVCodes = listParam(params, "cpv")
+	filter.Statuses = listParam(params, "status")
+	filter.ContractingBody = strings.TrimSpace(params.Get("body"))
+	filter.Text = strings.TrimSpace(params.Get("q"))
+	filter.Search = params.Get("search")
+
+	if name := params.Get("profile"); name != "" {
+		profile, err := d.store.GetProfile(name)
+		if err != nil {
+			return query, err
+		}
+		if profile == nil {
+			return query, fmt.Errorf("%w: %s", errProfileNotFound, name)
+		}
+		filter.ProfileID = profile.ID
+	}
+
+	var err error
+	if filter.MinAmount, err = amountParam(params, "min_amount"); err != nil {
+		return query, err
+	}
+	if filter.MaxAmount, err = amountParam(params, "max_amount"); err != nil {
+		return query, err
+	}
+	if filter.DeadlineFrom, err = timeParam(params, "deadline_from", false); err != nil {
+		return query, err
+	}
+	if filter.DeadlineTo, err = timeParam(params, "deadline_to", true); err != nil {
+		return query, err
+	}
+	if filter.ScrapedFrom, err = timeParam(params, "scraped_from", false); err != nil {
+		return query, err
+	}
+	if filter.ScrapedTo, err = timeParam(params, "scraped_to", true); err != nil {
+		return query, err
+	}
+
+	if field := params.Get("sort"); field != "" {
+		switch storage.SortField(field) {
+		case storage.SortByScrapedAt, storage.SortByFirstSeenAt, storage.SortByArchivedAt,
+			storage.SortByStatus, storage.SortByAmount, storage.SortByDeadline, storage.SortByID:
+			query.sort.Field = storage.SortField(field)
+		default:
+			return query, fmt.Errorf("invalid sort %q", field)
+		}
+	}
+	switch params.Get("order") {
+	case "":
+	case "asc":
+		query.sort.Descending = false
+	case "desc":
+		query.sort.Descending = true
+	default:
+		return query, fmt.Errorf("invalid order %q, use asc or desc", params.Get("order"))
+	}
+
+	if query.limit, err = countParam(params, "limit"); err != nil {
+		return query, err
+	}
+	if query.offset, err = countParam(params, "offset"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// listParam reads a comma-separated query parameter, skipping empty entries
+func listParam(params url.Values, name string) []string {
+	var values []string
+	for _, value := range strings.Split(params.Get(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// amountParam reads an amount in euros, 0 if the parameter is not set. NaN, infinite and negative
+// amounts are refused, as they would match every contract or none.
+func amountParam(params url.Values, name string) (float64, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return amount, nil
+}
+
+// timeParam reads a date (YYYY-MM-DD, local time) or an RFC 3339 time, zero if the parameter is not
+// set. Dates that end a range are moved to the next midnight, so the whole day is included.
+func timeParam(params url.Values, name string, end bool) (time.Time, error) {
+	value := params.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, use YYYY-MM-DD", name, value)
+	}
+	return t, nil
+}
+
+// countParam reads a non-negative integer, 0 if the parameter is not set
+func countParam(params url.Values, name string) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+// limitParam reads the positive ?limit= of a listing, def when it is missing
+func limitParam(params url.Values, def int) (int, error) {
+	value := params.Get("limit")
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	return n, nil
+}
+
 // handleAPIStats returns statistics as JSON
 func (d *Dashboard) handleAPIStats(w http.ResponseWriter, r *http.Request) {
-	count, err := d.store.GetContractCount()
+	stats, err := d.collectStats()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get stats: %v", err), http.StatusInternalServerError)
 		return
 	}
-
-	stats := map[string]interface{}{
-		"total":    count,
-		"newToday": 0, // TODO: Implement new today logic
+	// Scrape runs, with their errors, stay behind the login, as does the watchlist
+	if d.readOnly(r) {
+		stats.LastRun = nil
+		stats.Watched = 0
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":       stats.Total,
+		"newToday":    stats.NewToday,
+		"watched":     stats.Watched,
+		"closingSoon": stats.ClosingSoon,
+		"totalValue":  stats.Breakdown.TotalValue,
+		"byStatus":    stats.Breakdown.ByStatus,
+		"lastRun":     stats.LastRun,
+		"breakdown":   stats.Breakdown,
+	})
+}
+
+// dashboardStats are the figures shown at the top of the dashboard
+type dashboardStats struct {
+	Total       int                `json:"total"`
+	NewToday    int                `json:"new_today"`    // Contracts first seen since midnight
+	Watched     int                `json:"watched"`      // Watched contracts, archived ones included, as listed by the Watching view
+	ClosingSoon int                `json:"closing_soon"` // Active contracts whose deadline is within closingSoonDays
+	LastRun     *storage.ScrapeRun `json:"last_run"`
+	Breakdown   *storage.Stats     `json:"breakdown"`
+}
+
+// closingSoonDays is how far ahead the deadlines counted as closing soon are
+const closingSoonDays = 7
+
+// collectStats gathers the dashboard statistics
+func (d *Dashboard) collectStats() (*dashboardStats, error) {
+	var stats dashboardStats
+	var err error
+	if stats.Total, err = d.store.GetContractCount(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	stats.NewToday, err = d.store.CountContractsFirstSeenSince(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.LastRun, err = d.store.GetLastScrapeRun(); err != nil {
+		return nil, err
+	}
+	if stats.Breakdown, err = d.store.GetStats(); err != nil {
+		return nil, err
+	}
+
+	_, stats.Watched, err = d.store.GetContractsPage(storage.ContractFilter{Watched: true, Archive: storage.ArchiveInclude}, storage.DefaultContractSort, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	closingSoon := storage.ContractFilter{DeadlineFrom: now, DeadlineTo: now.AddDate(0, 0, closingSoonDays)}
+	if _, stats.ClosingSoon, err = d.store.GetContractsPage(closingSoon, storage.DefaultContractSort, 1, 0); err != nil {
+		return nil, err
+	}
+	return &stats, nil
 }
 
 // handleDeleteAll deletes all contracts
 func (d *Dashboard) handleDeleteAll(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	d.writeResult(w, d.store.DeleteAllContracts(requestActor(r)))
+}
+
+// handleDeleteContract deletes a specific contract
+func (d *Dashboard) handleDeleteContract(w http.ResponseWriter, r *http.Request) {
+	// Parse the request body
+	var request struct {
+		ID string `json:"id"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.ID == "" {
+		http.Error(w, "Contract ID is required", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := d.contractID(w, request.ID)
+	if !ok {
+		return
+	}
+
+	d.writeResult(w, d.store.DeleteContract(id, requestActor(r)))
+}
+
+// handleRestoreContract restores a specific soft-deleted contract
+func (d *Dashboard) handleRestoreContract(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.ID == "" {
+		http.Error(w, "Contract ID is required", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := d.contractID(w, request.ID)
+	if !ok {
 		return
 	}
 
-	err := d.store.DeleteAllContracts()
+	d.writeResult(w, d.store.RestoreContract(id, requestActor(r)))
+}
+
+// handleRestoreAll restores every soft-deleted contract
+func (d *Dashboard) handleRestoreAll(w http.ResponseWriter, r *http.Request) {
+	restored, err := d.store.RestoreAllContracts(requestActor(r))
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
+		d.writeResult(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
+		"success":  true,
+		"restored": restored,
 	})
 }
 
-// handleDeleteContract deletes a specific contract
-func (d *Dashboard) handleDeleteContract(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleUnarchiveContract moves an archived contract back to the active list
+func (d *Dashboard) handleUnarchiveContract(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Parse the request body
+	if request.ID == "" {
+		http.Error(w, "Contract ID is required", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := d.contractID(w, request.ID)
+	if !ok {
+		return
+	}
+
+	d.writeResult(w, d.store.UnarchiveContract(id))
+}
+
+// handleAPIDeletedContracts returns the soft-deleted contracts that can still be restored
+func (d *Dashboard) handleAPIDeletedContracts(w http.ResponseWriter, r *http.Request) {
+	contracts, err := d.store.GetDeletedContracts()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get deleted contracts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contracts)
+}
+
+// handleAPIStatusChanges returns recent status changes as JSON
+func (d *Dashboard) handleAPIStatusChanges(w http.ResponseWriter, r *http.Request) {
+	statusChanges, err := d.store.GetRecentStatusChanges()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get status changes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Acknowledged changes are left out unless ?acknowledged=1
+	if r.URL.Query().Get("acknowledged") != "1" {
+		pending := []storage.StatusChange{}
+		for _, change := range statusChanges {
+			if change.AcknowledgedAt == nil {
+				pending = append(pending, change)
+			}
+		}
+		statusChanges = pending
+	}
+	// Visitors see that a change was acknowledged, not by whom
+	if d.readOnly(r) {
+		for i := range statusChanges {
+			statusChanges[i].AcknowledgedBy = ""
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statusChanges)
+}
+
+// handleAckStatusChange acknowledges the status change {id}, hiding it for every dashboard user
+func (d *Dashboard) handleAckStatusChange(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid status change ID", http.StatusBadRequest)
+		return
+	}
+
+	d.writeResult(w, d.store.AcknowledgeStatusChange(id, requestActor(r)))
+}
+
+// handleAPIRevisions returns the field revisions of one contract (?id=...) or the recent revisions of all contracts
+func (d *Dashboard) handleAPIRevisions(w http.ResponseWriter, r *http.Request) {
+	var revisions []storage.ContractRevision
+	var err error
+	if ref := r.URL.Query().Get("id"); ref != "" {
+		id, ok := d.contractID(w, ref)
+		if !ok {
+			return
+		}
+		revisions, err = d.store.GetContractRevisions(id)
+	} else {
+		revisions, err = d.store.GetRecentRevisions()
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get revisions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// handleMarkSeen clears the unseen flag of the contracts in {"ids": [...]}, or of every contract with {"all": true}
+func (d *Dashboard) handleMarkSeen(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		IDs []string `json:"ids"`
+		All bool     `json:"all"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var seen int64
+	var err error
+	if request.All {
+		seen, err = d.store.MarkAllContractsSeen()
+	} else {
+		ids := make([]string, 0, len(request.IDs))
+		for _, ref := range request.IDs {
+			id, ok := d.contractID(w, ref)
+			if !ok {
+				return
+			}
+			ids = append(ids, id)
+		}
+		seen, err = d.store.MarkContractsSeen(ids)
+	}
+
+	if err != nil {
+		d.writeResult(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"seen":    seen,
+	})
+}
+
+// contractID maps a contract reference from a request, its uid or its id, to the id it is stored under.
+// On failure it writes the error response and returns false.
+func (d *Dashboard) contractID(w http.ResponseWriter, ref string) (string, bool) {
+	id, err := d.store.ResolveContractID(ref)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to resolve contract: %v", err), http.StatusInternalServerError)
+		return "", false
+	}
+	return id, true
+}
+
+// handleWatchContract puts a contract on the watchlist
+func (d *Dashboard) handleWatchContract(w http.ResponseWriter, r *http.Request) {
+	d.handleContractAction(w, r, d.store.WatchContract)
+}
+
+// handleUnwatchContract removes a contract from the watchlist
+func (d *Dashboard) handleUnwatchContract(w http.ResponseWriter, r *http.Request) {
+	d.handleContractAction(w, r, d.store.UnwatchContract)
+}
+
+// handleContractAction decodes a {"id": ...} request and applies action to the contract
+func (d *Dashboard) handleContractAction(w http.ResponseWriter, r *http.Request, action func(contractID string) error) {
 	var request struct {
 		ID string `json:"id"`
 	}
-	
+
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
@@ -94,9 +659,205 @@ func (d *Dashboard) handleDeleteContract(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := d.store.DeleteContract(request.ID)
+	id, ok := d.contractID(w, request.ID)
+	if !ok {
+		return
+	}
+
+	d.writeResult(w, action(id))
+}
+
+// handleAPIProfiles lists the search profiles with their number of contracts
+func (d *Dashboard) handleAPIProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := d.store.GetProfiles()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, fmt.Sprintf("Failed to get profiles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profiles)
+}
+
+// handleAPITags lists the tags in use with their number of contracts
+func (d *Dashboard) handleAPITags(w http.ResponseWriter, r *http.Request) {
+	tags, err := d.store.GetTags()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tags: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if d.readOnly(r) {
+		tags = []storage.TagCount{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// handleAPIStatuses counts the contracts by status. It takes the filters of /api/contracts, except
+// status, so the counts match the listed contracts.
+func (d *Dashboard) handleAPIStatuses(w http.ResponseWriter, r *http.Request) {
+	query, err := d.contractQuery(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	if d.readOnly(r) {
+		hideTeamFilters(&query)
+	}
+	statuses, err := d.store.GetStatusCounts(query.filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get statuses: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// handleAPICPVCodes lists the CPV codes listed by contracts with their number of contracts
+func (d *Dashboard) handleAPICPVCodes(w http.ResponseWriter, r *http.Request) {
+	codes, err := d.store.GetCPVCodes()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get CPV codes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(codes)
+}
+
+// handleAddTag labels a contract with a tag
+func (d *Dashboard) handleAddTag(w http.ResponseWriter, r *http.Request) {
+	d.handleTagChange(w, r, d.store.AddTag)
+}
+
+// handleRemoveTag removes a tag from a contract
+func (d *Dashboard) handleRemoveTag(w http.ResponseWriter, r *http.Request) {
+	d.handleTagChange(w, r, d.store.RemoveTag)
+}
+
+// handleTagChange decodes a {"id": ..., "tag": ...} request and applies change to it
+func (d *Dashboard) handleTagChange(w http.ResponseWriter, r *http.Request, change func(contractID, tag string) error) {
+	var request struct {
+		ID  string `json:"id"`
+		Tag string `json:"tag"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.ID == "" || request.Tag == "" {
+		http.Error(w, "Contract ID and tag are required", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := d.contractID(w, request.ID)
+	if !ok {
+		return
+	}
+
+	d.writeResult(w, change(id, request.Tag))
+}
+
+// handleAPINotes returns the notes of a contract (?id=...)
+func (d *Dashboard) handleAPINotes(w http.ResponseWriter, r *http.Request) {
+	id, ok := d.contractID(w, r.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+	if id == "" {
+		http.Error(w, "Contract ID is required", http.StatusBadRequest)
+		return
+	}
+
+	notes, err := d.store.GetNotes(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get notes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notes)
+}
+
+// handleAddNote attaches a note to a contract
+func (d *Dashboard) handleAddNote(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     string `json:"id"`
+		Author string `json:"author"`
+		Body   string `json:"body"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.ID == "" {
+		http.Error(w, "Contract ID is required", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := d.contractID(w, request.ID)
+	if !ok {
+		return
+	}
+
+	if user := authenticatedUser(r); user != "" {
+		request.Author = user
+	}
+	note, err := d.store.AddNote(id, request.Author, request.Body)
+	if err != nil {
+		d.writeResult(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"note":    note,
+	})
+}
+
+// handleUpdateNote replaces the text of a note
+func (d *Dashboard) handleUpdateNote(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		NoteID int64  `json:"note_id"`
+		Body   string `json:"body"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	d.writeResult(w, d.store.UpdateNote(request.NoteID, request.Body))
+}
+
+// handleDeleteNote removes a note
+func (d *Dashboard) handleDeleteNote(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		NoteID int64 `json:"note_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	d.writeResult(w, d.store.DeleteNote(request.NoteID))
+}
+
+// writeResult writes the {"success": ..., "error": ...} response of a dashboard action. A failed
+// action is answered with the status code matching its error, see errorStatus.
+func (d *Dashboard) writeResult(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
 			"error":   err.Error(),
@@ -104,44 +865,198 @@ func (d *Dashboard) handleDeleteContract(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 	})
 }
 
-// handleAPIStatusChanges returns recent status changes as JSON
-func (d *Dashboard) handleAPIStatusChanges(w http.ResponseWriter, r *http.Request) {
-	statusChanges, err := d.store.GetRecentStatusChanges()
+// errorStatus is the HTTP status code for an error returned by the store
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestActor names the user of a dashboard request for the audit log: the logged in user, or else the
+// name the browser sends in the X-Actor header (URL-encoded), and the client address
+func requestActor(r *http.Request) string {
+	name := authenticatedUser(r)
+	if name == "" {
+		var err error
+		name, err = url.QueryUnescape(r.Header.Get("X-Actor"))
+		if err != nil || strings.TrimSpace(name) == "" {
+			name = "dashboard"
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get status changes: %v", err), http.StatusInternalServerError)
+		host = r.RemoteAddr
+	}
+	return fmt.Sprintf("%s (%s)", strings.TrimSpace(name), host)
+}
+
+// handleAPIAuditLog returns the most recent audit log entries (?limit=N, 100 by default)
+func (d *Dashboard) handleAPIAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r.URL.Query(), 100)
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := d.store.GetAuditLog(limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get audit log: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(statusChanges)
+	json.NewEncoder(w).Encode(entries)
 }
 
-// handleHistory displays the complete status changes history
+// handleAPIScrapeRuns returns the most recent scrape runs (?limit=N, 20 by default)
+func (d *Dashboard) handleAPIScrapeRuns(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r.URL.Query(), 20)
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	runs, err := d.store.GetScrapeRuns(limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get scrape runs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// handleExport downloads the contracts matching the filters and sort read by contractQuery, all
+// of them rather than one page, as CSV (the default) or JSON with ?format=
+func (d *Dashboard) handleExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if format != export.FormatCSV && format != export.FormatJSON {
+		http.Error(w, fmt.Sprintf("invalid format %q, use csv or json", format), http.StatusBadRequest)
+		return
+	}
+
+	query, err := d.contractQuery(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	// The rows are streamed, so a failure half way can only be logged
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=contracts-%s.%s", time.Now().Format("2006-01-02"), format))
+	lw, err := export.NewListWriter(w, format)
+	if err == nil {
+		err = d.store.ForEachContract(query.filter, query.sort, lw.Write)
+	}
+	if err == nil {
+		err = lw.Close()
+	}
+	if err != nil {
+		log.Printf("Failed to export contracts: %v", err)
+	}
+}
+
+// handleExportContracts downloads every contract (including archived ones) as gzipped CSV
+func (d *Dashboard) handleExportContracts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+export.ContractsFile)
+	if err := export.StreamContracts(d.store, w); err != nil {
+		log.Printf("Failed to export contracts: %v", err)
+	}
+}
+
+// handleExportStatusChanges downloads the full status change history as gzipped CSV
+func (d *Dashboard) handleExportStatusChanges(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+export.StatusChangesFile)
+	if err := export.StreamStatusChanges(d.store, w); err != nil {
+		log.Printf("Failed to export status changes: %v", err)
+	}
+}
+
+// handleReport downloads the Excel report
+func (d *Dashboard) handleReport(w http.ResponseWriter, r *http.Request) {
+	// Build the workbook first so a failure can still be reported as an HTTP error
+	var buf bytes.Buffer
+	if err := report.Write(d.store, &buf, nil); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to generate report: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", report.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+report.FileName)
+	w.Write(buf.Bytes())
+}
+
+// handleCalendar serves the submission deadlines of the watched contracts, or of the contracts with
+// ?tag=, as an iCalendar feed to subscribe to from Outlook or Google Calendar
+func (d *Dashboard) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	name := "Watched LED screen contracts"
+	var contracts []scraper.Contract
+	var err error
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		name = "LED screen contracts tagged " + tag
+		contracts, err = d.store.GetContractsByTag(tag)
+	} else {
+		contracts, err = d.store.GetWatchedContracts()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", calendar.ContentType)
+	w.Header().Set("Content-Disposition", "inline; filename="+calendar.FileName)
+	if err := calendar.Write(w, contracts, calendar.Options{Name: name}); err != nil {
+		log.Printf("Failed to write calendar: %v", err)
+	}
+}
+
+// handleHistory displays the complete status and field changes history
 func (d *Dashboard) handleHistory(w http.ResponseWriter, r *http.Request) {
 	statusChanges, err := d.store.GetAllStatusChanges()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	
-	tmplParsed, err := template.New("history").Parse(HistoryTemplate)
+
+	revisions, err := d.store.GetAllRevisions()
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditLog, err := d.store.GetAuditLog(100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	
 	data := struct {
+		page
 		StatusChanges []storage.StatusChange
+		Revisions     []storage.ContractRevision
+		AuditLog      []storage.AuditEntry
 	}{
+		page:          d.page(r),
 		StatusChanges: statusChanges,
+		Revisions:     revisions,
+		AuditLog:      auditLog,
 	}
 	
-	w.Header().Set("Content-Type", "text/html")
-	tmplParsed.Execute(w, data)
+	d.renderPage(w, r, http.StatusOK, "history.html", data)
 } 
\ No newline at end of file
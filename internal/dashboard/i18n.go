@@ -0,0 +1,368 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Dashboard languages. Spanish is the default, as the users are Spanish companies bidding for contracts.
+const (
+	languageSpanish = "es"
+	languageEnglish = "en"
+)
+
+// languageSetting is the user setting holding the chosen language
+const languageSetting = "language"
+
+// translations holds the text of the dashboard in every language but English, keyed by the English
+// text, which templates and dashboard.js pass to t. Keys with %d or %s are formats.
+var translations = map[string]map[string]string{
+	languageSpanish: {
+		// Page titles and navigation
+		"LED Screen Contracts Dashboard": "Panel de Contratos de Pantallas LED",
+		"Analytics":                      "Estadísticas",
+		"Deadline Calendar":              "Calendario de Plazos",
+		"Change History":                 "Historial de Cambios",
+		"Log In":                         "Iniciar sesión",
+		"Log Out (%s)":                   "Cerrar sesión (%s)",
+		"← Back to Dashboard":            "← Volver al panel",
+		"← Previous":                     "← Anterior",
+		"Next →":                         "Siguiente →",
+		"User":                           "Usuario",
+		"Password":                       "Contraseña",
+		"Wrong user name or password":    "Usuario o contraseña incorrectos",
+
+		// Dashboard header and controls
+		"Total Contracts":                      "Total de contratos",
+		"New Today":                            "Nuevos hoy",
+		"Unseen":                               "Sin ver",
+		"Closing in 7 Days":                    "Cierran en 7 días",
+		"Estimated Value":                      "Valor estimado",
+		"By Status":                            "Por estado",
+		"Last Run":                             "Última ejecución",
+		"Search contracts...":                  "Buscar contratos...",
+		"All tags":                             "Todas las etiquetas",
+		"Refresh":                              "Actualizar",
+		"Run Scrape Now":                       "Buscar ahora",
+		"View History":                         "Historial",
+		"Calendar":                             "Calendario",
+		"Submission deadlines on a month grid": "Plazos de presentación en un calendario mensual",
+		"Download Report":                      "Descargar informe",
+		"Export CSV":                           "Exportar CSV",
+		"Export JSON":                          "Exportar JSON",
+		"Download the contracts listed below, with the current filters and order": "Descarga los contratos de la lista, con los filtros y el orden actuales",
+		"Calendar Feed (.ics)":                             "Suscripción de calendario (.ics)",
+		"Subscribe to this address from your calendar app": "Suscríbete a esta dirección desde tu aplicación de calendario",
+		"News Feed (Atom)":                                 "Canal de novedades (Atom)",
+		"Follow the new contracts from your feed reader; add ?tag= or ?profile= to the address to narrow it": "Sigue los contratos nuevos desde tu lector de feeds; añade ?tag= o ?profile= a la dirección para acotarlo",
+		"Delete All":      "Eliminar todos",
+		"Restore Deleted": "Restaurar eliminados",
+		"Show Archived":   "Ver archivados",
+		"Show Active":     "Ver activos",
+		"Watching":        "Seguidos",
+		"Watching (%d)":   "Seguidos (%d)",
+		"All Contracts":   "Todos los contratos",
+		"Light Theme":     "Tema claro",
+		"Dark Theme":      "Tema oscuro",
+		"Language":        "Idioma",
+		"Showing the contract linked from a notification.": "Se muestra el contrato enlazado desde una notificación.",
+		"Show all contracts":    "Ver todos los contratos",
+		"Scrape":                "Búsqueda",
+		"Recent Status Changes": "Cambios de estado recientes",
+		"Sort by:":              "Ordenar por:",
+		"Deadline":              "Plazo",
+		"Amount":                "Importe",
+		"Scraped":               "Encontrado",
+		"Status":                "Estado",
+		"Loading contracts...":  "Cargando contratos...",
+
+		// Contract list (dashboard.js)
+		"%d–%d of %d":                       "%d–%d de %d",
+		"Last Run · %s · %d new, %d errors": "Última ejecución · %s · %d nuevos, %d errores",
+		"All (%d)":                          "Todos (%d)",
+		"No status":                         "Sin estado",
+		"contract not found":                "contrato no encontrado",
+		"Error loading contracts: %s":       "Error al cargar los contratos: %s",
+		"No watched contracts. Click ☆ on a contract to be alerted about its status changes and deadlines.": "No sigues ningún contrato. Pulsa ☆ en un contrato para recibir avisos de sus cambios de estado y plazos.",
+		"No contracts found": "No se encontraron contratos",
+		"NEW":                "NUEVO",
+		"Stop watching":      "Dejar de seguir",
+		"Watch: always notify about status changes and deadlines": "Seguir: avisar siempre de cambios de estado y plazos",
+		"Move back to active contracts":                           "Devolver a los contratos activos",
+		"Delete contract":                                         "Eliminar contrato",
+		"Type":                                                    "Tipo",
+		"Submission Date":                                         "Fecha de presentación",
+		"Contracting Body":                                        "Órgano de contratación",
+		"Scraped At":                                              "Encontrado el",
+		"Documents":                                               "Documentos",
+		"Not available":                                           "No disponible",
+		"Remove tag":                                              "Quitar etiqueta",
+		"Add a tag such as to bid, won or ignore": "Añade una etiqueta como licitar, ganado o descartar",
+		"+ tag":                  "+ etiqueta",
+		"to bid, won, ignore...": "licitar, ganado, descartar...",
+		"📝 Notes":                "📝 Notas",
+		"Are you sure you want to delete contract \"%s\"? It can be restored later with \"Restore Deleted\".": "¿Seguro que quieres eliminar el contrato \"%s\"? Se puede recuperar después con \"Restaurar eliminados\".",
+		"Are you sure you want to delete all contracts? They can be restored later with \"Restore Deleted\".": "¿Seguro que quieres eliminar todos los contratos? Se pueden recuperar después con \"Restaurar eliminados\".",
+		"Error deleting contract: %s":    "Error al eliminar el contrato: %s",
+		"Error deleting contracts: %s":   "Error al eliminar los contratos: %s",
+		"Error updating watchlist: %s":   "Error al actualizar los contratos seguidos: %s",
+		"Error unarchiving contract: %s": "Error al desarchivar el contrato: %s",
+		"Error updating tags: %s":        "Error al actualizar las etiquetas: %s",
+		"Restored %d contracts":          "Se restauraron %d contratos",
+		"Error restoring contracts: %s":  "Error al restaurar los contratos: %s",
+
+		// Bulk actions (dashboard.js)
+		"Select all":               "Seleccionar todos",
+		"Select for a bulk action": "Seleccionar para una acción en bloque",
+		"%d selected":              "%d seleccionados",
+		"Tag":                      "Etiquetar",
+		"Mark Seen":                "Marcar como vistos",
+		"Archive":                  "Archivar",
+		"Clear selection":          "Quitar selección",
+		"Are you sure you want to delete the %d selected contracts? They can be restored later with \"Restore Deleted\".": "¿Seguro que quieres eliminar los %d contratos seleccionados? Se pueden recuperar después con \"Restaurar eliminados\".",
+		"Error archiving contracts: %s":       "Error al archivar los contratos: %s",
+		"Error marking contracts as seen: %s": "Error al marcar los contratos como vistos: %s",
+
+		// Trash
+		"Trash": "Papelera",
+		"Deleted contracts, to restore them one by one":                                       "Contratos eliminados, para restaurarlos uno a uno",
+		"%d deleted contracts, most recently deleted first":                                   "%d contratos eliminados, los más recientes primero",
+		"Deleted contracts are kept until an administrator purges them with --purge-deleted.": "Los contratos eliminados se conservan hasta que un administrador los purga con --purge-deleted.",
+		"Restore all":             "Restaurar todos",
+		"Restore":                 "Restaurar",
+		"ID":                      "ID",
+		"Deleted":                 "Eliminado",
+		"The trash is empty":      "La papelera está vacía",
+		"Undo":                    "Deshacer",
+		"Contract \"%s\" deleted": "Contrato \"%s\" eliminado",
+		"%d contracts deleted":    "%d contratos eliminados",
+
+		// Notes (dashboard.js)
+		"Anonymous":                          "Anónimo",
+		"(edited)":                           "(editada)",
+		"Edit":                               "Editar",
+		"Delete":                             "Eliminar",
+		"Add a note... (Ctrl+Enter to save)": "Añade una nota... (Ctrl+Intro para guardar)",
+		"Add":                                "Añadir",
+		"Save":                               "Guardar",
+		"Cancel":                             "Cancelar",
+		"Delete this note?":                  "¿Eliminar esta nota?",
+		"Error saving note: %s":              "Error al guardar la nota: %s",
+		"Your name (shown next to your notes and in the audit log):": "Tu nombre (aparece junto a tus notas y en el registro de auditoría):",
+
+		// Scrapes (dashboard.js)
+		"Opening the search form":                           "Abriendo el formulario de búsqueda",
+		"Searching for the CPV code":                        "Buscando el código CPV",
+		"Reading the contracts from the results":            "Leyendo los contratos de los resultados",
+		"Fetching the document links":                       "Obteniendo los enlaces a los documentos",
+		"Scraping %s…":                                      "Buscando %s…",
+		"the default profile":                               "el perfil por defecto",
+		"Scrape failed · %s":                                "Búsqueda fallida · %s",
+		"Scrape finished · %s":                              "Búsqueda terminada · %s",
+		"Error: %s":                                         "Error: %s",
+		"%d contracts found, %d new, %d changed, %d errors": "%d contratos encontrados, %d nuevos, %d modificados, %d errores",
+		"Error starting the scrape: %s":                     "Error al iniciar la búsqueda: %s",
+
+		// History
+		"No status changes found":            "No hay cambios de estado",
+		"Field Changes":                      "Cambios de campos",
+		"No field changes found":             "No hay cambios de campos",
+		"Audit Log":                          "Registro de auditoría",
+		"%d affected":                        "%d afectados",
+		"No destructive operations recorded": "No hay operaciones destructivas registradas",
+
+		// Contract page
+		"Description": "Descripción",
+		"First Seen":  "Visto por primera vez",
+		"Link":        "Enlace",
+		"Changes":     "Cambios",
+		"%+d days":    "%+d días",
+		"No changes recorded since the contract was first seen": "No se han registrado cambios desde que se vio el contrato por primera vez",
+		"Details and changes of the contract":                   "Detalles y cambios del contrato",
+		"Status Timeline":                                       "Evolución del estado",
+		"Acknowledged by %s on %s":                              "Revisado por %s el %s",
+		"Not acknowledged yet":                                  "Aún sin revisar",
+		"First seen as":                                         "Visto por primera vez como",
+		"Download through the dashboard":                        "Descargar a través del panel",
+
+		// Scrape runs
+		"Scrape Runs": "Ejecuciones",
+		"Duration, counts, errors, screenshots and page snapshots of each scrape": "Duración, recuentos, errores, capturas y páginas guardadas de cada búsqueda",
+		"The last %d scrapes, newest first":                                       "Las últimas %d búsquedas, de la más reciente a la más antigua",
+		"%d pages, %d contracts found, %d new, %d changed, %d errors":             "%d páginas, %d contratos encontrados, %d nuevos, %d modificados, %d errores",
+		"Screenshots (%d)":        "Capturas (%d)",
+		"Page snapshots (%d)":     "Páginas guardadas (%d)",
+		"+%d more":                "y %d más",
+		"No scrapes recorded yet": "Aún no se ha registrado ninguna búsqueda",
+		"running":                 "en curso",
+		"success":                 "correcta",
+		"partial":                 "parcial",
+		"failed":                  "fallida",
+
+		// Notifications
+		"Notifications": "Notificaciones",
+		"What was sent, to whom and over which channel": "Qué se envió, a quién y por qué canal",
+		"The last %d notifications, newest first":       "Las últimas %d notificaciones, de la más reciente a la más antigua",
+		"Date":                                 "Fecha",
+		"Event":                                "Evento",
+		"Channel":                              "Canal",
+		"Recipients":                           "Destinatarios",
+		"Attempts":                             "Intentos",
+		"sent %s":                              "enviada el %s",
+		"next attempt %s":                      "próximo intento el %s",
+		"set up on the channel":                "configurados en el canal",
+		"Resend":                               "Reenviar",
+		"No notifications sent yet":            "Aún no se ha enviado ninguna notificación",
+		"Error resending the notification: %s": "Error al reenviar la notificación: %s",
+		"New contracts":                        "Contratos nuevos",
+		"Watched contracts":                    "Contratos seguidos",
+		"Status changes":                       "Cambios de estado",
+		"Scrape summary":                       "Resumen de la búsqueda",
+		"Scrape failure":                       "Fallo de la búsqueda",
+		"sent":                                 "enviada",
+		"pending":                              "pendiente",
+		"held":                                 "retenida",
+
+		// Settings
+		"Settings": "Ajustes",
+		"CPV codes, notification recipients, filters and schedule": "Códigos CPV, destinatarios, filtros y horario de las notificaciones",
+		"Changes apply at once, without restarting the scraper":    "Los cambios se aplican al momento, sin reiniciar el programa",
+		"Search Profiles":     "Perfiles de búsqueda",
+		"Profile":             "Perfil",
+		"CPV code":            "Código CPV",
+		"Contracts":           "Contratos",
+		"the scraper default": "el del programa",
+		"New profile":         "Nuevo perfil",
+		"Each profile searches the portal for its CPV code and keeps its own contracts. Codes can be given with or without check digit, e.g. 32351200-0.": "Cada perfil busca en la plataforma su código CPV y guarda sus propios contratos. Los códigos pueden llevar o no el dígito de control, p. ej. 32351200-0.",
+		"Filters":          "Filtros",
+		"Schedule":         "Horario",
+		"Email recipients": "Destinatarios del correo",
+		"Addresses the emails are sent to, separated by commas": "Direcciones a las que se envían los correos, separadas por comas",
+		"Keywords": "Palabras clave",
+		"Only new contracts whose description contains one of these words are notified, separated by commas. Empty notifies every contract.": "Solo se avisa de los contratos nuevos cuya descripción contenga alguna de estas palabras, separadas por comas. Vacío avisa de todos.",
+		"Minimum amount (€)": "Importe mínimo (€)",
+		"New contracts with a lower estimated amount are not notified. Empty for any amount.": "No se avisa de los contratos nuevos con un importe estimado menor. Vacío para cualquier importe.",
+		"Quiet hours": "Horas de silencio",
+		"Alerts are held until they end and then sent in one digest, e.g. 22:00-07:00. Empty for none.": "Los avisos se retienen hasta que terminan y se envían en un resumen, p. ej. 22:00-07:00. Vacío para ninguna.",
+		"Quiet weekends": "Fines de semana en silencio",
+		"Hold the alerts on Saturdays and Sundays": "Retener los avisos los sábados y domingos",
+		"Messages per hour":                        "Mensajes por hora",
+		"Most messages sent to the email or a channel per hour; the rest wait for the next digest. 0 for no limit.": "Máximo de mensajes por hora al correo o a un canal; el resto espera al siguiente resumen. 0 para no limitar.",
+		"Settings saved":                "Ajustes guardados",
+		"Error saving the settings: %s": "Error al guardar los ajustes: %s",
+
+		// Notification channels of the settings page
+		"Notification Channels": "Canales de notificación",
+		"The alerts also go to these channels, on top of those set in the environment. Channels are added and removed at once.": "Las alertas también se envían a estos canales, además de los configurados en el entorno. Los canales se añaden y eliminan al momento.",
+		"Added":                            "Añadido",
+		"Send Test":                        "Enviar prueba",
+		"Remove":                           "Eliminar",
+		"No channels added yet":            "Aún no se ha añadido ningún canal",
+		"Add a Channel":                    "Añadir un canal",
+		"Kind":                             "Tipo",
+		"Add Channel":                      "Añadir canal",
+		"Email (SMTP)":                     "Correo (SMTP)",
+		"SMTP server":                      "Servidor SMTP",
+		"Port":                             "Puerto",
+		"Username":                         "Usuario",
+		"From address":                     "Remitente",
+		"Bot token":                        "Token del bot",
+		"Chat IDs":                         "IDs de chat",
+		"Webhook URL":                      "URL del webhook",
+		"Signing secret":                   "Secreto de firma",
+		"Error adding the channel: %s":     "Error al añadir el canal: %s",
+		"Test sent, check that it arrived": "Prueba enviada, comprueba que ha llegado",
+		"Error sending the test: %s":       "Error al enviar la prueba: %s",
+		"Remove this channel? It will no longer be notified.": "¿Eliminar este canal? Dejará de recibir notificaciones.",
+		"Error removing the channel: %s":                      "Error al eliminar el canal: %s",
+
+		// Analytics
+		"%d contracts · %s estimated": "%d contratos · %s estimados",
+		"Contracts per Month":         "Contratos por mes",
+		"%s: %d contracts, %s":        "%s: %d contratos, %s",
+		"No contracts yet":            "Aún no hay contratos",
+		"Budget by Contracting Body":  "Presupuesto por órgano de contratación",
+		"%d contracts":                "%d contratos",
+		"Status Funnel":               "Embudo de estados",
+		"Time to Adjudication":        "Tiempo hasta la adjudicación",
+		"average days":                "días de media",
+		"median days":                 "días de mediana",
+		"adjudicated contracts, counted from when they were first seen": "contratos adjudicados, contando desde que se vieron por primera vez",
+		"No contract has been seen changing to Adjudicada yet":          "Aún no se ha visto ningún contrato pasar a Adjudicada",
+
+		// Calendar
+		"Submission deadlines of active contracts this month: %d":  "Plazos de presentación de contratos activos este mes: %d",
+		"Submission deadlines of watched contracts this month: %d": "Plazos de presentación de contratos seguidos este mes: %d",
+		"This Month": "Este mes",
+		"All Active": "Todos los activos",
+		"Watched":    "Seguidos",
+		"Mon":        "Lun",
+		"Tue":        "Mar",
+		"Wed":        "Mié",
+		"Thu":        "Jue",
+		"Fri":        "Vie",
+		"Sat":        "Sáb",
+		"Sun":        "Dom",
+	},
+}
+
+// spanishMonths are the month names for calendar titles in Spanish
+var spanishMonths = []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto",
+	"septiembre", "octubre", "noviembre", "diciembre"}
+
+// translate returns text in the language, formatted with args if given. Text without a
+// translation is shown in English.
+func translate(language, text string, args ...interface{}) string {
+	if translated, ok := translations[language][text]; ok {
+		text = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// translator returns the t function of the templates for a language
+func translator(language string) func(string, ...interface{}) string {
+	return func(text string, args ...interface{}) string {
+		return translate(language, text, args...)
+	}
+}
+
+// formatMonth formats a month as a calendar title, e.g. "November 2025" or "noviembre de 2025"
+func formatMonth(language string, month time.Time) string {
+	if language == languageSpanish {
+		return fmt.Sprintf("%s de %d", spanishMonths[month.Month()-1], month.Year())
+	}
+	return month.Format("January 2006")
+}
+
+// language returns the language chosen by the user or browser of a request
+func (d *Dashboard) language(r *http.Request) string {
+	if language := d.userSetting(r, languageSetting, languageSpanish, languageEnglish); language != "" {
+		return language
+	}
+	return languageSpanish
+}
+
+// handleSetLanguage saves the language chosen with {"language": "en"} or "es" for the logged in
+// user, or else for the browser
+func (d *Dashboard) handleSetLanguage(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Language string `json:"language"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.Language != languageSpanish && request.Language != languageEnglish {
+		http.Error(w, `Language must be "es" or "en"`, http.StatusBadRequest)
+		return
+	}
+
+	d.writeResult(w, d.saveUserSetting(w, r, languageSetting, request.Language))
+}
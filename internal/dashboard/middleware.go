@@ -0,0 +1,123 @@
+package dashboard
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Request logging modes, see SetRequestLog
+const (
+	RequestLogAll    = "all"    // Every request (the default)
+	RequestLogErrors = "errors" // Requests answered with a 4xx or 5xx status, and panics
+	RequestLogOff    = "off"    // Only panics
+)
+
+// SetRequestLog selects which requests are logged (RequestLogAll, RequestLogErrors or RequestLogOff)
+func (d *Dashboard) SetRequestLog(mode string) error {
+	switch mode {
+	case "":
+		mode = RequestLogAll
+	case RequestLogAll, RequestLogErrors, RequestLogOff:
+	default:
+		return fmt.Errorf("unknown request log mode %q (use %s, %s or %s)", mode, RequestLogAll, RequestLogErrors, RequestLogOff)
+	}
+	d.requestLog = mode
+	return nil
+}
+
+// statusRecorder remembers the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int // 0 until the header is written
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer, e.g. to flush
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// logRequests logs every request with its method, path, status, duration, size and client, as
+// "key=value" fields. A handler that panics is answered with a 500 instead of dropping the
+// connection, and the panic is logged with its stack trace.
+func (d *Dashboard) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					// Deliberate abort of the response, which net/http handles quietly
+					panic(err)
+				}
+				log.Printf("Warning: panic serving method=%s path=%q: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+				if recorder.status == 0 {
+					writeRequestError(recorder, r, "Internal server error", http.StatusInternalServerError)
+				} else {
+					// Part of the response is sent already, so the client can only tell from the cut connection
+					defer panic(http.ErrAbortHandler)
+				}
+			}
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if d.requestLog == RequestLogOff || (d.requestLog == RequestLogErrors && status < 400) {
+				return
+			}
+			log.Printf("HTTP method=%s path=%q status=%d duration=%s bytes=%d remote=%s",
+				r.Method, r.URL.RequestURI(), status, time.Since(start).Round(time.Microsecond), recorder.bytes, d.clientAddress(r))
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// clientAddress returns the IP address a request came from. Behind trusted proxies it is the last
+// address of X-Forwarded-For that is not a trusted proxy itself, as the ones before it were given by
+// the client.
+func (d *Dashboard) clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = strings.TrimSpace(r.RemoteAddr)
+	}
+	if !d.trustedProxy(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(addr) == nil {
+			break
+		}
+		host = addr
+		if !d.trustedProxy(addr) {
+			break
+		}
+	}
+	return host
+}
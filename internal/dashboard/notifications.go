@@ -0,0 +1,123 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"scraper/internal/storage"
+)
+
+// notificationsShown is how many notifications the notifications page lists by default, the newest ones
+const notificationsShown = 100
+
+// notificationEvents labels the notification events, in English for the templates to translate
+var notificationEvents = map[string]string{
+	"new_contracts":  "New contracts",
+	"watchlist":      "Watched contracts",
+	"status_changes": "Status changes",
+	"run_summary":    "Scrape summary",
+	"scrape_failure": "Scrape failure",
+}
+
+// Notifier sends the notifications logged as failed again and tells who they go to, see SetNotifier.
+// It is implemented by *notification.Notifier.
+type Notifier interface {
+	Redeliver(target, event string, payload []byte) error
+	Recipients(target string) []string
+}
+
+// SetNotifier lets dashboard users resend failed notifications from the notifications page with
+// notifier, which also names the recipients of the email. Without a notifier they cannot be resent.
+func (d *Dashboard) SetNotifier(notifier Notifier) {
+	d.notifier = notifier
+}
+
+// notificationView is a notification log entry as the notifications page shows it
+type notificationView struct {
+	storage.NotificationLogEntry
+	EventLabel string // English name of the event, translated by the template
+	Recipients string // Email addresses, empty for channels
+	CanResend  bool
+}
+
+// handleNotificationsPage serves the notification history: the newest notifications (?limit=, 100
+// by default) with where they went and whether they were sent, and a resend button on failed ones
+func (d *Dashboard) handleNotificationsPage(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r.URL.Query(), notificationsShown)
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := d.store.GetNotificationLog(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]notificationView, len(entries))
+	for i, entry := range entries {
+		views[i] = notificationView{NotificationLogEntry: entry, EventLabel: entry.Event, CanResend: d.canResend(entry)}
+		if label, ok := notificationEvents[entry.Event]; ok {
+			views[i].EventLabel = label
+		}
+		if d.notifier != nil {
+			views[i].Recipients = strings.Join(d.notifier.Recipients(entry.Target), ", ")
+		}
+	}
+
+	d.renderPage(w, r, http.StatusOK, "notifications.html", struct {
+		page
+		Notifications []notificationView
+	}{
+		page:          d.page(r),
+		Notifications: views,
+	})
+}
+
+// canResend reports whether a notification can be resent from the dashboard: it failed for good
+// and its payload was kept
+func (d *Dashboard) canResend(entry storage.NotificationLogEntry) bool {
+	return d.notifier != nil && entry.Status == storage.NotificationFailed && len(entry.Payload) > 0
+}
+
+// handleResendNotification sends a failed notification again. The attempt is recorded like the
+// automatic retries, so the entry turns to sent or keeps its status with the new error.
+func (d *Dashboard) handleResendNotification(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+	if d.notifier == nil {
+		http.Error(w, "Resending notifications is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	entry, err := d.store.GetNotification(id)
+	if err != nil {
+		d.writeResult(w, err)
+		return
+	}
+	if entry == nil {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+	if !d.canResend(*entry) {
+		http.Error(w, fmt.Sprintf("Only failed notifications can be resent, this one is %s", entry.Status), http.StatusConflict)
+		return
+	}
+
+	sendErr := d.notifier.Redeliver(entry.Target, entry.Event, entry.Payload)
+	if err := d.store.RecordNotificationRetry(*entry, sendErr); err != nil {
+		d.writeResult(w, err)
+		return
+	}
+	if sendErr != nil {
+		d.writeResult(w, fmt.Errorf("failed to resend the %s notification to %s: %w", entry.Event, entry.Target, sendErr))
+		return
+	}
+	d.writeResult(w, nil)
+}
@@ -0,0 +1,75 @@
+package dashboard
+
+import (
+	"net/http"
+	"strings"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// publicReadPaths are what visitors who are not logged in may read from a public dashboard: the
+// contract list and pages, what the list loads and the feed. Notes, history, settings, runs and
+// notifications stay behind the login, as do the exports.
+var publicReadPaths = map[string]bool{
+	"/":                   true,
+	"/contract":           true,
+	feedPath:              true,
+	"/api/contracts":      true,
+	"/api/search":         true,
+	"/api/stats":          true,
+	"/api/tags":           true,
+	"/api/statuses":       true,
+	"/api/status-changes": true,
+}
+
+// publicPreferencePaths save the theme and language of a visitor's browser, which changes nothing of
+// the contracts
+var publicPreferencePaths = map[string]bool{
+	"/api/theme":    true,
+	"/api/language": true,
+}
+
+// SetPublic serves the contract list and contract pages read-only to visitors who are not logged in,
+// e.g. to publish the tracked tenders for transparency. Everything that changes a contract, and the
+// pages that show more than the contracts, still need a user of SetAuth or an API token; without
+// users, the whole dashboard is read-only.
+func (d *Dashboard) SetPublic(public bool) {
+	d.public = public
+}
+
+// readOnly reports whether a request is from a visitor of a public dashboard, who may only read
+func (d *Dashboard) readOnly(r *http.Request) bool {
+	return d.public && authenticatedUser(r) == ""
+}
+
+// isPublicRead reports whether a visitor who is not logged in may make a request to a public dashboard
+func isPublicRead(r *http.Request) bool {
+	path := r.URL.Path
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// Documents are public on the portal already; the dashboard only keeps a copy
+		isDocument := strings.HasPrefix(path, "/api/contracts/") && strings.HasSuffix(path, "/download")
+		return publicReadPaths[path] || publicPaths[path] || strings.HasPrefix(path, "/static/") || isDocument
+	case http.MethodPost:
+		return publicPreferencePaths[path]
+	}
+	return false
+}
+
+// hideTeamData clears what the team added to a contract before it is shown to a visitor of a public
+// dashboard: its tags and whether it is watched
+func hideTeamData(contract *scraper.Contract) {
+	contract.Tags = nil
+	contract.Watched = false
+}
+
+// hideTeamFilters drops the tag and watchlist filters of a listing requested by a visitor, who may
+// not find the contracts by what they cannot see
+func hideTeamFilters(query *contractListQuery) {
+	query.filter.Tags = nil
+	if query.filter.Watched {
+		query.filter.Watched = false
+		query.filter.Archive = storage.ArchiveExclude
+	}
+}
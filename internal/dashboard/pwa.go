@@ -0,0 +1,18 @@
+package dashboard
+
+import "net/http"
+
+// publicPaths are what browsers fetch to install the dashboard as an app, which they may do without
+// the login cookie or password. They hold nothing of the contracts.
+var publicPaths = map[string]bool{
+	"/sw.js":                true,
+	"/static/manifest.json": true,
+	"/static/icon-192.png":  true,
+	"/static/icon-512.png":  true,
+}
+
+// handleServiceWorker serves the service worker that makes the dashboard installable and keeps the
+// pages and contracts seen last available offline. It lives at the root so it can control every page.
+func (d *Dashboard) handleServiceWorker(w http.ResponseWriter, r *http.Request) {
+	d.serveStatic(w, r, "sw.js")
+}
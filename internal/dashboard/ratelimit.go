@@ -0,0 +1,190 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Requests requests per Per to each client, in bursts of up to Requests. The zero
+// value does not limit.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Default rate limits, see SetRateLimits
+var (
+	DefaultAPIRateLimit         = RateLimit{Requests: 300, Per: time.Minute}
+	DefaultDestructiveRateLimit = RateLimit{Requests: 10, Per: time.Minute}
+)
+
+// ParseRateLimit parses a rate limit such as "300/m": a number of requests per second (s), minute
+// (m) or hour (h). "off" and "0" disable the limit.
+func ParseRateLimit(value string) (RateLimit, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" || value == "0" {
+		return RateLimit{}, nil
+	}
+
+	count, unit, ok := strings.Cut(value, "/")
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 300/m or off", value)
+	}
+
+	units := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	per, ok := units[strings.TrimSpace(unit)]
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, the unit must be s, m or h", value)
+	}
+	return RateLimit{Requests: requests, Per: per}, nil
+}
+
+// String formats the limit like ParseRateLimit reads it
+func (l RateLimit) String() string {
+	if l.Requests <= 0 {
+		return "off"
+	}
+	unit := map[time.Duration]string{time.Second: "s", time.Minute: "m", time.Hour: "h"}[l.Per]
+	if unit == "" {
+		return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, unit)
+}
+
+// SetRateLimits limits how often each client, an API token or else an IP address, may call the
+// API, and separately how often it may call the endpoints that delete contracts or start a scrape
+func (d *Dashboard) SetRateLimits(api, destructive RateLimit) {
+	d.apiLimiter = newRateLimiter(api)
+	d.destructiveLimiter = newRateLimiter(destructive)
+}
+
+// rateLimiter is a token bucket per client
+type rateLimiter struct {
+	limit       RateLimit
+	mu          sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// tokenBucket holds the requests a client can still make right away
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newRateLimiter returns a limiter for limit, nil if it does not limit
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return nil
+	}
+	return &rateLimiter{limit: limit, buckets: make(map[string]*tokenBucket), lastCleanup: time.Now()}
+}
+
+// allow takes a request from the bucket of client. When it is empty it returns false and how long
+// until the next request is allowed.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	burst := float64(l.limit.Requests)
+	perToken := l.limit.Per / time.Duration(l.limit.Requests)
+
+	// Forget the clients whose bucket has refilled, so the map does not grow with every address seen
+	if now.Sub(l.lastCleanup) > l.limit.Per {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.updated) > l.limit.Per {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, updated: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+float64(now.Sub(bucket.updated))/float64(perToken))
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// refund gives back a request taken by allow that was refused by another limit after all
+func (l *rateLimiter) refund(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bucket, ok := l.buckets[client]; ok {
+		bucket.tokens = math.Min(float64(l.limit.Requests), bucket.tokens+1)
+	}
+}
+
+// isDestructive reports whether a request deletes contracts, restores all of them, starts a scrape or
+// sends a test message through a channel
+func isDestructive(r *http.Request) bool {
+	if r.Method == http.MethodDelete {
+		return true
+	}
+	if r.Method != http.MethodPost {
+		return false
+	}
+	switch r.URL.Path {
+	case "/api/delete-all", "/api/delete-contract", "/api/delete-contracts", "/api/restore-all", "/api/scrape",
+		"/api/v1/contracts/delete", "/api/v1/deleted-contracts/restore", "/api/v1/scrape":
+		return true
+	}
+	return strings.Contains(r.URL.Path, "/channels/") && strings.HasSuffix(r.URL.Path, "/test")
+}
+
+// limitRequests answers 429 to the API requests of a client over its rate limit. Clients are told
+// apart by API token, or else by IP address. The destructive limit is checked first, so a refused
+// delete or scrape does not use up the API requests of the client, and a request refused by the API
+// limit gets its destructive request back.
+func (d *Dashboard) limitRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := d.clientAddress(r)
+		if user := authenticatedUser(r); strings.HasPrefix(user, "token:") {
+			client = user
+		}
+
+		var limiters []*rateLimiter
+		if isDestructive(r) {
+			limiters = append(limiters, d.destructiveLimiter)
+		}
+		limiters = append(limiters, d.apiLimiter)
+		for i, limiter := range limiters {
+			if limiter == nil {
+				continue
+			}
+			if ok, wait := limiter.allow(client); !ok {
+				for _, taken := range limiters[:i] {
+					if taken != nil {
+						taken.refund(client)
+					}
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeRequestError(w, r, fmt.Sprintf("Too many requests, limit is %s", limiter.limit), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
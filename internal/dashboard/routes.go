@@ -1,17 +1,77 @@
 package dashboard
 
-import "net/http"
-
-// registerRoutes registers all HTTP routes for the dashboard
+// registerRoutes registers all HTTP routes for the dashboard on its own mux. Patterns name the
+// method they answer, so other methods get 405 Method Not Allowed.
 func (d *Dashboard) registerRoutes() {
+	mux := d.mux
+
 	// Main pages
-	http.HandleFunc("/", d.handleHome)
-	http.HandleFunc("/history", d.handleHistory)
-	
-	// API endpoints
-	http.HandleFunc("/api/contracts", d.handleAPIContracts)
-	http.HandleFunc("/api/stats", d.handleAPIStats)
-	http.HandleFunc("/api/delete-all", d.handleDeleteAll)
-	http.HandleFunc("/api/delete-contract", d.handleDeleteContract)
-	http.HandleFunc("/api/status-changes", d.handleAPIStatusChanges)
-} 
\ No newline at end of file
+	mux.HandleFunc("GET /{$}", d.handleHome)
+	mux.HandleFunc("GET /history", d.handleHistory)
+	mux.HandleFunc("GET /analytics", d.handleAnalytics)
+	mux.HandleFunc("GET /calendar", d.handleDeadlineCalendar)
+	mux.HandleFunc("GET "+feedPath, d.handleFeed)
+	mux.HandleFunc("GET /contract", d.handleContractPage)
+	mux.HandleFunc("GET /runs", d.handleRunsPage)
+	mux.HandleFunc("GET /runs/screenshot", d.handleRunScreenshot)
+	mux.HandleFunc("GET /runs/snapshot", d.handleRunSnapshot)
+	mux.HandleFunc("GET /notifications", d.handleNotificationsPage)
+	mux.HandleFunc("GET /settings", d.handleSettingsPage)
+	mux.HandleFunc("GET /trash", d.handleTrashPage)
+	mux.HandleFunc("/login", d.handleLogin) // Form on GET, checked on POST
+	mux.HandleFunc("/logout", d.handleLogout)
+	mux.HandleFunc("GET /static/", d.handleStatic)
+	mux.HandleFunc("GET /sw.js", d.handleServiceWorker)
+
+	// API endpoints used by the dashboard page
+	mux.HandleFunc("GET /api/contracts", d.handleAPIContracts)
+	mux.HandleFunc("GET /api/search", d.handleAPISearch)
+	mux.HandleFunc("GET /api/stats", d.handleAPIStats)
+	mux.HandleFunc("POST /api/delete-all", d.handleDeleteAll)
+	mux.HandleFunc("POST /api/delete-contract", d.handleDeleteContract)
+	mux.HandleFunc("POST /api/delete-contracts", d.handleDeleteContracts)
+	mux.HandleFunc("POST /api/archive-contracts", d.handleArchiveContracts)
+	mux.HandleFunc("POST /api/tag-contracts", d.handleTagContracts)
+	mux.HandleFunc("POST /api/restore-contract", d.handleRestoreContract)
+	mux.HandleFunc("POST /api/restore-all", d.handleRestoreAll)
+	mux.HandleFunc("GET /api/deleted-contracts", d.handleAPIDeletedContracts)
+	mux.HandleFunc("POST /api/unarchive-contract", d.handleUnarchiveContract)
+	mux.HandleFunc("GET /api/status-changes", d.handleAPIStatusChanges)
+	mux.HandleFunc("POST /api/status-changes/{id}/ack", d.handleAckStatusChange)
+	mux.HandleFunc("GET /api/revisions", d.handleAPIRevisions)
+	mux.HandleFunc("GET /api/contracts/{id}/documents/{docID}/download", d.handleDownloadDocument)
+	mux.HandleFunc("GET /api/scrape-runs", d.handleAPIScrapeRuns)
+	mux.HandleFunc("POST /api/notifications/{id}/resend", d.handleResendNotification)
+	mux.HandleFunc("GET /api/scrape", d.handleScrapeStatus)
+	mux.HandleFunc("POST /api/scrape", d.handleStartScrape)
+	mux.HandleFunc("GET /api/audit-log", d.handleAPIAuditLog)
+	mux.HandleFunc("GET /api/profiles", d.handleAPIProfiles)
+	mux.HandleFunc("GET /api/tags", d.handleAPITags)
+	mux.HandleFunc("GET /api/statuses", d.handleAPIStatuses)
+	mux.HandleFunc("GET /api/cpv-codes", d.handleAPICPVCodes)
+	mux.HandleFunc("POST /api/add-tag", d.handleAddTag)
+	mux.HandleFunc("POST /api/remove-tag", d.handleRemoveTag)
+	mux.HandleFunc("POST /api/mark-seen", d.handleMarkSeen)
+	mux.HandleFunc("POST /api/watch-contract", d.handleWatchContract)
+	mux.HandleFunc("POST /api/unwatch-contract", d.handleUnwatchContract)
+	mux.HandleFunc("POST /api/theme", d.handleSetTheme)
+	mux.HandleFunc("POST /api/language", d.handleSetLanguage)
+	mux.HandleFunc("POST /api/settings", d.handleSaveSettings)
+	mux.HandleFunc("POST /api/channels", d.handleAddChannel)
+	mux.HandleFunc("POST /api/channels/{id}/delete", d.handleDeleteChannel)
+	mux.HandleFunc("POST /api/channels/{id}/test", d.handleTestChannel)
+	mux.HandleFunc("GET /api/notes", d.handleAPINotes)
+	mux.HandleFunc("POST /api/add-note", d.handleAddNote)
+	mux.HandleFunc("POST /api/update-note", d.handleUpdateNote)
+	mux.HandleFunc("POST /api/delete-note", d.handleDeleteNote)
+	mux.HandleFunc("GET /api/export", d.handleExport)
+	mux.HandleFunc("GET /api/export/contracts.csv.gz", d.handleExportContracts)
+	mux.HandleFunc("GET /api/export/status_changes.csv.gz", d.handleExportStatusChanges)
+	mux.HandleFunc("GET /api/report.xlsx", d.handleReport)
+	mux.HandleFunc("GET /api/calendar.ics", d.handleCalendar)
+	mux.HandleFunc("/api/", d.handleUnknownAPI)
+
+	// Versioned API for integrations, and its documentation
+	d.registerAPIRoutes()
+	mux.HandleFunc("GET /api/docs", d.handleAPIDocs)
+}
@@ -0,0 +1,140 @@
+package dashboard
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// runsShown is how many scrape runs the runs page lists by default, the newest ones
+const runsShown = 50
+
+// runSnapshotsShown bounds the HTML snapshots linked per run; the rest are only counted
+const runSnapshotsShown = 20
+
+// runView is a scrape run as the runs page shows it, with the files it left behind
+type runView struct {
+	storage.ScrapeRun
+	Duration      string
+	Screenshots   []string          // Paths relative to scraper.ScreenshotsRoot
+	Snapshots     []storage.RawPage // Contract detail pages stored during the run
+	MoreSnapshots int               // Snapshots stored but not listed
+}
+
+// handleRunsPage serves the scrape run history: the newest runs (?limit=, 50 by default) with their
+// duration, counts and errors, and the screenshots and page snapshots taken while they ran
+func (d *Dashboard) handleRunsPage(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r.URL.Query(), runsShown)
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	runs, err := d.store.GetScrapeRuns(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Files are not recorded per run, so they are matched to the run by when they were written
+	screenshots, err := scraper.AllScreenshots()
+	if err != nil {
+		log.Printf("Warning: Failed to list screenshots: %v", err)
+	}
+
+	views := make([]runView, len(runs))
+	for i, run := range runs {
+		end := time.Now()
+		if run.FinishedAt != nil {
+			end = *run.FinishedAt
+		}
+		views[i] = runView{ScrapeRun: run, Duration: run.Duration().Round(time.Second).String()}
+
+		for _, screenshot := range screenshots {
+			if !screenshot.TakenAt.Before(run.StartedAt) && !screenshot.TakenAt.After(end) {
+				views[i].Screenshots = append(views[i].Screenshots, screenshot.Path)
+			}
+		}
+
+		snapshots, err := d.store.GetRawPagesBetween(run.StartedAt, end)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(snapshots) > runSnapshotsShown {
+			views[i].MoreSnapshots = len(snapshots) - runSnapshotsShown
+			snapshots = snapshots[:runSnapshotsShown]
+		}
+		views[i].Snapshots = snapshots
+	}
+
+	d.renderPage(w, r, http.StatusOK, "runs.html", struct {
+		page
+		Runs []runView
+	}{
+		page: d.page(r),
+		Runs: views,
+	})
+}
+
+// handleRunScreenshot serves a screenshot of a scrape, ?path= being relative to scraper.ScreenshotsRoot
+func (d *Dashboard) handleRunScreenshot(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if !strings.HasSuffix(path, ".png") {
+		http.NotFound(w, r)
+		return
+	}
+
+	// The root keeps the path from leaving the screenshots directory
+	root, err := os.OpenRoot(scraper.ScreenshotsRoot)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer root.Close()
+	file, err := root.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeContent(w, r, path, info.ModTime(), file)
+}
+
+// handleRunSnapshot serves a stored contract detail page, ?id= being its raw page ID. The page is
+// sandboxed, so the portal's scripts do not run with the dashboard's origin.
+func (d *Dashboard) handleRunSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid page ID", http.StatusBadRequest)
+		return
+	}
+
+	page, err := d.store.GetRawPage(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if page == nil {
+		http.Error(w, "Page not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write([]byte(page.HTML))
+}
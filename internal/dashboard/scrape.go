@@ -0,0 +1,125 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"scraper/internal/storage"
+)
+
+// ScrapeRunner runs a scrape of a search profile (the default one when empty), calling progress
+// with the scraper.Step* constants as it goes, and returns the finished run
+type ScrapeRunner func(profile string, progress func(step string)) (*storage.ScrapeRun, error)
+
+// scrapeJobs runs the scrapes started from the dashboard, one at a time
+type scrapeJobs struct {
+	runner ScrapeRunner
+	mu     sync.Mutex
+	job    scrapeJob // The running or last finished scrape
+}
+
+// scrapeJob is the state of a scrape started from the dashboard, as polled by the page
+type scrapeJob struct {
+	Running    bool               `json:"running"`
+	Profile    string             `json:"profile,omitempty"`
+	StartedBy  string             `json:"started_by,omitempty"`
+	StartedAt  *time.Time         `json:"started_at,omitempty"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	Steps      []string           `json:"steps"` // Steps started so far, the last one is in progress
+	Run        *storage.ScrapeRun `json:"run,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// SetScrapeRunner lets dashboard users start a scrape with runner and follow its progress. Without
+// a runner the dashboard cannot scrape.
+func (d *Dashboard) SetScrapeRunner(runner ScrapeRunner) {
+	if runner == nil {
+		d.scrape = nil
+		return
+	}
+	d.scrape = &scrapeJobs{runner: runner}
+}
+
+// start starts a scrape in the background, unless one is running already
+func (s *scrapeJobs) start(profile, actor string) (scrapeJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.job.Running {
+		return s.job, false
+	}
+
+	now := time.Now()
+	s.job = scrapeJob{Running: true, Profile: profile, StartedBy: actor, StartedAt: &now, Steps: []string{}}
+	go s.run(profile)
+	return s.job, true
+}
+
+// run runs the scrape and records its outcome
+func (s *scrapeJobs) run(profile string) {
+	run, err := s.runner(profile, func(step string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.job.Steps = append(s.job.Steps, step)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.job.Running = false
+	s.job.FinishedAt = &now
+	s.job.Run = run
+	if err != nil {
+		log.Printf("Warning: Scrape started from the dashboard failed: %v", err)
+		s.job.Error = err.Error()
+	}
+}
+
+// status returns a copy of the running or last finished scrape
+func (s *scrapeJobs) status() scrapeJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.job
+	job.Steps = append([]string{}, s.job.Steps...)
+	return job
+}
+
+// handleScrapeStatus returns the progress of the running or last scrape started from the dashboard
+func (d *Dashboard) handleScrapeStatus(w http.ResponseWriter, r *http.Request) {
+	if d.scrape == nil {
+		http.Error(w, "Scraping from the dashboard is not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.scrape.status())
+}
+
+// handleStartScrape starts a scrape, of the optional profile parameter
+func (d *Dashboard) handleStartScrape(w http.ResponseWriter, r *http.Request) {
+	if d.scrape == nil {
+		http.Error(w, "Scraping from the dashboard is not enabled", http.StatusNotFound)
+		return
+	}
+
+	actor := requestActor(r)
+	job, started := d.scrape.start(strings.TrimSpace(r.FormValue("profile")), actor)
+	w.Header().Set("Content-Type", "application/json")
+	if !started {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "A scrape is already running",
+			"job":     job,
+		})
+		return
+	}
+	log.Printf("Scrape started from the dashboard by %s", actor)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"job":     job,
+	})
+}
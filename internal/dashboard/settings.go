@@ -0,0 +1,71 @@
+package dashboard
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// settingCookiePrefix names the cookies keeping the settings of a browser without a logged in user,
+// e.g. "dashboard_setting_theme", so that visitors write nothing to the database
+const settingCookiePrefix = "dashboard_setting_"
+
+// legacyBrowserCookie identified a browser whose settings older versions kept in the database. They
+// are still read, until the browser saves the setting again in its own cookie.
+const legacyBrowserCookie = "dashboard_browser"
+
+// browserCookieDuration is how long a browser keeps its settings
+const browserCookieDuration = 365 * 24 * time.Hour
+
+// userSetting returns the setting name of the user or browser of a request when it is one of
+// allowed, and otherwise "", e.g. for a value saved by an older version. The logged in user's choice
+// comes first, then the browser's, so a user who never changed a setting gets the choice made in
+// the browser before logging in.
+func (d *Dashboard) userSetting(r *http.Request, name string, allowed ...string) string {
+	var values []string
+	if user := authenticatedUser(r); user != "" {
+		values = append(values, d.storedSetting(user, name))
+	}
+	if cookie, err := r.Cookie(settingCookiePrefix + name); err == nil {
+		values = append(values, cookie.Value)
+	}
+	if cookie, err := r.Cookie(legacyBrowserCookie); err == nil && cookie.Value != "" {
+		values = append(values, d.storedSetting("browser:"+cookie.Value, name))
+	}
+
+	for _, value := range values {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// storedSetting returns a setting saved in the database, "" if there is none or it cannot be read
+func (d *Dashboard) storedSetting(owner, name string) string {
+	value, err := d.store.GetUserSetting(owner, name)
+	if err != nil {
+		log.Printf("Warning: Failed to get the %s of %s: %v", name, owner, err)
+	}
+	return value
+}
+
+// saveUserSetting saves a setting for the logged in user, or else in a cookie of the browser
+func (d *Dashboard) saveUserSetting(w http.ResponseWriter, r *http.Request, name, value string) error {
+	if user := authenticatedUser(r); user != "" {
+		return d.store.SetUserSetting(user, name, value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     settingCookiePrefix + name,
+		Value:    value,
+		Path:     d.url("/"),
+		MaxAge:   int(browserCookieDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
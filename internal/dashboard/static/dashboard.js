@@ -0,0 +1,1017 @@
+// Contracts page script, loaded by templates/dashboard.html, which sets authenticatedUser, canScrape,
+// readOnly, basePath, the prefix of every dashboard URL, and messages, the translations of the text below
+
+let contracts = [];
+// The list is filtered, sorted and paged by the server, pageSize contracts at a time
+const pageSize = 50;
+let page = 0;
+let totalContracts = 0;
+let searchTimer = null;
+// selectedStatuses are the statuses the list is narrowed to with the chips, none for every status
+let selectedStatuses = [];
+let statusCounts = [];
+// sortDefaults is the order a column is sorted in when first clicked: the most urgent or biggest first
+const sortDefaults = { deadline: 'asc', amount: 'desc', scraped_at: 'desc', status: 'asc' };
+let sortField = localStorage.getItem('contractSort') || 'scraped_at';
+let sortOrder = localStorage.getItem('contractSortOrder') || sortDefaults[sortField] || 'desc';
+let showArchived = false;
+let showWatching = false;
+let watchedCount = 0;
+// focusedContract is the contract opened from a notification link (/?contract=...), shown alone
+let focusedContract = new URLSearchParams(window.location.search).get('contract');
+// scrapeSteps are the steps of a scrape, in order, as reported by /api/scrape
+const scrapeSteps = [
+    ['navigate', 'Opening the search form'],
+    ['search', 'Searching for the CPV code'],
+    ['extract', 'Reading the contracts from the results'],
+    ['enhance', 'Fetching the document links'],
+];
+let scrapeTimer = null;
+// selectedContracts are the refs (see contractRef) of the listed contracts ticked for a bulk action
+let selectedContracts = new Set();
+// undoContracts are the refs of the contracts just deleted, restored by the Undo button of the toast
+let undoContracts = [];
+let undoTimer = null;
+
+// t translates English text to the language of the page, filling the %s and %d in it with args in
+// order, like the t of the templates
+function t(text, ...args) {
+    let next = 0;
+    return (messages[text] || text).replace(/%[sd]/g, () => args[next++]);
+}
+
+// listParams returns the filters of the list, except the status, shared by the list and the status counts
+function listParams() {
+    const params = new URLSearchParams();
+    if (showArchived) params.set('archived', '1');
+    if (showWatching) params.set('watching', '1');
+    const tag = readOnly ? '' : document.getElementById('tagFilter').value;
+    if (tag) params.set('tag', tag);
+    const search = document.getElementById('searchInput').value.trim();
+    if (search) params.set('search', search);
+    return params;
+}
+
+// loadContracts lists a page of contracts; while searching, /api/search lists the best matches first
+// with the matching words highlighted
+function loadContracts() {
+    const params = listParams();
+    if (focusedContract) params.set('id', focusedContract);
+    if (selectedStatuses.length > 0) params.set('status', selectedStatuses.join(','));
+    params.set('limit', pageSize);
+    params.set('offset', page * pageSize);
+    let endpoint = '/api/contracts?';
+    if (params.has('search') && !focusedContract) {
+        params.set('q', params.get('search'));
+        params.delete('search');
+        endpoint = '/api/search?';
+    } else {
+        params.set('sort', sortField);
+        params.set('order', sortOrder);
+    }
+    fetch(basePath + endpoint + params.toString())
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(response.status === 404 ? t('contract not found') : response.statusText);
+            }
+            totalContracts = parseInt(response.headers.get('X-Total-Count') || '0', 10);
+            return response.json();
+        })
+        .then(data => {
+            contracts = data;
+            displayContracts(contracts);
+            updatePager();
+            if (focusedContract && contracts.length === 1) {
+                document.getElementById('focusBanner').style.display = 'block';
+                if (!readOnly) {
+                    toggleNotes(contractRef(contracts[0]));
+                }
+            }
+            markSeen(contracts);
+            loadStats();
+            loadStatusChanges();
+            loadTags();
+            loadStatuses();
+        })
+        .catch(error => {
+            document.getElementById('contractsContainer').innerHTML =
+                '<div class="error">' + t('Error loading contracts: %s', error.message) + '</div>';
+        });
+}
+
+// exportContracts downloads every contract matching the current filters, in the current order
+function exportContracts(format) {
+    const params = listParams();
+    if (selectedStatuses.length > 0) params.set('status', selectedStatuses.join(','));
+    params.set('sort', sortField);
+    params.set('order', sortOrder);
+    params.set('format', format);
+    window.location.href = basePath + '/api/export?' + params.toString();
+}
+
+// reloadContracts lists the first page again, after the filters or the sort changed
+function reloadContracts() {
+    page = 0;
+    loadContracts();
+}
+
+// sortBy sorts the list by a column, or reverses the order if it is sorted by it already
+function sortBy(field) {
+    if (field === sortField) {
+        sortOrder = sortOrder === 'asc' ? 'desc' : 'asc';
+    } else {
+        sortField = field;
+        sortOrder = sortDefaults[field];
+    }
+    localStorage.setItem('contractSort', sortField);
+    localStorage.setItem('contractSortOrder', sortOrder);
+    updateSortBar();
+    reloadContracts();
+}
+
+function updateSortBar() {
+    document.querySelectorAll('.sort-btn').forEach(button => {
+        const active = button.dataset.sort === sortField;
+        button.classList.toggle('active', active);
+        button.textContent = button.textContent.replace(/ [▲▼]$/, '') + (active ? (sortOrder === 'asc' ? ' ▲' : ' ▼') : '');
+    });
+}
+
+function changePage(delta) {
+    page = Math.max(0, page + delta);
+    loadContracts();
+    window.scrollTo(0, 0);
+}
+
+function updatePager() {
+    const pager = document.getElementById('pager');
+    if (focusedContract || totalContracts <= pageSize) {
+        pager.style.display = 'none';
+        return;
+    }
+    pager.style.display = 'flex';
+    const first = page * pageSize + 1;
+    const last = Math.min((page + 1) * pageSize, totalContracts);
+    document.getElementById('pageInfo').textContent = t('%d–%d of %d', first, last, totalContracts);
+    document.getElementById('prevPage').disabled = page === 0;
+    document.getElementById('nextPage').disabled = last >= totalContracts;
+}
+
+// euros formats the estimated value card, rounded to whole euros like the analytics page
+const euros = new Intl.NumberFormat('es-ES', {style: 'currency', currency: 'EUR', maximumFractionDigits: 0});
+
+function loadStats() {
+    fetch(basePath + '/api/stats')
+        .then(response => response.json())
+        .then(data => {
+            document.getElementById('totalContracts').textContent = data.total;
+            document.getElementById('newContracts').textContent = data.newToday;
+            document.getElementById('closingSoon').textContent = data.closingSoon;
+            document.getElementById('totalValue').textContent = euros.format(data.totalValue || 0);
+            // The most common statuses, one a line
+            document.getElementById('statusBreakdown').innerHTML = (data.byStatus || []).slice(0, 4).map(group =>
+                '<div><span class="contract-status status-' + getStatusClass(group.key) + '">' + escapeHtml(group.key || t('No status')) + '</span> ' + group.count + '</div>'
+            ).join('') || '-';
+            watchedCount = data.watched || 0;
+            updateWatchingToggle();
+            if (data.lastRun && !readOnly) {
+                document.getElementById('lastRunStatus').textContent = data.lastRun.status;
+                document.getElementById('lastRunLabel').textContent = t('Last Run · %s · %d new, %d errors',
+                    new Date(data.lastRun.started_at).toLocaleString(), data.lastRun.contracts_new, (data.lastRun.errors || []).length);
+            }
+        })
+        .catch(error => console.error('Error loading stats:', error));
+}
+
+function loadTags() {
+    if (readOnly) {
+        return;
+    }
+    fetch(basePath + '/api/tags')
+        .then(response => response.json())
+        .then(tags => {
+            const select = document.getElementById('tagFilter');
+            const selected = select.value;
+            select.innerHTML = '<option value="">' + t('All tags') + '</option>' + (tags || []).map(entry =>
+                '<option value="' + escapeHtml(entry.tag) + '"' + (entry.tag === selected ? ' selected' : '') + '>' + escapeHtml(entry.tag) + ' (' + entry.count + ')</option>'
+            ).join('');
+            // Suggest the tags in use when tagging a contract, so everyone spells them the same
+            document.getElementById('tagSuggestions').innerHTML = (tags || []).map(entry =>
+                '<option value="' + escapeHtml(entry.tag) + '">'
+            ).join('');
+        })
+        .catch(error => console.error('Error loading tags:', error));
+}
+
+function loadStatuses() {
+    fetch(basePath + '/api/statuses?' + listParams().toString())
+        .then(response => response.json())
+        .then(data => {
+            statusCounts = data || [];
+            displayStatusChips();
+        })
+        .catch(error => console.error('Error loading statuses:', error));
+}
+
+function displayStatusChips() {
+    const total = statusCounts.reduce((sum, s) => sum + s.count, 0);
+    document.getElementById('statusChips').innerHTML =
+        '<span class="status-chip' + (selectedStatuses.length === 0 ? ' active' : '') + '" onclick="clearStatuses()">' + t('All (%d)', total) + '</span>' +
+        statusCounts.map((s, i) =>
+            '<span class="status-chip' + (selectedStatuses.includes(s.status) ? ' active' : '') + '" onclick="toggleStatus(' + i + ')">' +
+                (s.status || t('No status')) + ' (' + s.count + ')</span>'
+        ).join('');
+}
+
+// toggleStatus adds a status to the ones listed, or removes it
+function toggleStatus(index) {
+    const status = statusCounts[index].status;
+    if (selectedStatuses.includes(status)) {
+        selectedStatuses = selectedStatuses.filter(s => s !== status);
+    } else {
+        selectedStatuses.push(status);
+    }
+    displayStatusChips();
+    reloadContracts();
+}
+
+function clearStatuses() {
+    selectedStatuses = [];
+    displayStatusChips();
+    reloadContracts();
+}
+
+function loadStatusChanges() {
+    fetch(basePath + '/api/status-changes')
+        .then(response => response.json())
+        .then(data => {
+            displayStatusChanges(data);
+        })
+        .catch(error => console.error('Error loading status changes:', error));
+}
+
+function displayStatusChanges(statusChanges) {
+    const container = document.getElementById('statusChangesContainer');
+    const list = document.getElementById('statusChangesList');
+
+    if (statusChanges.length === 0) {
+        container.style.display = 'none';
+        return;
+    }
+
+    container.style.display = 'block';
+
+    // Changes dismissed before acknowledgements were stored on the server are acknowledged there once
+    const dismissedChanges = JSON.parse(localStorage.getItem('dismissedStatusChanges') || '[]');
+    if (!readOnly) {
+        statusChanges.filter(change => dismissedChanges.includes(change.id)).forEach(change => acknowledgeChange(change.id));
+    }
+    localStorage.removeItem('dismissedStatusChanges');
+
+    const visibleChanges = statusChanges.filter(change => !dismissedChanges.includes(change.id));
+
+    if (visibleChanges.length === 0) {
+        container.style.display = 'none';
+        return;
+    }
+
+    list.innerHTML = visibleChanges.map((change, index) => {
+        return '<div class="status-change-item" data-change-id="' + change.id + '">' +
+            '<div class="status-change-info">' +
+                '<div class="status-change-contract">' + change.contract_id + '</div>' +
+                '<div class="status-change-details">' +
+                    '<span>' + change.old_status + '</span>' +
+                    '<span class="status-change-arrow">→</span>' +
+                    '<span>' + change.new_status + '</span>' +
+                '</div>' +
+            '</div>' +
+            '<div class="status-change-time">' + new Date(change.changed_at).toLocaleString() + '</div>' +
+            (readOnly ? '' : '<button class="status-change-checkmark" onclick="dismissChange(' + change.id + ')">✓</button>') +
+        '</div>';
+    }).join('');
+}
+
+function dismissChange(changeId) {
+    const item = document.querySelector('[data-change-id="' + changeId + '"]');
+    if (item) {
+        // Add vanishing animation
+        item.classList.add('vanishing');
+
+        // Acknowledge it on the server so it stays dismissed on every browser
+        acknowledgeChange(changeId);
+
+        // Remove the element after animation completes
+        setTimeout(() => {
+            item.remove();
+
+            // Check if there are any remaining status changes
+            const remainingItems = document.querySelectorAll('.status-change-item');
+            if (remainingItems.length === 0) {
+                document.getElementById('statusChangesContainer').style.display = 'none';
+            }
+        }, 500);
+    }
+}
+
+// acknowledgeChange records who dismissed a change without asking for a name, as it also runs on page load
+function acknowledgeChange(changeId) {
+    const actor = authenticatedUser || localStorage.getItem('noteAuthor') || '';
+    fetch(basePath + '/api/status-changes/' + changeId + '/ack', { method: 'POST', headers: { 'X-Actor': encodeURIComponent(actor) } })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                console.error('Error acknowledging status change:', data.error);
+            }
+        })
+        .catch(error => console.error('Error acknowledging status change:', error));
+}
+
+function getStatusClass(status) {
+    const statusMap = {
+        'publicada': 'publicada',
+        'adjudicada': 'adjudicada',
+        'anulada': 'anulada',
+        'evaluación previa': 'evaluación-previa',
+        'evaluacion previa': 'evaluación-previa',
+        'resuelta': 'resuelta'
+    };
+    return statusMap[status.toLowerCase()] || status.toLowerCase().replace(/\s+/g, '-');
+}
+
+function displayContracts(contractsToShow) {
+    const container = document.getElementById('contractsContainer');
+
+    if (contractsToShow.length === 0) {
+        selectedContracts.clear();
+        updateBulkBar();
+        container.innerHTML = showWatching && !focusedContract
+            ? '<div class="loading">' + t('No watched contracts. Click ☆ on a contract to be alerted about its status changes and deadlines.') + '</div>'
+            : '<div class="loading">' + t('No contracts found') + '</div>';
+        return;
+    }
+
+    // Only listed contracts stay selected, so a bulk action never touches what is not shown
+    const listed = new Set(contractsToShow.map(contractRef));
+    selectedContracts = new Set([...selectedContracts].filter(ref => listed.has(ref)));
+
+    container.innerHTML = contractsToShow.map(contract =>
+    '<div class="contract' + (contract.seen_at ? '' : ' unseen') + (selectedContracts.has(contractRef(contract)) ? ' selected' : '') + '">' +
+        '<div class="contract-header">' +
+            '<div class="contract-id">' + (readOnly ? '' : '<input type="checkbox" class="select-contract" data-ref="' + escapeHtml(contractRef(contract)) + '" onchange="selectContract(this)"' + (selectedContracts.has(contractRef(contract)) ? ' checked' : '') + ' title="' + t('Select for a bulk action') + '">') + '<a href="' + basePath + '/contract?id=' + encodeURIComponent(contractRef(contract)) + '" title="' + t('Details and changes of the contract') + '">' + contract.id + '</a>' + (contract.seen_at ? '' : '<span class="unseen-badge">' + t('NEW') + '</span>') + '</div>' +
+            '<div class="contract-actions">' +
+                '<div class="contract-status status-' + getStatusClass(contract.status) + '">' + contract.status + '</div>' +
+                (readOnly ? '' :
+                '<button class="delete-contract-btn watch-btn" onclick="toggleWatch(\'' + contractRef(contract) + '\', ' + contract.watched + ')" title="' + (contract.watched ? t('Stop watching') : t('Watch: always notify about status changes and deadlines')) + '">' + (contract.watched ? '★' : '☆') + '</button>' +
+                (contract.archived_at ? '<button class="delete-contract-btn" onclick="unarchiveContract(\'' + contractRef(contract) + '\')" title="' + t('Move back to active contracts') + '">↩</button>' : '') +
+                '<button class="delete-contract-btn" onclick="deleteContract(\'' + contractRef(contract) + '\', \'' + contract.id + '\')" title="' + t('Delete contract') + '">×</button>') +
+            '</div>' +
+        '</div>' +
+        '<div class="contract-body">' +
+            '<div class="contract-description">' + (contract.snippet_field === 'description' ? contract.snippet : contract.description) + '</div>' +
+            (contract.snippet && contract.snippet_field !== 'description' ? '<div class="search-snippet">' + contract.snippet + '</div>' : '') +
+            '<div class="contract-details">' +
+                '<div class="detail-item">' +
+                    '<div class="detail-label">' + t('Type') + '</div>' +
+                    '<div>' + contract.contract_type + '</div>' +
+                '</div>' +
+                '<div class="detail-item">' +
+                    '<div class="detail-label">' + t('Amount') + '</div>' +
+                    '<div class="amount">' + contract.amount + '</div>' +
+                '</div>' +
+                '<div class="detail-item">' +
+                    '<div class="detail-label">' + t('Submission Date') + '</div>' +
+                    '<div>' + contract.submission_date + '</div>' +
+                '</div>' +
+                '<div class="detail-item">' +
+                    '<div class="detail-label">' + t('Contracting Body') + '</div>' +
+                    '<div>' + contract.contracting_body + '</div>' +
+                '</div>' +
+                '<div class="detail-item">' +
+                    '<div class="detail-label">' + t('Scraped At') + '</div>' +
+                    '<div>' + new Date(contract.scraped_at).toLocaleString() + '</div>' +
+                '</div>' +
+                '<div class="detail-item">' +
+                    '<div class="detail-label">' + t('Documents') + '</div>' +
+                    '<div class="document-buttons">' +
+                        (contract.pliego_link ? '<a href="' + documentURL(contract, 'pliego') + '" class="document-link pliego" title="' + t('Download through the dashboard') + '">Pliego</a>' : '') +
+                        (contract.anuncio_link ? '<a href="' + documentURL(contract, 'anuncio') + '" class="document-link anuncio" title="' + t('Download through the dashboard') + '">Anuncio</a>' : '') +
+                        (!contract.pliego_link && !contract.anuncio_link ? '<span class="no-docs">' + t('Not available') + '</span>' : '') +
+                    '</div>' +
+                '</div>' +
+            '</div>' +
+            '<div class="contract-tags">' +
+                (contract.tags || []).map(tag => readOnly
+                    ? '<span class="tag">' + escapeHtml(tag) + '</span>'
+                    : '<span class="tag" data-tag="' + escapeHtml(tag) + '" onclick="removeTag(\'' + contractRef(contract) + '\', this.dataset.tag)" title="' + t('Remove tag') + '">' + escapeHtml(tag) + ' ×</span>'
+                ).join('') +
+                (readOnly ? '' : '<span class="tag add-tag" onclick="addTag(this, \'' + contractRef(contract) + '\')" title="' + t('Add a tag such as to bid, won or ignore') + '">' + t('+ tag') + '</span>') +
+            '</div>' +
+            // Notes are internal, so visitors of a public dashboard do not see them
+            (readOnly ? '' :
+            '<span class="notes-toggle" onclick="toggleNotes(\'' + contractRef(contract) + '\')">' + t('📝 Notes') + '</span>' +
+            '<div class="notes" id="notes-' + contractRef(contract) + '" style="display: none;"></div>') +
+        '</div>' +
+    '</div>'
+).join('');
+}
+
+function refreshData() {
+    loadContracts();
+}
+
+// contractRef is how API calls refer to a contract: its stable uid, which survives changes to the displayed id
+function contractRef(contract) {
+    return contract.uid || contract.id;
+}
+
+// Documents are downloaded through the dashboard, which keeps a copy, as portal links expire
+function documentURL(contract, type) {
+    return basePath + '/api/contracts/' + encodeURIComponent(contractRef(contract)) + '/documents/' + type + '/download';
+}
+
+function deleteContract(contractId, label) {
+    if (confirm(t('Are you sure you want to delete contract "%s"? It can be restored later with "Restore Deleted".', label))) {
+        fetch(basePath + '/api/delete-contract', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'X-Actor': encodeURIComponent(userName()),
+            },
+            body: JSON.stringify({ id: contractId })
+        })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                loadContracts();
+                showUndo([contractId], t('Contract "%s" deleted', label));
+            } else {
+                alert(t('Error deleting contract: %s', data.error));
+            }
+        })
+        .catch(error => {
+            alert(t('Error deleting contract: %s', error.message));
+        });
+    }
+}
+
+// undoSeconds is how long the Undo button stays after a delete; the trash page restores them later
+const undoSeconds = 10;
+
+// showUndo offers to restore the contracts just deleted for a few seconds
+function showUndo(refs, message) {
+    undoContracts = refs;
+    document.getElementById('undoMessage').textContent = message;
+    document.getElementById('undoToast').style.display = 'flex';
+    clearTimeout(undoTimer);
+    undoTimer = setTimeout(hideUndo, undoSeconds * 1000);
+}
+
+function hideUndo() {
+    clearTimeout(undoTimer);
+    undoContracts = [];
+    document.getElementById('undoToast').style.display = 'none';
+}
+
+// undoDelete restores the contracts of the last delete
+function undoDelete() {
+    const refs = undoContracts;
+    hideUndo();
+    Promise.all(refs.map(ref =>
+        fetch(basePath + '/api/restore-contract', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'X-Actor': encodeURIComponent(userName()),
+            },
+            body: JSON.stringify({ id: ref })
+        })
+        .then(response => response.text())
+        .then(text => {
+            let data;
+            try {
+                data = JSON.parse(text);
+            } catch (error) {
+                data = { success: false, error: text.trim() };
+            }
+            if (!data.success) {
+                throw new Error(data.error);
+            }
+        })
+    ))
+    .catch(error => {
+        alert(t('Error restoring contracts: %s', error.message));
+    })
+    .finally(loadContracts);
+}
+
+function deleteAll() {
+    if (confirm(t('Are you sure you want to delete all contracts? They can be restored later with "Restore Deleted".'))) {
+        fetch(basePath + '/api/delete-all', { method: 'POST', headers: { 'X-Actor': encodeURIComponent(userName()) } })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    loadContracts();
+                } else {
+                    alert(t('Error deleting contracts: %s', data.error));
+                }
+            })
+            .catch(error => {
+                alert(t('Error deleting contracts: %s', error.message));
+            });
+    }
+}
+
+function selectContract(checkbox) {
+    if (checkbox.checked) {
+        selectedContracts.add(checkbox.dataset.ref);
+    } else {
+        selectedContracts.delete(checkbox.dataset.ref);
+    }
+    checkbox.closest('.contract').classList.toggle('selected', checkbox.checked);
+    updateBulkBar();
+}
+
+// selectAllContracts ticks or clears every contract listed on this page
+function selectAllContracts(selected) {
+    document.querySelectorAll('#contractsContainer .select-contract').forEach(checkbox => {
+        checkbox.checked = selected;
+        selectContract(checkbox);
+    });
+    if (!selected) {
+        selectedContracts.clear();
+        updateBulkBar();
+    }
+}
+
+function updateBulkBar() {
+    if (readOnly) {
+        return;
+    }
+    const listed = document.querySelectorAll('#contractsContainer .select-contract').length;
+    const selectAll = document.getElementById('selectAll');
+    selectAll.checked = listed > 0 && selectedContracts.size === listed;
+    selectAll.indeterminate = selectedContracts.size > 0 && selectedContracts.size < listed;
+    document.getElementById('bulkCount').textContent = selectedContracts.size > 0 ? t('%d selected', selectedContracts.size) : '';
+    document.getElementById('bulkActions').style.display = selectedContracts.size > 0 ? 'flex' : 'none';
+    // Archived contracts cannot be archived again
+    document.getElementById('bulkArchiveButton').style.display = showArchived ? 'none' : '';
+}
+
+function bulkDelete() {
+    if (confirm(t('Are you sure you want to delete the %d selected contracts? They can be restored later with "Restore Deleted".', selectedContracts.size))) {
+        const deleted = [...selectedContracts];
+        bulkAction('/api/delete-contracts', {}, 'Error deleting contracts: %s', userName(), data => {
+            showUndo(deleted, t('%d contracts deleted', data.deleted));
+        });
+    }
+}
+
+function bulkArchive() {
+    bulkAction('/api/archive-contracts', {}, 'Error archiving contracts: %s');
+}
+
+function bulkTag() {
+    const tag = document.getElementById('bulkTag').value.trim();
+    if (!tag) {
+        document.getElementById('bulkTag').focus();
+        return;
+    }
+    bulkAction('/api/tag-contracts', { tag: tag }, 'Error updating tags: %s');
+}
+
+function bulkMarkSeen() {
+    bulkAction('/api/mark-seen', {}, 'Error marking contracts as seen: %s');
+}
+
+// bulkAction applies an action to the selected contracts, clearing the selection once it is done.
+// errorMessage is the English text of the error alert, actor the user named in the audit log, if any,
+// and done is called with the response once it succeeded.
+function bulkAction(url, body, errorMessage, actor, done) {
+    body.ids = [...selectedContracts];
+    const headers = { 'Content-Type': 'application/json' };
+    if (actor !== undefined) {
+        headers['X-Actor'] = encodeURIComponent(actor);
+    }
+    fetch(basePath + url, {
+        method: 'POST',
+        headers: headers,
+        body: JSON.stringify(body)
+    })
+    .then(response => response.text())
+    .then(text => {
+        let data;
+        try {
+            data = JSON.parse(text);
+        } catch (error) {
+            data = { success: false, error: text.trim() };
+        }
+        if (data.success) {
+            selectedContracts.clear();
+            document.getElementById('bulkTag').value = '';
+            loadContracts();
+            if (done) {
+                done(data);
+            }
+        } else {
+            alert(t(errorMessage, data.error));
+        }
+    })
+    .catch(error => {
+        alert(t(errorMessage, error.message));
+    });
+}
+
+function toggleArchived() {
+    showArchived = !showArchived;
+    selectedContracts.clear();
+    document.getElementById('archiveToggle').textContent = showArchived ? t('Show Active') : t('Show Archived');
+    reloadContracts();
+}
+
+// Contracts count as seen once they were listed; the NEW badge stays until the next reload
+function markSeen(listed) {
+    const unseen = listed.filter(contract => !contract.seen_at).map(contractRef);
+    document.getElementById('unseenContracts').textContent = unseen.length;
+    if (unseen.length === 0 || readOnly) {
+        return;
+    }
+    fetch(basePath + '/api/mark-seen', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ ids: unseen })
+    })
+    .catch(error => console.error('Error marking contracts as seen:', error));
+}
+
+function toggleWatching() {
+    showWatching = !showWatching;
+    updateWatchingToggle();
+    reloadContracts();
+}
+
+// The theme is saved on the server, for the logged in user or else for this browser
+function toggleTheme() {
+    const theme = document.documentElement.dataset.theme === 'light' ? 'dark' : 'light';
+    document.documentElement.dataset.theme = theme;
+    document.getElementById('themeToggle').textContent = theme === 'light' ? t('Dark Theme') : t('Light Theme');
+    fetch(basePath + '/api/theme', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ theme: theme })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            console.error('Error saving theme:', data.error);
+        }
+    })
+    .catch(error => console.error('Error saving theme:', error));
+}
+
+// changeLanguage saves the language on the server, like the theme, and shows the page in it
+function changeLanguage(language) {
+    fetch(basePath + '/api/language', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ language: language })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            window.location.reload();
+        } else {
+            alert(data.error);
+        }
+    })
+    .catch(error => alert(error.message));
+}
+
+function updateWatchingToggle() {
+    if (readOnly) {
+        return;
+    }
+    document.getElementById('watchingToggle').textContent = showWatching ? t('All Contracts') : t('Watching (%d)', watchedCount);
+}
+
+function toggleWatch(contractId, watched) {
+    fetch(basePath + (watched ? '/api/unwatch-contract' : '/api/watch-contract'), {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ id: contractId })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadContracts();
+            loadStats();
+        } else {
+            alert(t('Error updating watchlist: %s', data.error));
+        }
+    })
+    .catch(error => {
+        alert(t('Error updating watchlist: %s', error.message));
+    });
+}
+
+function unarchiveContract(contractId) {
+    fetch(basePath + '/api/unarchive-contract', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ id: contractId })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadContracts();
+        } else {
+            alert(t('Error unarchiving contract: %s', data.error));
+        }
+    })
+    .catch(error => {
+        alert(t('Error unarchiving contract: %s', error.message));
+    });
+}
+
+// addTag turns the "+ tag" chip into an input suggesting the tags in use; Enter adds the tag, Escape cancels
+function addTag(chip, contractId) {
+    const input = document.createElement('input');
+    input.className = 'tag-input';
+    input.setAttribute('list', 'tagSuggestions');
+    input.placeholder = t('to bid, won, ignore...');
+    chip.replaceWith(input);
+    input.focus();
+
+    input.addEventListener('keydown', event => {
+        if (event.key === 'Enter' && input.value.trim()) {
+            changeTag('/api/add-tag', contractId, input.value.trim());
+        } else if (event.key === 'Escape') {
+            input.replaceWith(chip);
+        }
+    });
+    input.addEventListener('blur', () => {
+        if (input.isConnected && !input.value.trim()) {
+            input.replaceWith(chip);
+        }
+    });
+}
+
+function removeTag(contractId, tag) {
+    changeTag('/api/remove-tag', contractId, tag);
+}
+
+function changeTag(url, contractId, tag) {
+    fetch(basePath + url, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ id: contractId, tag: tag })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadContracts();
+        } else {
+            alert(t('Error updating tags: %s', data.error));
+        }
+    })
+    .catch(error => {
+        alert(t('Error updating tags: %s', error.message));
+    });
+}
+
+// escapeHtml escapes text for HTML, quotes included so it is safe in attribute values too
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML.replace(/"/g, '&quot;').replace(/'/g, '&#39;');
+}
+
+function toggleNotes(contractId) {
+    const container = document.getElementById('notes-' + contractId);
+    if (container.style.display === 'none') {
+        container.style.display = 'block';
+        loadNotes(contractId);
+    } else {
+        container.style.display = 'none';
+    }
+}
+
+function loadNotes(contractId) {
+    fetch(basePath + '/api/notes?id=' + encodeURIComponent(contractId))
+        .then(response => response.json())
+        .then(notes => {
+            const container = document.getElementById('notes-' + contractId);
+            container.innerHTML = (notes || []).map(note =>
+                '<div class="note">' +
+                    '<div class="note-meta">' + escapeHtml(note.author || t('Anonymous')) + ' · ' +
+                        new Date(note.created_at).toLocaleString() + (note.updated_at ? ' ' + t('(edited)') : '') +
+                        '<a onclick="editNote(\'' + contractId + '\', ' + note.id + ')">' + t('Edit') + '</a>' +
+                        '<a onclick="deleteNote(\'' + contractId + '\', ' + note.id + ')">' + t('Delete') + '</a>' +
+                    '</div>' +
+                    '<div class="note-body" id="note-body-' + note.id + '">' + escapeHtml(note.body) + '</div>' +
+                '</div>'
+            ).join('') +
+            '<div class="note-form">' +
+                '<textarea class="search note-editor" id="note-input-' + contractId + '" placeholder="' + t('Add a note... (Ctrl+Enter to save)') + '"' +
+                    ' onkeydown="if (event.key === \'Enter\' && (event.ctrlKey || event.metaKey)) addNote(\'' + contractId + '\')"></textarea>' +
+                '<button class="btn btn-primary" onclick="addNote(\'' + contractId + '\')">' + t('Add') + '</button>' +
+            '</div>';
+        })
+        .catch(error => console.error('Error loading notes:', error));
+}
+
+function addNote(contractId) {
+    const body = document.getElementById('note-input-' + contractId).value;
+    if (!body.trim()) {
+        return;
+    }
+    postNoteChange('/api/add-note', { id: contractId, author: userName(), body: body }, contractId);
+}
+
+// userName asks once for the name shown next to notes and recorded in the audit log
+function userName() {
+    if (authenticatedUser) {
+        return authenticatedUser;
+    }
+    let name = localStorage.getItem('noteAuthor');
+    if (name === null) {
+        name = prompt(t('Your name (shown next to your notes and in the audit log):')) || '';
+        localStorage.setItem('noteAuthor', name);
+    }
+    return name;
+}
+
+// editNote replaces the text of a note with an editor; Save stores it, Cancel reloads the notes
+function editNote(contractId, noteId) {
+    const body = document.getElementById('note-body-' + noteId);
+    if (document.getElementById('note-editor-' + noteId)) {
+        return;
+    }
+    const text = body.textContent;
+    body.innerHTML =
+        '<textarea class="search note-editor" id="note-editor-' + noteId + '"></textarea>' +
+        '<div class="note-form">' +
+            '<button class="btn btn-primary" onclick="saveNote(\'' + contractId + '\', ' + noteId + ')">' + t('Save') + '</button>' +
+            '<button class="btn btn-primary" onclick="loadNotes(\'' + contractId + '\')">' + t('Cancel') + '</button>' +
+        '</div>';
+    const editor = document.getElementById('note-editor-' + noteId);
+    editor.value = text;
+    editor.focus();
+}
+
+function saveNote(contractId, noteId) {
+    const body = document.getElementById('note-editor-' + noteId).value;
+    if (!body.trim()) {
+        return;
+    }
+    postNoteChange('/api/update-note', { note_id: noteId, body: body }, contractId);
+}
+
+function deleteNote(contractId, noteId) {
+    if (confirm(t('Delete this note?'))) {
+        postNoteChange('/api/delete-note', { note_id: noteId }, contractId);
+    }
+}
+
+function postNoteChange(url, payload, contractId) {
+    fetch(basePath + url, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(payload)
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadNotes(contractId);
+        } else {
+            alert(t('Error saving note: %s', data.error));
+        }
+    })
+    .catch(error => {
+        alert(t('Error saving note: %s', error.message));
+    });
+}
+
+function restoreAll() {
+    fetch(basePath + '/api/restore-all', { method: 'POST', headers: { 'X-Actor': encodeURIComponent(userName()) } })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                alert(t('Restored %d contracts', data.restored));
+                loadContracts();
+            } else {
+                alert(t('Error restoring contracts: %s', data.error));
+            }
+        })
+        .catch(error => {
+            alert(t('Error restoring contracts: %s', error.message));
+        });
+}
+
+// startScrape asks the server to scrape now and follows the progress of the scrape
+function startScrape() {
+    fetch(basePath + '/api/scrape', { method: 'POST', headers: { 'X-Actor': encodeURIComponent(userName()) } })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                alert(data.error);
+            }
+            showScrape(data.job);
+        })
+        .catch(error => {
+            alert(t('Error starting the scrape: %s', error.message));
+        });
+}
+
+// pollScrape shows the progress of the running or last scrape, checking again every 2 seconds while it runs
+function pollScrape(quiet) {
+    fetch(basePath + '/api/scrape')
+        .then(response => response.json())
+        .then(job => {
+            if (quiet && !job.running) {
+                return;
+            }
+            showScrape(job);
+        })
+        .catch(error => console.error('Error loading scrape progress:', error));
+}
+
+function showScrape(job) {
+    if (!job || !job.started_at) {
+        return;
+    }
+    const steps = job.steps || [];
+    document.getElementById('scrapeProgress').style.display = 'block';
+    document.getElementById('scrapeButton').disabled = job.running;
+    document.getElementById('scrapeTitle').textContent = job.running
+        ? t('Scraping %s…', job.profile || t('the default profile'))
+        : t(job.error ? 'Scrape failed · %s' : 'Scrape finished · %s', new Date(job.finished_at).toLocaleString());
+
+    const reached = scrapeSteps.filter(step => steps.includes(step[0])).length;
+    document.getElementById('scrapeSteps').innerHTML = scrapeSteps.map((step, i) => {
+        let state = '', mark = '○';
+        if (i < reached - 1 || (i === reached - 1 && !job.running && !job.error)) {
+            state = 'done';
+            mark = '✓';
+        } else if (i === reached - 1) {
+            state = 'current';
+            mark = job.running ? '…' : '✗';
+        }
+        return '<li class="' + state + '">' + mark + ' ' + t(step[1]) + '</li>';
+    }).join('');
+
+    let result = '';
+    if (job.error) {
+        result = t('Error: %s', escapeHtml(job.error));
+    } else if (job.run) {
+        result = t('%d contracts found, %d new, %d changed, %d errors', job.run.contracts_found, job.run.contracts_new,
+            job.run.contracts_changed, (job.run.errors || []).length);
+    }
+    document.getElementById('scrapeResult').innerHTML = result;
+
+    clearTimeout(scrapeTimer);
+    if (job.running) {
+        scrapeTimer = setTimeout(pollScrape, 2000);
+    } else if (job.run || job.error) {
+        refreshData();
+        loadStats();
+    }
+}
+
+// Search functionality: the server searches the words in the ID, description and contracting body
+// once typing pauses, and lists the best matches first
+document.getElementById('searchInput').addEventListener('input', function() {
+    clearTimeout(searchTimer);
+    searchTimer = setTimeout(reloadContracts, 300);
+});
+
+// Load data on page load
+updateSortBar();
+loadContracts();
+if (canScrape) {
+    // Pick up a scrape started earlier or by someone else
+    pollScrape(true);
+}
+
+// Auto-refresh every 30 seconds
+setInterval(loadStats, 30000);
+
+// The service worker lets phones install the dashboard as an app and shows the contracts seen last
+// when offline
+if ('serviceWorker' in navigator) {
+    navigator.serviceWorker.register(basePath + '/sw.js', { scope: basePath + '/' })
+        .catch(error => console.error('Error registering the service worker:', error));
+}
@@ -0,0 +1,86 @@
+// Service worker of the installable dashboard, served at /sw.js so it controls every page. Pages and
+// API responses come from the network, falling back to the last copy when offline, so the contracts
+// seen last can still be checked on the go; the static files are served from the cache while it is
+// refreshed in the background.
+
+const cacheName = 'contracts-v1';
+// scope is the dashboard address, with the base path, e.g. https://example.com/licitaciones/
+const scope = self.registration.scope;
+const staticFiles = ['static/dashboard.css', 'static/dashboard.js', 'static/manifest.json', 'static/icon-192.png', 'static/icon-512.png'];
+
+self.addEventListener('install', event => {
+    event.waitUntil(
+        caches.open(cacheName)
+            .then(cache => cache.addAll(staticFiles.map(file => scope + file)))
+            .then(() => self.skipWaiting())
+    );
+});
+
+self.addEventListener('activate', event => {
+    event.waitUntil(
+        caches.keys()
+            .then(names => Promise.all(names.filter(name => name !== cacheName).map(name => caches.delete(name))))
+            .then(() => self.clients.claim())
+    );
+});
+
+self.addEventListener('fetch', event => {
+    const request = event.request;
+    if (request.method !== 'GET' || !request.url.startsWith(scope)) {
+        return;
+    }
+    const path = request.url.slice(scope.length);
+    // Nothing of a user is kept once they log out; downloads are too big to keep
+    if (path.startsWith('logout')) {
+        event.respondWith(caches.delete(cacheName).then(() => fetch(request)));
+        return;
+    }
+    if (path.startsWith('login') || path.includes('/download') || path.startsWith('api/export') || path.startsWith('api/report')) {
+        return;
+    }
+    if (path.startsWith('static/')) {
+        event.respondWith(staleWhileRevalidate(request));
+        return;
+    }
+    event.respondWith(networkFirst(request));
+});
+
+// networkFirst answers from the network and keeps a copy, or answers the copy when offline
+function networkFirst(request) {
+    return fetch(request)
+        .then(response => {
+            keep(request, response);
+            return response;
+        })
+        .catch(() => caches.match(request).then(cached => cached || offline(request)));
+}
+
+// staleWhileRevalidate answers from the cache at once, if it can, and refreshes the copy
+function staleWhileRevalidate(request) {
+    return caches.match(request).then(cached => {
+        const fetched = fetch(request).then(response => {
+            keep(request, response);
+            return response;
+        });
+        return cached || fetched;
+    });
+}
+
+// keep caches a response, unless it failed or is a redirect, such as to the login page
+function keep(request, response) {
+    if (response.ok && !response.redirected && response.type === 'basic') {
+        const copy = response.clone();
+        caches.open(cacheName).then(cache => cache.put(request, copy));
+    }
+}
+
+// offline answers a request that was never cached while there is no connection
+function offline(request) {
+    if (request.mode === 'navigate') {
+        return new Response('<!DOCTYPE html><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">' +
+            '<body style="font-family: sans-serif; background: #000; color: #fff; text-align: center; padding: 60px 20px;">' +
+            '<h1 style="color: #ff6600;">Sin conexión · Offline</h1><p>Esta página no está disponible sin conexión. · This page is not available offline.</p>',
+            { status: 503, headers: { 'Content-Type': 'text/html; charset=utf-8' } });
+    }
+    return new Response(JSON.stringify({ success: false, error: 'offline' }), { status: 503, headers: { 'Content-Type': 'application/json' } });
+}
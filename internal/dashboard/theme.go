@@ -0,0 +1,43 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Dashboard themes; dark is the default
+const (
+	themeDark  = "dark"
+	themeLight = "light"
+)
+
+// themeSetting is the user setting holding the chosen theme
+const themeSetting = "theme"
+
+// theme returns the theme chosen by the user or browser of a request
+func (d *Dashboard) theme(r *http.Request) string {
+	if theme := d.userSetting(r, themeSetting, themeDark, themeLight); theme != "" {
+		return theme
+	}
+	return themeDark
+}
+
+// handleSetTheme saves the theme chosen with {"theme": "light"} or "dark" for the logged in user,
+// or else for the browser
+func (d *Dashboard) handleSetTheme(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Theme string `json:"theme"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.Theme != themeDark && request.Theme != themeLight {
+		http.Error(w, `Theme must be "dark" or "light"`, http.StatusBadRequest)
+		return
+	}
+
+	d.writeResult(w, d.saveUserSetting(w, r, themeSetting, request.Theme))
+}
@@ -0,0 +1,104 @@
+package dashboard
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certReloadInterval is how often the certificate files are checked for renewals
+const certReloadInterval = time.Minute
+
+// acmeHTTPAddr is where the HTTP-01 challenges of Let's Encrypt are answered, and plain HTTP is
+// redirected to HTTPS, when the certificates are obtained automatically
+const acmeHTTPAddr = ":80"
+
+// SetTLS serves the dashboard over HTTPS with the certificate and key in the given PEM files. The
+// files are read again when they change, so renewed certificates are picked up without a restart.
+func (d *Dashboard) SetTLS(certFile, keyFile string) error {
+	certificate := &certificateFiles{certFile: certFile, keyFile: keyFile}
+	if err := certificate.load(); err != nil {
+		return err
+	}
+	d.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certificate.get}
+	return nil
+}
+
+// SetAutocert serves the dashboard over HTTPS with certificates for hosts obtained from Let's Encrypt
+// and cached in cacheDir. Let's Encrypt must reach the dashboard on port 443, or on port 80 where
+// the challenges are answered. email is given to Let's Encrypt for expiry notices and may be empty.
+func (d *Dashboard) SetAutocert(hosts []string, cacheDir, email string) error {
+	if len(hosts) == 0 {
+		return fmt.Errorf("at least one host name is required")
+	}
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return fmt.Errorf("failed to create certificate cache %s: %w", cacheDir, err)
+	}
+
+	d.autocert = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(hosts...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+	}
+	d.tlsConfig = d.autocert.TLSConfig()
+	d.tlsConfig.MinVersion = tls.VersionTLS12
+	return nil
+}
+
+// serveACMEChallenges answers the HTTP-01 challenges and redirects the rest of plain HTTP to HTTPS.
+// Failing to listen is only a warning, as the TLS-ALPN challenge on port 443 may still work.
+func (d *Dashboard) serveACMEChallenges() {
+	if err := http.ListenAndServe(acmeHTTPAddr, d.autocert.HTTPHandler(nil)); err != nil {
+		log.Printf("Warning: Failed to answer Let's Encrypt HTTP challenges on %s: %v", acmeHTTPAddr, err)
+	}
+}
+
+// certificateFiles is a certificate loaded from PEM files, reloaded when they change
+type certificateFiles struct {
+	certFile, keyFile string
+	mu                sync.Mutex
+	certificate       *tls.Certificate
+	modTime           time.Time
+	checkedAt         time.Time
+}
+
+// load reads the certificate and its key
+func (c *certificateFiles) load() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate: %w", err)
+	}
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate %s and key %s: %w", c.certFile, c.keyFile, err)
+	}
+
+	c.certificate, c.modTime = &certificate, info.ModTime()
+	return nil
+}
+
+// get returns the certificate for a handshake, reloading it first if the file changed. A certificate
+// that fails to reload is kept, so a half-written renewal does not take the dashboard down.
+func (c *certificateFiles) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checkedAt) >= certReloadInterval {
+		c.checkedAt = time.Now()
+		if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+			if err := c.load(); err != nil {
+				log.Printf("Warning: Failed to reload the dashboard certificate, keeping the current one: %v", err)
+			} else {
+				log.Printf("Reloaded the dashboard certificate %s", c.certFile)
+			}
+		}
+	}
+	return c.certificate, nil
+}
@@ -0,0 +1,26 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+
+	"scraper/internal/scraper"
+)
+
+// handleTrashPage serves the trash: the soft-deleted contracts, most recently deleted first, each
+// with a button restoring it. They stay there until purged with --purge-deleted.
+func (d *Dashboard) handleTrashPage(w http.ResponseWriter, r *http.Request) {
+	contracts, err := d.store.GetDeletedContracts()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get deleted contracts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	d.renderPage(w, r, http.StatusOK, "trash.html", struct {
+		page
+		Contracts []scraper.Contract
+	}{
+		page:      d.page(r),
+		Contracts: contracts,
+	})
+}
@@ -0,0 +1,226 @@
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// File names written by ToDirectory
+const (
+	ContractsFile     = "contracts.csv.gz"
+	StatusChangesFile = "status_changes.csv.gz"
+)
+
+// ContractColumns is the header of the contracts export. Columns are only ever appended,
+// so positional readers keep working across versions.
+var ContractColumns = []string{
+	"id",
+	"description",
+	"contract_type",
+	"status",
+	"amount_text",
+	"amount_eur",
+	"submission_date_text",
+	"deadline",
+	"contracting_body",
+	"link",
+	"pliego_link",
+	"anuncio_link",
+	"scraped_at",
+	"archived_at",
+}
+
+// StatusChangeColumns is the header of the status changes export
+var StatusChangeColumns = []string{
+	"id",
+	"contract_id",
+	"old_status",
+	"new_status",
+	"changed_at",
+}
+
+// ContractWriter streams contracts into a gzipped CSV file. Timestamps are RFC 3339 in UTC
+// and amounts use a dot as decimal separator; unknown values are left empty.
+type ContractWriter struct {
+	gz     *gzip.Writer
+	writer *csv.Writer
+}
+
+// NewContractWriter writes the contracts header to w and returns a writer for the rows
+func NewContractWriter(w io.Writer) (*ContractWriter, error) {
+	gz := gzip.NewWriter(w)
+	writer := csv.NewWriter(gz)
+	if err := writer.Write(ContractColumns); err != nil {
+		return nil, fmt.Errorf("failed to write contracts header: %w", err)
+	}
+	return &ContractWriter{gz: gz, writer: writer}, nil
+}
+
+// Write appends one contract row
+func (cw *ContractWriter) Write(contract scraper.Contract) error {
+	if err := cw.writer.Write(contractRecord(contract)); err != nil {
+		return fmt.Errorf("failed to write contract %s: %w", contract.ID, err)
+	}
+	return nil
+}
+
+// contractRecord returns the ContractColumns of a contract
+func contractRecord(contract scraper.Contract) []string {
+	return []string{
+		contract.ID,
+		contract.Description,
+		contract.ContractType,
+		contract.Status,
+		contract.Amount,
+		formatAmount(contract.AmountValue),
+		contract.SubmissionDate,
+		formatTime(contract.Deadline),
+		contract.ContractingBody,
+		contract.Link,
+		contract.PliegoLink,
+		contract.AnuncioLink,
+		formatTime(&contract.ScrapedAt),
+		formatTime(contract.ArchivedAt),
+	}
+}
+
+// Close flushes the remaining rows and finishes the gzip stream. It does not close the underlying writer.
+func (cw *ContractWriter) Close() error {
+	return closeCSV(cw.writer, cw.gz)
+}
+
+// StatusChangeWriter streams status changes into a gzipped CSV file
+type StatusChangeWriter struct {
+	gz     *gzip.Writer
+	writer *csv.Writer
+}
+
+// NewStatusChangeWriter writes the status changes header to w and returns a writer for the rows
+func NewStatusChangeWriter(w io.Writer) (*StatusChangeWriter, error) {
+	gz := gzip.NewWriter(w)
+	writer := csv.NewWriter(gz)
+	if err := writer.Write(StatusChangeColumns); err != nil {
+		return nil, fmt.Errorf("failed to write status changes header: %w", err)
+	}
+	return &StatusChangeWriter{gz: gz, writer: writer}, nil
+}
+
+// Write appends one status change row
+func (sw *StatusChangeWriter) Write(change storage.StatusChange) error {
+	record := []string{
+		strconv.Itoa(change.ID),
+		change.ContractID,
+		change.OldStatus,
+		change.NewStatus,
+		change.ChangedAt,
+	}
+	if err := sw.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write status change %d: %w", change.ID, err)
+	}
+	return nil
+}
+
+// Close flushes the remaining rows and finishes the gzip stream. It does not close the underlying writer.
+func (sw *StatusChangeWriter) Close() error {
+	return closeCSV(sw.writer, sw.gz)
+}
+
+// StreamContracts writes every stored contract (including archived ones) to w as gzipped CSV
+// without loading them all into memory
+func StreamContracts(store storage.Store, w io.Writer) error {
+	cw, err := NewContractWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := store.ForEachContract(storage.ContractFilter{Archive: storage.ArchiveInclude}, storage.ContractSort{Field: storage.SortByID}, cw.Write); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+// StreamStatusChanges writes the full status change history to w as gzipped CSV
+func StreamStatusChanges(store storage.Store, w io.Writer) error {
+	sw, err := NewStatusChangeWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := store.ForEachStatusChange(sw.Write); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// ToDirectory exports every stored contract (including archived ones) and every status change
+// into dir and returns the paths of the written files
+func ToDirectory(store storage.Store, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	contractsPath := filepath.Join(dir, ContractsFile)
+	if err := writeFile(contractsPath, func(w io.Writer) error { return StreamContracts(store, w) }); err != nil {
+		return nil, err
+	}
+
+	changesPath := filepath.Join(dir, StatusChangesFile)
+	if err := writeFile(changesPath, func(w io.Writer) error { return StreamStatusChanges(store, w) }); err != nil {
+		return nil, err
+	}
+
+	return []string{contractsPath, changesPath}, nil
+}
+
+// writeFile creates path and fills it with write
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	return nil
+}
+
+// closeCSV flushes the CSV writer and finishes the gzip stream
+func closeCSV(writer *csv.Writer, gz *gzip.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish gzip stream: %w", err)
+	}
+	return nil
+}
+
+// formatAmount renders a parsed amount, or an empty string when it is unknown
+func formatAmount(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// formatTime renders a timestamp in RFC 3339 UTC, or an empty string when it is not set
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
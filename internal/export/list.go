@@ -0,0 +1,116 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// Formats of a contract list download, see NewListWriter
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ContentType returns the MIME type of a contract list format
+func ContentType(format string) string {
+	if format == FormatJSON {
+		return "application/json"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ListWriter streams a list of contracts, e.g. the filtered contracts of the dashboard, in one of the
+// list formats (FormatCSV or FormatJSON)
+type ListWriter struct {
+	w       io.Writer
+	format  string
+	csv     *csv.Writer // Nil unless writing CSV
+	written int
+}
+
+// NewListWriter starts a contract list in the given format on w. CSV starts with a UTF-8 byte order
+// mark so Excel shows accented characters correctly, followed by the ContractColumns header.
+func NewListWriter(w io.Writer, format string) (*ListWriter, error) {
+	lw := &ListWriter{w: w, format: format}
+	switch format {
+	case FormatCSV:
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %w", err)
+		}
+		lw.csv = csv.NewWriter(w)
+		if err := lw.csv.Write(ContractColumns); err != nil {
+			return nil, fmt.Errorf("failed to write contracts header: %w", err)
+		}
+	case FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown export format %q (use %s or %s)", format, FormatCSV, FormatJSON)
+	}
+	return lw, nil
+}
+
+// Write appends one contract to the list
+func (lw *ListWriter) Write(contract scraper.Contract) error {
+	if lw.csv != nil {
+		record := contractRecord(contract)
+		for i, cell := range record {
+			record[i] = escapeFormula(cell)
+		}
+		if err := lw.csv.Write(record); err != nil {
+			return fmt.Errorf("failed to write contract %s: %w", contract.ID, err)
+		}
+		lw.written++
+		return nil
+	}
+
+	// The same layout as a JSON array encoded with an indent of two spaces, as /api/contracts lists them
+	encoded, err := json.MarshalIndent(contract, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode contract %s: %w", contract.ID, err)
+	}
+	separator := ",\n  "
+	if lw.written == 0 {
+		separator = "[\n  "
+	}
+	if _, err := io.WriteString(lw.w, separator); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	if _, err := lw.w.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	lw.written++
+	return nil
+}
+
+// Close finishes the list. It does not close the underlying writer.
+func (lw *ListWriter) Close() error {
+	if lw.csv != nil {
+		lw.csv.Flush()
+		if err := lw.csv.Error(); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+		return nil
+	}
+
+	end := "\n]\n"
+	if lw.written == 0 {
+		end = "[]\n"
+	}
+	if _, err := io.WriteString(lw.w, end); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// escapeFormula keeps a spreadsheet from running a cell as a formula, as a description or contracting
+// body starting with "=", "+", "-" or "@" would be, by prefixing it with a quote
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,163 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// ContentType is the MIME type of the generated feed
+const ContentType = "application/atom+xml; charset=utf-8"
+
+// Options describes the feed, so its title can be translated and its links point to the dashboard
+// it is served from
+type Options struct {
+	Title   string // Feed title shown by feed readers
+	SelfURL string // Absolute address of the feed, which is also its identifier
+	SiteURL string // Absolute address of the dashboard
+}
+
+// atomFeed is an Atom 1.0 feed (RFC 4287)
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+// Write writes an Atom feed with one entry per contract, dated when the contract was first seen, so
+// readers show each contract once as it is discovered rather than again at every re-scrape. Entries
+// are identified by the contract, and contracts are expected newest first.
+func Write(w io.Writer, contracts []scraper.Contract, opts Options) error {
+	feed := atomFeed{
+		ID:      opts.SelfURL,
+		Title:   opts.Title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "LED Screen Contract Scraper"},
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: opts.SelfURL},
+			{Rel: "alternate", Type: "text/html", Href: opts.SiteURL},
+		},
+	}
+	if len(contracts) > 0 && !contracts[0].FirstSeenAt.IsZero() {
+		feed.Updated = contracts[0].FirstSeenAt.UTC().Format(time.RFC3339)
+	}
+
+	for _, contract := range contracts {
+		seen := contract.FirstSeenAt
+		if seen.IsZero() {
+			seen = contract.ScrapedAt
+		}
+		entry := atomEntry{
+			ID:        "urn:led-contracts:contract:" + url.PathEscape(contract.ID),
+			Title:     contract.ID + " - " + contract.Description,
+			Published: seen.UTC().Format(time.RFC3339),
+			Updated:   seen.UTC().Format(time.RFC3339),
+			Content:   atomText{Type: "html", Body: content(contract)},
+		}
+		if contract.ContractingBody != "" {
+			entry.Author = &atomAuthor{Name: contract.ContractingBody}
+		}
+		if link := contractLink(contract); link != "" {
+			entry.Links = append(entry.Links, atomLink{Rel: "alternate", Type: "text/html", Href: link})
+		}
+		if contract.Status != "" {
+			entry.Categories = append(entry.Categories, atomCategory{Term: contract.Status})
+		}
+		for _, tag := range contract.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
+
+// content is the HTML body of the entry of a contract: its details and links to the portal and
+// documents
+func content(contract scraper.Contract) string {
+	var b strings.Builder
+	b.WriteString("<p>" + html.EscapeString(contract.Description) + "</p><ul>")
+	for _, field := range []struct{ label, value string }{
+		{"Contracting body", contract.ContractingBody},
+		{"Type", contract.ContractType},
+		{"Status", contract.Status},
+		{"Amount", contract.Amount},
+		{"Submission deadline", contract.SubmissionDate},
+	} {
+		if field.value != "" {
+			b.WriteString("<li><strong>" + field.label + ":</strong> " + html.EscapeString(field.value) + "</li>")
+		}
+	}
+	b.WriteString("</ul>")
+
+	var links []string
+	for _, link := range []struct{ label, href string }{
+		{"Portal", contract.Link},
+		{"Pliego", contract.PliegoLink},
+		{"Anuncio", contract.AnuncioLink},
+	} {
+		if link.href != "" {
+			links = append(links, `<a href="`+html.EscapeString(link.href)+`">`+link.label+"</a>")
+		}
+	}
+	if len(links) > 0 {
+		b.WriteString("<p>" + strings.Join(links, " · ") + "</p>")
+	}
+	return b.String()
+}
+
+// contractLink returns the dashboard page of a contract if it is linked, otherwise the portal
+func contractLink(contract scraper.Contract) string {
+	if contract.DashboardLink != "" {
+		return contract.DashboardLink
+	}
+	return contract.Link
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"bytes"
+	"log"
+	"time"
+
+	"scraper/internal/calendar"
+	"scraper/internal/scraper"
+)
+
+// SetCalendarInvites makes new contract and watchlist emails attach an iCalendar file with the
+// upcoming submission deadlines of their contracts, to add them to Outlook or Google Calendar
+func (n *Notifier) SetCalendarInvites(enabled bool) {
+	n.calendarInvites = enabled
+}
+
+// calendarInvite returns the iCalendar attachment of an email alert, or nil if it is disabled or no
+// contract of the alert has an upcoming deadline
+func (n *Notifier) calendarInvite(event string, a alert) []Attachment {
+	if !n.calendarInvites {
+		return nil
+	}
+
+	var contracts []scraper.Contract
+	switch event {
+	case EventNewContracts:
+		contracts = a.Contracts
+	case EventWatchlist:
+		contracts = a.Deadlines
+		for _, update := range a.Modifications {
+			if update.Field == "submission_date" {
+				contracts = append(contracts, update.Contract)
+			}
+		}
+	}
+
+	var upcoming []scraper.Contract
+	for _, contract := range calendar.WithDeadlines(contracts) {
+		if contract.Deadline.After(time.Now()) {
+			upcoming = append(upcoming, contract)
+		}
+	}
+	if len(upcoming) == 0 {
+		return nil
+	}
+
+	lang := n.languageOf(TargetEmail)
+	var buf bytes.Buffer
+	opts := calendar.Options{Name: translate(lang, "LED screen contract deadlines"), Label: translate(lang, "Deadline")}
+	if err := calendar.Write(&buf, upcoming, opts); err != nil {
+		log.Printf("Warning: Sending the email without deadlines: %v", err)
+		return nil
+	}
+	return []Attachment{{Filename: calendar.FileName, ContentType: calendar.ContentType, Data: buf.Bytes()}}
+}
@@ -0,0 +1,223 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"scraper/internal/scraper"
+)
+
+// Alert types, as named in webhook payloads and rules
+const (
+	EventNewContracts = "new_contracts"
+	EventWatchlist    = "watchlist"
+)
+
+// Channel delivers contract alerts to a chat or push service alongside the email
+type Channel interface {
+	// Name identifies the channel in logs and errors
+	Name() string
+	// SendNewContracts announces newly found contracts
+	SendNewContracts(contracts []scraper.Contract) error
+	// SendWatchlist reports status changes, modified fields and upcoming deadlines of watched contracts
+	SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error
+}
+
+// AddChannel makes the notifier deliver its alerts to channel as well
+func (n *Notifier) AddChannel(channel Channel) {
+	n.channelsMu.Lock()
+	n.channels = append(n.channels, channel)
+	n.channelsMu.Unlock()
+	n.applyLanguages()
+}
+
+// DashboardChannel is a channel added on the dashboard settings page with its target name, which must
+// not change when other channels are added or removed, as queued notifications are kept by it
+type DashboardChannel struct {
+	Target  string
+	Channel Channel
+}
+
+// SetDashboardChannels replaces the channels added on the dashboard settings page, which are notified
+// after the ones added with AddChannel
+func (n *Notifier) SetDashboardChannels(channels []DashboardChannel) {
+	targets := make([]channelTarget, len(channels))
+	for i, channel := range channels {
+		targets[i] = channelTarget{name: channel.Target, channel: channel.Channel}
+	}
+	n.channelsMu.Lock()
+	n.dashboardChannels = targets
+	n.channelsMu.Unlock()
+	n.applyLanguages()
+}
+
+// SendTest posts a sample new contract alert to a channel of the dashboard, in the language of its
+// target, so a channel can be checked before or after it is added. It skips the delivery log and the
+// schedule.
+func (n *Notifier) SendTest(channel DashboardChannel) error {
+	if setter, ok := channel.Channel.(languageSetter); ok {
+		setter.SetLanguage(n.languageOf(channel.Target))
+	}
+	a := n.withDashboardLinks(alert{Contracts: []scraper.Contract{SampleContract()}})
+	return channel.Channel.SendNewContracts(a.Contracts)
+}
+
+// emailEnabled reports whether an SMTP server is configured; without one only the channels are used
+func (n *Notifier) emailEnabled() bool {
+	return n.smtpHost != ""
+}
+
+// httpClient is used by the channels that talk to an HTTP API
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// postJSON sends payload as a JSON POST request and fails unless the response status is 2xx
+func postJSON(endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	return postBody(endpoint, "application/json", body, nil)
+}
+
+// postMultipart sends fields and a file as a multipart/form-data POST request and fails unless the
+// response status is 2xx
+func postMultipart(endpoint string, fields map[string]string, fileField string, file Attachment) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="%s"; filename="%s"`, fileField, file.Filename)},
+		"Content-Type":        {file.ContentType},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	part.Write(file.Data)
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	return postBody(endpoint, writer.FormDataContentType(), buf.Bytes(), nil)
+}
+
+// statusError is returned by postBody for a response with a status other than 2xx
+type statusError struct {
+	code   int
+	status string
+	detail string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected response %s: %s", e.status, e.detail)
+}
+
+// postBody sends body as a POST request with the given extra headers and fails unless the response status is 2xx
+func postBody(endpoint, contentType string, body []byte, headers map[string]string) error {
+	return sendBody(http.MethodPost, endpoint, contentType, body, headers)
+}
+
+// sendBody sends body as a request with the given method and extra headers and fails unless the
+// response status is 2xx
+func sendBody(method, endpoint, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// Drop the URL from the error, it may carry a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &statusError{code: resp.StatusCode, status: resp.Status, detail: string(bytes.TrimSpace(detail))}
+	}
+	return nil
+}
+
+// truncateText cuts text to at most limit bytes without splitting a UTF-8 character
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit - len("…")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
+
+// newContractLines returns a one-line summary of every new contract, for the plain text channels
+func newContractLines(contracts []scraper.Contract) []string {
+	var lines []string
+	for _, contract := range contracts {
+		lines = append(lines, fmt.Sprintf("• %s (%s): %s", contract.ID, contract.Amount, contract.Description))
+	}
+	return lines
+}
+
+// watchlistLines returns a one-line summary of every watchlist alert, for the plain text channels,
+// and the contracts they are about
+func (l *localized) watchlistLines(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) ([]string, []scraper.Contract) {
+	var lines []string
+	var contracts []scraper.Contract
+	for _, update := range updates {
+		lines = append(lines, fmt.Sprintf("• %s: %s → %s", update.Contract.ID, update.OldStatus, update.NewStatus))
+		contracts = append(contracts, update.Contract)
+	}
+	for _, update := range modified {
+		lines = append(lines, fmt.Sprintf("• %s: %s %s → %s", update.Contract.ID, l.t(update.Field), update.OldValue, update.NewValue))
+		contracts = append(contracts, update.Contract)
+	}
+	for _, contract := range deadlines {
+		lines = append(lines, fmt.Sprintf("• %s: %s", contract.ID, l.t("deadline %s", contract.SubmissionDate)))
+		contracts = append(contracts, contract)
+	}
+	return lines, contracts
+}
+
+// contractLink returns the link a notification about a contract opens: its dashboard page if the
+// dashboard is linked, otherwise the portal
+func contractLink(contract scraper.Contract) string {
+	if contract.DashboardLink != "" {
+		return contract.DashboardLink
+	}
+	return contract.Link
+}
+
+// singleLink returns the link of the contracts when they are all the same contract, so tapping a
+// notification about one contract opens it
+func singleLink(contracts []scraper.Contract) string {
+	if len(contracts) == 0 {
+		return ""
+	}
+	for _, contract := range contracts[1:] {
+		if contract.ID != contracts[0].ID {
+			return ""
+		}
+	}
+	return contractLink(contracts[0])
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"net/url"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// SetDashboardURL makes every alert link its contracts to their page in the dashboard, served at
+// baseURL (e.g. "https://contratos.example.com"). An empty baseURL removes the links.
+func (n *Notifier) SetDashboardURL(baseURL string) {
+	n.dashboardURL = strings.TrimRight(baseURL, "/")
+}
+
+// dashboardLink returns the dashboard page of a contract. New contracts have no uid until they are
+// saved, so they are linked by id, which the dashboard resolves as well.
+func (n *Notifier) dashboardLink(contract scraper.Contract) string {
+	ref := contract.UID
+	if ref == "" {
+		ref = contract.ID
+	}
+	return n.dashboardURL + "/?contract=" + url.QueryEscape(ref)
+}
+
+// withDashboardLinks returns the alert with the DashboardLink of its contracts filled in, if the
+// dashboard URL is set. The slices are copied, as they are shared with the caller.
+func (n *Notifier) withDashboardLinks(a alert) alert {
+	if n.dashboardURL == "" {
+		return a
+	}
+
+	linked := alert{
+		Contracts:     make([]scraper.Contract, len(a.Contracts)),
+		StatusChanges: make([]StatusUpdate, len(a.StatusChanges)),
+		Modifications: make([]FieldUpdate, len(a.Modifications)),
+		Deadlines:     make([]scraper.Contract, len(a.Deadlines)),
+	}
+	for i, contract := range a.Contracts {
+		contract.DashboardLink = n.dashboardLink(contract)
+		linked.Contracts[i] = contract
+	}
+	for i, update := range a.StatusChanges {
+		update.Contract.DashboardLink = n.dashboardLink(update.Contract)
+		linked.StatusChanges[i] = update
+	}
+	for i, update := range a.Modifications {
+		update.Contract.DashboardLink = n.dashboardLink(update.Contract)
+		linked.Modifications[i] = update
+	}
+	for i, contract := range a.Deadlines {
+		contract.DashboardLink = n.dashboardLink(contract)
+		linked.Deadlines[i] = contract
+	}
+	return linked
+}
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// DefaultDedupWindow is how long a contract announced once is not announced again for the same event
+const DefaultDedupWindow = 24 * time.Hour
+
+// SetDedupWindow makes the notifier leave out of an alert what it already notified within window,
+// e.g. the same new contract found by a manual and a scheduled run. Zero turns deduplication off.
+// It needs a delivery log, see SetDeliveryLog.
+func (n *Notifier) SetDedupWindow(window time.Duration) {
+	n.dedupWindow = window
+}
+
+// dedup returns the alert without the items notified within the dedup window, and the keys of the
+// items left, to be recorded once the alert is dispatched
+func (n *Notifier) dedup(event string, a alert) (alert, []string) {
+	if n.deliveryLog == nil || n.dedupWindow <= 0 {
+		return a, nil
+	}
+
+	var keys []string
+	for _, contract := range a.Contracts {
+		keys = append(keys, contractKey(event, contract))
+	}
+	for _, update := range a.StatusChanges {
+		keys = append(keys, statusKey(event, update))
+	}
+	for _, update := range a.Modifications {
+		keys = append(keys, fieldKey(event, update))
+	}
+	for _, contract := range a.Deadlines {
+		keys = append(keys, deadlineKey(event, contract))
+	}
+
+	notified, err := n.deliveryLog.GetNotifiedKeys(keys, time.Now().Add(-n.dedupWindow))
+	if err != nil {
+		log.Printf("Warning: Not deduplicating the %s notification: %v", event, err)
+		return a, nil
+	}
+
+	var fresh alert
+	var freshKeys []string
+	for _, contract := range a.Contracts {
+		if key := contractKey(event, contract); !notified[key] {
+			fresh.Contracts = append(fresh.Contracts, contract)
+			freshKeys = append(freshKeys, key)
+		}
+	}
+	for _, update := range a.StatusChanges {
+		if key := statusKey(event, update); !notified[key] {
+			fresh.StatusChanges = append(fresh.StatusChanges, update)
+			freshKeys = append(freshKeys, key)
+		}
+	}
+	for _, update := range a.Modifications {
+		if key := fieldKey(event, update); !notified[key] {
+			fresh.Modifications = append(fresh.Modifications, update)
+			freshKeys = append(freshKeys, key)
+		}
+	}
+	for _, contract := range a.Deadlines {
+		if key := deadlineKey(event, contract); !notified[key] {
+			fresh.Deadlines = append(fresh.Deadlines, contract)
+			freshKeys = append(freshKeys, key)
+		}
+	}
+
+	if skipped := len(keys) - len(freshKeys); skipped > 0 {
+		fmt.Printf("🔁 Skipping %d items of the %s notification already sent in the last %s\n", skipped, event, n.dedupWindow)
+	}
+	return fresh, freshKeys
+}
+
+// recordNotified records the keys of a dispatched alert in the delivery log
+func (n *Notifier) recordNotified(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := n.deliveryLog.RecordNotifiedKeys(keys, time.Now().Add(-n.dedupWindow)); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// contractKey identifies a contract announced as new
+func contractKey(event string, contract scraper.Contract) string {
+	return event + ":" + contract.ID
+}
+
+// deadlineKey identifies a deadline reminder, so a postponed deadline is reminded again
+func deadlineKey(event string, contract scraper.Contract) string {
+	return event + ":deadline:" + contract.ID + ":" + contract.SubmissionDate
+}
+
+// statusKey identifies a status change of a contract, so a later change to another status is still notified
+func statusKey(event string, update StatusUpdate) string {
+	return event + ":status:" + update.Contract.ID + ":" + update.NewStatus
+}
+
+// fieldKey identifies a modified field of a contract by a hash of its new value, which may be long
+func fieldKey(event string, update FieldUpdate) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(update.NewValue))
+	return fmt.Sprintf("%s:field:%s:%s:%x", event, update.Contract.ID, update.Field, hash.Sum64())
+}
+
+// empty reports whether an alert has nothing to notify
+func (a alert) empty() bool {
+	return len(a.Contracts) == 0 && len(a.StatusChanges) == 0 && len(a.Modifications) == 0 && len(a.Deadlines) == 0
+}
@@ -0,0 +1,204 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// EventStatusChanges is the alert type of the status change email; it is not sent to the channels
+const EventStatusChanges = "status_changes"
+
+// TargetEmail is the delivery target of the emails. Channels are targets named after Name(), with
+// "#2", "#3"… appended when several channels share a name.
+const TargetEmail = "email"
+
+// DeliveryLog records the outcome of every delivery, so that failed ones can be sent again with
+// Redeliver, and keeps the alerts held by the schedule until they go out with SendDigest
+type DeliveryLog interface {
+	RecordNotification(target, event string, payload []byte, sendErr error) error
+	HoldNotification(target, event string, payload []byte) error
+	CountSentNotifications(target string, since time.Time) (int, error)
+	GetNotifiedKeys(keys []string, since time.Time) (map[string]bool, error)
+	RecordNotifiedKeys(keys []string, expireBefore time.Time) error
+}
+
+// SetDeliveryLog makes the notifier record every delivery in deliveryLog
+func (n *Notifier) SetDeliveryLog(deliveryLog DeliveryLog) {
+	n.deliveryLog = deliveryLog
+}
+
+// alert is the content of a notification. It is the payload recorded with failed deliveries.
+type alert struct {
+	Contracts     []scraper.Contract `json:"contracts,omitempty"`
+	StatusChanges []StatusUpdate     `json:"status_changes,omitempty"`
+	Modifications []FieldUpdate      `json:"modifications,omitempty"`
+	Deadlines     []scraper.Contract `json:"deadlines,omitempty"`
+}
+
+// dispatch delivers an alert to every target of the event and returns the failures joined, so one
+// broken target does not keep the alert from the others. Non-urgent alerts are held instead while
+// the schedule does not allow sending to a target, and items already notified are left out.
+func (n *Notifier) dispatch(event string, a alert) error {
+	a, keys := n.dedup(event, a)
+	if a.empty() {
+		return nil
+	}
+	defer n.recordNotified(keys)
+
+	var errs []error
+	for _, target := range n.targets(event) {
+		if !urgent(event, a) {
+			if reason := n.holdReason(target); reason != "" {
+				err := n.hold(target, event, a)
+				if err == nil {
+					fmt.Printf("⏸️ Holding the %s notification to %s for the next digest (%s)\n", event, target, reason)
+					continue
+				}
+				log.Printf("Warning: Failed to hold the %s notification to %s, sending it now: %v", event, target, err)
+			}
+		}
+
+		err := n.deliver(target, event, a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+		n.recordDelivery(target, event, a, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Redeliver sends a payload recorded by the delivery log to its target again
+func (n *Notifier) Redeliver(target, event string, payload []byte) error {
+	var a alert
+	if err := json.Unmarshal(payload, &a); err != nil {
+		return fmt.Errorf("failed to decode notification: %w", err)
+	}
+	return n.deliver(target, event, a)
+}
+
+// Recipients returns the addresses the notifications to a target go to: the recipients of the
+// email, or nil for a channel, whose recipients are set up on the service itself
+func (n *Notifier) Recipients(target string) []string {
+	if target != TargetEmail {
+		return nil
+	}
+	return n.recipients()
+}
+
+// recordDelivery logs a delivery in the delivery log, if any. Failing to log it is only a warning,
+// as the notification itself went out.
+func (n *Notifier) recordDelivery(target, event string, a alert, sendErr error) {
+	if n.deliveryLog == nil {
+		return
+	}
+
+	var payload []byte
+	if sendErr != nil {
+		var err error
+		if payload, err = json.Marshal(a); err != nil {
+			log.Printf("Warning: Failed to encode %s notification to %s for retry: %v", event, target, err)
+			return
+		}
+	}
+	if err := n.deliveryLog.RecordNotification(target, event, payload, sendErr); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// targets returns the targets an event is delivered to: the email if SMTP is configured and, except
+// for status changes, every channel
+func (n *Notifier) targets(event string) []string {
+	var targets []string
+	if n.emailEnabled() {
+		targets = append(targets, TargetEmail)
+	}
+	if event != EventStatusChanges {
+		for _, target := range n.channelTargets() {
+			targets = append(targets, target.name)
+		}
+	}
+	return targets
+}
+
+// channelTarget is a channel with its target name
+type channelTarget struct {
+	name    string
+	channel Channel
+}
+
+// channelTargets returns the channels in the order they were added, those of the dashboard last, with
+// their target names (see TargetEmail). The channels of the environment are named by position, which
+// only changes with the configuration; those of the dashboard keep the names they were given.
+func (n *Notifier) channelTargets() []channelTarget {
+	n.channelsMu.RLock()
+	defer n.channelsMu.RUnlock()
+
+	var targets []channelTarget
+	seen := make(map[string]int)
+	for _, channel := range n.channels {
+		name := channel.Name()
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+		targets = append(targets, channelTarget{name: name, channel: channel})
+	}
+	return append(targets, n.dashboardChannels...)
+}
+
+// unknownTargetError is the error of a delivery to a channel target that is not configured, e.g. a
+// channel removed from the dashboard after a notification to it was queued
+func unknownTargetError(target string) error {
+	return fmt.Errorf("channel %s is not configured, it may have been removed since the notification was queued", target)
+}
+
+// emailTemplate returns the email template of an event and its data for an alert
+func emailTemplate(event string, a alert) (string, EmailData, error) {
+	switch event {
+	case EventNewContracts:
+		return templateNewContracts, EmailData{Contracts: a.Contracts}, nil
+	case EventWatchlist:
+		return templateWatchlist, EmailData{StatusChanges: a.StatusChanges, Modifications: a.Modifications, Deadlines: a.Deadlines}, nil
+	case EventStatusChanges:
+		return templateStatusChanges, EmailData{StatusChanges: a.StatusChanges}, nil
+	}
+	return "", EmailData{}, fmt.Errorf("unknown event %q", event)
+}
+
+// deliver sends an alert of the given event to one target
+func (n *Notifier) deliver(target, event string, a alert) error {
+	a = n.withDashboardLinks(a)
+	if target == TargetEmail {
+		if !n.emailEnabled() {
+			return fmt.Errorf("email is not configured")
+		}
+		name, data, err := emailTemplate(event, a)
+		if err != nil {
+			return err
+		}
+		var attachments []Attachment
+		if event == EventNewContracts {
+			attachments, data.PliegoButtons = n.pliegoDocuments(a.Contracts)
+		}
+		attachments = append(attachments, n.calendarInvite(event, a)...)
+		return n.sendTemplate(name, data, attachments...)
+	}
+
+	for _, channelTarget := range n.channelTargets() {
+		if channelTarget.name != target {
+			continue
+		}
+		switch event {
+		case EventNewContracts:
+			return channelTarget.channel.SendNewContracts(a.Contracts)
+		case EventWatchlist:
+			return channelTarget.channel.SendWatchlist(a.StatusChanges, a.Modifications, a.Deadlines)
+		}
+		return fmt.Errorf("unknown event %q", event)
+	}
+	return unknownTargetError(target)
+}
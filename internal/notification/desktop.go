@@ -0,0 +1,102 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// desktopMessageLimit bounds the text of a desktop notification, which only shows a few lines
+const desktopMessageLimit = 500
+
+// desktopAppName is the application desktop notifications are shown for
+const desktopAppName = "LED Screen Contract Scraper"
+
+// windowsToastAppID shows the toasts as Windows PowerShell, an app registered on every Windows 10 and
+// later machine; toasts of an unregistered app are silently dropped
+const windowsToastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// windowsToastScript shows a toast with the title and message of the SCRAPER_NOTIFY_TITLE and
+// SCRAPER_NOTIFY_MESSAGE environment variables, so they need no quoting
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:SCRAPER_NOTIFY_TITLE)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode($env:SCRAPER_NOTIFY_MESSAGE)) | Out-Null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + windowsToastAppID + `').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// DesktopChannel shows alerts as native notifications on the machine the scraper runs on: with
+// notify-send on Linux, osascript on macOS and a toast on Windows
+type DesktopChannel struct {
+	localized
+}
+
+// NewDesktopChannel creates a channel showing desktop notifications
+func NewDesktopChannel() *DesktopChannel {
+	return &DesktopChannel{}
+}
+
+// Name identifies the channel
+func (d *DesktopChannel) Name() string {
+	return "desktop"
+}
+
+// SendNewContracts shows one notification summarizing the new contracts of the run
+func (d *DesktopChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	return d.send(d.t("%d new LED screen contract(s)", len(contracts)), newContractLines(contracts))
+}
+
+// SendWatchlist shows one notification with the changes and deadlines of watched contracts
+func (d *DesktopChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	lines, _ := d.watchlistLines(updates, modified, deadlines)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return d.send(d.t("Watched LED screen contracts"), lines)
+}
+
+// send shows a notification with the notification command of the operating system
+func (d *DesktopChannel) send(title string, lines []string) error {
+	cmd, err := desktopCommand(runtime.GOOS, title, truncateText(strings.Join(lines, "\n"), desktopMessageLimit))
+	if err != nil {
+		return err
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if detail := strings.TrimSpace(string(output)); detail != "" {
+			return fmt.Errorf("failed to show desktop notification: %w: %s", err, detail)
+		}
+		return fmt.Errorf("failed to show desktop notification: %w", err)
+	}
+	return nil
+}
+
+// desktopCommand returns the command showing a notification on the operating system goos
+func desktopCommand(goos, title, message string) (*exec.Cmd, error) {
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", "--app-name="+desktopAppName, title, message), nil
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return exec.Command("osascript", "-e", script), nil
+	case "windows":
+		cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+		cmd.Env = append(os.Environ(), "SCRAPER_NOTIFY_TITLE="+title, "SCRAPER_NOTIFY_MESSAGE="+message)
+		return cmd, nil
+	}
+	return nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+}
+
+// appleScriptString quotes text as an AppleScript string literal
+func appleScriptString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
@@ -0,0 +1,38 @@
+package notification
+
+import "scraper/internal/scraper"
+
+// EmailChannel emails the alerts through an SMTP server of its own, with the templates of the main
+// email, e.g. to a second mailbox added on the dashboard settings page
+type EmailChannel struct {
+	notifier *Notifier
+}
+
+// NewEmailChannel creates a channel sending from fromEmail to toEmails through the given SMTP server.
+// The connection is secured as with SMTP_TLS=auto.
+func NewEmailChannel(smtpHost, smtpPort, smtpUsername, smtpPassword, fromEmail string, toEmails []string) *EmailChannel {
+	return &EmailChannel{notifier: NewNotifier(smtpHost, smtpPort, smtpUsername, smtpPassword, fromEmail, toEmails)}
+}
+
+// Name identifies the channel. It is not TargetEmail, which names the main email.
+func (e *EmailChannel) Name() string {
+	return "smtp"
+}
+
+// SetLanguage sets the language of the emails
+func (e *EmailChannel) SetLanguage(lang string) {
+	e.notifier.language = lang
+}
+
+// SendNewContracts emails the new contracts, as the main email does
+func (e *EmailChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+	return e.notifier.deliver(TargetEmail, EventNewContracts, alert{Contracts: contracts})
+}
+
+// SendWatchlist emails the status changes, modifications and upcoming deadlines of watched contracts
+func (e *EmailChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	return e.notifier.deliver(TargetEmail, EventWatchlist, alert{StatusChanges: updates, Modifications: modified, Deadlines: deadlines})
+}
@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventScrapeFailure is the type of the alerts sent when scraping keeps failing, see SendFailureAlert
+const EventScrapeFailure = "scrape_failure"
+
+// FailureAlert reports that scraping failed several runs in a row, or that it works again
+type FailureAlert struct {
+	Profile    string      `json:"profile,omitempty"`
+	Failures   int         `json:"failures"`        // Failed runs in a row
+	Since      time.Time   `json:"since"`           // Start of the first failed run
+	Error      string      `json:"error,omitempty"` // Error of the last failed run
+	Recovered  bool        `json:"recovered"`       // The last run succeeded after the failures
+	Screenshot *Attachment `json:"-"`               // Last screenshot of the failing page, nil if none
+}
+
+// FailureAlertSender is implemented by the channels that can post failure alerts
+type FailureAlertSender interface {
+	SendFailureAlert(alert FailureAlert) error
+}
+
+// SendFailureAlert sends a failure alert to the given targets, or to every target if there are none.
+// The screenshot is attached by email and by the channels that can post images. Failure alerts are
+// urgent, so they are not held, deduplicated or logged.
+func (n *Notifier) SendFailureAlert(targets []string, alert FailureAlert) error {
+	if len(targets) == 0 {
+		targets = n.targets(EventWatchlist)
+	}
+
+	var errs []error
+	for _, target := range targets {
+		if err := n.deliverFailureAlert(target, alert); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliverFailureAlert sends a failure alert to one target
+func (n *Notifier) deliverFailureAlert(target string, alert FailureAlert) error {
+	if target == TargetEmail {
+		if !n.emailEnabled() {
+			return fmt.Errorf("email is not configured")
+		}
+		var attachments []Attachment
+		if alert.Screenshot != nil {
+			attachments = append(attachments, *alert.Screenshot)
+		}
+		return n.sendTemplate(templateScrapeFailure, EmailData{Failure: &alert}, attachments...)
+	}
+
+	for _, channelTarget := range n.channelTargets() {
+		if channelTarget.name != target {
+			continue
+		}
+		sender, ok := channelTarget.channel.(FailureAlertSender)
+		if !ok {
+			return fmt.Errorf("channel %s cannot post failure alerts", target)
+		}
+		return sender.SendFailureAlert(alert)
+	}
+	return unknownTargetError(target)
+}
+
+// failureAlertText returns the title and the lines of a failure alert, for the text channels
+func (l *localized) failureAlertText(alert FailureAlert) (string, []string) {
+	var lines []string
+	if alert.Profile != "" {
+		lines = append(lines, l.t("Profile: %s", alert.Profile))
+	}
+	since := alert.Since.Local().Format("02/01/2006 15:04")
+	if alert.Recovered {
+		lines = append(lines, l.t("Scraping works again after %d failed runs since %s.", alert.Failures, since))
+		return "✅ " + l.t("Scraping recovered"), lines
+	}
+
+	lines = append(lines, l.t("The last %d runs failed, since %s. The portal may have changed.", alert.Failures, since))
+	if alert.Error != "" {
+		lines = append(lines, l.t("Last error: %s", truncateText(alert.Error, 500)))
+	}
+	return "🚨 " + l.t("Scraping is failing"), lines
+}
@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// gotifyMessageLimit bounds the message shown by the Gotify apps, which show long messages in full
+const gotifyMessageLimit = 4000
+
+// GotifyPriorities are the priorities, from 0 to 10, of the messages of each kind. The Android app
+// is silent up to 3, plays a sound from 4 and pops the notification up from 8.
+type GotifyPriorities struct {
+	NewContracts int // New contracts
+	Watchlist    int // Status changes and modifications of watched contracts
+	Deadline     int // Watchlist alerts with an upcoming deadline
+	Urgent       int // Watchlist alerts with a deadline tomorrow or earlier
+}
+
+// DefaultGotifyPriorities makes deadlines pop up and new contracts only play a sound
+var DefaultGotifyPriorities = GotifyPriorities{NewContracts: 5, Watchlist: 5, Deadline: 8, Urgent: 10}
+
+// ParseGotifyPriorities parses priorities written as comma-separated key=value pairs overriding the
+// defaults, e.g. "new_contracts=2,deadline=9". The keys are new_contracts, watchlist, deadline and urgent.
+func ParseGotifyPriorities(text string) (GotifyPriorities, error) {
+	priorities := DefaultGotifyPriorities
+	for _, pair := range strings.Split(text, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return priorities, fmt.Errorf("invalid priority %q: expected key=value", pair)
+		}
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || priority < 0 || priority > 10 {
+			return priorities, fmt.Errorf("invalid priority %q: expected a number from 0 to 10", pair)
+		}
+
+		switch strings.TrimSpace(key) {
+		case EventNewContracts:
+			priorities.NewContracts = priority
+		case EventWatchlist:
+			priorities.Watchlist = priority
+		case "deadline":
+			priorities.Deadline = priority
+		case "urgent":
+			priorities.Urgent = priority
+		default:
+			return priorities, fmt.Errorf("invalid priority %q: unknown key %q", pair, key)
+		}
+	}
+	return priorities, nil
+}
+
+// GotifyChannel sends alerts as push notifications through a self-hosted Gotify server
+type GotifyChannel struct {
+	localized
+	server     string
+	token      string // Application token
+	priorities GotifyPriorities
+}
+
+// NewGotifyChannel creates a channel sending to server with an application token
+func NewGotifyChannel(server, token string, priorities GotifyPriorities) *GotifyChannel {
+	return &GotifyChannel{server: strings.TrimSuffix(server, "/"), token: token, priorities: priorities}
+}
+
+// Name identifies the channel
+func (g *GotifyChannel) Name() string {
+	return "gotify"
+}
+
+// SendNewContracts sends one message listing the new contracts
+func (g *GotifyChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	return g.send(g.t("%d new LED screen contract(s)", len(contracts)), newContractLines(contracts), g.priorities.NewContracts, singleLink(contracts))
+}
+
+// SendWatchlist sends one message with the changes and deadlines of watched contracts, with the
+// deadline priority when a deadline is included and the urgent one when it falls tomorrow or earlier
+func (g *GotifyChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	lines, contracts := g.watchlistLines(updates, modified, deadlines)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	priority := g.priorities.Watchlist
+	if len(deadlines) > 0 {
+		priority = g.priorities.Deadline
+	}
+	if deadlineByTomorrow(deadlines, time.Now()) {
+		priority = g.priorities.Urgent
+	}
+	return g.send(g.t("Watched LED screen contracts"), lines, priority, singleLink(contracts))
+}
+
+// SendRunSummary sends a run summary, with the watchlist priority, or the deadline one if the run failed
+func (g *GotifyChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := g.runSummaryText(summary)
+	priority := g.priorities.Watchlist
+	if summary.Status == "failed" {
+		priority = g.priorities.Deadline
+	}
+	return g.send(title, lines, priority, "")
+}
+
+// SendFailureAlert sends a failure alert with the urgent priority, or the watchlist one once recovered
+func (g *GotifyChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := g.failureAlertText(alert)
+	priority := g.priorities.Urgent
+	if alert.Recovered {
+		priority = g.priorities.Watchlist
+	}
+	return g.send(title, lines, priority, "")
+}
+
+// send posts a message to the message API; link is opened when the notification is tapped
+func (g *GotifyChannel) send(title string, lines []string, priority int, link string) error {
+	message := map[string]interface{}{
+		"title":    title,
+		"message":  truncateText(strings.Join(lines, "\n"), gotifyMessageLimit),
+		"priority": priority,
+	}
+	if link != "" {
+		message["extras"] = map[string]interface{}{
+			"client::notification": map[string]interface{}{"click": map[string]string{"url": link}},
+		}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if err := postBody(g.server+"/message", "application/json", body, map[string]string{"X-Gotify-Key": g.token}); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// reservedHeaders are set by the notifier on every email and cannot be added with AddHeader
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Reply-To":                  true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+}
+
+// header is an extra header of the emails
+type header struct {
+	name  string
+	value string
+}
+
+// SetReplyTo makes replies to the emails go to address, or to a comma-separated list of addresses,
+// rather than to the sender
+func (n *Notifier) SetReplyTo(address string) error {
+	if _, err := mail.ParseAddressList(address); err != nil {
+		return fmt.Errorf("invalid Reply-To %q: %w", address, err)
+	}
+	n.replyTo = address
+	return nil
+}
+
+// AddHeader adds a header to every email, e.g. "X-Priority: 1" or "List-Unsubscribe:
+// <mailto:alerts@example.com?subject=unsubscribe>" for mail clients to offer an unsubscribe button
+func (n *Notifier) AddHeader(name, value string) error {
+	name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+	value = strings.TrimSpace(value)
+	if name == "" || strings.ContainsAny(name, " \t:\r\n") {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("invalid value of header %s: line breaks are not allowed", name)
+	}
+	if reservedHeaders[name] {
+		return fmt.Errorf("header %s is set by the scraper", name)
+	}
+	n.headers = append(n.headers, header{name: name, value: value})
+	return nil
+}
+
+// messageHeaders returns the headers of an email with the given subject, with a new Message-ID
+func (n *Notifier) messageHeaders(subject string) []string {
+	headers := []string{
+		fmt.Sprintf("From: %s", n.fromEmail),
+		fmt.Sprintf("To: %s", strings.Join(n.recipients(), ", ")),
+		fmt.Sprintf("Subject: %s", mime.QEncoding.Encode("utf-8", subject)),
+		fmt.Sprintf("Date: %s", time.Now().Format(time.RFC1123Z)),
+		fmt.Sprintf("Message-ID: %s", n.messageID()),
+	}
+	if n.replyTo != "" {
+		headers = append(headers, fmt.Sprintf("Reply-To: %s", n.replyTo))
+	}
+	for _, h := range n.headers {
+		headers = append(headers, fmt.Sprintf("%s: %s", h.name, mime.QEncoding.Encode("utf-8", h.value)))
+	}
+	return append(headers, "MIME-Version: 1.0")
+}
+
+// messageID returns a new globally unique Message-ID in the domain of the sender (RFC 5322 3.6.4)
+func (n *Notifier) messageID() string {
+	domain := "localhost"
+	if at := strings.LastIndex(n.fromEmail, "@"); at >= 0 && at < len(n.fromEmail)-1 {
+		domain = strings.TrimSuffix(n.fromEmail[at+1:], ">")
+	}
+
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
@@ -0,0 +1,191 @@
+package notification
+
+import (
+	"fmt"
+)
+
+// Languages notifications are written in. The contract data itself (descriptions, statuses) stays as
+// published on the portal, in Spanish.
+const (
+	LanguageEnglish = "en" // The default
+	LanguageSpanish = "es"
+)
+
+// translations holds the text of the notifications in every language but English, keyed by the
+// English text, which is used for the missing entries
+var translations = map[string]map[string]string{
+	LanguageSpanish: {
+		// Email templates
+		"New LED Screen Contracts Found":                                                        "Nuevos contratos de pantallas LED",
+		"New LED Screen Contracts Found (%d)":                                                   "Nuevos contratos de pantallas LED (%d)",
+		"We found %d new contract(s) for LED screens:":                                          "Hemos encontrado %d contrato(s) nuevo(s) de pantallas LED:",
+		"LED Screen Contracts Status Changed":                                                   "Cambios de estado en contratos de pantallas LED",
+		"LED Screen Contracts Status Changed (%d)":                                              "Cambios de estado en contratos de pantallas LED (%d)",
+		"Watched LED Screen Contracts: %d status change(s), %d modification(s), %d deadline(s)": "Contratos de pantallas LED vigilados: %d cambio(s) de estado, %d modificación(es), %d plazo(s)",
+		"Status Changes":          "Cambios de estado",
+		"Modified Tenders":        "Licitaciones modificadas",
+		"Upcoming Deadlines":      "Plazos próximos",
+		"Type:":                   "Tipo:",
+		"Status:":                 "Estado:",
+		"Amount:":                 "Importe:",
+		"Submission Date:":        "Fecha de presentación:",
+		"Contracting Body:":       "Órgano de contratación:",
+		"Documents:":              "Documentos:",
+		"Announcement (Anuncio)":  "Anuncio",
+		"Specifications (Pliego)": "Pliego",
+		"Download the Pliego":     "Descargar el pliego",
+		"View in the dashboard":   "Ver en el panel",
+		"View on the portal":      "Ver en el portal",
+		"You receive this email because these contracts are on your watchlist.":        "Recibes este correo porque estos contratos están en tu lista de seguimiento.",
+		"This notification was sent automatically by the LED Screen Contract Scraper.": "Esta notificación se ha enviado automáticamente desde el scraper de contratos de pantallas LED.",
+		"LED Screen Contracts Report":      "Informe de contratos de pantallas LED",
+		"LED Screen Contracts Report (%s)": "Informe de contratos de pantallas LED (%s)",
+		"The attached workbook lists the active contracts, the status changes of the last 24 hours and the upcoming submission deadlines.": "El libro adjunto recoge los contratos activos, los cambios de estado de las últimas 24 horas y los próximos plazos de presentación.",
+		"This report was sent automatically by the LED Screen Contract Scraper.":                                                           "Este informe se ha enviado automáticamente desde el scraper de contratos de pantallas LED.",
+
+		// Channels
+		"%d new LED screen contract(s)": "%d contrato(s) nuevo(s) de pantallas LED",
+		"Watched LED screen contracts":  "Contratos de pantallas LED vigilados",
+		"Watched LED screen contracts: %d status change(s), %d modification(s), %d deadline(s)": "Contratos de pantallas LED vigilados: %d cambio(s) de estado, %d modificación(es), %d plazo(s)",
+		"Deadline":              "Plazo",
+		"deadline %s":           "plazo %s",
+		"Status":                "Estado",
+		"Amount":                "Importe",
+		"Submission Date":       "Fecha de presentación",
+		"Dashboard":             "Panel",
+		"Open":                  "Abrir",
+		"Open in the dashboard": "Abrir en el panel",
+		"Open on the portal":    "Abrir en el portal",
+
+		// Calendar invites
+		"LED screen contract deadlines": "Plazos de contratos de pantallas LED",
+
+		// Run summaries
+		"Scrape run succeeded":                    "Ejecución del scraper correcta",
+		"Scrape run finished with errors":         "Ejecución del scraper terminada con errores",
+		"Scrape run failed":                       "Ejecución del scraper fallida",
+		"Profile: %s":                             "Perfil: %s",
+		"Contracts: %d found, %d new, %d changed": "Contratos: %d encontrados, %d nuevos, %d cambiados",
+		"Duration: %s (%s scraper)":               "Duración: %s (scraper %s)",
+		"Errors: %d":                              "Errores: %d",
+		"• … and %d more":                         "• … y %d más",
+		"Next run: %s":                            "Próxima ejecución: %s",
+		"Started:":                                "Inicio:",
+		"Duration:":                               "Duración:",
+		"Contracts:":                              "Contratos:",
+		"%d found, %d new, %d changed":            "%d encontrados, %d nuevos, %d cambiados",
+		"Next run:":                               "Próxima ejecución:",
+		"Errors":                                  "Errores",
+
+		// Failure alerts
+		"Scraping is failing": "El scraper está fallando",
+		"Scraping recovered":  "El scraper se ha recuperado",
+		"The last %d runs failed, since %s. The portal may have changed.": "Las últimas %d ejecuciones han fallado, desde el %s. Puede que el portal haya cambiado.",
+		"Scraping works again after %d failed runs since %s.":             "El scraper vuelve a funcionar tras %d ejecuciones fallidas desde el %s.",
+		"Last error: %s": "Último error: %s",
+		"Last error:":    "Último error:",
+		"The last screenshot of the portal is attached.": "Se adjunta la última captura de pantalla del portal.",
+
+		// Fields of modified contracts
+		"description":      "descripción",
+		"contract_type":    "tipo de contrato",
+		"amount":           "importe",
+		"submission_date":  "fecha de presentación",
+		"contracting_body": "órgano de contratación",
+		"link":             "enlace",
+		"pliego_link":      "enlace al pliego",
+		"anuncio_link":     "enlace al anuncio",
+	},
+}
+
+// translate returns text in the given language, formatted with args as by fmt.Sprintf if there are any
+func translate(lang, text string, args ...interface{}) string {
+	if translated, ok := translations[lang][text]; ok {
+		text = translated
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// checkLanguage fails for a language notifications cannot be written in
+func checkLanguage(lang string) error {
+	if lang == LanguageEnglish {
+		return nil
+	}
+	if _, ok := translations[lang]; !ok {
+		return fmt.Errorf("unsupported language %q, use %s or %s", lang, LanguageEnglish, LanguageSpanish)
+	}
+	return nil
+}
+
+// localized is embedded by the channels that write their messages in the language set by the notifier
+type localized struct {
+	lang string
+}
+
+// SetLanguage sets the language of the messages
+func (l *localized) SetLanguage(lang string) {
+	l.lang = lang
+}
+
+// t translates text into the language of the messages, see translate
+func (l *localized) t(text string, args ...interface{}) string {
+	return translate(l.lang, text, args...)
+}
+
+// languageSetter is implemented by the channels that can be localized
+type languageSetter interface {
+	SetLanguage(lang string)
+}
+
+// SetLanguage sets the language of the notifications to every target without one of its own
+func (n *Notifier) SetLanguage(lang string) error {
+	if err := checkLanguage(lang); err != nil {
+		return err
+	}
+	n.language = lang
+	n.applyLanguages()
+	return nil
+}
+
+// SetTargetLanguage sets the language of the notifications to one target, TargetEmail or a channel
+// target, e.g. Spanish emails next to an English Slack channel
+func (n *Notifier) SetTargetLanguage(target, lang string) error {
+	if err := checkLanguage(lang); err != nil {
+		return err
+	}
+	if n.targetLanguages == nil {
+		n.targetLanguages = make(map[string]string)
+	}
+	n.targetLanguages[target] = lang
+	n.applyLanguages()
+	return nil
+}
+
+// languageOf returns the language of the notifications to a target
+func (n *Notifier) languageOf(target string) string {
+	if lang, ok := n.targetLanguages[target]; ok {
+		return lang
+	}
+	if n.language != "" {
+		return n.language
+	}
+	return LanguageEnglish
+}
+
+// applyLanguages passes the language of every channel target on to the channel
+func (n *Notifier) applyLanguages() {
+	for _, target := range n.channelTargets() {
+		if channel, ok := target.channel.(languageSetter); ok {
+			channel.SetLanguage(n.languageOf(target.name))
+		}
+	}
+}
+
+// T translates text into the language of the email, formatted with args as by fmt.Sprintf. Templates
+// call it as {{$.T "Amount:"}} or {{$.T "We found %d new contract(s) for LED screens:" (len .Contracts)}}.
+func (d EmailData) T(text string, args ...interface{}) string {
+	return translate(d.Language, text, args...)
+}
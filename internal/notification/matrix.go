@@ -0,0 +1,192 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// matrixMessageLimit bounds the HTML of one message, keeping the event with its plain text version
+// under the 64 KiB limit of Matrix events; longer alerts are split
+const matrixMessageLimit = 24000
+
+// matrixTxnCounter makes the transaction IDs of the messages sent by this process unique
+var matrixTxnCounter int64
+
+// MatrixChannel sends alerts as messages to Matrix rooms, e.g. for Element users on a self-hosted homeserver
+type MatrixChannel struct {
+	localized
+	homeserver string
+	token      string   // Access token of the account the messages are sent from
+	roomIDs    []string // Internal room IDs ("!abc:example.org") the account has joined
+}
+
+// matrixItem is one contract of a message, as plain text and as HTML
+type matrixItem struct {
+	text string
+	html string
+}
+
+// NewMatrixChannel creates a channel posting to the rooms on homeserver with the access token
+func NewMatrixChannel(homeserver, token string, roomIDs []string) *MatrixChannel {
+	return &MatrixChannel{homeserver: strings.TrimSuffix(homeserver, "/"), token: token, roomIDs: roomIDs}
+}
+
+// Name identifies the channel
+func (m *MatrixChannel) Name() string {
+	return "matrix"
+}
+
+// SendNewContracts posts a summary of every new contract with its links
+func (m *MatrixChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	var items []matrixItem
+	for _, contract := range contracts {
+		items = append(items, matrixItem{
+			text: fmt.Sprintf("%s · %s\n%s\n%s · %s\n%s%s", contract.ID, contract.Status, contract.Description,
+				contract.Amount, contract.SubmissionDate, contract.ContractingBody, m.textLink(contract)),
+			html: fmt.Sprintf("<b>%s</b> · %s<br>%s<br>💶 %s · 📅 %s<br>🏛 %s%s",
+				html.EscapeString(contract.ID), html.EscapeString(contract.Status), html.EscapeString(contract.Description),
+				html.EscapeString(contract.Amount), html.EscapeString(contract.SubmissionDate),
+				html.EscapeString(contract.ContractingBody), m.links(contract)),
+		})
+	}
+	return m.send(m.t("%d new LED screen contract(s)", len(contracts)), "🆕", items)
+}
+
+// SendWatchlist posts the status changes, modifications and upcoming deadlines of watched contracts
+func (m *MatrixChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	var items []matrixItem
+	for _, update := range updates {
+		items = append(items, matrixItem{
+			text: fmt.Sprintf("%s: %s → %s%s", update.Contract.ID, update.OldStatus, update.NewStatus, m.textLink(update.Contract)),
+			html: fmt.Sprintf("🔄 <b>%s</b><br>%s → <b>%s</b>%s",
+				html.EscapeString(update.Contract.ID), html.EscapeString(update.OldStatus), html.EscapeString(update.NewStatus), m.links(update.Contract)),
+		})
+	}
+	for _, update := range modified {
+		items = append(items, matrixItem{
+			text: fmt.Sprintf("%s: %s %s → %s%s", update.Contract.ID, m.t(update.Field), update.OldValue, update.NewValue, m.textLink(update.Contract)),
+			html: fmt.Sprintf("✏️ <b>%s</b><br>%s: <del>%s</del> → <b>%s</b>%s",
+				html.EscapeString(update.Contract.ID), html.EscapeString(m.t(update.Field)), html.EscapeString(update.OldValue), html.EscapeString(update.NewValue), m.links(update.Contract)),
+		})
+	}
+	for _, contract := range deadlines {
+		items = append(items, matrixItem{
+			text: fmt.Sprintf("%s: %s\n%s%s", contract.ID, m.t("deadline %s", contract.SubmissionDate), contract.Description, m.textLink(contract)),
+			html: fmt.Sprintf("⏰ <b>%s</b><br>%s: <b>%s</b><br>%s%s",
+				html.EscapeString(contract.ID), html.EscapeString(m.t("Deadline")), html.EscapeString(contract.SubmissionDate), html.EscapeString(contract.Description), m.links(contract)),
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	return m.send(m.t("Watched LED screen contracts"), "👀", items)
+}
+
+// SendRunSummary posts a run summary
+func (m *MatrixChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := m.runSummaryText(summary)
+	var escaped []string
+	for _, line := range lines {
+		escaped = append(escaped, html.EscapeString(line))
+	}
+	return m.send(title, "", []matrixItem{{text: strings.Join(lines, "\n"), html: strings.Join(escaped, "<br>")}})
+}
+
+// SendFailureAlert posts a failure alert
+func (m *MatrixChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := m.failureAlertText(alert)
+	var escaped []string
+	for _, line := range lines {
+		escaped = append(escaped, html.EscapeString(line))
+	}
+	return m.send(title, "", []matrixItem{{text: strings.Join(lines, "\n"), html: strings.Join(escaped, "<br>")}})
+}
+
+// links formats the links to the contract in the dashboard, on the portal and its documents
+func (m *MatrixChannel) links(contract scraper.Contract) string {
+	var links []string
+	for _, link := range []struct{ label, url string }{
+		{m.t("Dashboard"), contract.DashboardLink},
+		{"Portal", contract.Link},
+		{"Pliego", contract.PliegoLink},
+		{"Anuncio", contract.AnuncioLink},
+	} {
+		if link.url != "" {
+			links = append(links, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link.url), link.label))
+		}
+	}
+	if len(links) == 0 {
+		return ""
+	}
+	return "<br>🔗 " + strings.Join(links, " · ")
+}
+
+// textLink formats the link of the plain text version of an item
+func (m *MatrixChannel) textLink(contract scraper.Contract) string {
+	if link := contractLink(contract); link != "" {
+		return "\n" + link
+	}
+	return ""
+}
+
+// send posts the title, after icon if any, and items to every room, split into as few messages as fit the length limit
+func (m *MatrixChannel) send(title, icon string, items []matrixItem) error {
+	var messages []matrixItem
+	current := matrixItem{text: title, html: "<b>" + html.EscapeString(title) + "</b>"}
+	if icon != "" {
+		current.html = icon + " " + current.html
+	}
+	for _, item := range items {
+		if current.html != "" && len(current.html)+len(item.html)+8 > matrixMessageLimit {
+			messages = append(messages, current)
+			current = matrixItem{}
+		}
+		if current.html != "" {
+			current.text += "\n\n"
+			current.html += "<br><br>"
+		}
+		current.text += item.text
+		current.html += item.html
+	}
+	messages = append(messages, current)
+
+	for _, roomID := range m.roomIDs {
+		for _, message := range messages {
+			if err := m.sendMessage(roomID, message); err != nil {
+				return fmt.Errorf("failed to send message to room %s: %w", roomID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sendMessage sends one m.notice message, which bots use so clients do not answer it, to a room
+func (m *MatrixChannel) sendMessage(roomID string, message matrixItem) error {
+	body, err := json.Marshal(map[string]string{
+		"msgtype":        "m.notice",
+		"body":           message.text,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": message.html,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	// The transaction ID lets the homeserver drop a message sent twice by a retried request
+	txnID := fmt.Sprintf("scraper-%d-%d", time.Now().UnixNano(), atomic.AddInt64(&matrixTxnCounter, 1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", m.homeserver, url.PathEscape(roomID), txnID)
+	return sendBody(http.MethodPut, endpoint, "application/json", body, map[string]string{"Authorization": "Bearer " + m.token})
+}
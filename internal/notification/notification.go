@@ -1,10 +1,18 @@
 package notification
 
 import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"html/template"
 	"log"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"strings"
+	"sync"
+	"time"
 
 	"scraper/internal/scraper"
 )
@@ -17,6 +25,41 @@ type Notifier struct {
 	smtpPassword string
 	fromEmail    string
 	toEmails     []string
+	replyTo      string             // Reply-To header, "" for none
+	headers      []header           // Extra headers of every email, see AddHeader
+	channels     []Channel          // Chat and push services notified alongside the email
+	templates    *template.Template // Email templates loaded by LoadTemplates, nil for the built-in ones
+
+	pliegoMode    string // How new contract emails offer the Pliego, see SetPliegoMode
+	pliegoMaxSize int64  // Largest Pliego attached, in bytes
+
+	tlsMode   string      // How the SMTP connection is secured, see SetTLS
+	tlsConfig *tls.Config // Certificate verification of the SMTP server, nil for the defaults
+
+	oauth2 *oauth2TokenSource // Access tokens for XOAUTH2, nil to log in with the password
+
+	dialTimeout  time.Duration // Connecting to the SMTP server, see SetTimeouts
+	ioTimeout    time.Duration // Each read and write on the SMTP connection
+	smtpMu       sync.Mutex    // Guards the connection kept open between messages
+	smtpClient   *smtp.Client  // Authenticated connection reused by the next message, nil if none
+	smtpLastUsed time.Time
+
+	deliveryLog DeliveryLog   // Records every delivery, nil to not record them
+	schedule    Schedule      // Quiet hours and hourly limit, see SetSchedule
+	filter      Rule          // New contracts notified to any target, see SetFilter
+	dedupWindow time.Duration // Items notified this recently are left out, see SetDedupWindow
+
+	// configMu guards toEmails, schedule and filter, which the dashboard settings change at runtime
+	configMu sync.RWMutex
+
+	dashboardChannels []channelTarget // Channels added on the dashboard settings page, see SetDashboardChannels
+	channelsMu        sync.RWMutex    // Guards channels and dashboardChannels, which the dashboard settings change too
+
+	dashboardURL    string // External base URL of the dashboard linked from alerts, "" for no links
+	calendarInvites bool   // Deadlines are attached as iCalendar files, see SetCalendarInvites
+
+	language        string            // Language of the notifications, see SetLanguage
+	targetLanguages map[string]string // Languages of the targets set by SetTargetLanguage
 }
 
 // NewNotifier creates a new notifier instance
@@ -31,130 +74,183 @@ func NewNotifier(smtpHost, smtpPort, smtpUsername, smtpPassword, fromEmail strin
 	}
 }
 
-// SendNewContractsNotification sends an email notification about new contracts
+// SetRecipients replaces the addresses the emails are sent to
+func (n *Notifier) SetRecipients(toEmails []string) {
+	n.configMu.Lock()
+	defer n.configMu.Unlock()
+	n.toEmails = append([]string(nil), toEmails...)
+}
+
+// recipients returns the addresses the emails are sent to
+func (n *Notifier) recipients() []string {
+	n.configMu.RLock()
+	defer n.configMu.RUnlock()
+	return append([]string(nil), n.toEmails...)
+}
+
+// SetFilter leaves out of the new contract notifications, to every target, the contracts not matching
+// the amount and keyword filters of rule. Its events are ignored; channel rules still apply on top.
+func (n *Notifier) SetFilter(rule Rule) {
+	n.configMu.Lock()
+	defer n.configMu.Unlock()
+	n.filter = rule
+}
+
+// SendNewContractsNotification notifies about new contracts by email and on every channel
 func (n *Notifier) SendNewContractsNotification(contracts []scraper.Contract) error {
-	if len(contracts) == 0 {
+	n.configMu.RLock()
+	filter := n.filter
+	n.configMu.RUnlock()
+
+	var matching []scraper.Contract
+	for _, contract := range contracts {
+		if filter.Matches(contract) {
+			matching = append(matching, contract)
+		}
+	}
+	if len(matching) == 0 {
 		return nil
 	}
 
-	subject := fmt.Sprintf("New LED Screen Contracts Found (%d)", len(contracts))
-	body := n.buildEmailBody(contracts)
+	return n.dispatch(EventNewContracts, alert{Contracts: matching})
+}
 
-	return n.sendEmail(subject, body)
+// StatusUpdate is a status change of a contract
+type StatusUpdate struct {
+	Contract  scraper.Contract `json:"contract"`
+	OldStatus string           `json:"old_status"`
+	NewStatus string           `json:"new_status"`
 }
 
-// sendEmail sends an email using SMTP
-func (n *Notifier) sendEmail(subject, body string) error {
-	auth := smtp.PlainAuth("", n.smtpUsername, n.smtpPassword, n.smtpHost)
+// FieldUpdate is a change to a field of a contract other than its status, e.g. its amount or deadline
+type FieldUpdate struct {
+	Contract scraper.Contract `json:"contract"`
+	Field    string           `json:"field"`
+	OldValue string           `json:"old_value"`
+	NewValue string           `json:"new_value"`
+}
 
-	// Build email headers
-	headers := []string{
-		fmt.Sprintf("From: %s", n.fromEmail),
-		fmt.Sprintf("To: %s", strings.Join(n.toEmails, ", ")),
-		fmt.Sprintf("Subject: %s", subject),
-		"MIME-Version: 1.0",
-		"Content-Type: text/html; charset=UTF-8",
-		"",
-		body,
+// SendWatchlistNotification notifies about status changes, modified fields and upcoming deadlines of
+// watched contracts by email and on every channel
+func (n *Notifier) SendWatchlistNotification(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	if len(updates) == 0 && len(modified) == 0 && len(deadlines) == 0 {
+		return nil
 	}
 
-	message := strings.Join(headers, "\r\n")
+	return n.dispatch(EventWatchlist, alert{StatusChanges: updates, Modifications: modified, Deadlines: deadlines})
+}
 
-	// Send email
-	err := smtp.SendMail(
-		n.smtpHost+":"+n.smtpPort,
-		auth,
-		n.fromEmail,
-		n.toEmails,
-		[]byte(message),
-	)
+// SendStatusChangeNotification emails the status changes of contracts. Watched contracts are left out
+// by the caller, as their changes are part of the watchlist notification.
+func (n *Notifier) SendStatusChangeNotification(updates []StatusUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return n.dispatch(EventStatusChanges, alert{StatusChanges: updates})
+}
+
+// Attachment is a file attached to a notification email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
 
+// SendReport emails a generated report as an attachment
+func (n *Notifier) SendReport(attachment Attachment) error {
+	return n.sendTemplate(templateReport, EmailData{Date: time.Now().Format("02/01/2006")}, attachment)
+}
+
+// sendTemplate renders the named email template with data and sends it
+func (n *Notifier) sendTemplate(name string, data EmailData, attachments ...Attachment) error {
+	subject, body, err := n.renderEmail(name, data)
 	if err != nil {
+		return err
+	}
+	return n.sendEmail(subject, body, attachments...)
+}
+
+// sendEmail sends an email using SMTP, as multipart/mixed when there are attachments
+func (n *Notifier) sendEmail(subject, body string, attachments ...Attachment) error {
+	// Build email headers
+	headers := n.messageHeaders(subject)
+
+	var message string
+	if len(attachments) == 0 {
+		headers = append(headers, "Content-Type: text/html; charset=UTF-8", "", body)
+		message = strings.Join(headers, "\r\n")
+	} else {
+		multipartBody, contentType, err := buildMultipartBody(body, attachments)
+		if err != nil {
+			return err
+		}
+		headers = append(headers, "Content-Type: "+contentType, "", "")
+		message = strings.Join(headers, "\r\n") + multipartBody
+	}
+
+	// Send email
+	if err := n.transmit([]byte(message)); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	log.Printf("Email notification sent to %s", strings.Join(n.toEmails, ", "))
+	log.Printf("Email notification sent to %s", strings.Join(n.recipients(), ", "))
 	return nil
 }
 
-// buildEmailBody creates the HTML email body
-func (n *Notifier) buildEmailBody(contracts []scraper.Contract) string {
-	var sb strings.Builder
-
-	sb.WriteString(`
-	<html>
-	<head>
-		<style>
-			body { font-family: Arial, sans-serif; margin: 20px; }
-			.contract { border: 1px solid #ddd; margin: 10px 0; padding: 15px; border-radius: 5px; }
-			.contract-id { font-weight: bold; color: #333; }
-			.contract-description { margin: 10px 0; }
-			.contract-details { color: #666; font-size: 14px; }
-			.amount { color: #2c5aa0; font-weight: bold; }
-			.status { color: #28a745; font-weight: bold; }
-		</style>
-	</head>
-	<body>
-		<h2>New LED Screen Contracts Found</h2>
-		<p>We found <strong>`)
-	sb.WriteString(fmt.Sprintf("%d", len(contracts)))
-	sb.WriteString(`</strong> new contract(s) for LED screens:</p>
-	`)
+// buildMultipartBody encodes the HTML body and the base64 attachments as a multipart/mixed body
+func buildMultipartBody(body string, attachments []Attachment) (string, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
 
-	for _, contract := range contracts {
-		sb.WriteString(`
-		<div class="contract">
-			<div class="contract-id">`)
-		sb.WriteString(contract.ID)
-		sb.WriteString(`</div>
-			<div class="contract-description">`)
-		sb.WriteString(contract.Description)
-		sb.WriteString(`</div>
-			<div class="contract-details">
-				<strong>Type:</strong> `)
-		sb.WriteString(contract.ContractType)
-		sb.WriteString(` | <strong>Status:</strong> <span class="status">`)
-		sb.WriteString(contract.Status)
-		sb.WriteString(`</span> | <strong>Amount:</strong> <span class="amount">`)
-		sb.WriteString(contract.Amount)
-		sb.WriteString(`</span><br>
-				<strong>Submission Date:</strong> `)
-		sb.WriteString(contract.SubmissionDate)
-		sb.WriteString(` | <strong>Contracting Body:</strong> `)
-		sb.WriteString(contract.ContractingBody)
-		sb.WriteString(`
-			</div>
-		</div>
-		`)
+	htmlPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/html; charset=UTF-8"},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create email body part: %w", err)
 	}
+	htmlPart.Write([]byte(body))
 
-	sb.WriteString(`
-		<p><small>This notification was sent automatically by the LED Screen Contract Scraper.</small></p>
-	</body>
-	</html>
-	`)
+	for _, attachment := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf(`attachment; filename="%s"`, attachment.Filename)},
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create attachment part: %w", err)
+		}
 
-	return sb.String()
+		// Wrap the base64 text at 76 characters as required by RFC 2045
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", "", fmt.Errorf("failed to finish email body: %w", err)
+	}
+
+	return buf.String(), "multipart/mixed; boundary=" + writer.Boundary(), nil
 }
 
 // TestConnection tests the email configuration
 func (n *Notifier) TestConnection() error {
 	log.Println("Testing email configuration...")
 
-	// Try to authenticate with SMTP server
-	auth := smtp.PlainAuth("", n.smtpUsername, n.smtpPassword, n.smtpHost)
-
-	// Create a test connection
-	addr := n.smtpHost + ":" + n.smtpPort
-	client, err := smtp.Dial(addr)
+	// Create a test connection, secured as it is when sending
+	client, err := n.dialSMTP()
 	if err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+		return err
 	}
 	defer client.Close()
 
 	// Authenticate
-	if err := client.Auth(auth); err != nil {
-		return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+	if err := n.authenticate(client); err != nil {
+		return err
 	}
 
 	log.Println("Email configuration test successful")
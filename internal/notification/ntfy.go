@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// DefaultNtfyServer is the public ntfy server, used when no server URL is configured
+const DefaultNtfyServer = "https://ntfy.sh"
+
+// ntfyMessageLimit is the maximum message length ntfy shows in full
+const ntfyMessageLimit = 4096
+
+// ntfy message priorities
+const (
+	ntfyPriorityDefault = 3
+	ntfyPriorityHigh    = 4
+)
+
+// NtfyChannel publishes alerts as push notifications to an ntfy topic
+type NtfyChannel struct {
+	localized
+	server string
+	topic  string
+	auth   string // Authorization header value, empty for public topics
+}
+
+// NewNtfyChannel creates a channel publishing to topic on server. Protected topics need either an
+// access token or a username and password.
+func NewNtfyChannel(server, topic, token, username, password string) *NtfyChannel {
+	channel := &NtfyChannel{server: strings.TrimSuffix(server, "/"), topic: topic}
+	if channel.server == "" {
+		channel.server = DefaultNtfyServer
+	}
+	switch {
+	case token != "":
+		channel.auth = "Bearer " + token
+	case username != "":
+		channel.auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	}
+	return channel
+}
+
+// Name identifies the channel
+func (n *NtfyChannel) Name() string {
+	return "ntfy"
+}
+
+// SendNewContracts publishes one notification listing the new contracts
+func (n *NtfyChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	return n.publish(n.t("%d new LED screen contract(s)", len(contracts)), newContractLines(contracts), ntfyPriorityDefault, "new", singleLink(contracts))
+}
+
+// SendWatchlist publishes one notification with the changes and deadlines of watched contracts; it
+// has high priority when a deadline is included
+func (n *NtfyChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	lines, contracts := n.watchlistLines(updates, modified, deadlines)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	priority := ntfyPriorityDefault
+	if len(deadlines) > 0 {
+		priority = ntfyPriorityHigh
+	}
+	return n.publish(n.t("Watched LED screen contracts"), lines, priority, "eyes", singleLink(contracts))
+}
+
+// SendRunSummary publishes a run summary; a failed run has high priority
+func (n *NtfyChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := n.runSummaryText(summary)
+	priority := ntfyPriorityDefault
+	if summary.Status == "failed" {
+		priority = ntfyPriorityHigh
+	}
+	return n.publish(title, lines, priority, "bar_chart", "")
+}
+
+// SendFailureAlert publishes a failure alert with high priority
+func (n *NtfyChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := n.failureAlertText(alert)
+	tag := "rotating_light"
+	if alert.Recovered {
+		tag = "white_check_mark"
+	}
+	return n.publish(title, lines, ntfyPriorityHigh, tag, "")
+}
+
+// publish sends a notification through the JSON publishing API; click is opened when it is tapped
+func (n *NtfyChannel) publish(title string, lines []string, priority int, tag, click string) error {
+	message := map[string]interface{}{
+		"topic":    n.topic,
+		"title":    title,
+		"message":  truncateText(strings.Join(lines, "\n"), ntfyMessageLimit),
+		"priority": priority,
+		"tags":     []string{tag},
+	}
+	if click != "" {
+		message["click"] = click
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	var headers map[string]string
+	if n.auth != "" {
+		headers = map[string]string{"Authorization": n.auth}
+	}
+	if err := postBody(n.server, "application/json", body, headers); err != nil {
+		return fmt.Errorf("failed to publish to topic %s: %w", n.topic, err)
+	}
+	return nil
+}
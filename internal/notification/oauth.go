@@ -0,0 +1,133 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryMargin renews an access token this long before it expires
+const oauth2ExpiryMargin = time.Minute
+
+// OAuth2Config is the OAuth2 client the notifier gets SMTP access tokens from. With a refresh token
+// the refresh_token grant is used (Gmail, Microsoft 365 with a user), otherwise the client_credentials
+// grant (Microsoft 365 with an application).
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	Scope        string // Optional, e.g. "https://outlook.office365.com/.default" for client_credentials
+}
+
+// oauth2TokenSource fetches access tokens and caches them until shortly before they expire
+type oauth2TokenSource struct {
+	config OAuth2Config
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// SetOAuth2 makes the notifier authenticate to the SMTP server with XOAUTH2 and access tokens from
+// config instead of the password
+func (n *Notifier) SetOAuth2(config OAuth2Config) error {
+	if config.TokenURL == "" || config.ClientID == "" {
+		return fmt.Errorf("OAuth2 needs a token URL and a client ID")
+	}
+	n.oauth2 = &oauth2TokenSource{config: config}
+	return nil
+}
+
+// Token returns a valid access token, fetching a new one if the cached one is about to expire
+func (s *oauth2TokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expiry) {
+		return s.token, nil
+	}
+
+	form := url.Values{"client_id": {s.config.ClientID}}
+	if s.config.ClientSecret != "" {
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+	if s.config.Scope != "" {
+		form.Set("scope", s.config.Scope)
+	}
+	if s.config.RefreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", s.config.RefreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	resp, err := httpClient.PostForm(s.config.TokenURL, form)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("failed to request OAuth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		RefreshToken     string `json:"refresh_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode OAuth2 token response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("OAuth2 token request failed (%s): %s %s", resp.Status, token.Error, token.ErrorDescription)
+	}
+
+	// Some providers rotate the refresh token; the new one replaces it for the rest of the process
+	if token.RefreshToken != "" {
+		s.config.RefreshToken = token.RefreshToken
+	}
+	s.token = token.AccessToken
+	s.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - oauth2ExpiryMargin)
+	return s.token, nil
+}
+
+// Forget drops the cached access token, e.g. after the server rejected it, so the next Token call fetches a new one
+func (s *oauth2TokenSource) Forget() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// xoauth2Auth implements the XOAUTH2 SASL mechanism of Gmail and Microsoft 365
+type xoauth2Auth struct {
+	username string
+	token    string
+	host     string
+}
+
+// Start sends the username and access token. Like smtp.PlainAuth it refuses to send the token over
+// an unencrypted connection, except to localhost.
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && a.host != "localhost" && a.host != "127.0.0.1" && a.host != "::1" {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+// Next fails on the error details the server sends when it rejects the token
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, fmt.Errorf("token rejected: %s", strings.TrimSpace(string(fromServer)))
+	}
+	return nil, nil
+}
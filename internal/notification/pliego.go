@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// How the Pliego (specifications) document of a contract is offered in new contract emails
+const (
+	PliegoModeNone   = ""       // Not at all, the default
+	PliegoModeButton = "button" // A download button linking to the portal
+	PliegoModeAttach = "attach" // The PDF is attached, with the button as fallback
+)
+
+// maxAttachmentsSize bounds the Pliego attachments of one email, keeping it under common mail server limits
+const maxAttachmentsSize = 15 << 20
+
+// pliegoClient downloads Pliego documents, which can take longer than a chat API call
+var pliegoClient = &http.Client{Timeout: 60 * time.Second}
+
+// unsafeFilenameChars matches the characters replaced in attachment filenames
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SetPliegoMode sets how new contract emails offer the Pliego of contracts with a known PliegoLink.
+// In PliegoModeAttach documents larger than maxSize bytes get the download button instead.
+func (n *Notifier) SetPliegoMode(mode string, maxSize int64) error {
+	switch mode {
+	case PliegoModeNone, PliegoModeButton, PliegoModeAttach:
+	default:
+		return fmt.Errorf("unknown Pliego mode %q", mode)
+	}
+	n.pliegoMode = mode
+	n.pliegoMaxSize = maxSize
+	return nil
+}
+
+// pliegoDocuments returns the Pliego attachments of the contracts and the IDs of the contracts that
+// get a download button instead
+func (n *Notifier) pliegoDocuments(contracts []scraper.Contract) ([]Attachment, map[string]bool) {
+	if n.pliegoMode == PliegoModeNone {
+		return nil, nil
+	}
+
+	var attachments []Attachment
+	buttons := make(map[string]bool)
+	var total int64
+	for _, contract := range contracts {
+		if contract.PliegoLink == "" {
+			continue
+		}
+		if n.pliegoMode == PliegoModeButton {
+			buttons[contract.ID] = true
+			continue
+		}
+
+		attachment, err := downloadPliego(contract, n.pliegoMaxSize)
+		if err == nil && total+int64(len(attachment.Data)) > maxAttachmentsSize {
+			err = fmt.Errorf("the email would exceed %d MB", maxAttachmentsSize>>20)
+		}
+		if err != nil {
+			log.Printf("Warning: Not attaching the Pliego of %s: %v", contract.ID, err)
+			buttons[contract.ID] = true
+			continue
+		}
+		attachments = append(attachments, attachment)
+		total += int64(len(attachment.Data))
+	}
+	return attachments, buttons
+}
+
+// downloadPliego downloads the Pliego PDF of a contract, failing if it is larger than maxSize bytes
+func downloadPliego(contract scraper.Contract, maxSize int64) (Attachment, error) {
+	resp, err := pliegoClient.Get(contract.PliegoLink)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Attachment{}, fmt.Errorf("failed to download: %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "text/") {
+		return Attachment{}, fmt.Errorf("not a PDF document (%s)", contentType)
+	}
+	if resp.ContentLength > maxSize {
+		return Attachment{}, fmt.Errorf("%d bytes is over the %d byte limit", resp.ContentLength, maxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to download: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return Attachment{}, fmt.Errorf("over the %d byte limit", maxSize)
+	}
+
+	filename := "pliego-" + contract.ID + ".pdf"
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		filename = params["filename"]
+	}
+	if contentType == "" || strings.HasPrefix(contentType, "application/octet-stream") {
+		contentType = "application/pdf"
+	}
+
+	return Attachment{
+		Filename:    strings.Trim(unsafeFilenameChars.ReplaceAllString(filename, "_"), "_"),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// pushoverAPIURL is the Pushover message API endpoint
+const pushoverAPIURL = "https://api.pushover.net/1/messages.json"
+
+// Pushover limits on the length of a message and of its title
+const (
+	pushoverMessageLimit = 1024
+	pushoverTitleLimit   = 250
+)
+
+// pushoverAttachmentLimit is the largest image attached to a notification; larger ones are left out
+const pushoverAttachmentLimit = 2500000
+
+// Pushover message priorities
+const (
+	pushoverPriorityNormal    = 0
+	pushoverPriorityHigh      = 1
+	pushoverPriorityEmergency = 2
+)
+
+// An emergency notification is repeated every pushoverRetry until it is acknowledged or
+// pushoverExpire has passed
+const (
+	pushoverRetry  = 5 * time.Minute
+	pushoverExpire = 2 * time.Hour
+)
+
+// PushoverChannel sends alerts as Pushover notifications
+type PushoverChannel struct {
+	localized
+	token string // Application API token
+	user  string // User or group key
+}
+
+// NewPushoverChannel creates a channel sending to the user or group key with the application token
+func NewPushoverChannel(token, user string) *PushoverChannel {
+	return &PushoverChannel{token: token, user: user}
+}
+
+// Name identifies the channel
+func (p *PushoverChannel) Name() string {
+	return "pushover"
+}
+
+// SendNewContracts sends one normal priority notification listing the new contracts
+func (p *PushoverChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	return p.send(p.t("%d new LED screen contract(s)", len(contracts)), newContractLines(contracts), pushoverPriorityNormal, singleLink(contracts))
+}
+
+// SendWatchlist sends one notification with the changes and deadlines of watched contracts. It has
+// high priority when a deadline is included, and emergency priority, repeated until acknowledged,
+// when a deadline falls tomorrow or earlier.
+func (p *PushoverChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	lines, contracts := p.watchlistLines(updates, modified, deadlines)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	priority := pushoverPriorityNormal
+	if len(deadlines) > 0 {
+		priority = pushoverPriorityHigh
+	}
+	if deadlineByTomorrow(deadlines, time.Now()) {
+		priority = pushoverPriorityEmergency
+	}
+	return p.send(p.t("Watched LED screen contracts"), lines, priority, singleLink(contracts))
+}
+
+// SendRunSummary sends a run summary; a failed run has high priority
+func (p *PushoverChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := p.runSummaryText(summary)
+	priority := pushoverPriorityNormal
+	if summary.Status == "failed" {
+		priority = pushoverPriorityHigh
+	}
+	return p.send(title, lines, priority, "")
+}
+
+// deadlineByTomorrow reports whether one of the contracts is due before the end of the day after now
+func deadlineByTomorrow(contracts []scraper.Contract, now time.Time) bool {
+	year, month, day := now.Date()
+	endOfTomorrow := time.Date(year, month, day+2, 0, 0, 0, 0, now.Location())
+	for _, contract := range contracts {
+		if contract.Deadline != nil && contract.Deadline.Before(endOfTomorrow) {
+			return true
+		}
+	}
+	return false
+}
+
+// SendFailureAlert sends a failure alert with high priority and the screenshot, if any
+func (p *PushoverChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := p.failureAlertText(alert)
+	priority := pushoverPriorityHigh
+	if alert.Recovered {
+		priority = pushoverPriorityNormal
+	}
+	return p.send(title, lines, priority, "", alert.Screenshot)
+}
+
+// send posts a notification to the message API; link is opened from the notification. An image
+// attachment is shown in the notification.
+func (p *PushoverChannel) send(title string, lines []string, priority int, link string, attachments ...*Attachment) error {
+	form := url.Values{
+		"token":    {p.token},
+		"user":     {p.user},
+		"title":    {truncateText(title, pushoverTitleLimit)},
+		"message":  {truncateText(strings.Join(lines, "\n"), pushoverMessageLimit)},
+		"priority": {strconv.Itoa(priority)},
+	}
+	if priority == pushoverPriorityEmergency {
+		form.Set("retry", strconv.Itoa(int(pushoverRetry.Seconds())))
+		form.Set("expire", strconv.Itoa(int(pushoverExpire.Seconds())))
+	}
+	if link != "" {
+		form.Set("url", link)
+		form.Set("url_title", p.t("Open"))
+	}
+
+	for _, attachment := range attachments {
+		if attachment == nil || len(attachment.Data) > pushoverAttachmentLimit {
+			continue
+		}
+		fields := make(map[string]string)
+		for name := range form {
+			fields[name] = form.Get(name)
+		}
+		if err := postMultipart(pushoverAPIURL, fields, "attachment", *attachment); err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		return nil
+	}
+
+	if err := postBody(pushoverAPIURL, "application/x-www-form-urlencoded", []byte(form.Encode()), nil); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package notification
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// Rule selects the alerts a channel receives. The zero rule matches everything. The amount and
+// keyword filters apply to new contracts only.
+type Rule struct {
+	Events    []string // Alert types to deliver; empty means all
+	MinAmount float64  // Skip contracts whose parsed amount is known and lower
+	Keywords  []string // Only contracts whose description contains one of them (case-insensitive)
+}
+
+// ParseRule parses a rule written as comma-separated key=value pairs, with "|" between the values
+// of a key, e.g. "events=new_contracts,min_amount=50000,keywords=videowall|pantalla"
+func ParseRule(text string) (Rule, error) {
+	var rule Rule
+	for _, pair := range strings.Split(text, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid rule %q: expected key=value", pair)
+		}
+
+		switch strings.TrimSpace(key) {
+		case "events":
+			for _, event := range splitValues(value) {
+				if event != EventNewContracts && event != EventWatchlist && event != EventRunSummary && event != EventScrapeFailure {
+					return rule, fmt.Errorf("invalid rule %q: unknown event %q", pair, event)
+				}
+				rule.Events = append(rule.Events, event)
+			}
+		case "min_amount":
+			amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return rule, fmt.Errorf("invalid rule %q: %w", pair, err)
+			}
+			rule.MinAmount = amount
+		case "keywords":
+			rule.Keywords = splitValues(strings.ToLower(value))
+		default:
+			return rule, fmt.Errorf("invalid rule %q: unknown key %q", pair, key)
+		}
+	}
+	return rule, nil
+}
+
+// splitValues splits the "|"-separated values of a rule key, dropping empty ones
+func splitValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// allowsEvent reports whether the rule delivers alerts of the given type
+func (r Rule) allowsEvent(event string) bool {
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, allowed := range r.Events {
+		if allowed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether an alert about the contract passes the amount and keyword filters
+func (r Rule) Matches(contract scraper.Contract) bool {
+	if r.MinAmount > 0 && contract.AmountValue > 0 && contract.AmountValue < r.MinAmount {
+		return false
+	}
+	if len(r.Keywords) == 0 {
+		return true
+	}
+	description := strings.ToLower(contract.Description)
+	for _, keyword := range r.Keywords {
+		if strings.Contains(description, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleChannel delivers to a channel only the alerts matching a rule
+type ruleChannel struct {
+	Channel
+	rule Rule
+}
+
+// WithRule returns a channel that passes on to channel only the alerts matching rule
+func WithRule(channel Channel, rule Rule) Channel {
+	return &ruleChannel{Channel: channel, rule: rule}
+}
+
+// SendRunSummary passes on a run summary if the rule selects them and the channel can post them
+func (c *ruleChannel) SendRunSummary(summary RunSummary) error {
+	if !c.rule.allowsEvent(EventRunSummary) {
+		return nil
+	}
+	sender, ok := c.Channel.(RunSummarySender)
+	if !ok {
+		return fmt.Errorf("channel %s cannot post run summaries", c.Name())
+	}
+	return sender.SendRunSummary(summary)
+}
+
+// SendFailureAlert passes on a failure alert if the rule selects them and the channel can post them
+func (c *ruleChannel) SendFailureAlert(alert FailureAlert) error {
+	if !c.rule.allowsEvent(EventScrapeFailure) {
+		return nil
+	}
+	sender, ok := c.Channel.(FailureAlertSender)
+	if !ok {
+		return fmt.Errorf("channel %s cannot post failure alerts", c.Name())
+	}
+	return sender.SendFailureAlert(alert)
+}
+
+// SetLanguage passes the language of the messages on to the channel, if it is localized
+func (c *ruleChannel) SetLanguage(lang string) {
+	if channel, ok := c.Channel.(languageSetter); ok {
+		channel.SetLanguage(lang)
+	}
+}
+
+// SendNewContracts passes on the new contracts matching the rule
+func (c *ruleChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if !c.rule.allowsEvent(EventNewContracts) {
+		return nil
+	}
+
+	var matching []scraper.Contract
+	for _, contract := range contracts {
+		if c.rule.Matches(contract) {
+			matching = append(matching, contract)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	return c.Channel.SendNewContracts(matching)
+}
+
+// SendWatchlist passes on the watchlist alerts if the rule selects them. Watched contracts are always
+// notified, so the amount and keyword filters do not apply.
+func (c *ruleChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	if !c.rule.allowsEvent(EventWatchlist) {
+		return nil
+	}
+	return c.Channel.SendWatchlist(updates, modified, deadlines)
+}
@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// RenderedEmail is an email rendered by RenderSample
+type RenderedEmail struct {
+	Template string // Template file name, e.g. "new_contracts.html"
+	Subject  string
+	Body     string // HTML
+}
+
+// sampleAlert is a made-up alert of one event, see sampleAlerts
+type sampleAlert struct {
+	event string
+	alert alert
+}
+
+// SampleContract returns a made-up contract for previewing notifications
+func SampleContract() scraper.Contract {
+	deadline := time.Now().AddDate(0, 0, 2)
+	return scraper.Contract{
+		ID:              "SAMPLE/2025/0001",
+		Expediente:      "SAMPLE/2025/0001",
+		Description:     "Servicio de mantenimiento de equipos informáticos (notificación de prueba)",
+		ContractType:    "Servicios",
+		Status:          "Publicada",
+		Amount:          "125.000,00 EUR",
+		SubmissionDate:  deadline.Format("02/01/2006 15:04"),
+		ContractingBody: "Ayuntamiento de Ejemplo",
+		Link:            "https://contrataciondelestado.es/wps/portal/licitaciones",
+		PliegoLink:      "https://contrataciondelestado.es/wps/portal/licitaciones",
+		AnuncioLink:     "https://contrataciondelestado.es/wps/portal/licitaciones",
+		AmountValue:     125000,
+		Deadline:        &deadline,
+		ScrapedAt:       time.Now(),
+		FirstSeenAt:     time.Now(),
+	}
+}
+
+// sampleAlerts returns an alert of every event built around contract: it is announced as new, changes
+// status, has its amount modified and its deadline coming up
+func sampleAlerts(contract scraper.Contract) []sampleAlert {
+	oldStatus := contract.Status
+	if oldStatus == "" {
+		oldStatus = "Publicada"
+	}
+	update := StatusUpdate{Contract: contract, OldStatus: oldStatus, NewStatus: "Adjudicada"}
+	modified := FieldUpdate{Contract: contract, Field: "amount", OldValue: "100.000,00 EUR", NewValue: contract.Amount}
+
+	return []sampleAlert{
+		{EventNewContracts, alert{Contracts: []scraper.Contract{contract}}},
+		{EventWatchlist, alert{StatusChanges: []StatusUpdate{update}, Modifications: []FieldUpdate{modified}, Deadlines: []scraper.Contract{contract}}},
+		{EventStatusChanges, alert{StatusChanges: []StatusUpdate{update}}},
+	}
+}
+
+// SendSample sends a sample of every notification built around contract to the email and every
+// channel. Samples skip the delivery log, the schedule and deduplication.
+func (n *Notifier) SendSample(contract scraper.Contract) error {
+	var errs []error
+	for _, sample := range sampleAlerts(contract) {
+		for _, target := range n.targets(sample.event) {
+			if err := n.deliver(target, sample.event, sample.alert); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", target, sample.event, err))
+				continue
+			}
+			fmt.Printf("📨 Sent the sample %s notification to %s\n", sample.event, target)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RenderSample renders every email template with samples built around contract, without sending
+// anything. The Pliego is offered as a button rather than downloaded.
+func (n *Notifier) RenderSample(contract scraper.Contract) ([]RenderedEmail, error) {
+	var emails []RenderedEmail
+	for _, sample := range sampleAlerts(contract) {
+		name, data, err := emailTemplate(sample.event, n.withDashboardLinks(sample.alert))
+		if err != nil {
+			return nil, err
+		}
+		if sample.event == EventNewContracts && n.pliegoMode != PliegoModeNone && contract.PliegoLink != "" {
+			data.PliegoButtons = map[string]bool{contract.ID: true}
+		}
+
+		subject, body, err := n.renderEmail(name, data)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, RenderedEmail{Template: name, Subject: subject, Body: body})
+	}
+
+	subject, body, err := n.renderEmail(templateReport, EmailData{Date: time.Now().Format("02/01/2006")})
+	if err != nil {
+		return nil, err
+	}
+	return append(emails, RenderedEmail{Template: templateReport, Subject: subject, Body: body}), nil
+}
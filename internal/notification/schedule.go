@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// Schedule limits when the notifier sends non-urgent alerts. Alerts due during quiet hours or over
+// the hourly limit are held in the delivery log and sent in one digest per target once allowed again.
+// Watchlist alerts with upcoming deadlines are urgent and always sent at once.
+type Schedule struct {
+	QuietFrom     int  // Start of the quiet hours, in minutes after midnight
+	QuietTo       int  // End of the quiet hours; equal to QuietFrom for none
+	QuietWeekends bool // Saturdays and Sundays are quiet all day
+	MaxPerHour    int  // Messages sent to a target per hour, 0 for no limit
+}
+
+// ParseQuietHours parses quiet hours such as "22:00-07:00" into minutes after midnight. The end may be
+// before the start, for quiet hours over midnight.
+func ParseQuietHours(value string) (from, to int, err error) {
+	var fromHour, fromMinute, toHour, toMinute int
+	if _, err := fmt.Sscanf(value, "%d:%d-%d:%d", &fromHour, &fromMinute, &toHour, &toMinute); err != nil {
+		return 0, 0, fmt.Errorf("invalid quiet hours %q, expected HH:MM-HH:MM", value)
+	}
+	for _, hour := range []int{fromHour, toHour} {
+		if hour < 0 || hour > 23 {
+			return 0, 0, fmt.Errorf("invalid quiet hours %q, hours go from 0 to 23", value)
+		}
+	}
+	for _, minute := range []int{fromMinute, toMinute} {
+		if minute < 0 || minute > 59 {
+			return 0, 0, fmt.Errorf("invalid quiet hours %q, minutes go from 0 to 59", value)
+		}
+	}
+	return fromHour*60 + fromMinute, toHour*60 + toMinute, nil
+}
+
+// SetSchedule sets the quiet hours and hourly limit of the notifier. Holding alerts needs a delivery
+// log, see SetDeliveryLog; without one they are sent at once.
+func (n *Notifier) SetSchedule(schedule Schedule) {
+	n.configMu.Lock()
+	defer n.configMu.Unlock()
+	n.schedule = schedule
+}
+
+// Quiet reports whether t falls in the quiet hours, in the local time zone
+func (s Schedule) Quiet(t time.Time) bool {
+	if s.QuietWeekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return true
+	}
+	if s.QuietFrom == s.QuietTo {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if s.QuietFrom < s.QuietTo {
+		return minute >= s.QuietFrom && minute < s.QuietTo
+	}
+	return minute >= s.QuietFrom || minute < s.QuietTo
+}
+
+// CanSend reports whether non-urgent alerts can be sent to a target now: it is not quiet hours and the
+// target is under the hourly limit
+func (n *Notifier) CanSend(target string) bool {
+	return n.holdReason(target) == ""
+}
+
+// holdReason returns why non-urgent alerts to a target are held now, or "" if they can be sent
+func (n *Notifier) holdReason(target string) string {
+	if n.deliveryLog == nil {
+		return ""
+	}
+	n.configMu.RLock()
+	schedule := n.schedule
+	n.configMu.RUnlock()
+
+	if schedule.Quiet(time.Now()) {
+		return "quiet hours"
+	}
+	if schedule.MaxPerHour > 0 {
+		sent, err := n.deliveryLog.CountSentNotifications(target, time.Now().Add(-time.Hour))
+		if err != nil {
+			log.Printf("Warning: Not limiting notifications to %s: %v", target, err)
+			return ""
+		}
+		if sent >= schedule.MaxPerHour {
+			return fmt.Sprintf("limit of %d per hour", schedule.MaxPerHour)
+		}
+	}
+	return ""
+}
+
+// urgent reports whether an alert is sent even during quiet hours or over the hourly limit
+func urgent(event string, a alert) bool {
+	return event == EventWatchlist && len(a.Deadlines) > 0
+}
+
+// hold queues an alert to a target in the delivery log for the next digest
+func (n *Notifier) hold(target, event string, a alert) error {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return n.deliveryLog.HoldNotification(target, event, payload)
+}
+
+// SendDigest sends the alerts held for a target as one notification and returns its payload, which is
+// to be queued for retry if sending failed
+func (n *Notifier) SendDigest(target, event string, payloads [][]byte) ([]byte, error) {
+	var digest alert
+	for _, payload := range payloads {
+		var a alert
+		if err := json.Unmarshal(payload, &a); err != nil {
+			return nil, fmt.Errorf("failed to decode notification: %w", err)
+		}
+		digest.Contracts = mergeContracts(digest.Contracts, a.Contracts)
+		digest.StatusChanges = append(digest.StatusChanges, a.StatusChanges...)
+		digest.Modifications = append(digest.Modifications, a.Modifications...)
+		digest.Deadlines = mergeContracts(digest.Deadlines, a.Deadlines)
+	}
+
+	payload, err := json.Marshal(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return payload, n.deliver(target, event, digest)
+}
+
+// mergeContracts appends contracts to merged, replacing the earlier version of a contract listed twice
+func mergeContracts(merged, contracts []scraper.Contract) []scraper.Contract {
+	for _, contract := range contracts {
+		replaced := false
+		for i := range merged {
+			if merged[i].ID == contract.ID {
+				merged[i] = contract
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, contract)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// signalMessageLimit is the longest message Signal shows in full, longer ones are sent as attachments
+const signalMessageLimit = 2000
+
+// SignalChannel sends alerts as Signal messages through a signal-cli REST API server
+// (https://github.com/bbernhard/signal-cli-rest-api) with a registered or linked number
+type SignalChannel struct {
+	localized
+	apiURL     string
+	number     string   // Number the messages are sent from, as registered with signal-cli
+	recipients []string // Phone numbers, usernames or group IDs ("group.…")
+}
+
+// NewSignalChannel creates a channel sending from number to the recipients through the REST API at apiURL
+func NewSignalChannel(apiURL, number string, recipients []string) *SignalChannel {
+	return &SignalChannel{apiURL: strings.TrimSuffix(apiURL, "/"), number: number, recipients: recipients}
+}
+
+// Name identifies the channel
+func (s *SignalChannel) Name() string {
+	return "signal"
+}
+
+// SendNewContracts sends one message listing the new contracts with their links
+func (s *SignalChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	lines := []string{s.t("%d new LED screen contract(s)", len(contracts))}
+	for i, line := range newContractLines(contracts) {
+		lines = append(lines, line)
+		if link := contractLink(contracts[i]); link != "" {
+			lines = append(lines, "  "+link)
+		}
+	}
+	return s.send(lines)
+}
+
+// SendWatchlist sends one message with the changes and deadlines of watched contracts
+func (s *SignalChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	lines, contracts := s.watchlistLines(updates, modified, deadlines)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	lines = append([]string{s.t("Watched LED screen contracts")}, lines...)
+	if link := singleLink(contracts); link != "" {
+		lines = append(lines, "", s.t("Open")+": "+link)
+	}
+	return s.send(lines)
+}
+
+// SendRunSummary sends a run summary
+func (s *SignalChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := s.runSummaryText(summary)
+	return s.send(append([]string{title}, lines...))
+}
+
+// SendFailureAlert sends a failure alert with the screenshot, if any
+func (s *SignalChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := s.failureAlertText(alert)
+	return s.send(append([]string{title}, lines...), alert.Screenshot)
+}
+
+// send posts a message, with the attachments, to every recipient through the v2 send endpoint
+func (s *SignalChannel) send(lines []string, attachments ...*Attachment) error {
+	payload := map[string]interface{}{
+		"number":     s.number,
+		"recipients": s.recipients,
+		"message":    truncateText(strings.Join(lines, "\n"), signalMessageLimit),
+	}
+	var encoded []string
+	for _, attachment := range attachments {
+		if attachment != nil {
+			encoded = append(encoded, fmt.Sprintf("data:%s;filename=%s;base64,%s",
+				attachment.ContentType, attachment.Filename, base64.StdEncoding.EncodeToString(attachment.Data)))
+		}
+	}
+	if len(encoded) > 0 {
+		payload["base64_attachments"] = encoded
+	}
+	if err := postJSON(s.apiURL+"/v2/send", payload); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// slackMaxBlocks is the maximum number of Block Kit blocks in one Slack message; longer alerts are split
+const slackMaxBlocks = 50
+
+// slackTextLimit is the maximum length of the text of a section block
+const slackTextLimit = 3000
+
+// SlackChannel posts alerts to a Slack channel through an incoming webhook
+type SlackChannel struct {
+	localized
+	webhookURL string
+}
+
+// NewSlackChannel creates a channel posting to the given incoming webhook URL
+func NewSlackChannel(webhookURL string) *SlackChannel {
+	return &SlackChannel{webhookURL: webhookURL}
+}
+
+// Name identifies the channel
+func (s *SlackChannel) Name() string {
+	return "slack"
+}
+
+// SendNewContracts posts a section with the summary and portal link of every new contract
+func (s *SlackChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	var blocks []map[string]interface{}
+	for _, contract := range contracts {
+		text := fmt.Sprintf("*%s* · %s\n%s\n:euro: %s · :date: %s · :classical_building: %s",
+			slackEscape(contract.ID), slackEscape(contract.Status), slackEscape(contract.Description),
+			slackEscape(contract.Amount), slackEscape(contract.SubmissionDate), slackEscape(contract.ContractingBody))
+		blocks = append(blocks, s.section(text, contractLink(contract)))
+	}
+
+	title := s.t("%d new LED screen contract(s)", len(contracts))
+	return s.send(title, blocks)
+}
+
+// SendWatchlist posts the status changes, modifications and upcoming deadlines of watched contracts
+func (s *SlackChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	var blocks []map[string]interface{}
+	for _, update := range updates {
+		text := fmt.Sprintf(":arrows_counterclockwise: *%s*\n%s → *%s*",
+			slackEscape(update.Contract.ID), slackEscape(update.OldStatus), slackEscape(update.NewStatus))
+		blocks = append(blocks, s.section(text, contractLink(update.Contract)))
+	}
+	for _, update := range modified {
+		text := fmt.Sprintf(":pencil2: *%s*\n%s: ~%s~ → *%s*",
+			slackEscape(update.Contract.ID), slackEscape(s.t(update.Field)), slackEscape(update.OldValue), slackEscape(update.NewValue))
+		blocks = append(blocks, s.section(text, contractLink(update.Contract)))
+	}
+	for _, contract := range deadlines {
+		text := fmt.Sprintf(":alarm_clock: *%s*\n%s: *%s*\n%s",
+			slackEscape(contract.ID), slackEscape(s.t("Deadline")), slackEscape(contract.SubmissionDate), slackEscape(contract.Description))
+		blocks = append(blocks, s.section(text, contractLink(contract)))
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	title := s.t("Watched LED screen contracts: %d status change(s), %d modification(s), %d deadline(s)", len(updates), len(modified), len(deadlines))
+	return s.send(title, blocks)
+}
+
+// SendRunSummary posts a run summary as one section
+func (s *SlackChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := s.runSummaryText(summary)
+	return s.send(title, []map[string]interface{}{s.section(slackEscape(strings.Join(lines, "\n")), "")})
+}
+
+// SendFailureAlert posts a failure alert as one section
+func (s *SlackChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := s.failureAlertText(alert)
+	return s.send(title, []map[string]interface{}{s.section(slackEscape(strings.Join(lines, "\n")), "")})
+}
+
+// section returns a section block with mrkdwn text and, when link is set, a button opening it
+func (s *SlackChannel) section(text, link string) map[string]interface{} {
+	block := map[string]interface{}{
+		"type": "section",
+		"text": map[string]interface{}{"type": "mrkdwn", "text": truncateText(text, slackTextLimit)},
+	}
+	if link != "" {
+		block["accessory"] = map[string]interface{}{
+			"type": "button",
+			"text": map[string]interface{}{"type": "plain_text", "text": s.t("Open")},
+			"url":  link,
+		}
+	}
+	return block
+}
+
+// send posts the blocks under a header, split into messages of at most slackMaxBlocks blocks. The
+// title is also the notification text shown where blocks are not rendered.
+func (s *SlackChannel) send(title string, blocks []map[string]interface{}) error {
+	header := map[string]interface{}{
+		"type": "header",
+		"text": map[string]interface{}{"type": "plain_text", "text": truncateText(title, 150)},
+	}
+
+	for start := 0; start < len(blocks); start += slackMaxBlocks - 1 {
+		end := start + slackMaxBlocks - 1
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+
+		message := append([]map[string]interface{}{header}, blocks[start:end]...)
+		if err := postJSON(s.webhookURL, map[string]interface{}{"text": title, "blocks": message}); err != nil {
+			return fmt.Errorf("failed to post message: %w", err)
+		}
+	}
+	return nil
+}
+
+// slackEscape escapes the characters Slack gives a meaning in mrkdwn text
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
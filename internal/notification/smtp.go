@@ -0,0 +1,247 @@
+package notification
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+)
+
+// How the connection to the SMTP server is secured
+const (
+	SMTPTLSAuto     = "auto"     // Implicit TLS on port 465, otherwise STARTTLS if the server offers it (the default)
+	SMTPTLSStartTLS = "starttls" // STARTTLS, failing if the server does not offer it
+	SMTPTLSImplicit = "tls"      // TLS from the start of the connection, usually on port 465
+	SMTPTLSNone     = "none"     // Plain text; password authentication is then refused except to localhost
+)
+
+// Default SMTP timeouts, used when SetTimeouts is not called
+const (
+	DefaultSMTPDialTimeout = 10 * time.Second // Connecting to the server
+	DefaultSMTPTimeout     = 30 * time.Second // Each read and write, e.g. waiting for a reply
+)
+
+// smtpIdleTimeout closes a connection kept open between messages once it has been idle this long,
+// well before servers drop idle clients
+const smtpIdleTimeout = time.Minute
+
+// SetTimeouts sets how long connecting to the SMTP server and each read or write may take. A zero
+// duration keeps the default.
+func (n *Notifier) SetTimeouts(dial, io time.Duration) {
+	n.dialTimeout = dial
+	n.ioTimeout = io
+}
+
+// SetTLS sets how the SMTP connection is secured. Server certificates are verified against the system
+// roots plus the PEM certificates in caFile, if set; insecureSkipVerify accepts any certificate.
+func (n *Notifier) SetTLS(mode string, insecureSkipVerify bool, caFile string) error {
+	switch mode {
+	case "", SMTPTLSAuto, SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return fmt.Errorf("unknown SMTP TLS mode %q", mode)
+	}
+
+	config := &tls.Config{ServerName: n.smtpHost, InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		config.RootCAs = roots
+	}
+
+	n.tlsMode = mode
+	n.tlsConfig = config
+	return nil
+}
+
+// dialSMTP connects to the SMTP server, securing the connection as set by SetTLS
+func (n *Notifier) dialSMTP() (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.smtpHost, n.smtpPort)
+	config := n.tlsConfig
+	if config == nil {
+		config = &tls.Config{ServerName: n.smtpHost}
+	}
+
+	mode := n.tlsMode
+	if (mode == "" || mode == SMTPTLSAuto) && n.smtpPort == "465" {
+		mode = SMTPTLSImplicit
+	}
+
+	dialer := net.Dialer{Timeout: orDefault(n.dialTimeout, DefaultSMTPDialTimeout)}
+	rawConn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	var conn net.Conn = &timeoutConn{Conn: rawConn, timeout: orDefault(n.ioTimeout, DefaultSMTPTimeout)}
+
+	if mode == SMTPTLSImplicit {
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, n.smtpHost)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	if mode == SMTPTLSNone || mode == SMTPTLSImplicit {
+		return client, nil
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(config); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	} else if mode == SMTPTLSStartTLS {
+		client.Close()
+		return nil, fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+	}
+	return client, nil
+}
+
+// authenticate logs in to the SMTP server when a username is configured, with XOAUTH2 if SetOAuth2
+// was called and the password otherwise
+func (n *Notifier) authenticate(client *smtp.Client) error {
+	if n.smtpUsername == "" {
+		return nil
+	}
+
+	auth := smtp.PlainAuth("", n.smtpUsername, n.smtpPassword, n.smtpHost)
+	if n.oauth2 != nil {
+		token, err := n.oauth2.Token()
+		if err != nil {
+			return err
+		}
+		auth = &xoauth2Auth{username: n.smtpUsername, token: token, host: n.smtpHost}
+	}
+
+	if err := client.Auth(auth); err != nil {
+		if n.oauth2 != nil {
+			n.oauth2.Forget()
+		}
+		return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+	}
+	return nil
+}
+
+// transmit sends a message from fromEmail to every recipient. The authenticated connection is kept
+// open for the next messages until it has been idle for smtpIdleTimeout or Close is called.
+func (n *Notifier) transmit(message []byte) error {
+	n.smtpMu.Lock()
+	defer n.smtpMu.Unlock()
+
+	client, err := n.session()
+	if err != nil {
+		return err
+	}
+	if err := n.sendMessage(client, message); err != nil {
+		// The connection may be out of step after a failed command, so the next message opens a new one
+		n.smtpClient.Close()
+		n.smtpClient = nil
+		return err
+	}
+	n.smtpLastUsed = time.Now()
+	return nil
+}
+
+// session returns the open authenticated connection, or opens one. A connection that was idle too
+// long or fails to reset is replaced.
+func (n *Notifier) session() (*smtp.Client, error) {
+	if n.smtpClient != nil {
+		if time.Since(n.smtpLastUsed) < smtpIdleTimeout && n.smtpClient.Reset() == nil {
+			return n.smtpClient, nil
+		}
+		n.smtpClient.Close()
+		n.smtpClient = nil
+	}
+
+	client, err := n.dialSMTP()
+	if err != nil {
+		return nil, err
+	}
+	if err := n.authenticate(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	n.smtpClient = client
+	return client, nil
+}
+
+// Close ends the SMTP connection kept open between messages, if any
+func (n *Notifier) Close() error {
+	n.smtpMu.Lock()
+	defer n.smtpMu.Unlock()
+
+	if n.smtpClient == nil {
+		return nil
+	}
+	err := n.smtpClient.Quit()
+	n.smtpClient.Close()
+	n.smtpClient = nil
+	return err
+}
+
+// sendMessage sends one message over an authenticated connection
+func (n *Notifier) sendMessage(client *smtp.Client, message []byte) error {
+	if err := client.Mail(n.fromEmail); err != nil {
+		return fmt.Errorf("sender %s rejected: %w", n.fromEmail, err)
+	}
+	for _, to := range n.recipients() {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return nil
+}
+
+// timeoutConn gives every read and write its own deadline, so a server that stops responding fails
+// the command instead of blocking forever
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Write(b)
+}
+
+// orDefault returns d, or fallback when d is not set
+func orDefault(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventRunSummary is the type of the run summaries sent to operators, see SendRunSummary
+const EventRunSummary = "run_summary"
+
+// runSummaryErrorLimit bounds the errors listed in a run summary
+const runSummaryErrorLimit = 5
+
+// RunSummary is the outcome of a scrape run, reported to operators so they know the pipeline is alive
+type RunSummary struct {
+	RunID            int64      `json:"run_id,omitempty"`
+	Profile          string     `json:"profile,omitempty"`
+	ScraperType      string     `json:"scraper_type"`
+	Status           string     `json:"status"` // success, partial or failed
+	StartedAt        time.Time  `json:"started_at"`
+	FinishedAt       time.Time  `json:"finished_at"`
+	ContractsFound   int        `json:"contracts_found"`
+	ContractsNew     int        `json:"contracts_new"`
+	ContractsChanged int        `json:"contracts_changed"`
+	Errors           []string   `json:"errors,omitempty"`
+	NextRunAt        *time.Time `json:"next_run_at,omitempty"` // When the next run is expected, if known
+}
+
+// Duration returns how long the run took
+func (s RunSummary) Duration() time.Duration {
+	return s.FinishedAt.Sub(s.StartedAt).Round(time.Second)
+}
+
+// RunSummarySender is implemented by the channels that can post run summaries
+type RunSummarySender interface {
+	SendRunSummary(summary RunSummary) error
+}
+
+// SendRunSummary sends a run summary to the given targets only, TargetEmail or channel targets, so
+// contract alerts and operations can go to different places. Run summaries are not logged, held or
+// retried.
+func (n *Notifier) SendRunSummary(targets []string, summary RunSummary) error {
+	var errs []error
+	for _, target := range targets {
+		if err := n.deliverRunSummary(target, summary); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliverRunSummary sends a run summary to one target
+func (n *Notifier) deliverRunSummary(target string, summary RunSummary) error {
+	if target == TargetEmail {
+		if !n.emailEnabled() {
+			return fmt.Errorf("email is not configured")
+		}
+		return n.sendTemplate(templateRunSummary, EmailData{Run: &summary})
+	}
+
+	for _, channelTarget := range n.channelTargets() {
+		if channelTarget.name != target {
+			continue
+		}
+		sender, ok := channelTarget.channel.(RunSummarySender)
+		if !ok {
+			return fmt.Errorf("channel %s cannot post run summaries", target)
+		}
+		return sender.SendRunSummary(summary)
+	}
+	return unknownTargetError(target)
+}
+
+// runSummaryText returns the title and the lines of a run summary, for the text channels
+func (l *localized) runSummaryText(summary RunSummary) (string, []string) {
+	title := "✅ " + l.t("Scrape run succeeded")
+	switch summary.Status {
+	case "partial":
+		title = "⚠️ " + l.t("Scrape run finished with errors")
+	case "failed":
+		title = "❌ " + l.t("Scrape run failed")
+	}
+
+	var lines []string
+	if summary.Profile != "" {
+		lines = append(lines, l.t("Profile: %s", summary.Profile))
+	}
+	lines = append(lines,
+		l.t("Contracts: %d found, %d new, %d changed", summary.ContractsFound, summary.ContractsNew, summary.ContractsChanged),
+		l.t("Duration: %s (%s scraper)", summary.Duration(), summary.ScraperType),
+	)
+	if len(summary.Errors) > 0 {
+		lines = append(lines, l.t("Errors: %d", len(summary.Errors)))
+		for i, message := range summary.Errors {
+			if i == runSummaryErrorLimit {
+				lines = append(lines, l.t("• … and %d more", len(summary.Errors)-i))
+				break
+			}
+			lines = append(lines, "• "+truncateText(message, 300))
+		}
+	}
+	if summary.NextRunAt != nil {
+		lines = append(lines, l.t("Next run: %s", summary.NextRunAt.Local().Format("02/01/2006 15:04")))
+	}
+	return title, lines
+}
@@ -0,0 +1,161 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// teamsSectionsPerCard bounds the sections of one card, keeping it well under the Teams size limit
+const teamsSectionsPerCard = 10
+
+// teamsThemeColor is the accent color of the cards
+const teamsThemeColor = "FF6600"
+
+// TeamsChannel posts alerts as connector cards to a Microsoft Teams incoming webhook
+type TeamsChannel struct {
+	localized
+	webhookURL string
+}
+
+// NewTeamsChannel creates a channel posting to the given Teams incoming webhook URL
+func NewTeamsChannel(webhookURL string) *TeamsChannel {
+	return &TeamsChannel{webhookURL: webhookURL}
+}
+
+// Name identifies the channel
+func (t *TeamsChannel) Name() string {
+	return "teams"
+}
+
+// teamsSection is a section of a connector card about one contract
+type teamsSection struct {
+	ActivityTitle    string        `json:"activityTitle"`
+	ActivitySubtitle string        `json:"activitySubtitle,omitempty"`
+	Text             string        `json:"text,omitempty"`
+	Facts            []teamsFact   `json:"facts,omitempty"`
+	PotentialAction  []teamsAction `json:"potentialAction,omitempty"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	Targets []teamsTarget `json:"targets"`
+}
+
+type teamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+// SendNewContracts posts a card with a section per new contract
+func (t *TeamsChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	var sections []teamsSection
+	for _, contract := range contracts {
+		sections = append(sections, teamsSection{
+			ActivityTitle:    contract.ID,
+			ActivitySubtitle: contract.ContractingBody,
+			Text:             contract.Description,
+			Facts: []teamsFact{
+				{t.t("Status"), contract.Status},
+				{t.t("Amount"), contract.Amount},
+				{t.t("Submission Date"), contract.SubmissionDate},
+			},
+			PotentialAction: t.links(contract),
+		})
+	}
+	return t.send(t.t("%d new LED screen contract(s)", len(contracts)), sections)
+}
+
+// SendWatchlist posts a card with the status changes, modifications and upcoming deadlines of watched contracts
+func (t *TeamsChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	var sections []teamsSection
+	for _, update := range updates {
+		sections = append(sections, teamsSection{
+			ActivityTitle:   update.Contract.ID,
+			Text:            fmt.Sprintf("%s: %s → **%s**", t.t("Status"), update.OldStatus, update.NewStatus),
+			PotentialAction: t.links(update.Contract),
+		})
+	}
+	for _, update := range modified {
+		sections = append(sections, teamsSection{
+			ActivityTitle:   update.Contract.ID,
+			Text:            fmt.Sprintf("%s: ~~%s~~ → **%s**", t.t(update.Field), update.OldValue, update.NewValue),
+			PotentialAction: t.links(update.Contract),
+		})
+	}
+	for _, contract := range deadlines {
+		sections = append(sections, teamsSection{
+			ActivityTitle:   contract.ID,
+			Text:            fmt.Sprintf("%s: **%s**\n\n%s", t.t("Deadline"), contract.SubmissionDate, contract.Description),
+			PotentialAction: t.links(contract),
+		})
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+
+	title := t.t("Watched LED screen contracts: %d status change(s), %d modification(s), %d deadline(s)", len(updates), len(modified), len(deadlines))
+	return t.send(title, sections)
+}
+
+// SendRunSummary posts a card with a run summary
+func (t *TeamsChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := t.runSummaryText(summary)
+	return t.send(title, []teamsSection{{ActivityTitle: summary.StartedAt.Local().Format("02/01/2006 15:04"), Text: strings.Join(lines, "\n\n")}})
+}
+
+// SendFailureAlert posts a card with a failure alert
+func (t *TeamsChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := t.failureAlertText(alert)
+	return t.send(title, []teamsSection{{ActivityTitle: title, Text: strings.Join(lines, "\n\n")}})
+}
+
+// links returns the buttons opening the contract in the dashboard, on the portal and its documents
+func (t *TeamsChannel) links(contract scraper.Contract) []teamsAction {
+	var actions []teamsAction
+	for _, link := range []struct{ label, url string }{
+		{t.t("Open in the dashboard"), contract.DashboardLink},
+		{t.t("Open on the portal"), contract.Link},
+		{"Pliego", contract.PliegoLink},
+		{"Anuncio", contract.AnuncioLink},
+	} {
+		if link.url != "" {
+			actions = append(actions, teamsAction{Type: "OpenUri", Name: link.label, Targets: []teamsTarget{{OS: "default", URI: link.url}}})
+		}
+	}
+	return actions
+}
+
+// send posts the sections as one or more cards of at most teamsSectionsPerCard sections
+func (t *TeamsChannel) send(title string, sections []teamsSection) error {
+	for start := 0; start < len(sections); start += teamsSectionsPerCard {
+		end := start + teamsSectionsPerCard
+		if end > len(sections) {
+			end = len(sections)
+		}
+
+		card := map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    title,
+			"title":      title,
+			"themeColor": teamsThemeColor,
+			"sections":   sections[start:end],
+		}
+		if err := postJSON(t.webhookURL, card); err != nil {
+			return fmt.Errorf("failed to post card: %w", err)
+		}
+	}
+	return nil
+}
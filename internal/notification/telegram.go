@@ -0,0 +1,164 @@
+package notification
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// telegramAPI is the base URL of the Telegram Bot API
+const telegramAPI = "https://api.telegram.org"
+
+// telegramMessageLimit is the maximum length of a Telegram message; longer alerts are split
+const telegramMessageLimit = 4096
+
+// telegramCaptionLimit is the maximum length of the caption of a photo
+const telegramCaptionLimit = 1024
+
+// telegramDescriptionLimit bounds the description shown per contract, so every item fits a message
+const telegramDescriptionLimit = 1000
+
+// TelegramChannel sends alerts through a Telegram bot to one or more chats
+type TelegramChannel struct {
+	localized
+	token   string
+	chatIDs []string
+}
+
+// NewTelegramChannel creates a channel for the bot with the given token. The chats are user, group
+// or channel IDs (or @channelname) the bot is allowed to post in.
+func NewTelegramChannel(token string, chatIDs []string) *TelegramChannel {
+	return &TelegramChannel{token: token, chatIDs: chatIDs}
+}
+
+// Name identifies the channel
+func (t *TelegramChannel) Name() string {
+	return "telegram"
+}
+
+// SendNewContracts posts a summary of every new contract with links to the portal
+func (t *TelegramChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	var items []string
+	for _, contract := range contracts {
+		items = append(items, t.contract(contract))
+	}
+	return t.send("🆕 <b>"+html.EscapeString(t.t("%d new LED screen contract(s)", len(contracts)))+"</b>", items)
+}
+
+// SendWatchlist posts the status changes, modifications and upcoming deadlines of watched contracts
+func (t *TelegramChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	var items []string
+	for _, update := range updates {
+		items = append(items, fmt.Sprintf("🔄 <b>%s</b>\n%s → <b>%s</b>%s",
+			html.EscapeString(update.Contract.ID), html.EscapeString(update.OldStatus), html.EscapeString(update.NewStatus), t.links(update.Contract)))
+	}
+	for _, update := range modified {
+		items = append(items, fmt.Sprintf("✏️ <b>%s</b>\n%s: <s>%s</s> → <b>%s</b>%s",
+			html.EscapeString(update.Contract.ID), html.EscapeString(t.t(update.Field)), html.EscapeString(update.OldValue), html.EscapeString(update.NewValue), t.links(update.Contract)))
+	}
+	for _, contract := range deadlines {
+		items = append(items, fmt.Sprintf("⏰ <b>%s</b>\n%s: <b>%s</b>\n%s%s",
+			html.EscapeString(contract.ID), html.EscapeString(t.t("Deadline")), html.EscapeString(contract.SubmissionDate), html.EscapeString(truncateText(contract.Description, telegramDescriptionLimit)), t.links(contract)))
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	return t.send("👀 <b>"+html.EscapeString(t.t("Watched LED screen contracts"))+"</b>", items)
+}
+
+// SendRunSummary posts a run summary
+func (t *TelegramChannel) SendRunSummary(summary RunSummary) error {
+	title, lines := t.runSummaryText(summary)
+	return t.send("<b>"+html.EscapeString(title)+"</b>", []string{html.EscapeString(strings.Join(lines, "\n"))})
+}
+
+// SendFailureAlert posts a failure alert, as the caption of the screenshot if there is one
+func (t *TelegramChannel) SendFailureAlert(alert FailureAlert) error {
+	title, lines := t.failureAlertText(alert)
+	text := "<b>" + html.EscapeString(title) + "</b>\n\n" + html.EscapeString(strings.Join(lines, "\n"))
+	if alert.Screenshot == nil {
+		return t.send(text, nil)
+	}
+
+	for _, chatID := range t.chatIDs {
+		err := postMultipart(fmt.Sprintf("%s/bot%s/sendPhoto", telegramAPI, t.token), map[string]string{
+			"chat_id":    chatID,
+			"caption":    truncateText(text, telegramCaptionLimit),
+			"parse_mode": "HTML",
+		}, "photo", *alert.Screenshot)
+		if err != nil {
+			return fmt.Errorf("failed to send photo to chat %s: %w", chatID, err)
+		}
+	}
+	return nil
+}
+
+// contract formats the summary of a contract
+func (t *TelegramChannel) contract(contract scraper.Contract) string {
+	return fmt.Sprintf("<b>%s</b> · %s\n%s\n💶 %s · 📅 %s\n🏛 %s%s",
+		html.EscapeString(contract.ID),
+		html.EscapeString(contract.Status),
+		html.EscapeString(truncateText(contract.Description, telegramDescriptionLimit)),
+		html.EscapeString(contract.Amount),
+		html.EscapeString(contract.SubmissionDate),
+		html.EscapeString(contract.ContractingBody),
+		t.links(contract))
+}
+
+// links formats the links to the contract in the dashboard, on the portal and its documents
+func (t *TelegramChannel) links(contract scraper.Contract) string {
+	var links []string
+	for _, link := range []struct{ label, url string }{
+		{t.t("Dashboard"), contract.DashboardLink},
+		{"Portal", contract.Link},
+		{"Pliego", contract.PliegoLink},
+		{"Anuncio", contract.AnuncioLink},
+	} {
+		if link.url != "" {
+			links = append(links, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link.url), link.label))
+		}
+	}
+	if len(links) == 0 {
+		return ""
+	}
+	return "\n🔗 " + strings.Join(links, " · ")
+}
+
+// send posts the header and items to every chat, split into as few messages as fit the length limit
+func (t *TelegramChannel) send(header string, items []string) error {
+	var messages []string
+	current := header
+	for _, item := range items {
+		if len(current)+2+len(item) > telegramMessageLimit {
+			messages = append(messages, current)
+			current = ""
+		}
+		if current != "" {
+			current += "\n\n"
+		}
+		current += item
+	}
+	messages = append(messages, current)
+
+	for _, chatID := range t.chatIDs {
+		for _, message := range messages {
+			err := postJSON(fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, t.token), map[string]interface{}{
+				"chat_id":                  chatID,
+				"text":                     message,
+				"parse_mode":               "HTML",
+				"disable_web_page_preview": true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to send message to chat %s: %w", chatID, err)
+			}
+		}
+	}
+	return nil
+}
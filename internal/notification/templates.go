@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// defaultTemplates are the email templates built into the binary
+var defaultTemplates = template.Must(parseBuiltinTemplates())
+
+// Email templates. Each file also defines "<name>_subject", the subject line, e.g. "watchlist_subject".
+const (
+	templateNewContracts  = "new_contracts.html"
+	templateStatusChanges = "status_changes.html"
+	templateWatchlist     = "watchlist.html"
+	templateReport        = "report.html"
+	templateRunSummary    = "run_summary.html"
+	templateScrapeFailure = "scrape_failure.html"
+)
+
+// EmailData is passed to every email template. Only the fields that belong to the email are set.
+type EmailData struct {
+	Contracts     []scraper.Contract // new_contracts.html
+	PliegoButtons map[string]bool    // new_contracts.html: IDs of the contracts shown with a Pliego download button
+	StatusChanges []StatusUpdate     // status_changes.html, watchlist.html
+	Modifications []FieldUpdate      // watchlist.html
+	Deadlines     []scraper.Contract // watchlist.html
+	Date          string             // report.html, as dd/mm/yyyy
+	Run           *RunSummary        // run_summary.html
+	Failure       *FailureAlert      // scrape_failure.html
+	Language      string             // Language of the text, see T; set by the notifier
+}
+
+// LoadTemplates overrides the built-in email templates with the .html files in dir. A file replaces
+// the built-in template with the same name, and a {{define}} in it replaces the block of that name,
+// so e.g. a layout.html redefining "header" restyles every email.
+func (n *Notifier) LoadTemplates(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return fmt.Errorf("failed to list templates: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no .html templates found in %s", dir)
+	}
+
+	// Parse the built-in templates again rather than cloning them, as an executed template cannot be cloned
+	templates, err := parseBuiltinTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to parse the built-in templates: %w", err)
+	}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", file, err)
+		}
+		if _, err := templates.New(filepath.Base(file)).Parse(string(text)); err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", file, err)
+		}
+	}
+
+	n.templates = templates
+	return nil
+}
+
+// parseBuiltinTemplates parses the embedded templates. They hang off an "email" root rather than the
+// first file, so that an override can replace any file.
+func parseBuiltinTemplates() (*template.Template, error) {
+	return template.New("email").ParseFS(templateFiles, "templates/*.html")
+}
+
+// renderEmail executes the named template and its subject with data
+func (n *Notifier) renderEmail(name string, data EmailData) (string, string, error) {
+	templates := n.templates
+	if templates == nil {
+		templates = defaultTemplates
+	}
+
+	data.Language = n.languageOf(TargetEmail)
+
+	var subject, body bytes.Buffer
+	if err := templates.ExecuteTemplate(&subject, strings.TrimSuffix(name, ".html")+"_subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render the subject of %s: %w", name, err)
+	}
+	if err := templates.ExecuteTemplate(&body, name, data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+
+	// The subject is a single header line, not HTML, so undo the escaping of the values in it
+	return strings.Join(strings.Fields(html.UnescapeString(subject.String())), " "), body.String(), nil
+}
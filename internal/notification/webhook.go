@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body, as "sha256=<hex>"
+const WebhookSignatureHeader = "X-Scraper-Signature-256"
+
+// WebhookEventHeader carries the event type of the request
+const WebhookEventHeader = "X-Scraper-Event"
+
+// webhookRetryDelay is the wait before the first retry; it doubles after every failed attempt
+const webhookRetryDelay = 2 * time.Second
+
+// WebhookPayload is the JSON body posted by the webhook channel. Only the lists that belong to the
+// event are set.
+type WebhookPayload struct {
+	Event         string             `json:"event"`
+	SentAt        time.Time          `json:"sent_at"`
+	Contracts     []scraper.Contract `json:"contracts,omitempty"`      // new_contracts
+	StatusChanges []StatusUpdate     `json:"status_changes,omitempty"` // watchlist
+	Modifications []FieldUpdate      `json:"modifications,omitempty"`  // watchlist
+	Deadlines     []scraper.Contract `json:"deadlines,omitempty"`      // watchlist
+	Run           *RunSummary        `json:"run,omitempty"`            // run_summary
+	Failure       *FailureAlert      `json:"failure,omitempty"`        // scrape_failure
+}
+
+// WebhookChannel posts alerts as JSON to a URL, for automation tools such as n8n or Zapier
+type WebhookChannel struct {
+	url     string
+	secret  string
+	retries int
+}
+
+// NewWebhookChannel creates a channel posting to url. With a secret every request is signed with
+// HMAC-SHA256 in WebhookSignatureHeader. Failed requests are retried up to retries times.
+func NewWebhookChannel(url, secret string, retries int) *WebhookChannel {
+	return &WebhookChannel{url: url, secret: secret, retries: retries}
+}
+
+// Name identifies the channel
+func (w *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+// SendNewContracts posts a new_contracts event
+func (w *WebhookChannel) SendNewContracts(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+	return w.send(WebhookPayload{Event: EventNewContracts, Contracts: contracts})
+}
+
+// SendWatchlist posts a watchlist event
+func (w *WebhookChannel) SendWatchlist(updates []StatusUpdate, modified []FieldUpdate, deadlines []scraper.Contract) error {
+	if len(updates) == 0 && len(modified) == 0 && len(deadlines) == 0 {
+		return nil
+	}
+	return w.send(WebhookPayload{Event: EventWatchlist, StatusChanges: updates, Modifications: modified, Deadlines: deadlines})
+}
+
+// SendRunSummary posts a run_summary event
+func (w *WebhookChannel) SendRunSummary(summary RunSummary) error {
+	return w.send(WebhookPayload{Event: EventRunSummary, Run: &summary})
+}
+
+// SendFailureAlert posts a scrape_failure event, without the screenshot
+func (w *WebhookChannel) SendFailureAlert(alert FailureAlert) error {
+	return w.send(WebhookPayload{Event: EventScrapeFailure, Failure: &alert})
+}
+
+// send signs and posts the payload, retrying with a doubling delay after network errors, 429 and 5xx responses
+func (w *WebhookChannel) send(payload WebhookPayload) error {
+	payload.SentAt = time.Now().UTC().Truncate(time.Second)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	headers := map[string]string{WebhookEventHeader: payload.Event}
+	if w.secret != "" {
+		headers[WebhookSignatureHeader] = "sha256=" + signPayload(w.secret, body)
+	}
+
+	delay := webhookRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = postBody(w.url, "application/json", body, headers)
+		if err == nil || attempt >= w.retries || !retryable(err) {
+			break
+		}
+		log.Printf("Webhook request failed (%v), retrying in %s", err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post %s event: %w", payload.Event, err)
+	}
+	return nil
+}
+
+// signPayload returns the hex HMAC-SHA256 of body with the shared secret
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryable reports whether a failed request may succeed when sent again
+func retryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code == http.StatusTooManyRequests || statusErr.code >= 500
+	}
+	return true
+}
@@ -0,0 +1,269 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"scraper/internal/scraper"
+	"scraper/internal/storage"
+)
+
+// FileName is the suggested name for downloaded and attached reports
+const FileName = "contracts-report.xlsx"
+
+// ContentType is the MIME type of the generated workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// UpcomingDeadlineWindow is how far ahead the "Upcoming Deadlines" sheet looks
+const UpcomingDeadlineWindow = 14 * 24 * time.Hour
+
+// Sheet names of the workbook
+const (
+	activeSheet    = "Active Contracts"
+	changesSheet   = "Status Changes"
+	deadlinesSheet = "Upcoming Deadlines"
+)
+
+// contractHeaders are the columns of the contract sheets
+var contractHeaders = []string{"ID", "Description", "Type", "Status", "Amount (EUR)", "Deadline", "Contracting Body", "Link", "Scraped At"}
+
+// contractWidths are the column widths of the contract sheets
+var contractWidths = []float64{22, 60, 16, 18, 16, 18, 40, 40, 18}
+
+// styles holds the cell styles shared by every sheet
+type styles struct {
+	header int
+	amount int
+	date   int
+}
+
+// Write builds the workbook with active contracts, recent status changes and upcoming deadlines and writes it to w.
+// Contracts carrying any of excludeTags (e.g. "ignore") are left out of every sheet unless they are watched.
+func Write(store storage.Store, w io.Writer, excludeTags []string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	st, err := newStyles(f)
+	if err != nil {
+		return err
+	}
+
+	// The new file starts with a default sheet; rename it instead of leaving it empty
+	if err := f.SetSheetName(f.GetSheetName(0), activeSheet); err != nil {
+		return fmt.Errorf("failed to create sheet %s: %w", activeSheet, err)
+	}
+	for _, sheet := range []string{changesSheet, deadlinesSheet} {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return fmt.Errorf("failed to create sheet %s: %w", sheet, err)
+		}
+	}
+
+	if err := writeActiveContracts(f, st, store, excludeTags); err != nil {
+		return err
+	}
+	if err := writeStatusChanges(f, st, store, excludeTags); err != nil {
+		return err
+	}
+	if err := writeUpcomingDeadlines(f, st, store, excludeTags); err != nil {
+		return err
+	}
+
+	f.SetActiveSheet(0)
+	if _, err := f.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// newStyles registers the header, amount and date styles
+func newStyles(f *excelize.File) (styles, error) {
+	var st styles
+	var err error
+
+	st.header, err = f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FF6600"}},
+	})
+	if err != nil {
+		return st, fmt.Errorf("failed to create header style: %w", err)
+	}
+
+	amountFormat := `#,##0.00 "€"`
+	st.amount, err = f.NewStyle(&excelize.Style{CustomNumFmt: &amountFormat})
+	if err != nil {
+		return st, fmt.Errorf("failed to create amount style: %w", err)
+	}
+
+	dateFormat := "dd/mm/yyyy hh:mm"
+	st.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return st, fmt.Errorf("failed to create date style: %w", err)
+	}
+
+	return st, nil
+}
+
+// startSheet opens a stream writer on sheet, sets the column widths and writes a frozen header row
+func startSheet(f *excelize.File, st styles, sheet string, headers []string, widths []float64) (*excelize.StreamWriter, error) {
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sheet %s: %w", sheet, err)
+	}
+
+	for i, width := range widths {
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return nil, fmt.Errorf("failed to set column width: %w", err)
+		}
+	}
+
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, fmt.Errorf("failed to freeze header row: %w", err)
+	}
+
+	row := make([]interface{}, len(headers))
+	for i, header := range headers {
+		row[i] = excelize.Cell{StyleID: st.header, Value: header}
+	}
+	if err := sw.SetRow("A1", row); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	return sw, nil
+}
+
+// contractRow converts a contract into a styled sheet row
+func contractRow(st styles, contract scraper.Contract) []interface{} {
+	var amount interface{}
+	if contract.AmountValue != 0 {
+		amount = excelize.Cell{StyleID: st.amount, Value: contract.AmountValue}
+	}
+
+	var deadline interface{}
+	if contract.Deadline != nil {
+		deadline = excelize.Cell{StyleID: st.date, Value: localTime(*contract.Deadline)}
+	}
+
+	return []interface{}{
+		contract.ID,
+		contract.Description,
+		contract.ContractType,
+		contract.Status,
+		amount,
+		deadline,
+		contract.ContractingBody,
+		contract.Link,
+		excelize.Cell{StyleID: st.date, Value: localTime(contract.ScrapedAt)},
+	}
+}
+
+// writeActiveContracts streams every active contract into the first sheet
+func writeActiveContracts(f *excelize.File, st styles, store storage.Store, excludeTags []string) error {
+	sw, err := startSheet(f, st, activeSheet, contractHeaders, contractWidths)
+	if err != nil {
+		return err
+	}
+
+	rowNumber := 2
+	err = store.ForEachContract(storage.ContractFilter{ExcludeTags: excludeTags}, storage.ContractSort{Field: storage.SortByID}, func(contract scraper.Contract) error {
+		cell, _ := excelize.CoordinatesToCellName(1, rowNumber)
+		rowNumber++
+		return sw.SetRow(cell, contractRow(st, contract))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write active contracts: %w", err)
+	}
+
+	return sw.Flush()
+}
+
+// writeUpcomingDeadlines lists the contracts closing within UpcomingDeadlineWindow, soonest first
+func writeUpcomingDeadlines(f *excelize.File, st styles, store storage.Store, excludeTags []string) error {
+	now := time.Now()
+	filter := storage.ContractFilter{DeadlineFrom: now, DeadlineTo: now.Add(UpcomingDeadlineWindow), ExcludeTags: excludeTags}
+	contracts, _, err := store.GetContractsPage(filter, storage.ContractSort{Field: storage.SortByDeadline}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	sw, err := startSheet(f, st, deadlinesSheet, contractHeaders, contractWidths)
+	if err != nil {
+		return err
+	}
+
+	for i, contract := range contracts {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := sw.SetRow(cell, contractRow(st, contract)); err != nil {
+			return fmt.Errorf("failed to write contract %s: %w", contract.ID, err)
+		}
+	}
+
+	return sw.Flush()
+}
+
+// writeStatusChanges lists the status changes of the last 24 hours
+func writeStatusChanges(f *excelize.File, st styles, store storage.Store, excludeTags []string) error {
+	changes, err := store.GetRecentStatusChanges()
+	if err != nil {
+		return err
+	}
+
+	excluded := make(map[string]bool)
+	for _, tag := range excludeTags {
+		contracts, err := store.GetContractsByTag(tag)
+		if err != nil {
+			return err
+		}
+		for _, contract := range contracts {
+			// Watched contracts are always reported
+			excluded[contract.ID] = !contract.Watched
+		}
+	}
+
+	sw, err := startSheet(f, st, changesSheet,
+		[]string{"Contract ID", "Old Status", "New Status", "Changed At"},
+		[]float64{22, 20, 20, 18})
+	if err != nil {
+		return err
+	}
+
+	rowNumber := 2
+	for _, change := range changes {
+		if excluded[change.ContractID] {
+			continue
+		}
+
+		var changedAt interface{} = change.ChangedAt
+		if t, err := time.Parse(time.RFC3339Nano, change.ChangedAt); err == nil {
+			changedAt = excelize.Cell{StyleID: st.date, Value: localTime(t)}
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, rowNumber)
+		rowNumber++
+		row := []interface{}{change.ContractID, change.OldStatus, change.NewStatus, changedAt}
+		if err := sw.SetRow(cell, row); err != nil {
+			return fmt.Errorf("failed to write status change %d: %w", change.ID, err)
+		}
+	}
+
+	return sw.Flush()
+}
+
+// reportLocation is the time zone used for dates in the workbook, since spreadsheet cells carry no time zone
+var reportLocation = loadReportLocation()
+
+// loadReportLocation returns Spanish peninsular time, or UTC if the zone database is unavailable
+func loadReportLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// localTime converts t to the report time zone
+func localTime(t time.Time) time.Time {
+	return t.In(reportLocation)
+}
@@ -515,17 +515,17 @@ func (c *CLIScraper) ListScreenshots() ([]string, error) {
 	return screenshots, nil
 }
 
-// ExtractDocumentLinksFromContract visits a contract detail page and extracts Pliego and Anuncio links
-func (c *CLIScraper) ExtractDocumentLinksFromContract(contractLink string) (pliegoLink, anuncioLink string, err error) {
+// FetchContractDetail visits a contract detail page and returns its HTML
+func (c *CLIScraper) FetchContractDetail(contractLink string) (string, error) {
 	if contractLink == "" {
-		return "", "", nil
+		return "", nil
 	}
 	
-	log.Printf("🔍 Visiting contract detail page to extract document links...")
+	log.Printf("🔍 Visiting contract detail page...")
 	
 	// Navigate to the contract detail page
 	if err := c.driver.Get(contractLink); err != nil {
-		return "", "", fmt.Errorf("failed to navigate to contract detail page: %w", err)
+		return "", fmt.Errorf("failed to navigate to contract detail page: %w", err)
 	}
 	
 	// Wait for page to load
@@ -534,7 +534,16 @@ func (c *CLIScraper) ExtractDocumentLinksFromContract(contractLink string) (plie
 	// Get the page source
 	htmlContent, err := c.driver.PageSource()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get contract detail page source: %w", err)
+		return "", fmt.Errorf("failed to get contract detail page source: %w", err)
+	}
+	return htmlContent, nil
+}
+
+// ExtractDocumentLinksFromContract visits a contract detail page and extracts Pliego and Anuncio links
+func (c *CLIScraper) ExtractDocumentLinksFromContract(contractLink string) (pliegoLink, anuncioLink string, err error) {
+	htmlContent, err := c.FetchContractDetail(contractLink)
+	if err != nil || htmlContent == "" {
+		return "", "", err
 	}
 	
 	// Extract document links using the core scraper method
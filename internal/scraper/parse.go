@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// deadlineLayouts are the date formats used by the portal for submission deadlines
+var deadlineLayouts = []string{
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseAmount converts a Spanish-formatted amount such as "12.345,67 EUR" into euros.
+// It returns false when the text does not contain a number.
+func ParseAmount(text string) (float64, bool) {
+	cleaned := strings.TrimSpace(text)
+	cleaned = strings.TrimSuffix(cleaned, "EUR")
+	cleaned = strings.TrimSuffix(cleaned, "€")
+	cleaned = strings.TrimSpace(cleaned)
+	cleaned = strings.ReplaceAll(cleaned, " ", "")
+	if cleaned == "" {
+		return 0, false
+	}
+
+	// Thousands are separated with dots and decimals with a comma
+	cleaned = strings.ReplaceAll(cleaned, ".", "")
+	cleaned = strings.ReplaceAll(cleaned, ",", ".")
+
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// ParseDeadline converts a submission date such as "20/11/2025" or "20/11/2025 14:00" into a time.
+// Dates without a time of day are treated as ending at 23:59 Spanish peninsular time.
+// It returns false when the text is empty or not a recognized date.
+func ParseDeadline(text string) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, false
+	}
+
+	location := spainLocation()
+	for _, layout := range deadlineLayouts {
+		deadline, err := time.ParseInLocation(layout, text, location)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "15") {
+			deadline = deadline.Add(23*time.Hour + 59*time.Minute)
+		}
+		return deadline, true
+	}
+
+	return time.Time{}, false
+}
+
+// cpvCodePattern matches a CPV code with its optional check digit, such as "32351200-0"
+var cpvCodePattern = regexp.MustCompile(`\b(\d{8})(?:-\d)?\b`)
+
+// cpvDivisions are the first two digits of the valid CPV codes, used to tell codes apart from other
+// eight-digit numbers on a page
+var cpvDivisions = map[string]bool{
+	"03": true, "09": true, "14": true, "15": true, "16": true, "18": true, "19": true, "22": true,
+	"24": true, "30": true, "31": true, "32": true, "33": true, "34": true, "35": true, "37": true,
+	"38": true, "39": true, "41": true, "42": true, "43": true, "44": true, "45": true, "48": true,
+	"50": true, "51": true, "55": true, "60": true, "63": true, "64": true, "65": true, "66": true,
+	"70": true, "71": true, "72": true, "73": true, "75": true, "76": true, "77": true, "79": true,
+	"80": true, "85": true, "90": true, "92": true, "98": true,
+}
+
+// NormalizeCPVCode returns the eight-digit code of a CPV code such as "32351200-0", or "" if text is not one
+func NormalizeCPVCode(text string) string {
+	match := cpvCodePattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil || !cpvDivisions[match[1][:2]] {
+		return ""
+	}
+	return match[1]
+}
+
+// ParseCPVCodes returns the CPV codes listed on a contract detail page, in page order and without
+// duplicates. Codes are read from each element labelled "CPV" together with its siblings, which is
+// where the portal puts the value next to the label.
+func ParseCPVCodes(htmlContent string) []string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil
+	}
+
+	var codes []string
+	seen := make(map[string]bool)
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		// Only elements whose own text mentions CPV, so a whole section is not scanned
+		ownText := s.Contents().FilterFunction(func(_ int, node *goquery.Selection) bool {
+			return goquery.NodeName(node) == "#text"
+		}).Text()
+		if !strings.Contains(strings.ToUpper(ownText), "CPV") {
+			return
+		}
+
+		for _, match := range cpvCodePattern.FindAllString(strings.Join(textNodes(s.Parent()), " "), -1) {
+			if code := NormalizeCPVCode(match); code != "" && !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	})
+	return codes
+}
+
+// textNodes returns the text nodes under a selection in document order. Unlike Text it keeps the
+// texts of adjacent cells apart, so "CPV" in one cell and "32351200" in the next are separate words.
+func textNodes(s *goquery.Selection) []string {
+	var texts []string
+	s.Contents().Each(func(_ int, node *goquery.Selection) {
+		if goquery.NodeName(node) == "#text" {
+			texts = append(texts, node.Text())
+		} else {
+			texts = append(texts, textNodes(node)...)
+		}
+	})
+	return texts
+}
+
+// spainLocation returns the Europe/Madrid time zone, or UTC if the zone database is unavailable
+func spainLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// ParseTypedFields fills AmountValue and Deadline from the scraped Amount and SubmissionDate text
+func (c *Contract) ParseTypedFields() {
+	if amount, ok := ParseAmount(c.Amount); ok {
+		c.AmountValue = amount
+	}
+	if deadline, ok := ParseDeadline(c.SubmissionDate); ok {
+		c.Deadline = &deadline
+	}
+}
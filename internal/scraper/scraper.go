@@ -12,17 +12,31 @@ import (
 
 // Contract represents a contract from the procurement platform
 type Contract struct {
-	ID                string    `json:"id"`
-	Description       string    `json:"description"`
-	ContractType      string    `json:"contract_type"`
-	Status            string    `json:"status"`
-	Amount            string    `json:"amount"`
-	SubmissionDate    string    `json:"submission_date"`
-	ContractingBody   string    `json:"contracting_body"`
-	Link              string    `json:"link"`
-	PliegoLink        string    `json:"pliego_link"`
-	AnuncioLink       string    `json:"anuncio_link"`
-	ScrapedAt         time.Time `json:"scraped_at"`
+	ID                string     `json:"id"`            // Storage key; the expediente unless another contracting body already used it
+	UID               string     `json:"uid,omitempty"` // Stable internal ULID for API URLs, filled in by the storage layer
+	Expediente        string     `json:"expediente"`    // File number as published, only unique per contracting body
+	Description       string     `json:"description"`
+	ContractType      string     `json:"contract_type"`
+	Status            string     `json:"status"`
+	Amount            string     `json:"amount"`
+	SubmissionDate    string     `json:"submission_date"`
+	ContractingBody   string     `json:"contracting_body"`
+	Link              string     `json:"link"`
+	PliegoLink        string     `json:"pliego_link"`
+	AnuncioLink       string     `json:"anuncio_link"`
+	DashboardLink     string     `json:"dashboard_link,omitempty"`
+	AmountValue       float64    `json:"amount_value"`       // Amount parsed into euros (0 if unknown)
+	Deadline          *time.Time `json:"deadline,omitempty"` // SubmissionDate parsed into a timestamp
+	ScrapedAt         time.Time  `json:"scraped_at"`
+	FirstSeenAt       time.Time  `json:"first_seen_at"`         // When the contract was first saved; kept across re-scrapes
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"` // Set when the contract was archived as closed or expired
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`  // Set when the contract was soft-deleted
+	SeenAt            *time.Time `json:"seen_at,omitempty"`     // Set once the contract was viewed in the dashboard; nil means unseen
+	Tags              []string   `json:"tags,omitempty"`        // User labels such as "to bid", filled in by the storage layer
+	Watched           bool       `json:"watched"`               // On the watchlist, filled in by the storage layer
+	ProfileID         int64      `json:"profile_id"`            // Search profile the contract was found by; 0 is the default profile
+	CPVCodes          []string   `json:"cpv_codes,omitempty"`   // CPV codes listed on the detail page; stored ones are filled in by the storage layer
+	DetailHTML        string     `json:"-"`                     // Detail page HTML fetched by this scrape; the storage layer keeps it for reprocessing
 }
 
 // ScraperInterface defines the interface that both HTTP and Selenium scrapers must implement
@@ -34,15 +48,35 @@ type ScraperInterface interface {
 	WaitForResults() error
 	ExtractContracts() ([]Contract, error)
 	ExtractAllContracts() ([]Contract, error)
+	ContractDetailFetcher
 	Close() error
 }
 
+// ContractDetailFetcher visits a contract detail page and returns its HTML
+type ContractDetailFetcher interface {
+	FetchContractDetail(contractLink string) (string, error)
+}
+
+// ContractLookup retrieves previously stored contracts (implemented by the storage layer)
+type ContractLookup interface {
+	FindStoredContract(contract Contract) (*Contract, error)
+}
+
 // CoreScraper contains the unified business logic that orchestrates the scraping process
 type CoreScraper struct {
-	baseURL string
-	cpvCode string
+	baseURL  string
+	cpvCode  string
+	progress func(step string) // Told about each step of the scrape, see SetProgress
 }
 
+// Steps of a scrape, as reported to the progress function
+const (
+	StepNavigate = "navigate" // Opening the search form
+	StepSearch   = "search"   // Entering the CPV code and waiting for the results
+	StepExtract  = "extract"  // Reading the contracts off the results
+	StepEnhance  = "enhance"  // Fetching the document links of each contract
+)
+
 // NewCoreScraper creates a new core scraper with business logic
 func NewCoreScraper() *CoreScraper {
 	return &CoreScraper{
@@ -61,11 +95,30 @@ func (c *CoreScraper) GetCPVCode() string {
 	return c.cpvCode
 }
 
+// SetCPVCode changes the CPV code to search for; an empty code keeps the current one
+func (c *CoreScraper) SetCPVCode(code string) {
+	if code != "" {
+		c.cpvCode = code
+	}
+}
+
 // GetBaseURL returns the base URL
 func (c *CoreScraper) GetBaseURL() string {
 	return c.baseURL
 }
 
+// SetProgress calls progress with the Step* constants as the scrape moves through its steps
+func (c *CoreScraper) SetProgress(progress func(step string)) {
+	c.progress = progress
+}
+
+// reportStep tells the progress function, if any, that a step has started
+func (c *CoreScraper) reportStep(step string) {
+	if c.progress != nil {
+		c.progress(step)
+	}
+}
+
 
 
 
@@ -157,17 +210,26 @@ func (c *CoreScraper) parseContractIDAndDescription(fullText string) (id, descri
 
 // ScrapeLEDContracts is the unified main function that orchestrates the scraping process
 // This is the single source of truth for the scraping workflow
-func (c *CoreScraper) ScrapeLEDContracts(scraper ScraperInterface) ([]Contract, error) {
+func (c *CoreScraper) ScrapeLEDContracts(scraper ScraperInterface) (contracts []Contract, err error) {
 	log.Println("Starting LED contract scraper with unified logic...")
+
+	// Keep a picture of the page a step failed on, for the failure alert
+	defer func() {
+		if err != nil {
+			captureFailure(scraper)
+		}
+	}()
 	
 	// Step 1: Navigate to search form
 	log.Println("Step 1: Navigating to search form...")
+	c.reportStep(StepNavigate)
 	if err := scraper.NavigateToSearchForm(); err != nil {
 		return nil, fmt.Errorf("failed to navigate to search form: %w", err)
 	}
 	
 	// Step 2: Enter CPV code
 	log.Println("Step 2: Entering CPV code...")
+	c.reportStep(StepSearch)
 	if err := scraper.EnterCPVCode(c.cpvCode); err != nil {
 		return nil, fmt.Errorf("failed to enter CPV code: %w", err)
 	}
@@ -192,7 +254,8 @@ func (c *CoreScraper) ScrapeLEDContracts(scraper ScraperInterface) ([]Contract,
 	
 	// Step 6: Extract contracts
 	log.Println("Step 6: Extracting contracts...")
-	contracts, err := scraper.ExtractContracts()
+	c.reportStep(StepExtract)
+	contracts, err = scraper.ExtractContracts()
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract contracts: %w", err)
 	}
@@ -243,6 +306,7 @@ func (c *CoreScraper) ExtractContractsFromTable(tableData [][]string) ([]Contrac
 		// Extract contract data from row
 		contract := Contract{
 			ID:              id,
+			Expediente:      id,
 			Description:     description,
 			ContractType:    strings.TrimSpace(row[1]),
 			Status:          strings.TrimSpace(row[2]),
@@ -251,6 +315,7 @@ func (c *CoreScraper) ExtractContractsFromTable(tableData [][]string) ([]Contrac
 			ContractingBody: strings.TrimSpace(row[5]),
 			ScrapedAt:       time.Now(),
 		}
+		contract.ParseTypedFields()
 
 		// Only include NEW contracts with status "Publicada" (Published) or "Evaluación Previa" (Pre-evaluation)
 		if strings.EqualFold(contract.Status, "Publicada") || strings.EqualFold(contract.Status, "Evaluación Previa") {
@@ -315,6 +380,7 @@ func (c *CoreScraper) ExtractContractsFromTableWithLinks(tableData [][]string, l
 		// Extract contract data from row
 		contract := Contract{
 			ID:              id,
+			Expediente:      id,
 			Description:     description,
 			ContractType:    strings.TrimSpace(row[1]),
 			Status:          strings.TrimSpace(row[2]),
@@ -326,6 +392,7 @@ func (c *CoreScraper) ExtractContractsFromTableWithLinks(tableData [][]string, l
 			AnuncioLink:     anuncioLink,
 			ScrapedAt:       time.Now(),
 		}
+		contract.ParseTypedFields()
 
 		// Only include NEW contracts with status "Publicada" (Published) or "Evaluación Previa" (Pre-evaluation)
 		if strings.EqualFold(contract.Status, "Publicada") || strings.EqualFold(contract.Status, "Evaluación Previa") {
@@ -426,10 +493,12 @@ func (c *CoreScraper) extractDocumentLinksFromRow(row []string) (pliegoLink, anu
 }
 
 // EnhanceContractsWithDocumentLinks visits each contract detail page and extracts document links
-// This method requires a Selenium scraper to navigate to individual contract pages
-// It also accepts a storage interface to check if contracts already have document links
-func (c *CoreScraper) EnhanceContractsWithDocumentLinks(contracts []Contract, seleniumScraper interface{}, storage interface{}) ([]Contract, error) {
+// The fetcher (usually a Selenium scraper) navigates to the individual contract pages, while the
+// optional lookup is used to skip contracts that already have document links stored.
+// The HTML of every visited page is kept in DetailHTML so it can be stored and re-parsed later.
+func (c *CoreScraper) EnhanceContractsWithDocumentLinks(contracts []Contract, fetcher ContractDetailFetcher, lookup ContractLookup) ([]Contract, error) {
 	enhancedContracts := make([]Contract, len(contracts))
+	c.reportStep(StepEnhance)
 	
 	log.Printf("🔍 Starting document link enhancement for %d contracts...", len(contracts))
 	
@@ -448,30 +517,23 @@ func (c *CoreScraper) EnhanceContractsWithDocumentLinks(contracts []Contract, se
 		}
 		
 		// Check if contract already has document links in the database
-		if storage != nil {
-			// Try to cast to the interface
-			storageInterface, ok := storage.(interface {
-				GetContractByID(string) (*Contract, error)
-			})
-			
-			if ok {
-				existingContract, err := storageInterface.GetContractByID(contract.ID)
-				if err != nil {
-					log.Printf("⚠️ Failed to check existing contract %s: %v", contract.ID, err)
-				} else if existingContract != nil {
-					if existingContract.PliegoLink != "" && existingContract.AnuncioLink != "" {
-						// Contract already has both document links, skip extraction
-						log.Printf("⏭️ Contract %s already has document links, skipping extraction", contract.ID)
-						enhancedContracts[i].PliegoLink = existingContract.PliegoLink
-						enhancedContracts[i].AnuncioLink = existingContract.AnuncioLink
-						contractsToSkip++
-						continue
-					} else if existingContract.PliegoLink != "" || existingContract.AnuncioLink != "" {
-						// Contract has partial document links, we'll try to complete them
-						log.Printf("🔄 Contract %s has partial document links, attempting to complete...", contract.ID)
-						enhancedContracts[i].PliegoLink = existingContract.PliegoLink
-						enhancedContracts[i].AnuncioLink = existingContract.AnuncioLink
-					}
+		if lookup != nil {
+			existingContract, err := lookup.FindStoredContract(contract)
+			if err != nil {
+				log.Printf("⚠️ Failed to check existing contract %s: %v", contract.ID, err)
+			} else if existingContract != nil {
+				if existingContract.PliegoLink != "" && existingContract.AnuncioLink != "" {
+					// Contract already has both document links, skip extraction
+					log.Printf("⏭️ Contract %s already has document links, skipping extraction", contract.ID)
+					enhancedContracts[i].PliegoLink = existingContract.PliegoLink
+					enhancedContracts[i].AnuncioLink = existingContract.AnuncioLink
+					contractsToSkip++
+					continue
+				} else if existingContract.PliegoLink != "" || existingContract.AnuncioLink != "" {
+					// Contract has partial document links, we'll try to complete them
+					log.Printf("🔄 Contract %s has partial document links, attempting to complete...", contract.ID)
+					enhancedContracts[i].PliegoLink = existingContract.PliegoLink
+					enhancedContracts[i].AnuncioLink = existingContract.AnuncioLink
 				}
 			}
 		}
@@ -479,32 +541,27 @@ func (c *CoreScraper) EnhanceContractsWithDocumentLinks(contracts []Contract, se
 		log.Printf("🔍 Processing contract %s with link: %s", contract.ID, contract.Link)
 		contractsToProcess++
 		
-		// Try to extract document links using Selenium scraper
-		if scraper, ok := seleniumScraper.(interface {
-			ExtractDocumentLinksFromContract(string) (string, string, error)
-		}); ok {
-			log.Printf("✅ Found compatible scraper, extracting document links for %s...", contract.ID)
-			pliegoLink, anuncioLink, err := scraper.ExtractDocumentLinksFromContract(contract.Link)
-			if err != nil {
-				log.Printf("⚠️ Failed to extract document links for contract %s: %v", contract.ID, err)
-				continue
-			}
-			
-			// Only update if we got new links (don't overwrite existing ones with empty values)
-			if pliegoLink != "" {
-				enhancedContracts[i].PliegoLink = pliegoLink
-			}
-			if anuncioLink != "" {
-				enhancedContracts[i].AnuncioLink = anuncioLink
-			}
-			
-			log.Printf("📄 Enhanced contract %s with document links - Pliego: %s, Anuncio: %s", 
-				contract.ID, 
-				func() string { if enhancedContracts[i].PliegoLink != "" { return "✓" } else { return "✗" } }(),
-				func() string { if enhancedContracts[i].AnuncioLink != "" { return "✓" } else { return "✗" } }())
-		} else {
-			log.Printf("❌ Selenium scraper does not implement ExtractDocumentLinksFromContract method")
+		htmlContent, err := fetcher.FetchContractDetail(contract.Link)
+		if err != nil {
+			log.Printf("⚠️ Failed to extract document links for contract %s: %v", contract.ID, err)
+			continue
+		}
+		enhancedContracts[i].DetailHTML = htmlContent
+		enhancedContracts[i].CPVCodes = ParseCPVCodes(htmlContent)
+		pliegoLink, anuncioLink := c.ExtractDocumentLinks(htmlContent)
+		
+		// Only update if we got new links (don't overwrite existing ones with empty values)
+		if pliegoLink != "" {
+			enhancedContracts[i].PliegoLink = pliegoLink
 		}
+		if anuncioLink != "" {
+			enhancedContracts[i].AnuncioLink = anuncioLink
+		}
+		
+		log.Printf("📄 Enhanced contract %s with document links - Pliego: %s, Anuncio: %s", 
+			contract.ID, 
+			func() string { if enhancedContracts[i].PliegoLink != "" { return "✓" } else { return "✗" } }(),
+			func() string { if enhancedContracts[i].AnuncioLink != "" { return "✓" } else { return "✗" } }())
 	}
 	
 	log.Printf("✅ Document link enhancement completed - Processed: %d, Skipped: %d", contractsToProcess, contractsToSkip)
@@ -552,6 +609,7 @@ func (c *CoreScraper) ExtractAllContractsFromTable(tableData [][]string) ([]Cont
 		// Extract contract data from row
 		contract := Contract{
 			ID:              id,
+			Expediente:      id,
 			Description:     description,
 			ContractType:    strings.TrimSpace(row[1]),
 			Status:          strings.TrimSpace(row[2]),
@@ -560,6 +618,7 @@ func (c *CoreScraper) ExtractAllContractsFromTable(tableData [][]string) ([]Cont
 			ContractingBody: strings.TrimSpace(row[5]),
 			ScrapedAt:       time.Now(),
 		}
+		contract.ParseTypedFields()
 
 		// Include ALL contracts for status change detection
 		allContracts = append(allContracts, contract)
@@ -725,21 +784,22 @@ func NewScraper(scraperType ScraperType) (ScraperInterface, error) {
 	}
 }
 
-// ScrapeContracts is the unified function that works with any scraper type
-func ScrapeContracts(scraperType ScraperType) ([]Contract, error) {
+// ScrapeContracts is the unified function that works with any scraper type.
+// An empty cpvCode searches for the default LED screens code.
+func ScrapeContracts(scraperType ScraperType, cpvCode string) ([]Contract, error) {
 	scraper, err := NewScraper(scraperType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create scraper: %w", err)
 	}
 	defer scraper.Close()
 
-	coreScraper := NewCoreScraper()
-	return coreScraper.ScrapeLEDContracts(scraper)
+	return ScrapeContractsWithScraper(scraper, cpvCode)
 }
 
 // ScrapeContractsWithScraper is a helper function that works with a specific scraper instance
-func ScrapeContractsWithScraper(scraper ScraperInterface) ([]Contract, error) {
+func ScrapeContractsWithScraper(scraper ScraperInterface, cpvCode string) ([]Contract, error) {
 	coreScraper := NewCoreScraper()
+	coreScraper.SetCPVCode(cpvCode)
 	return coreScraper.ScrapeLEDContracts(scraper)
 }
 
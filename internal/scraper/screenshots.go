@@ -0,0 +1,148 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScreenshotsRoot is the directory holding one screenshots folder per scraping session
+const ScreenshotsRoot = "screenshots"
+
+// PruneScreenshots deletes screenshots older than olderThan and removes session folders
+// that end up empty. It returns the number of deleted files.
+func PruneScreenshots(olderThan time.Duration) (int, error) {
+	sessions, err := os.ReadDir(ScreenshotsRoot)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read screenshots directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	deleted := 0
+	for _, session := range sessions {
+		if !session.IsDir() {
+			continue
+		}
+
+		sessionDir := filepath.Join(ScreenshotsRoot, session.Name())
+		files, err := os.ReadDir(sessionDir)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to read %s: %w", sessionDir, err)
+		}
+
+		remaining := len(files)
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || file.IsDir() || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(sessionDir, file.Name())); err != nil {
+				return deleted, fmt.Errorf("failed to delete screenshot: %w", err)
+			}
+			deleted++
+			remaining--
+		}
+
+		if remaining == 0 {
+			os.Remove(sessionDir)
+		}
+	}
+
+	return deleted, nil
+}
+
+// LatestScreenshot returns the path of the newest screenshot taken since t, or "" if there is none
+func LatestScreenshot(since time.Time) (string, error) {
+	sessions, err := os.ReadDir(ScreenshotsRoot)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read screenshots directory: %w", err)
+	}
+
+	var latest string
+	latestTime := since
+	for _, session := range sessions {
+		if !session.IsDir() {
+			continue
+		}
+
+		sessionDir := filepath.Join(ScreenshotsRoot, session.Name())
+		files, err := os.ReadDir(sessionDir)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", sessionDir, err)
+		}
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || file.IsDir() || !strings.HasSuffix(file.Name(), ".png") || info.ModTime().Before(latestTime) {
+				continue
+			}
+			latest, latestTime = filepath.Join(sessionDir, file.Name()), info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// Screenshot is a screenshot file in a session folder of ScreenshotsRoot
+type Screenshot struct {
+	Path    string // Relative to ScreenshotsRoot, e.g. "cli_session_2025-11-03_08-00-00/2025-11-03_08-00-05_cli_failure.png"
+	TakenAt time.Time
+}
+
+// AllScreenshots lists the screenshots of every session, oldest first
+func AllScreenshots() ([]Screenshot, error) {
+	sessions, err := os.ReadDir(ScreenshotsRoot)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read screenshots directory: %w", err)
+	}
+
+	var screenshots []Screenshot
+	for _, session := range sessions {
+		if !session.IsDir() {
+			continue
+		}
+
+		sessionDir := filepath.Join(ScreenshotsRoot, session.Name())
+		files, err := os.ReadDir(sessionDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", sessionDir, err)
+		}
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || file.IsDir() || !strings.HasSuffix(file.Name(), ".png") {
+				continue
+			}
+			screenshots = append(screenshots, Screenshot{Path: session.Name() + "/" + file.Name(), TakenAt: info.ModTime()})
+		}
+	}
+
+	sort.Slice(screenshots, func(i, j int) bool { return screenshots[i].TakenAt.Before(screenshots[j].TakenAt) })
+	return screenshots, nil
+}
+
+// screenshotTaker is implemented by the scrapers that can take screenshots
+type screenshotTaker interface {
+	TakeScreenshotWithDescription(description string) error
+}
+
+// captureFailure takes a screenshot of the page a scraping step failed on, if the scraper can
+func captureFailure(scraper ScraperInterface) {
+	taker, ok := scraper.(screenshotTaker)
+	if !ok {
+		return
+	}
+	if err := taker.TakeScreenshotWithDescription("failure"); err != nil {
+		log.Printf("Warning: Failed to take a screenshot of the failure: %v", err)
+	}
+}
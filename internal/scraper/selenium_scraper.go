@@ -359,17 +359,17 @@ func (s *SeleniumScraper) ExtractAllContracts() ([]Contract, error) {
 	return s.coreScraper.ExtractAllContractsFromHTML(htmlContent)
 }
 
-// ExtractDocumentLinksFromContract visits a contract detail page and extracts Pliego and Anuncio links
-func (s *SeleniumScraper) ExtractDocumentLinksFromContract(contractLink string) (pliegoLink, anuncioLink string, err error) {
+// FetchContractDetail visits a contract detail page and returns its HTML
+func (s *SeleniumScraper) FetchContractDetail(contractLink string) (string, error) {
 	if contractLink == "" {
-		return "", "", nil
+		return "", nil
 	}
 	
-	log.Printf("🔍 Visiting contract detail page to extract document links...")
+	log.Printf("🔍 Visiting contract detail page...")
 	
 	// Navigate to the contract detail page
 	if err := s.driver.Get(contractLink); err != nil {
-		return "", "", fmt.Errorf("failed to navigate to contract detail page: %w", err)
+		return "", fmt.Errorf("failed to navigate to contract detail page: %w", err)
 	}
 	
 	// Wait for page to load
@@ -378,7 +378,16 @@ func (s *SeleniumScraper) ExtractDocumentLinksFromContract(contractLink string)
 	// Get the page source
 	htmlContent, err := s.driver.PageSource()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get contract detail page source: %w", err)
+		return "", fmt.Errorf("failed to get contract detail page source: %w", err)
+	}
+	return htmlContent, nil
+}
+
+// ExtractDocumentLinksFromContract visits a contract detail page and extracts Pliego and Anuncio links
+func (s *SeleniumScraper) ExtractDocumentLinksFromContract(contractLink string) (pliegoLink, anuncioLink string, err error) {
+	htmlContent, err := s.FetchContractDetail(contractLink)
+	if err != nil || htmlContent == "" {
+		return "", "", err
 	}
 	
 	// Extract document links using the core scraper method
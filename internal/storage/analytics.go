@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// statusFunnelOrder is the order contracts usually go through the statuses of the portal. Statuses
+// that are not listed come after these ones.
+var statusFunnelOrder = []string{
+	"Anuncio Previo",
+	"Publicada",
+	"Evaluación Previa",
+	"Evaluación",
+	"Adjudicada",
+	"Parcialmente Adjudicada",
+	"Resuelta",
+	"Parcialmente Resuelta",
+}
+
+// AdjudicationStatus is the status reached when a contract is awarded
+const AdjudicationStatus = "Adjudicada"
+
+// AdjudicationTimes summarizes how long contracts took to be awarded, counted from when they were
+// first seen, the closest we have to their publication
+type AdjudicationTimes struct {
+	Contracts   int     `json:"contracts"` // Adjudicated contracts measured
+	AverageDays float64 `json:"average_days"`
+	MedianDays  float64 `json:"median_days"`
+}
+
+// GetStatusFunnel counts the contracts that have been in each status at some point, current or past,
+// in the order of statusFunnelOrder
+func (s *Storage) GetStatusFunnel() ([]StatusCount, error) {
+	query := `
+	SELECT status, COUNT(DISTINCT contract_uid) FROM (
+		SELECT uid AS contract_uid, status FROM contracts WHERE ` + notDeleted + `
+		UNION SELECT contract_uid, old_status FROM status_changes
+		UNION SELECT contract_uid, new_status FROM status_changes
+	) reached
+	WHERE status IS NOT NULL AND status != ''
+	AND contract_uid IN (SELECT uid FROM contracts WHERE ` + notDeleted + `)
+	GROUP BY status
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status funnel: %w", err)
+	}
+	defer rows.Close()
+
+	funnel := []StatusCount{}
+	for rows.Next() {
+		var count StatusCount
+		if err := rows.Scan(&count.Status, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan status funnel: %w", err)
+		}
+		funnel = append(funnel, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status funnel: %w", err)
+	}
+
+	rank := func(status string) int {
+		for i, ordered := range statusFunnelOrder {
+			if ordered == status {
+				return i
+			}
+		}
+		return len(statusFunnelOrder)
+	}
+	sort.SliceStable(funnel, func(i, j int) bool {
+		if rank(funnel[i].Status) != rank(funnel[j].Status) {
+			return rank(funnel[i].Status) < rank(funnel[j].Status)
+		}
+		return funnel[i].Count > funnel[j].Count
+	})
+	return funnel, nil
+}
+
+// GetAdjudicationTimes measures the days from when contracts were first seen to their first change
+// to AdjudicationStatus
+func (s *Storage) GetAdjudicationTimes() (*AdjudicationTimes, error) {
+	query := `
+	SELECT c.id, c.first_seen_at, sc.changed_at
+	FROM status_changes sc
+	JOIN contracts c ON c.uid = sc.contract_uid
+	WHERE sc.new_status = ? AND c.deleted_at IS NULL AND c.first_seen_at IS NOT NULL
+	ORDER BY sc.changed_at ASC
+	`
+
+	rows, err := s.db.Query(query, AdjudicationStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query adjudication times: %w", err)
+	}
+	defer rows.Close()
+
+	var days []float64
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		var firstSeenAt, changedAt time.Time
+		if err := rows.Scan(&id, &firstSeenAt, &changedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan adjudication time: %w", err)
+		}
+		// Only the first adjudication counts, in case the status went back and forth
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		days = append(days, max(changedAt.Sub(firstSeenAt).Hours()/24, 0))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read adjudication times: %w", err)
+	}
+
+	times := &AdjudicationTimes{Contracts: len(days)}
+	if len(days) == 0 {
+		return times, nil
+	}
+
+	var total float64
+	for _, d := range days {
+		total += d
+	}
+	times.AverageDays = total / float64(len(days))
+
+	sort.Float64s(days)
+	if middle := len(days) / 2; len(days)%2 == 1 {
+		times.MedianDays = days[middle]
+	} else {
+		times.MedianDays = (days[middle-1] + days[middle]) / 2
+	}
+	return times, nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// ArchivePolicy decides which contracts are no longer relevant for the active view
+type ArchivePolicy struct {
+	// TerminalStatuses are statuses after which a tender does not evolve any more
+	TerminalStatuses []string
+	// DeadlineGrace is how long after the submission deadline a contract stays active
+	DeadlineGrace time.Duration
+}
+
+// DefaultArchivePolicy archives resolved, cancelled and void tenders, and tenders whose
+// submission deadline passed more than 30 days ago
+var DefaultArchivePolicy = ArchivePolicy{
+	TerminalStatuses: []string{"Resuelta", "Anulada", "Desierta", "Desistida", "Renunciada"},
+	DeadlineGrace:    30 * 24 * time.Hour,
+}
+
+// ArchiveContracts archives the active contracts matched by the policy and returns how many were archived
+func (s *Storage) ArchiveContracts(policy ArchivePolicy) (int64, error) {
+	conditions := []string{"deadline < ?"}
+	args := []interface{}{time.Now().Add(-policy.DeadlineGrace).UTC()}
+
+	if len(policy.TerminalStatuses) > 0 {
+		placeholders := make([]string, len(policy.TerminalStatuses))
+		for i, status := range policy.TerminalStatuses {
+			placeholders[i] = "?"
+			args = append(args, status)
+		}
+		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+
+	query := fmt.Sprintf(`UPDATE contracts SET archived_at = CURRENT_TIMESTAMP WHERE %s AND (%s)`,
+		activeOnly, strings.Join(conditions, " OR "))
+
+	result, err := s.exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive contracts: %w", err)
+	}
+
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if archived > 0 {
+		log.Printf("Archived %d closed or expired contracts", archived)
+	}
+	return archived, nil
+}
+
+// ArchiveContractsByID archives the given active contracts, whatever the policy says, and returns
+// how many were archived
+func (s *Storage) ArchiveContractsByID(contractIDs []string) (int64, error) {
+	if len(contractIDs) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, len(contractIDs))
+	for i, id := range contractIDs {
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`UPDATE contracts SET archived_at = CURRENT_TIMESTAMP WHERE %s AND id IN (%s)`, activeOnly, placeholders(len(contractIDs)))
+	result, err := s.exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive contracts: %w", err)
+	}
+
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if archived > 0 {
+		log.Printf("Archived %d contracts", archived)
+	}
+	return archived, nil
+}
+
+// UnarchiveContract moves an archived contract back to the active view.
+// The next ArchiveContracts run archives it again if the policy still matches it.
+func (s *Storage) UnarchiveContract(contractID string) error {
+	query := `UPDATE contracts SET archived_at = NULL WHERE id = ? AND archived_at IS NOT NULL AND ` + notDeleted
+
+	result, err := s.exec(query, contractID)
+	if err != nil {
+		return fmt.Errorf("failed to unarchive contract %s: %w", contractID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFoundf("archived contract %s not found", contractID)
+	}
+
+	log.Printf("Contract %s unarchived", contractID)
+	return nil
+}
+
+// GetArchivedContracts retrieves the archived contracts, most recently archived first
+func (s *Storage) GetArchivedContracts() ([]scraper.Contract, error) {
+	return s.queryContracts("archived contracts",
+		`SELECT `+contractColumns+` FROM contracts WHERE archived_at IS NOT NULL AND `+notDeleted+` ORDER BY archived_at DESC`)
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditDeleteContract  = "delete_contract"
+	AuditDeleteAll       = "delete_all"
+	AuditRestoreContract = "restore_contract"
+	AuditRestoreAll      = "restore_all"
+	AuditPurgeDeleted    = "purge_deleted"
+	AuditPrune           = "prune"
+	AuditDeleteProfile   = "delete_profile"
+	AuditChangeSettings  = "change_settings"
+	AuditAddChannel      = "add_channel"
+	AuditRemoveChannel   = "remove_channel"
+)
+
+// AuditEntry records who ran a destructive operation, when, and on what, so accidental data loss on
+// a shared installation can be traced
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Actor     string    `json:"actor"`            // Who ran it, e.g. the dashboard user name or "cli:$USER"
+	Action    string    `json:"action"`           // One of the Audit* constants
+	Target    string    `json:"target,omitempty"` // The contract ID, profile name, cutoff, settings or channel the action applied to
+	Affected  int64     `json:"affected"`         // Number of contracts (rows for prune, settings for change_settings) changed
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// recordAudit adds an entry to the audit log within tx, so it is only kept if the operation commits
+func recordAudit(tx *sql.Tx, actor, action, target string, affected int64) error {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		actor = "unknown"
+	}
+
+	_, err := tx.Exec(`INSERT INTO audit_log (actor, action, target, affected, created_at) VALUES (?, ?, ?, ?, ?)`,
+		actor, action, target, affected, time.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		return fmt.Errorf("failed to record %s in audit log: %w", action, err)
+	}
+	return nil
+}
+
+// GetAuditLog retrieves the most recent audit log entries, newest first
+func (s *Storage) GetAuditLog(limit int) ([]AuditEntry, error) {
+	query := `
+	SELECT id, actor, action, target, affected, created_at
+	FROM audit_log
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		var target sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &target, &entry.Affected, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Target = target.String
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// backupTimeout bounds how long a backup or restore waits for other connections to release their locks
+const backupTimeout = 30 * time.Second
+
+// Backup writes a consistent snapshot of the SQLite database to path using SQLite's online
+// backup API, so it is safe while the scraper or the dashboard are writing. Backups of an
+// encrypted database are encrypted with the same passphrase.
+func (s *Storage) Backup(path string) error {
+	if _, ok := s.dialect.(sqliteDialect); !ok {
+		return fmt.Errorf("backup is only supported for SQLite; use mysqldump for MySQL/MariaDB")
+	}
+
+	dest, err := openSQLite(path, s.passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer dest.Close()
+
+	if err := copyDatabase(dest, s.db); err != nil {
+		return fmt.Errorf("failed to back up database to %s: %w", path, err)
+	}
+
+	log.Printf("Database backed up to %s", path)
+	return nil
+}
+
+// Restore replaces the contents of the SQLite database with the backup at path and then
+// applies any migrations the backup is missing
+func (s *Storage) Restore(path string) error {
+	if _, ok := s.dialect.(sqliteDialect); !ok {
+		return fmt.Errorf("restore is only supported for SQLite; use the mysql client for MySQL/MariaDB")
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup file not found: %w", err)
+	}
+
+	src, err := openSQLite(readOnlyDSN(path), s.passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer src.Close()
+
+	// Refuse files that are not a database written by this application
+	var tables int
+	err = src.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'contracts'`).Scan(&tables)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+	if tables == 0 {
+		return fmt.Errorf("%s is not a contracts database backup", path)
+	}
+
+	// Hold off this process's writes while the pages are replaced
+	s.writeMu.Lock()
+	err = copyDatabase(s.db, src)
+	s.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to restore database from %s: %w", path, err)
+	}
+
+	if err := s.migrate(); err != nil {
+		return fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+
+	log.Printf("Database restored from %s", path)
+	return nil
+}
+
+// BackupToDir writes a timestamped backup into dir and returns its path
+func (s *Storage) BackupToDir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("contracts-%s.db", time.Now().Format("20060102-150405")))
+	if err := s.Backup(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// readOnlyDSN returns a DSN opening the SQLite database at path read-only. go-sqlite3 only passes the
+// query string on to SQLite for "file:" URIs, where ?, # and % in the path must be escaped.
+func readOnlyDSN(path string) string {
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(filepath.ToSlash(path))
+	return "file:" + escaped + "?mode=ro"
+}
+
+// copyDatabase copies every page of the main database of src into dest
+func copyDatabase(dest, src *sql.DB) error {
+	ctx := context.Background()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := destDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("destination is not a SQLite connection")
+			}
+			srcSQLite, ok := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("source is not a SQLite connection")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			// Copy all pages at once, retrying while another connection holds a lock
+			deadline := time.Now().Add(backupTimeout)
+			for {
+				done, err := backup.Step(-1)
+				if err != nil {
+					backup.Finish()
+					return err
+				}
+				if done {
+					break
+				}
+				if time.Now().After(deadline) {
+					backup.Finish()
+					return fmt.Errorf("database stayed locked for %s", backupTimeout)
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NotificationChannel is a chat, push or email channel added on the dashboard settings page, notified
+// on top of the channels configured in the environment
+type NotificationChannel struct {
+	ID        int64             `json:"id"`
+	Kind      string            `json:"kind"`     // e.g. "slack" or "smtp"
+	Settings  map[string]string `json:"settings"` // Webhook URL, token, chats… by name, as the kind needs them
+	CreatedAt time.Time         `json:"created_at"`
+	CreatedBy string            `json:"created_by,omitempty"`
+}
+
+// Target names the channel as a notification target, e.g. "slack@3", in the delivery log and settings
+// such as NOTIFY_LANGUAGES. It is kept when other channels are added or removed.
+func (c NotificationChannel) Target() string {
+	return fmt.Sprintf("%s@%d", c.Kind, c.ID)
+}
+
+// GetNotificationChannels returns the channels added on the dashboard, oldest first
+func (s *Storage) GetNotificationChannels() ([]NotificationChannel, error) {
+	rows, err := s.db.Query(`SELECT id, kind, settings, created_at, created_by FROM notification_channels ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	channels := []NotificationChannel{}
+	for rows.Next() {
+		var channel NotificationChannel
+		var settings string
+		var createdBy sql.NullString
+		if err := rows.Scan(&channel.ID, &channel.Kind, &settings, &channel.CreatedAt, &createdBy); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		if err := json.Unmarshal([]byte(settings), &channel.Settings); err != nil {
+			return nil, fmt.Errorf("failed to decode settings of notification channel %d: %w", channel.ID, err)
+		}
+		channel.CreatedBy = createdBy.String
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+// AddNotificationChannel saves a channel and fills in its ID and creation time. The addition is
+// recorded in the audit log by actor; the settings are not, as they hold tokens and passwords.
+func (s *Storage) AddNotificationChannel(channel *NotificationChannel, actor string) error {
+	channel.Kind = strings.TrimSpace(channel.Kind)
+	if channel.Kind == "" {
+		return invalidf("a channel needs a kind")
+	}
+	settings, err := json.Marshal(channel.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode channel settings: %w", err)
+	}
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	channel.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	channel.CreatedBy = strings.TrimSpace(actor)
+	result, err := tx.Exec(`INSERT INTO notification_channels (kind, settings, created_at, created_by) VALUES (?, ?, ?, ?)`,
+		channel.Kind, string(settings), channel.CreatedAt, channel.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to save %s channel: %w", channel.Kind, err)
+	}
+	if channel.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get channel id: %w", err)
+	}
+
+	if err := recordAudit(tx, actor, AuditAddChannel, fmt.Sprintf("%s #%d", channel.Kind, channel.ID), 1); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteNotificationChannel removes a channel added on the dashboard, recording it in the audit log by actor
+func (s *Storage) DeleteNotificationChannel(id int64, actor string) error {
+	tx, err := s.beginWrite()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	var kind string
+	err = tx.QueryRow(`SELECT kind FROM notification_channels WHERE id = ?`, id).Scan(&kind)
+	if err == sql.ErrNoRows {
+		return notFoundf("channel %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get channel %d: %w", id, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM notification_channels WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete channel %d: %w", id, err)
+	}
+	if err := recordAudit(tx, actor, AuditRemoveChannel, fmt.Sprintf("%s #%d", kind, id), 1); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
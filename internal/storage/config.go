@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetConfig returns the runtime configuration saved from the dashboard settings page, by setting
+// name. Settings never saved are missing, so the environment variables apply to them.
+func (s *Storage) GetConfig() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT name, value FROM config_settings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config: %w", err)
+	}
+	defer rows.Close()
+
+	config := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan config setting: %w", err)
+		}
+		config[name] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return config, nil
+}
+
+// SetConfig saves settings of the runtime configuration. The settings whose value changed are
+// recorded in the audit log as one change by actor.
+func (s *Storage) SetConfig(values map[string]string, actor string) error {
+	current, err := s.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for name, value := range values {
+		if name == "" {
+			return invalidf("a setting needs a name")
+		}
+		if old, ok := current[name]; !ok || old != value {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	query := s.dialect.replaceQuery("config_settings", []string{"name", "value", "updated_at", "updated_by"},
+		[]string{"?", "?", "?", "?"}, nil)
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, name := range changed {
+		if _, err := tx.Exec(query, name, values[name], now, actor); err != nil {
+			return fmt.Errorf("failed to save setting %s: %w", name, err)
+		}
+	}
+
+	if err := recordAudit(tx, actor, AuditChangeSettings, strings.Join(changed, ", "), int64(len(changed))); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
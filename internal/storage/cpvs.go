@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"scraper/internal/scraper"
+)
+
+// A tender usually lists several CPV codes, so they are kept in contract_cpvs (one row per contract and
+// code) rather than in a contracts column. Codes are stored as their eight digits, without check digit.
+
+// CPVCount is a CPV code together with the number of contracts listing it
+type CPVCount struct {
+	Code  string `json:"code"`
+	Count int    `json:"count"`
+}
+
+// normalizeCPVCodes drops invalid and duplicate codes, keeping the order
+func normalizeCPVCodes(codes []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if code = scraper.NormalizeCPVCode(code); code != "" && !seen[code] {
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+	return normalized
+}
+
+// saveContractCPVs replaces the CPV codes of a contract within tx
+func saveContractCPVs(tx *sql.Tx, contractID string, codes []string) error {
+	if _, err := tx.Exec(`DELETE FROM contract_cpvs WHERE contract_uid = `+contractUIDOf, contractID); err != nil {
+		return fmt.Errorf("failed to clear CPV codes of contract %s: %w", contractID, err)
+	}
+
+	for _, code := range normalizeCPVCodes(codes) {
+		if _, err := tx.Exec(`INSERT INTO contract_cpvs (contract_uid, cpv_code) VALUES (`+contractUIDOf+`, ?)`, contractID, code); err != nil {
+			return fmt.Errorf("failed to save CPV code %s of contract %s: %w", code, contractID, err)
+		}
+	}
+	return nil
+}
+
+// GetContractsByCPV returns the active contracts listing any of the given CPV codes, most recently scraped first
+func (s *Storage) GetContractsByCPV(codes []string) ([]scraper.Contract, error) {
+	codes = normalizeCPVCodes(codes)
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no valid CPV code given")
+	}
+
+	contracts, _, err := s.GetContractsPage(ContractFilter{CPVCodes: codes}, DefaultContractSort, 0, 0)
+	return contracts, err
+}
+
+// GetCPVCodes lists every CPV code listed by a contract with its number of contracts, most used first
+func (s *Storage) GetCPVCodes() ([]CPVCount, error) {
+	query := `
+	SELECT p.cpv_code, COUNT(*) FROM contract_cpvs p
+	JOIN contracts c ON c.uid = p.contract_uid
+	WHERE c.deleted_at IS NULL
+	GROUP BY p.cpv_code
+	ORDER BY COUNT(*) DESC, p.cpv_code ASC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CPV codes: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []CPVCount
+	for rows.Next() {
+		var code CPVCount
+		if err := rows.Scan(&code.Code, &code.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan CPV code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CPV codes: %w", err)
+	}
+	return codes, nil
+}
+
+// contractCPVCodes returns the CPV codes of every contract by contract uid
+func (s *Storage) contractCPVCodes() (map[string][]string, error) {
+	stmt, err := s.prepared(`SELECT contract_uid, cpv_code FROM contract_cpvs ORDER BY cpv_code`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contract CPV codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := make(map[string][]string)
+	for rows.Next() {
+		var contractUID, code string
+		if err := rows.Scan(&contractUID, &code); err != nil {
+			return nil, fmt.Errorf("failed to scan contract CPV code: %w", err)
+		}
+		codes[contractUID] = append(codes[contractUID], code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read contract CPV codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// cpvCondition builds a "uid IN (contracts listing any of the codes)" condition and its arguments
+func cpvCondition(codes []string) (string, []interface{}) {
+	args := make([]interface{}, len(codes))
+	for i, code := range codes {
+		args[i] = scraper.NormalizeCPVCode(code)
+	}
+	return fmt.Sprintf("uid IN (SELECT contract_uid FROM contract_cpvs WHERE cpv_code IN (%s))", placeholders(len(codes))), args
+}
+
+// backfillContractCPVs fills contract_cpvs from the latest detail page stored for each contract. It runs
+// before migration 29, so it still refers to contracts by contract_id.
+func backfillContractCPVs(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT contract_id, html FROM raw_pages p
+		WHERE id = (SELECT MAX(id) FROM raw_pages WHERE contract_id = p.contract_id)`)
+	if err != nil {
+		return fmt.Errorf("failed to query raw pages: %w", err)
+	}
+
+	codes := make(map[string][]string)
+	for rows.Next() {
+		var contractID string
+		var compressed []byte
+		if err := rows.Scan(&contractID, &compressed); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan raw page: %w", err)
+		}
+
+		// A page that cannot be decompressed is skipped rather than failing the migration
+		if html, err := decompressPage(compressed); err == nil {
+			codes[contractID] = scraper.ParseCPVCodes(html)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read raw pages: %w", err)
+	}
+
+	for contractID, contractCodes := range codes {
+		for _, code := range normalizeCPVCodes(contractCodes) {
+			if _, err := tx.Exec(`INSERT INTO contract_cpvs (contract_id, cpv_code) VALUES (?, ?)`, contractID, code); err != nil {
+				return fmt.Errorf("failed to save CPV code %s of contract %s: %w", code, contractID, err)
+			}
+		}
+	}
+	return nil
+}
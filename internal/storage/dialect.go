@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dialect captures the SQL differences between the supported database engines
+type dialect interface {
+	// driverName returns the database/sql driver name
+	driverName() string
+	// keyType returns the column type used for indexed text columns such as contract IDs
+	keyType() string
+	// autoIncrementKey returns the definition of an auto-incrementing integer primary key column
+	autoIncrementKey() string
+	// tableOptions returns the options appended to CREATE TABLE statements
+	tableOptions() string
+	// daysAgo returns an expression for the current UTC time minus the given number of days
+	daysAgo(days int) string
+	// decimalType returns the column type used for monetary amounts
+	decimalType() string
+	// blobType returns the column type used for binary data such as compressed pages
+	blobType() string
+	// textIndexColumn returns the index column expression for a TEXT column
+	textIndexColumn(column string) string
+	// monthOf returns an expression formatting a timestamp column as "2006-01"
+	monthOf(column string) string
+	// dropIndex returns a statement removing an index from a table
+	dropIndex(table, index string) string
+	// configurePool sets the connection pool limits suited to the engine
+	configurePool(db *sql.DB)
+	// replaceQuery returns an insert statement that overwrites an existing row with the same key (the first column).
+	// Columns listed in keep are only overwritten when the incoming value is not NULL or empty.
+	replaceQuery(table string, columns, values, keep []string) string
+	// searchIndex returns the statements creating the full-text index of the contract ID, description
+	// and contracting body
+	searchIndex() []string
+	// searchCondition returns a condition on contracts matching every search term as a word prefix
+	searchCondition(terms []string) (string, []interface{})
+}
+
+// sqliteDialect is the dialect for the embedded SQLite database (the default)
+type sqliteDialect struct{}
+
+func (sqliteDialect) driverName() string { return "sqlite3" }
+
+func (sqliteDialect) keyType() string { return "TEXT" }
+
+func (sqliteDialect) autoIncrementKey() string { return "INTEGER PRIMARY KEY AUTOINCREMENT" }
+
+func (sqliteDialect) tableOptions() string { return "" }
+
+func (sqliteDialect) daysAgo(days int) string {
+	return fmt.Sprintf("datetime('now', '-%d day')", days)
+}
+
+func (sqliteDialect) decimalType() string { return "REAL" }
+
+func (sqliteDialect) blobType() string { return "BLOB" }
+
+func (sqliteDialect) textIndexColumn(column string) string { return column }
+
+func (sqliteDialect) monthOf(column string) string {
+	return fmt.Sprintf("strftime('%%Y-%%m', %s)", column)
+}
+
+func (sqliteDialect) dropIndex(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s", index)
+}
+
+// sqliteMaxConns bounds the SQLite connections. WAL lets them all read at once while writes queue
+// in beginWrite, so a few are enough for the dashboard next to a scrape.
+const sqliteMaxConns = 4
+
+// configurePool keeps every connection open: a local file never goes stale, and opening an encrypted
+// database runs the slow SQLCipher key derivation again
+func (sqliteDialect) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(sqliteMaxConns)
+	db.SetMaxIdleConns(sqliteMaxConns)
+	db.SetConnMaxLifetime(0)
+}
+
+// replaceQuery uses an upsert on the first column rather than INSERT OR REPLACE, which deletes the
+// old row first and so fails with foreign keys enabled while status_changes rows reference it
+func (sqliteDialect) replaceQuery(table string, columns, values, keep []string) string {
+	updates := make([]string, len(columns)-1)
+	for i, column := range columns[1:] {
+		updates[i] = fmt.Sprintf("%s = %s", column, keepExisting(column, "excluded."+column, table+"."+column, keep))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		table, strings.Join(columns, ", "), strings.Join(values, ", "), columns[0], strings.Join(updates, ", "))
+}
+
+// searchIndex keeps an FTS4 table in step with contracts through triggers, its docid being the
+// contract rowid. FTS5 would rank by itself but needs a build tag of the SQLite driver.
+func (sqliteDialect) searchIndex() []string {
+	return []string{
+		`CREATE VIRTUAL TABLE contracts_search USING fts4(id, description, contracting_body, tokenize=unicode61 "remove_diacritics=2")`,
+		`INSERT INTO contracts_search (docid, id, description, contracting_body)
+		SELECT rowid, id, COALESCE(description, ''), COALESCE(contracting_body, '') FROM contracts`,
+		`CREATE TRIGGER contracts_search_insert AFTER INSERT ON contracts BEGIN
+			INSERT INTO contracts_search (docid, id, description, contracting_body)
+			VALUES (new.rowid, new.id, COALESCE(new.description, ''), COALESCE(new.contracting_body, ''));
+		END`,
+		`CREATE TRIGGER contracts_search_update AFTER UPDATE OF id, description, contracting_body ON contracts BEGIN
+			DELETE FROM contracts_search WHERE docid = old.rowid;
+			INSERT INTO contracts_search (docid, id, description, contracting_body)
+			VALUES (new.rowid, new.id, COALESCE(new.description, ''), COALESCE(new.contracting_body, ''));
+		END`,
+		`CREATE TRIGGER contracts_search_delete AFTER DELETE ON contracts BEGIN
+			DELETE FROM contracts_search WHERE docid = old.rowid;
+		END`,
+	}
+}
+
+func (sqliteDialect) searchCondition(terms []string) (string, []interface{}) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + "*"
+	}
+	return "rowid IN (SELECT docid FROM contracts_search WHERE contracts_search MATCH ?)", []interface{}{strings.Join(prefixes, " ")}
+}
+
+// mysqlDialect is the dialect for MySQL and MariaDB servers
+type mysqlDialect struct{}
+
+func (mysqlDialect) driverName() string { return "mysql" }
+
+func (mysqlDialect) keyType() string { return "VARCHAR(255)" }
+
+func (mysqlDialect) autoIncrementKey() string { return "BIGINT AUTO_INCREMENT PRIMARY KEY" }
+
+func (mysqlDialect) tableOptions() string {
+	return " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+}
+
+func (mysqlDialect) daysAgo(days int) string {
+	return fmt.Sprintf("UTC_TIMESTAMP() - INTERVAL %d DAY", days)
+}
+
+func (mysqlDialect) decimalType() string { return "DECIMAL(18,2)" }
+
+// blobType is LONGBLOB because a BLOB holds at most 64KB
+func (mysqlDialect) blobType() string { return "LONGBLOB" }
+
+// textIndexColumn indexes a prefix because MySQL cannot index a whole TEXT column
+func (mysqlDialect) textIndexColumn(column string) string { return column + "(191)" }
+
+func (mysqlDialect) monthOf(column string) string {
+	return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m')", column)
+}
+
+func (mysqlDialect) dropIndex(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s", index, table)
+}
+
+// mysqlMaxConns bounds the MySQL connections of one process
+const mysqlMaxConns = 10
+
+// mysqlConnMaxLifetime recycles connections before the server or a proxy drops them as idle
+const mysqlConnMaxLifetime = 3 * time.Minute
+
+func (mysqlDialect) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(mysqlMaxConns)
+	db.SetMaxIdleConns(mysqlMaxConns)
+	db.SetConnMaxLifetime(mysqlConnMaxLifetime)
+}
+
+// replaceQuery uses ON DUPLICATE KEY UPDATE because REPLACE deletes the old row first,
+// which InnoDB rejects while status_changes rows still reference it
+func (mysqlDialect) replaceQuery(table string, columns, values, keep []string) string {
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		updates[i] = fmt.Sprintf("%s = %s", column, keepExisting(column, "VALUES("+column+")", column, keep))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		table, strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(updates, ", "))
+}
+
+// searchIndex uses an InnoDB FULLTEXT index, which skips words shorter than innodb_ft_min_token_size
+// (3 by default) and stopwords
+func (mysqlDialect) searchIndex() []string {
+	return []string{`CREATE FULLTEXT INDEX idx_contracts_search ON contracts (id, description, contracting_body)`}
+}
+
+func (mysqlDialect) searchCondition(terms []string) (string, []interface{}) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = "+" + term + "*"
+	}
+	return "MATCH (id, description, contracting_body) AGAINST (? IN BOOLEAN MODE)", []interface{}{strings.Join(prefixes, " ")}
+}
+
+// keepExisting returns the update expression for a column: the incoming value, or for columns listed
+// in keep the incoming value unless it is NULL or empty, in which case the stored one stays
+func keepExisting(column, incoming, existing string, keep []string) string {
+	for _, k := range keep {
+		if k == column {
+			return fmt.Sprintf("COALESCE(NULLIF(%s, ''), %s)", incoming, existing)
+		}
+	}
+	return incoming
+}
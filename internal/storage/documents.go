@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Document types, matching the links scraped for each contract
+const (
+	DocumentPliego  = "pliego"
+	DocumentAnuncio = "anuncio"
+)
+
+// Document is a downloaded copy of a contract document. Every download that produced a different
+// file is kept, so comparing the latest two versions shows whether a document changed.
+type Document struct {
+	ID           int64     `json:"id"`
+	ContractID   string    `json:"contract_id"`
+	Type         string    `json:"type"` // DocumentPliego or DocumentAnuncio
+	URL          string    `json:"url"`
+	LocalPath    string    `json:"local_path"`
+	SHA256       string    `json:"sha256"` // Hex digest of the file contents
+	Size         int64     `json:"size"`   // File size in bytes
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// documentColumns is the column list read by scanDocument
+const documentColumns = `id, ` + contractIDOf + `, type, url, local_path, sha256, size, downloaded_at`
+
+// scanDocument reads a row selected with documentColumns
+func scanDocument(row rowScanner) (Document, error) {
+	var doc Document
+	var localPath, sha sql.NullString
+	var size sql.NullInt64
+	err := row.Scan(&doc.ID, &doc.ContractID, &doc.Type, &doc.URL, &localPath, &sha, &size, &doc.DownloadedAt)
+	doc.LocalPath = localPath.String
+	doc.SHA256 = sha.String
+	doc.Size = size.Int64
+	return doc, err
+}
+
+// SaveDocument records a downloaded document and sets its ID. DownloadedAt defaults to now.
+func (s *Storage) SaveDocument(doc *Document) error {
+	if doc.Type == "" || doc.URL == "" {
+		return fmt.Errorf("document type and URL are required")
+	}
+	if err := s.requireContract(doc.ContractID); err != nil {
+		return err
+	}
+	if doc.DownloadedAt.IsZero() {
+		doc.DownloadedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	result, err := s.exec(`INSERT INTO documents (contract_uid, type, url, local_path, sha256, size, downloaded_at) VALUES (`+contractUIDOf+`, ?, ?, ?, ?, ?, ?)`,
+		doc.ContractID, doc.Type, doc.URL, doc.LocalPath, doc.SHA256, doc.Size, doc.DownloadedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save %s document of contract %s: %w", doc.Type, doc.ContractID, err)
+	}
+
+	doc.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get document id: %w", err)
+	}
+	return nil
+}
+
+// GetDocuments retrieves every downloaded version of the documents of a contract, newest first
+func (s *Storage) GetDocuments(contractID string) ([]Document, error) {
+	stmt, err := s.prepared(`SELECT ` + documentColumns + ` FROM documents WHERE contract_uid = ` + contractUIDOf + ` ORDER BY downloaded_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	rows, err := stmt.Query(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+	return docs, nil
+}
+
+// GetLatestDocument returns the most recent download of a contract document, or nil if it was never downloaded
+func (s *Storage) GetLatestDocument(contractID, docType string) (*Document, error) {
+	stmt, err := s.prepared(`SELECT ` + documentColumns + ` FROM documents WHERE contract_uid = ` + contractUIDOf + ` AND type = ? ORDER BY downloaded_at DESC, id DESC LIMIT 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query document: %w", err)
+	}
+
+	doc, err := scanDocument(stmt.QueryRow(contractID, docType))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s document of contract %s: %w", docType, contractID, err)
+	}
+	return &doc, nil
+}
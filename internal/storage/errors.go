@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the store can be told apart with errors.Is, e.g. to answer 404 or 400 over HTTP
+var (
+	ErrNotFound = errors.New("not found")       // The contract, note, profile, ... does not exist
+	ErrInvalid  = errors.New("invalid request") // The input was rejected, e.g. an empty note
+)
+
+// kindError is an error of one of the kinds above that keeps its own message
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string { return e.message }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// notFoundf formats an ErrNotFound error
+func notFoundf(format string, args ...interface{}) error {
+	return &kindError{kind: ErrNotFound, message: fmt.Sprintf(format, args...)}
+}
+
+// invalidf formats an ErrInvalid error
+func invalidf(format string, args ...interface{}) error {
+	return &kindError{kind: ErrInvalid, message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// ContractFilter narrows down contract queries. Zero values mean "no restriction".
+type ContractFilter struct {
+	Statuses        []string  // Exact status values, e.g. "Publicada"
+	ContractingBody string    // Substring of the contracting body
+	MinAmount       float64   // Minimum estimated amount in euros
+	MaxAmount       float64   // Maximum estimated amount in euros
+	ScrapedFrom     time.Time // Only contracts scraped at or after this time
+	ScrapedTo       time.Time // Only contracts scraped before this time
+	DeadlineFrom    time.Time // Only contracts with a deadline at or after this time
+	DeadlineTo      time.Time // Only contracts with a deadline before this time
+	Text            string    // Substring of the ID, description or contracting body
+	Search          string    // Words that must all start a word of the ID, description or contracting body, see SearchContracts
+	Tags            []string  // Only contracts carrying at least one of these tags
+	ExcludeTags     []string  // Skip contracts carrying any of these tags, unless they are watched
+	ProfileID       int64     // Only contracts of this search profile
+	CPVCodes        []string  // Only contracts listing at least one of these CPV codes
+	Watched         bool      // Only watched contracts
+	Unseen          bool      // Only contracts not yet listed in the dashboard
+	Archive         ArchiveScope
+}
+
+// ArchiveScope selects whether archived contracts are part of a query
+type ArchiveScope int
+
+const (
+	ArchiveExclude ArchiveScope = iota // Only active contracts (the default)
+	ArchiveInclude                     // Active and archived contracts
+	ArchiveOnly                        // Only archived contracts
+)
+
+// SortField identifies a column contracts can be ordered by
+type SortField string
+
+const (
+	SortByScrapedAt   SortField = "scraped_at"
+	SortByStatus      SortField = "status"
+	SortByAmount      SortField = "amount"
+	SortByDeadline    SortField = "deadline"
+	SortByID          SortField = "id"
+	SortByFirstSeenAt SortField = "first_seen_at"
+	SortByArchivedAt  SortField = "archived_at"
+)
+
+// ContractSort selects the ordering of contract queries
+type ContractSort struct {
+	Field      SortField
+	Descending bool
+}
+
+// DefaultContractSort lists the most recently scraped contracts first, like GetContracts
+var DefaultContractSort = ContractSort{Field: SortByScrapedAt, Descending: true}
+
+// GetContractsPage returns one page of contracts matching the filter together with the total
+// number of matching contracts. A limit <= 0 returns every matching contract.
+func (s *Storage) GetContractsPage(filter ContractFilter, sort ContractSort, limit, offset int) ([]scraper.Contract, int, error) {
+	where, args := s.contractWhereClause(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM contracts` + where
+	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count contracts: %w", err)
+	}
+
+	query := `SELECT ` + contractColumns + ` FROM contracts` + where + s.contractOrderClause(sort)
+	if limit > 0 {
+		if offset < 0 {
+			offset = 0
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query contracts page: %w", err)
+	}
+	defer rows.Close()
+
+	contracts, err := scanContracts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.attachUserData(contracts); err != nil {
+		return nil, 0, err
+	}
+
+	return contracts, total, nil
+}
+
+// ForEachContract calls fn for every contract matching the filter, in the order of sort, reading one
+// row at a time so memory use does not grow with the table. Contracts come with their tags, CPV codes
+// and watchlist flag like GetContractsPage. Iteration stops at the first error returned by fn. fn must
+// not write to the database while the iteration is running.
+func (s *Storage) ForEachContract(filter ContractFilter, sort ContractSort, fn func(contract scraper.Contract) error) error {
+	userData, err := s.loadUserData()
+	if err != nil {
+		return err
+	}
+
+	where, args := s.contractWhereClause(filter)
+	query := `SELECT ` + contractColumns + ` FROM contracts` + where + s.contractOrderClause(sort)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query contracts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		contract, err := scanContract(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan contract: %w", err)
+		}
+		userData.attach(&contract)
+		if err := fn(contract); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read contracts: %w", err)
+	}
+	return nil
+}
+
+// contractWhereClause builds the WHERE clause and its arguments for a filter
+func (s *Storage) contractWhereClause(filter ContractFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	// Soft-deleted contracts never show up in filtered listings
+	conditions = append(conditions, notDeleted)
+
+	switch filter.Archive {
+	case ArchiveExclude:
+		conditions = append(conditions, "archived_at IS NULL")
+	case ArchiveOnly:
+		conditions = append(conditions, "archived_at IS NOT NULL")
+	}
+
+	if filter.ProfileID != 0 {
+		conditions = append(conditions, "profile_id = ?")
+		args = append(args, filter.ProfileID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = "?"
+			args = append(args, status)
+		}
+		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+
+	if filter.ContractingBody != "" {
+		conditions = append(conditions, "contracting_body LIKE ?")
+		args = append(args, "%"+filter.ContractingBody+"%")
+	}
+
+	if filter.MinAmount > 0 {
+		conditions = append(conditions, "amount_value >= ?")
+		args = append(args, filter.MinAmount)
+	}
+
+	if filter.MaxAmount > 0 {
+		conditions = append(conditions, "amount_value <= ?")
+		args = append(args, filter.MaxAmount)
+	}
+
+	if !filter.ScrapedFrom.IsZero() {
+		conditions = append(conditions, "scraped_at >= ?")
+		args = append(args, filter.ScrapedFrom.UTC())
+	}
+
+	if !filter.ScrapedTo.IsZero() {
+		conditions = append(conditions, "scraped_at < ?")
+		args = append(args, filter.ScrapedTo.UTC())
+	}
+
+	if !filter.DeadlineFrom.IsZero() {
+		conditions = append(conditions, "deadline >= ?")
+		args = append(args, filter.DeadlineFrom.UTC())
+	}
+
+	if !filter.DeadlineTo.IsZero() {
+		conditions = append(conditions, "deadline < ?")
+		args = append(args, filter.DeadlineTo.UTC())
+	}
+
+	if filter.Text != "" {
+		conditions = append(conditions, "(id LIKE ? OR description LIKE ? OR contracting_body LIKE ?)")
+		pattern := "%" + filter.Text + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	if terms := searchTerms(filter.Search); len(terms) > 0 {
+		condition, searchArgs := s.dialect.searchCondition(terms)
+		conditions = append(conditions, condition)
+		args = append(args, searchArgs...)
+	}
+
+	if len(filter.Tags) > 0 {
+		condition, tagArgs := tagCondition("IN", filter.Tags)
+		conditions = append(conditions, condition)
+		args = append(args, tagArgs...)
+	}
+
+	if len(filter.CPVCodes) > 0 {
+		condition, cpvArgs := cpvCondition(filter.CPVCodes)
+		conditions = append(conditions, condition)
+		args = append(args, cpvArgs...)
+	}
+
+	if filter.Watched {
+		conditions = append(conditions, "uid IN (SELECT contract_uid FROM watchlist)")
+	}
+
+	if filter.Unseen {
+		conditions = append(conditions, "seen_at IS NULL")
+	}
+
+	if len(filter.ExcludeTags) > 0 {
+		condition, tagArgs := tagCondition("NOT IN", filter.ExcludeTags)
+		conditions = append(conditions, "("+condition+" OR uid IN (SELECT contract_uid FROM watchlist))")
+		args = append(args, tagArgs...)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// contractOrderClause builds the ORDER BY clause for a sort, falling back to the default order
+func (s *Storage) contractOrderClause(sort ContractSort) string {
+	var column string
+	switch sort.Field {
+	case SortByStatus:
+		column = "status"
+	case SortByAmount:
+		column = "amount_value"
+	case SortByDeadline:
+		column = "deadline"
+	case SortByID:
+		column = "id"
+	case SortByScrapedAt:
+		column = "scraped_at"
+	case SortByFirstSeenAt:
+		column = "first_seen_at"
+	case SortByArchivedAt:
+		column = "archived_at"
+	default:
+		sort = DefaultContractSort
+		column = "scraped_at"
+	}
+
+	direction := "ASC"
+	if sort.Descending {
+		direction = "DESC"
+	}
+
+	// Tie-break on id so pages are stable
+	return fmt.Sprintf(" ORDER BY %s %s, id ASC", column, direction)
+}
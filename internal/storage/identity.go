@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// Expediente numbers such as "13/25" are reused by different contracting bodies, so a contract is
+// identified by its normalized expediente together with its normalized contracting body (the
+// expediente and body_key columns, unique together within a search profile). The id column is a
+// surrogate key: the expediente for the first contract seen with that number, the expediente plus a
+// hash of the body for later ones.
+// Existing ids never change, so tags, notes and history keep pointing at the same contract.
+
+// maxKeyLength is the length of the key columns on MySQL (VARCHAR(255))
+const maxKeyLength = 255
+
+// normalizeExpediente upper-cases an expediente number and drops whitespace, so "13/25 " and "13 / 25" match
+func normalizeExpediente(expediente string) string {
+	return truncateKey(strings.ToUpper(strings.Join(strings.Fields(expediente), "")))
+}
+
+// normalizeBody lower-cases a contracting body name and collapses its whitespace
+func normalizeBody(body string) string {
+	return truncateKey(strings.ToLower(strings.Join(strings.Fields(body), " ")))
+}
+
+// truncateKey cuts a value to maxKeyLength characters so it fits an indexed key column
+func truncateKey(value string) string {
+	runes := []rune(value)
+	if len(runes) > maxKeyLength {
+		return string(runes[:maxKeyLength])
+	}
+	return value
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// resolverBatchSize bounds the number of placeholders in one preload query
+const resolverBatchSize = 500
+
+// storedIdentity is the id and body key of a stored contract
+type storedIdentity struct {
+	id      string
+	bodyKey string
+}
+
+// contractResolver maps scraped contracts to the ids they are stored under
+type contractResolver struct {
+	db       queryer
+	assigned map[string]string // ids handed out to new contracts of this batch, by expediente and body
+	taken    map[string]bool   // the same ids, so two new contracts never get the same one
+
+	// Filled by preload so resolve needs no query per contract
+	stored  map[string][]storedIdentity // stored contracts by profile and expediente
+	checked map[string]bool             // ids preload looked for, and so knows whether they are stored
+	known   map[string]bool             // the checked ids that are stored
+}
+
+// newContractResolver returns a resolver that reads through db, which may be a transaction
+func newContractResolver(db queryer) *contractResolver {
+	return &contractResolver{db: db, assigned: make(map[string]string), taken: make(map[string]bool)}
+}
+
+// preload reads the stored identities of a whole batch of scraped contracts with one query per
+// resolverBatchSize contracts, instead of the lookups resolve would otherwise run per contract
+func (r *contractResolver) preload(contracts []scraper.Contract) error {
+	r.stored = make(map[string][]storedIdentity)
+	r.checked = make(map[string]bool)
+	r.known = make(map[string]bool)
+
+	for start := 0; start < len(contracts); start += resolverBatchSize {
+		end := start + resolverBatchSize
+		if end > len(contracts) {
+			end = len(contracts)
+		}
+
+		var expedientes, ids []interface{}
+		for _, contract := range contracts[start:end] {
+			expediente := contract.Expediente
+			if expediente == "" {
+				expediente = contract.ID
+			}
+			expedientes = append(expedientes, normalizeExpediente(expediente))
+			ids = append(ids, strings.TrimSpace(expediente))
+			r.checked[strings.TrimSpace(expediente)] = true
+		}
+
+		query := fmt.Sprintf(`SELECT id, profile_id, expediente, body_key FROM contracts WHERE expediente IN (%s) OR id IN (%s)`,
+			placeholders(len(expedientes)), placeholders(len(ids)))
+		rows, err := r.db.Query(query, append(expedientes, ids...)...)
+		if err != nil {
+			return fmt.Errorf("failed to look up contracts: %w", err)
+		}
+
+		for rows.Next() {
+			var id string
+			var profile int64
+			var expediente, bodyKey sql.NullString
+			if err := rows.Scan(&id, &profile, &expediente, &bodyKey); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan contract: %w", err)
+			}
+			r.known[id] = true
+			key := identityKey(profile, expediente.String)
+			r.stored[key] = append(r.stored[key], storedIdentity{id: id, bodyKey: bodyKey.String})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read contracts: %w", err)
+		}
+	}
+	return nil
+}
+
+// identityKey keys stored contracts by profile and normalized expediente
+func identityKey(profile int64, expediente string) string {
+	return fmt.Sprintf("%d\x00%s", profile, expediente)
+}
+
+// lookup returns the stored contracts of a profile with the given normalized expediente
+func (r *contractResolver) lookup(profile int64, expediente string) ([]storedIdentity, error) {
+	if r.stored != nil {
+		return r.stored[identityKey(profile, expediente)], nil
+	}
+
+	rows, err := r.db.Query(`SELECT id, body_key FROM contracts WHERE profile_id = ? AND expediente = ?`, profile, expediente)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up contract %s: %w", expediente, err)
+	}
+	defer rows.Close()
+
+	var stored []storedIdentity
+	for rows.Next() {
+		var identity storedIdentity
+		var bodyKey sql.NullString
+		if err := rows.Scan(&identity.id, &bodyKey); err != nil {
+			return nil, fmt.Errorf("failed to scan contract %s: %w", expediente, err)
+		}
+		identity.bodyKey = bodyKey.String
+		stored = append(stored, identity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read contract %s: %w", expediente, err)
+	}
+	return stored, nil
+}
+
+// resolve sets the ID of a scraped contract to the id it is stored under and reports whether it is
+// already stored. A contract scraped without a contracting body matches the only stored contract with
+// its expediente. New contracts get a fresh id that is not in use yet.
+func (r *contractResolver) resolve(contract *scraper.Contract) (bool, error) {
+	if contract.Expediente == "" {
+		contract.Expediente = contract.ID
+	}
+	expediente, body := normalizeExpediente(contract.Expediente), normalizeBody(contract.ContractingBody)
+
+	stored, err := r.lookup(profileID(contract.ProfileID), expediente)
+	if err != nil {
+		return false, err
+	}
+
+	var ids, withoutBody []string
+	match := ""
+	for _, identity := range stored {
+		ids = append(ids, identity.id)
+		if identity.bodyKey == body {
+			match = identity.id
+		} else if identity.bodyKey == "" {
+			withoutBody = append(withoutBody, identity.id)
+		}
+	}
+
+	switch {
+	case match == "" && body == "" && len(ids) == 1:
+		match = ids[0]
+	case match == "" && len(withoutBody) == 1:
+		match = withoutBody[0]
+	}
+	if match != "" {
+		contract.ID = match
+		return true, nil
+	}
+
+	key := fmt.Sprintf("%d\x00%s\x00%s", profileID(contract.ProfileID), expediente, body)
+	if id, ok := r.assigned[key]; ok {
+		contract.ID = id
+		return false, nil
+	}
+
+	id := strings.TrimSpace(contract.Expediente)
+	for attempt := 0; ; attempt++ {
+		candidate := id
+		if attempt > 0 {
+			candidate = fmt.Sprintf("%s-%s", id, bodyHash(body, attempt))
+		}
+
+		used, err := r.idUsed(candidate)
+		if err != nil {
+			return false, err
+		}
+		if !used {
+			id = candidate
+			break
+		}
+	}
+
+	r.assigned[key] = id
+	r.taken[id] = true
+	contract.ID = id
+	return false, nil
+}
+
+// idUsed reports whether an id is stored or was handed out earlier in the batch
+func (r *contractResolver) idUsed(id string) (bool, error) {
+	if r.taken[id] {
+		return true, nil
+	}
+	if r.checked[id] {
+		return r.known[id], nil
+	}
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM contracts WHERE id = ?`, id).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check contract id %s: %w", id, err)
+	}
+	return count > 0, nil
+}
+
+// bodyHash returns a short hash of a contracting body used to tell apart contracts sharing an expediente
+func bodyHash(body string, attempt int) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s#%d", body, attempt)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// FindStoredContract returns the stored version of a scraped contract, or nil if it is not stored
+func (s *Storage) FindStoredContract(contract scraper.Contract) (*scraper.Contract, error) {
+	stored, err := newContractResolver(s.db).resolve(&contract)
+	if err != nil || !stored {
+		return nil, err
+	}
+	return s.GetContractByID(contract.ID)
+}
+
+// backfillContractIdentity fills the expediente and body_key of existing contracts from their id and
+// contracting body. Contracts that would share a key (the same tender stored under two spellings of
+// its expediente) keep their raw id as expediente so the unique index can be built.
+func backfillContractIdentity(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, contracting_body FROM contracts ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query contracts: %w", err)
+	}
+
+	type identity struct{ id, expediente, body string }
+	var identities []identity
+	used := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		var body sql.NullString
+		if err := rows.Scan(&id, &body); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan contract: %w", err)
+		}
+
+		contract := identity{id: id, expediente: normalizeExpediente(id), body: normalizeBody(body.String)}
+		if used[contract.expediente+"\x00"+contract.body] {
+			contract.expediente = id
+		}
+		used[contract.expediente+"\x00"+contract.body] = true
+		identities = append(identities, contract)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read contracts: %w", err)
+	}
+
+	for _, contract := range identities {
+		if _, err := tx.Exec(`UPDATE contracts SET expediente = ?, body_key = ? WHERE id = ?`,
+			contract.expediente, contract.body, contract.id); err != nil {
+			return fmt.Errorf("failed to backfill contract %s: %w", contract.id, err)
+		}
+	}
+	return nil
+}
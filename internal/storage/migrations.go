@@ -0,0 +1,750 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// migration is a versioned schema change that is applied once per database
+type migration struct {
+	version    int
+	name       string
+	statements func(d dialect) []string
+	// backfill optionally populates new columns after the statements ran
+	backfill func(tx *sql.Tx) error
+}
+
+// migrations lists every schema change in the order it must be applied.
+// Never edit a released migration; append a new one instead.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create contracts and status_changes tables",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS contracts (
+					id %s PRIMARY KEY,
+					description TEXT,
+					contract_type TEXT,
+					status TEXT,
+					amount TEXT,
+					submission_date TEXT,
+					contracting_body TEXT,
+					link TEXT,
+					pliego_link TEXT,
+					anuncio_link TEXT,
+					scraped_at DATETIME,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.keyType(), d.tableOptions()),
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS status_changes (
+					id %s,
+					contract_id %s NOT NULL,
+					old_status TEXT,
+					new_status TEXT NOT NULL,
+					changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (contract_id) REFERENCES contracts (id)
+				)%s`, d.autoIncrementKey(), d.keyType(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 2,
+		name:    "add typed amount_value and deadline columns",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`ALTER TABLE contracts ADD COLUMN amount_value %s`, d.decimalType()),
+				`ALTER TABLE contracts ADD COLUMN deadline DATETIME`,
+				`CREATE INDEX idx_contracts_amount_value ON contracts (amount_value)`,
+				`CREATE INDEX idx_contracts_deadline ON contracts (deadline)`,
+			}
+		},
+		backfill: backfillTypedColumns,
+	},
+	{
+		version: 3,
+		name:    "create contract_revisions table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS contract_revisions (
+					id %s,
+					contract_id %s NOT NULL,
+					field %s NOT NULL,
+					old_value TEXT,
+					new_value TEXT,
+					changed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_revisions_contract_id ON contract_revisions (contract_id)`,
+				`CREATE INDEX idx_contract_revisions_changed_at ON contract_revisions (changed_at)`,
+			}
+		},
+	},
+	{
+		version: 4,
+		name:    "add deleted_at column for soft deletes",
+		statements: func(d dialect) []string {
+			return []string{
+				`ALTER TABLE contracts ADD COLUMN deleted_at DATETIME`,
+				`CREATE INDEX idx_contracts_deleted_at ON contracts (deleted_at)`,
+			}
+		},
+	},
+	{
+		version: 5,
+		name:    "add archived_at column for archival",
+		statements: func(d dialect) []string {
+			return []string{
+				`ALTER TABLE contracts ADD COLUMN archived_at DATETIME`,
+				`CREATE INDEX idx_contracts_archived_at ON contracts (archived_at)`,
+			}
+		},
+	},
+	{
+		version: 6,
+		name:    "create scrape_runs table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS scrape_runs (
+					id %s,
+					started_at DATETIME NOT NULL,
+					finished_at DATETIME,
+					scraper_type TEXT,
+					profile TEXT,
+					status TEXT,
+					pages_processed INTEGER DEFAULT 0,
+					contracts_found INTEGER DEFAULT 0,
+					contracts_new INTEGER DEFAULT 0,
+					contracts_changed INTEGER DEFAULT 0,
+					errors TEXT
+				)%s`, d.autoIncrementKey(), d.tableOptions()),
+				`CREATE INDEX idx_scrape_runs_started_at ON scrape_runs (started_at)`,
+			}
+		},
+	},
+	{
+		version: 7,
+		name:    "create contract_tags table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS contract_tags (
+					contract_id %s NOT NULL,
+					tag %s NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (contract_id, tag)
+				)%s`, d.keyType(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_tags_tag ON contract_tags (tag)`,
+			}
+		},
+	},
+	{
+		version: 8,
+		name:    "create contract_notes table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS contract_notes (
+					id %s,
+					contract_id %s NOT NULL,
+					author TEXT,
+					body TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					updated_at DATETIME
+				)%s`, d.autoIncrementKey(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_notes_contract_id ON contract_notes (contract_id)`,
+			}
+		},
+	},
+	{
+		version: 9,
+		name:    "create watchlist table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS watchlist (
+					contract_id %s PRIMARY KEY,
+					watched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					reminded_deadline DATETIME
+				)%s`, d.keyType(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 10,
+		name:    "add first_seen_at column",
+		statements: func(d dialect) []string {
+			return []string{
+				`ALTER TABLE contracts ADD COLUMN first_seen_at DATETIME`,
+				`CREATE INDEX idx_contracts_first_seen_at ON contracts (first_seen_at)`,
+				// created_at was reset by every upsert, so the earliest recorded change is the best estimate when it is older
+				`UPDATE contracts SET first_seen_at = COALESCE(created_at, scraped_at)`,
+				`UPDATE contracts SET first_seen_at = (SELECT MIN(changed_at) FROM status_changes WHERE contract_id = contracts.id)
+				WHERE EXISTS (SELECT 1 FROM status_changes WHERE contract_id = contracts.id AND changed_at < contracts.first_seen_at)`,
+				`UPDATE contracts SET first_seen_at = (SELECT MIN(changed_at) FROM contract_revisions WHERE contract_id = contracts.id)
+				WHERE EXISTS (SELECT 1 FROM contract_revisions WHERE contract_id = contracts.id AND changed_at < contracts.first_seen_at)`,
+			}
+		},
+	},
+	{
+		version: 11,
+		name:    "add seen_at column for read state",
+		statements: func(d dialect) []string {
+			return []string{
+				`ALTER TABLE contracts ADD COLUMN seen_at DATETIME`,
+				`CREATE INDEX idx_contracts_seen_at ON contracts (seen_at)`,
+				// Contracts stored before read state existed count as seen
+				`UPDATE contracts SET seen_at = CURRENT_TIMESTAMP`,
+			}
+		},
+	},
+	{
+		version: 12,
+		name:    "add expediente and body_key columns unique per contracting body",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`ALTER TABLE contracts ADD COLUMN expediente %s`, d.keyType()),
+				fmt.Sprintf(`ALTER TABLE contracts ADD COLUMN body_key %s`, d.keyType()),
+				`CREATE UNIQUE INDEX idx_contracts_expediente_body ON contracts (expediente, body_key)`,
+			}
+		},
+		backfill: backfillContractIdentity,
+	},
+	{
+		version: 13,
+		name:    "add indices for status and history queries",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`CREATE INDEX idx_contracts_status ON contracts (%s)`, d.textIndexColumn("status")),
+				`CREATE INDEX idx_contracts_scraped_at ON contracts (scraped_at)`,
+				`CREATE INDEX idx_status_changes_contract_id ON status_changes (contract_id, changed_at)`,
+				// Recent-changes queries filter on changed_at alone
+				`CREATE INDEX idx_status_changes_changed_at ON status_changes (changed_at)`,
+			}
+		},
+	},
+	{
+		version: 14,
+		name:    "create profiles table and partition contracts by profile",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS profiles (
+					id %s,
+					name %s NOT NULL UNIQUE,
+					cpv_code TEXT,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.tableOptions()),
+				fmt.Sprintf(`INSERT INTO profiles (id, name) VALUES (%d, '%s')`, defaultProfileID, DefaultProfile),
+				fmt.Sprintf(`ALTER TABLE contracts ADD COLUMN profile_id INTEGER NOT NULL DEFAULT %d`, defaultProfileID),
+				// The same expediente and body may now be stored once per profile
+				d.dropIndex("contracts", "idx_contracts_expediente_body"),
+				`CREATE UNIQUE INDEX idx_contracts_profile_expediente_body ON contracts (profile_id, expediente, body_key)`,
+			}
+		},
+	},
+	{
+		version: 15,
+		name:    "create documents table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS documents (
+					id %s,
+					contract_id %s NOT NULL,
+					type %s NOT NULL,
+					url TEXT NOT NULL,
+					local_path TEXT,
+					sha256 %s,
+					size BIGINT,
+					downloaded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_documents_contract_id ON documents (contract_id, type)`,
+			}
+		},
+	},
+	{
+		version: 16,
+		name:    "create reminders table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS reminders (
+					id %s,
+					contract_id %s NOT NULL,
+					remind_at DATETIME NOT NULL,
+					channel %s NOT NULL,
+					deadline DATETIME,
+					sent_at DATETIME,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_reminders_due ON reminders (channel, sent_at, remind_at)`,
+				`CREATE INDEX idx_reminders_contract_id ON reminders (contract_id)`,
+				// Deadline reminders already sent through the watchlist are not sent again
+				fmt.Sprintf(`INSERT INTO reminders (contract_id, remind_at, channel, deadline, sent_at)
+				SELECT contract_id, reminded_deadline, '%s', reminded_deadline, CURRENT_TIMESTAMP
+				FROM watchlist WHERE reminded_deadline IS NOT NULL`, ReminderChannelEmail),
+			}
+		},
+	},
+	{
+		version: 17,
+		name:    "create raw_pages table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS raw_pages (
+					id %s,
+					contract_id %s NOT NULL,
+					scraped_at DATETIME NOT NULL,
+					sha256 %s NOT NULL,
+					size BIGINT NOT NULL,
+					html %s NOT NULL
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.blobType(), d.tableOptions()),
+				`CREATE INDEX idx_raw_pages_contract_id ON raw_pages (contract_id, scraped_at)`,
+				`CREATE INDEX idx_raw_pages_scraped_at ON raw_pages (scraped_at)`,
+			}
+		},
+	},
+	{
+		version: 18,
+		name:    "create audit_log table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS audit_log (
+					id %s,
+					actor TEXT NOT NULL,
+					action %s NOT NULL,
+					target TEXT,
+					affected BIGINT NOT NULL DEFAULT 0,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_audit_log_created_at ON audit_log (created_at)`,
+			}
+		},
+	},
+	{
+		version: 19,
+		name:    "create contract_cpvs table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS contract_cpvs (
+					contract_id %s NOT NULL,
+					cpv_code %s NOT NULL,
+					PRIMARY KEY (contract_id, cpv_code)
+				)%s`, d.keyType(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_cpvs_cpv_code ON contract_cpvs (cpv_code)`,
+			}
+		},
+		backfill: backfillContractCPVs,
+	},
+	{
+		version: 20,
+		name:    "add uid columns to contracts, status_changes and contract_revisions",
+		statements: func(d dialect) []string {
+			return []string{
+				`ALTER TABLE contracts ADD COLUMN uid CHAR(26)`,
+				`ALTER TABLE status_changes ADD COLUMN uid CHAR(26)`,
+				`ALTER TABLE contract_revisions ADD COLUMN uid CHAR(26)`,
+				`CREATE UNIQUE INDEX idx_contracts_uid ON contracts (uid)`,
+				`CREATE UNIQUE INDEX idx_status_changes_uid ON status_changes (uid)`,
+				`CREATE UNIQUE INDEX idx_contract_revisions_uid ON contract_revisions (uid)`,
+			}
+		},
+		backfill: backfillUIDs,
+	},
+	{
+		version: 21,
+		name:    "create notification_log table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS notification_log (
+					id %s,
+					target %s NOT NULL,
+					event %s NOT NULL,
+					status %s NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 1,
+					last_error TEXT,
+					payload %s,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					next_attempt_at DATETIME,
+					sent_at DATETIME
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.keyType(), d.blobType(), d.tableOptions()),
+				`CREATE INDEX idx_notification_log_status ON notification_log (status, next_attempt_at)`,
+			}
+		},
+	},
+	{
+		version: 22,
+		name:    "create notification_keys table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS notification_keys (
+					notification_key %s PRIMARY KEY,
+					notified_at DATETIME NOT NULL
+				)%s`, d.keyType(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 23,
+		name:    "create api_tokens table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS api_tokens (
+					id %s,
+					name %s NOT NULL UNIQUE,
+					token_hash %s NOT NULL UNIQUE,
+					hint TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					last_used_at DATETIME
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 24,
+		name:    "add status change acknowledgement",
+		statements: func(d dialect) []string {
+			return []string{
+				`ALTER TABLE status_changes ADD COLUMN acknowledged_at DATETIME`,
+				`ALTER TABLE status_changes ADD COLUMN acknowledged_by TEXT`,
+			}
+		},
+	},
+	{
+		version: 25,
+		name:    "create full-text search index on contracts",
+		statements: func(d dialect) []string {
+			return d.searchIndex()
+		},
+	},
+	{
+		version: 26,
+		name:    "create user_settings table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS user_settings (
+					user_name %s NOT NULL,
+					name %s NOT NULL,
+					value TEXT NOT NULL,
+					updated_at DATETIME NOT NULL,
+					PRIMARY KEY (user_name, name)
+				)%s`, d.keyType(), d.keyType(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 27,
+		name:    "create config_settings table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS config_settings (
+					name %s PRIMARY KEY,
+					value TEXT NOT NULL,
+					updated_at DATETIME NOT NULL,
+					updated_by TEXT
+				)%s`, d.keyType(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 28,
+		name:    "create notification_channels table",
+		statements: func(d dialect) []string {
+			return []string{
+				fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS notification_channels (
+					id %s,
+					kind TEXT NOT NULL,
+					settings TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					created_by TEXT
+				)%s`, d.autoIncrementKey(), d.tableOptions()),
+			}
+		},
+	},
+	{
+		version: 29,
+		name:    "reference contracts by uid instead of id",
+		statements: func(d dialect) []string {
+			var statements []string
+			statements = append(statements, referenceContractUID("status_changes",
+				[]string{"id", "old_status", "new_status", "changed_at", "uid", "acknowledged_at", "acknowledged_by"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					id %s,
+					contract_uid CHAR(26) NOT NULL,
+					old_status TEXT,
+					new_status TEXT NOT NULL,
+					changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					uid CHAR(26),
+					acknowledged_at DATETIME,
+					acknowledged_by TEXT,
+					FOREIGN KEY (contract_uid) REFERENCES contracts (uid)
+				)%s`, d.autoIncrementKey(), d.tableOptions()),
+				`CREATE INDEX idx_status_changes_contract_uid ON status_changes (contract_uid, changed_at)`,
+				`CREATE INDEX idx_status_changes_changed_at ON status_changes (changed_at)`,
+				`CREATE UNIQUE INDEX idx_status_changes_uid ON status_changes (uid)`,
+			)...)
+			statements = append(statements, referenceContractUID("contract_revisions",
+				[]string{"id", "field", "old_value", "new_value", "changed_at", "uid"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					id %s,
+					contract_uid CHAR(26) NOT NULL,
+					field %s NOT NULL,
+					old_value TEXT,
+					new_value TEXT,
+					changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					uid CHAR(26)
+				)%s`, d.autoIncrementKey(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_revisions_contract_uid ON contract_revisions (contract_uid)`,
+				`CREATE INDEX idx_contract_revisions_changed_at ON contract_revisions (changed_at)`,
+				`CREATE UNIQUE INDEX idx_contract_revisions_uid ON contract_revisions (uid)`,
+			)...)
+			statements = append(statements, referenceContractUID("contract_tags",
+				[]string{"tag", "created_at"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					contract_uid CHAR(26) NOT NULL,
+					tag %s NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (contract_uid, tag)
+				)%s`, d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_tags_tag ON contract_tags (tag)`,
+			)...)
+			statements = append(statements, referenceContractUID("contract_notes",
+				[]string{"id", "author", "body", "created_at", "updated_at"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					id %s,
+					contract_uid CHAR(26) NOT NULL,
+					author TEXT,
+					body TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					updated_at DATETIME
+				)%s`, d.autoIncrementKey(), d.tableOptions()),
+				`CREATE INDEX idx_contract_notes_contract_uid ON contract_notes (contract_uid)`,
+			)...)
+			statements = append(statements, referenceContractUID("watchlist",
+				[]string{"watched_at", "reminded_deadline"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					contract_uid CHAR(26) PRIMARY KEY,
+					watched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					reminded_deadline DATETIME
+				)%s`, d.tableOptions()),
+			)...)
+			statements = append(statements, referenceContractUID("documents",
+				[]string{"id", "type", "url", "local_path", "sha256", "size", "downloaded_at"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					id %s,
+					contract_uid CHAR(26) NOT NULL,
+					type %s NOT NULL,
+					url TEXT NOT NULL,
+					local_path TEXT,
+					sha256 %s,
+					size BIGINT,
+					downloaded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_documents_contract_uid ON documents (contract_uid, type)`,
+			)...)
+			statements = append(statements, referenceContractUID("reminders",
+				[]string{"id", "remind_at", "channel", "deadline", "sent_at", "created_at"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					id %s,
+					contract_uid CHAR(26) NOT NULL,
+					remind_at DATETIME NOT NULL,
+					channel %s NOT NULL,
+					deadline DATETIME,
+					sent_at DATETIME,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)%s`, d.autoIncrementKey(), d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_reminders_due ON reminders (channel, sent_at, remind_at)`,
+				`CREATE INDEX idx_reminders_contract_uid ON reminders (contract_uid)`,
+			)...)
+			statements = append(statements, referenceContractUID("raw_pages",
+				[]string{"id", "scraped_at", "sha256", "size", "html"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					id %s,
+					contract_uid CHAR(26) NOT NULL,
+					scraped_at DATETIME NOT NULL,
+					sha256 %s NOT NULL,
+					size BIGINT NOT NULL,
+					html %s NOT NULL
+				)%s`, d.autoIncrementKey(), d.keyType(), d.blobType(), d.tableOptions()),
+				`CREATE INDEX idx_raw_pages_contract_uid ON raw_pages (contract_uid, scraped_at)`,
+				`CREATE INDEX idx_raw_pages_scraped_at ON raw_pages (scraped_at)`,
+			)...)
+			statements = append(statements, referenceContractUID("contract_cpvs",
+				[]string{"cpv_code"},
+				fmt.Sprintf(`
+				CREATE TABLE %%s (
+					contract_uid CHAR(26) NOT NULL,
+					cpv_code %s NOT NULL,
+					PRIMARY KEY (contract_uid, cpv_code)
+				)%s`, d.keyType(), d.tableOptions()),
+				`CREATE INDEX idx_contract_cpvs_cpv_code ON contract_cpvs (cpv_code)`,
+			)...)
+			return statements
+		},
+	},
+	{
+		version: 30,
+		name:    "store scraped_at in UTC",
+		statements: func(d dialect) []string {
+			// MySQL already converts every timestamp to UTC, see NewMySQLStorage. SQLite kept the
+			// offset of the local time the scrape ran at, which sorts and compares as text.
+			if _, ok := d.(sqliteDialect); !ok {
+				return nil
+			}
+			return []string{
+				`UPDATE contracts SET scraped_at = strftime('%Y-%m-%d %H:%M:%f+00:00', scraped_at)
+				WHERE scraped_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND scraped_at NOT GLOB '*+00:00'`,
+			}
+		},
+	},
+}
+
+// referenceContractUID returns the statements rebuilding table with a contract_uid column holding the
+// uid of the contract in place of contract_id, so the contract id can change without breaking the
+// reference. create defines the new table, named by its %s; columns are those copied as they are.
+// Rows of contracts that are no longer stored are dropped. The indexes are created last, as SQLite
+// only frees their names when the old table is dropped.
+func referenceContractUID(table string, columns []string, create string, indexes ...string) []string {
+	rebuilt := table + "_by_uid"
+	return append([]string{
+		fmt.Sprintf(create, rebuilt),
+		fmt.Sprintf(`INSERT INTO %s (contract_uid, %s) SELECT c.uid, t.%s FROM %s t JOIN contracts c ON c.id = t.contract_id`,
+			rebuilt, strings.Join(columns, ", "), strings.Join(columns, ", t."), table),
+		`DROP TABLE ` + table,
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, rebuilt, table),
+	}, indexes...)
+}
+
+// backfillTypedColumns parses the amount and submission date text of existing contracts
+func backfillTypedColumns(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, amount, submission_date FROM contracts`)
+	if err != nil {
+		return fmt.Errorf("failed to query contracts: %w", err)
+	}
+
+	var contracts []scraper.Contract
+	for rows.Next() {
+		var contract scraper.Contract
+		var amount, submissionDate sql.NullString
+		if err := rows.Scan(&contract.ID, &amount, &submissionDate); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan contract: %w", err)
+		}
+		contract.Amount = amount.String
+		contract.SubmissionDate = submissionDate.String
+		contract.ParseTypedFields()
+		contracts = append(contracts, contract)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read contracts: %w", err)
+	}
+
+	for _, contract := range contracts {
+		_, err := tx.Exec(`UPDATE contracts SET amount_value = ?, deadline = ? WHERE id = ?`,
+			nullableAmount(contract), nullableDeadline(contract), contract.ID)
+		if err != nil {
+			return fmt.Errorf("failed to backfill contract %s: %w", contract.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// migrate brings the database schema up to date, recording applied versions in schema_migrations
+func (s *Storage) migrate() error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)%s`, s.dialect.tableOptions())
+
+	if _, err := s.exec(query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := s.db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := s.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		log.Printf("Applied database migration %d: %s", m.version, m.name)
+	}
+
+	return nil
+}
+
+// applyMigration runs a single migration inside a transaction.
+// MySQL commits DDL statements implicitly, so on that backend a failed migration
+// may leave its earlier statements applied.
+func (s *Storage) applyMigration(m migration) error {
+	tx, err := s.beginWrite()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	for _, statement := range m.statements(s.dialect) {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	if m.backfill != nil {
+		if err := m.backfill(tx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
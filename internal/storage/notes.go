@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ContractNote is an internal comment attached to a contract
+type ContractNote struct {
+	ID         int64      `json:"id"`
+	ContractID string     `json:"contract_id"`
+	Author     string     `json:"author"`
+	Body       string     `json:"body"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"` // Set once the note has been edited
+}
+
+// AddNote attaches a note to a contract
+func (s *Storage) AddNote(contractID, author, body string) (*ContractNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, invalidf("note text is required")
+	}
+
+	if err := s.requireContract(contractID); err != nil {
+		return nil, err
+	}
+
+	note := &ContractNote{
+		ContractID: contractID,
+		Author:     strings.TrimSpace(author),
+		Body:       body,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+
+	result, err := s.exec(`INSERT INTO contract_notes (contract_uid, author, body, created_at) VALUES (`+contractUIDOf+`, ?, ?, ?)`,
+		note.ContractID, note.Author, note.Body, note.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add note to contract %s: %w", contractID, err)
+	}
+
+	note.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note id: %w", err)
+	}
+
+	return note, nil
+}
+
+// UpdateNote replaces the text of a note
+func (s *Storage) UpdateNote(noteID int64, body string) error {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return invalidf("note text is required")
+	}
+
+	result, err := s.exec(`UPDATE contract_notes SET body = ?, updated_at = ? WHERE id = ?`,
+		body, time.Now().UTC().Truncate(time.Second), noteID)
+	if err != nil {
+		return fmt.Errorf("failed to update note %d: %w", noteID, err)
+	}
+
+	return requireRowAffected(result, fmt.Sprintf("note %d not found", noteID))
+}
+
+// DeleteNote removes a note
+func (s *Storage) DeleteNote(noteID int64) error {
+	result, err := s.exec(`DELETE FROM contract_notes WHERE id = ?`, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to delete note %d: %w", noteID, err)
+	}
+
+	return requireRowAffected(result, fmt.Sprintf("note %d not found", noteID))
+}
+
+// GetNotes retrieves the notes of a contract, oldest first so they read as a conversation
+func (s *Storage) GetNotes(contractID string) ([]ContractNote, error) {
+	query := `
+	SELECT id, ` + contractIDOf + `, author, body, created_at, updated_at
+	FROM contract_notes
+	WHERE contract_uid = ` + contractUIDOf + `
+	ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := s.db.Query(query, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []ContractNote
+	for rows.Next() {
+		var note ContractNote
+		var author sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&note.ID, &note.ContractID, &author, &note.Body, &note.CreatedAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+
+		note.Author = author.String
+		if updatedAt.Valid {
+			note.UpdatedAt = &updatedAt.Time
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	return notes, nil
+}
+
+// requireRowAffected returns an error with the given message when a statement changed no rows
+func requireRowAffected(result sql.Result, notFound string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFoundf("%s", notFound)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Statuses of notification log entries
+const (
+	NotificationSent    = "sent"
+	NotificationPending = "pending" // Failed, waiting for a retry
+	NotificationFailed  = "failed"  // Given up after MaxNotificationAttempts
+	NotificationHeld    = "held"    // Not sent yet, e.g. during quiet hours, to go out in the next digest
+)
+
+// MaxNotificationAttempts is how many times a notification is sent before giving up
+const MaxNotificationAttempts = 8
+
+// notificationRetryDelay is the wait before the first retry of a failed notification; it doubles
+// after every failed attempt, so the last retry happens about 10 hours after the first failure
+const notificationRetryDelay = 5 * time.Minute
+
+// NotificationLogEntry is a notification delivered, or to be delivered, to one target (the email or
+// a channel). The payload is kept only until the notification is sent.
+type NotificationLogEntry struct {
+	ID            int64      `json:"id"`
+	Target        string     `json:"target"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	Payload       []byte     `json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// notificationLogColumns is the column list read by scanNotificationLogEntry
+const notificationLogColumns = `id, target, event, status, attempts, last_error, payload, created_at, next_attempt_at, sent_at`
+
+// scanNotificationLogEntry reads a row selected with notificationLogColumns
+func scanNotificationLogEntry(row rowScanner) (NotificationLogEntry, error) {
+	var entry NotificationLogEntry
+	var lastError sql.NullString
+	var nextAttemptAt, sentAt sql.NullTime
+	err := row.Scan(&entry.ID, &entry.Target, &entry.Event, &entry.Status, &entry.Attempts, &lastError,
+		&entry.Payload, &entry.CreatedAt, &nextAttemptAt, &sentAt)
+	entry.LastError = lastError.String
+	if nextAttemptAt.Valid {
+		entry.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if sentAt.Valid {
+		entry.SentAt = &sentAt.Time
+	}
+	return entry, err
+}
+
+// RecordNotification logs the first delivery of a notification to a target. A failed one is queued
+// with its payload for a retry after notificationRetryDelay.
+func (s *Storage) RecordNotification(target, event string, payload []byte, sendErr error) error {
+	var err error
+	if sendErr == nil {
+		_, err = s.exec(`INSERT INTO notification_log (target, event, status, sent_at) VALUES (?, ?, ?, ?)`,
+			target, event, NotificationSent, time.Now().UTC().Truncate(time.Second))
+	} else {
+		_, err = s.exec(`INSERT INTO notification_log (target, event, status, last_error, payload, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			target, event, NotificationPending, sendErr.Error(), payload, nextNotificationAttempt(1))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to log %s notification to %s: %w", event, target, err)
+	}
+	return nil
+}
+
+// GetDueNotifications returns the queued notifications whose retry is due, oldest first
+func (s *Storage) GetDueNotifications() ([]NotificationLogEntry, error) {
+	query := `SELECT ` + notificationLogColumns + ` FROM notification_log
+	WHERE status = ? AND next_attempt_at <= ? ORDER BY id ASC`
+
+	rows, err := s.db.Query(query, NotificationPending, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []NotificationLogEntry
+	for rows.Next() {
+		entry, err := scanNotificationLogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	return entries, nil
+}
+
+// RecordNotificationRetry records the outcome of a retry of a queued notification. A failed retry is
+// queued again with a doubled delay, until MaxNotificationAttempts is reached.
+func (s *Storage) RecordNotificationRetry(entry NotificationLogEntry, sendErr error) error {
+	attempts := entry.Attempts + 1
+
+	var err error
+	switch {
+	case sendErr == nil:
+		_, err = s.exec(`UPDATE notification_log SET status = ?, attempts = ?, payload = NULL, next_attempt_at = NULL, sent_at = ? WHERE id = ?`,
+			NotificationSent, attempts, time.Now().UTC().Truncate(time.Second), entry.ID)
+	case attempts >= MaxNotificationAttempts:
+		_, err = s.exec(`UPDATE notification_log SET status = ?, attempts = ?, last_error = ?, next_attempt_at = NULL WHERE id = ?`,
+			NotificationFailed, attempts, sendErr.Error(), entry.ID)
+	default:
+		_, err = s.exec(`UPDATE notification_log SET attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+			attempts, sendErr.Error(), nextNotificationAttempt(attempts), entry.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update notification %d: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// nextNotificationAttempt returns when to retry a notification that failed the given number of times
+func nextNotificationAttempt(attempts int) time.Time {
+	return time.Now().Add(notificationRetryDelay << (attempts - 1)).UTC().Truncate(time.Second)
+}
+
+// GetNotificationLog returns the most recent notification log entries, newest first
+func (s *Storage) GetNotificationLog(limit int) ([]NotificationLogEntry, error) {
+	query := `SELECT ` + notificationLogColumns + ` FROM notification_log ORDER BY id DESC LIMIT ?`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []NotificationLogEntry
+	for rows.Next() {
+		entry, err := scanNotificationLogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	return entries, nil
+}
+
+// GetNotification returns a notification log entry with its payload, or nil if there is no entry with that ID
+func (s *Storage) GetNotification(id int64) (*NotificationLogEntry, error) {
+	row := s.db.QueryRow(`SELECT `+notificationLogColumns+` FROM notification_log WHERE id = ?`, id)
+	entry, err := scanNotificationLogEntry(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification %d: %w", id, err)
+	}
+	return &entry, nil
+}
+
+// HoldNotification queues a notification to a target that is not to be sent yet, e.g. during quiet hours
+func (s *Storage) HoldNotification(target, event string, payload []byte) error {
+	_, err := s.exec(`INSERT INTO notification_log (target, event, status, attempts, payload) VALUES (?, ?, ?, 0, ?)`,
+		target, event, NotificationHeld, payload)
+	if err != nil {
+		return fmt.Errorf("failed to hold %s notification to %s: %w", event, target, err)
+	}
+	return nil
+}
+
+// GetHeldNotifications returns the held notifications, oldest first
+func (s *Storage) GetHeldNotifications() ([]NotificationLogEntry, error) {
+	query := `SELECT ` + notificationLogColumns + ` FROM notification_log WHERE status = ? ORDER BY id ASC`
+
+	rows, err := s.db.Query(query, NotificationHeld)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query held notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []NotificationLogEntry
+	for rows.Next() {
+		entry, err := scanNotificationLogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	return entries, nil
+}
+
+// ReleaseHeldNotifications replaces held notifications of one target and event by the digest they
+// were sent in: logged as sent, or queued for retry with its payload when sendErr is set
+func (s *Storage) ReleaseHeldNotifications(held []NotificationLogEntry, payload []byte, sendErr error) error {
+	if len(held) == 0 {
+		return nil
+	}
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	for _, entry := range held {
+		if _, err := tx.Exec(`DELETE FROM notification_log WHERE id = ? AND status = ?`, entry.ID, NotificationHeld); err != nil {
+			return fmt.Errorf("failed to release notification %d: %w", entry.ID, err)
+		}
+	}
+
+	target, event := held[0].Target, held[0].Event
+	if sendErr == nil {
+		_, err = tx.Exec(`INSERT INTO notification_log (target, event, status, sent_at) VALUES (?, ?, ?, ?)`,
+			target, event, NotificationSent, time.Now().UTC().Truncate(time.Second))
+	} else {
+		_, err = tx.Exec(`INSERT INTO notification_log (target, event, status, last_error, payload, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			target, event, NotificationPending, sendErr.Error(), payload, nextNotificationAttempt(1))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to log %s digest to %s: %w", event, target, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CountSentNotifications returns how many notifications were sent to a target at or after t
+func (s *Storage) CountSentNotifications(target string, since time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notification_log WHERE target = ? AND status = ? AND sent_at >= ?`,
+		target, NotificationSent, since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications sent to %s: %w", target, err)
+	}
+	return count, nil
+}
+
+// GetNotifiedKeys returns which of the given notification keys, e.g. a contract announced as new, were
+// notified at or after t
+func (s *Storage) GetNotifiedKeys(keys []string, since time.Time) (map[string]bool, error) {
+	notified := make(map[string]bool)
+	for start := 0; start < len(keys); start += resolverBatchSize {
+		end := start + resolverBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		args := []interface{}{since.UTC()}
+		for _, key := range keys[start:end] {
+			args = append(args, key)
+		}
+		query := fmt.Sprintf(`SELECT notification_key FROM notification_keys WHERE notified_at >= ? AND notification_key IN (%s)`,
+			placeholders(end-start))
+		rows, err := s.db.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query notification keys: %w", err)
+		}
+
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan notification key: %w", err)
+			}
+			notified[key] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read notification keys: %w", err)
+		}
+	}
+	return notified, nil
+}
+
+// RecordNotifiedKeys records the notification keys as notified now and forgets the keys notified
+// before expireBefore, which no longer suppress anything
+func (s *Storage) RecordNotifiedKeys(keys []string, expireBefore time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	if _, err := tx.Exec(`DELETE FROM notification_keys WHERE notified_at < ?`, expireBefore.UTC()); err != nil {
+		return fmt.Errorf("failed to expire notification keys: %w", err)
+	}
+
+	query := s.dialect.replaceQuery("notification_keys", []string{"notification_key", "notified_at"}, []string{"?", "?"}, nil)
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, key := range keys {
+		if _, err := tx.Exec(query, key, now); err != nil {
+			return fmt.Errorf("failed to record notification key %s: %w", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
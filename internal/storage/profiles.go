@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultProfile is the search profile contracts belong to unless a scrape names another one
+const DefaultProfile = "default"
+
+// defaultProfileID is the id the profiles migration gives DefaultProfile; contracts stored before
+// profiles existed belong to it
+const defaultProfileID = 1
+
+// Profile is a saved search. Each profile keeps its own contracts, so the same tender found by two
+// searches is stored (and tagged, watched and notified about) once per profile.
+type Profile struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CPVCode   string    `json:"cpv_code,omitempty"` // CPV code searched for; empty uses the scraper default
+	CreatedAt time.Time `json:"created_at"`
+	Contracts int       `json:"contracts"` // Stored contracts, soft-deleted ones excluded
+}
+
+// profileID returns the profile a contract belongs to, treating unset as the default profile
+func profileID(id int64) int64 {
+	if id == 0 {
+		return defaultProfileID
+	}
+	return id
+}
+
+// SaveProfile creates a profile if it does not exist and returns it. A non-empty CPV code replaces
+// the one stored for the profile; an empty one keeps it.
+func (s *Storage) SaveProfile(name, cpvCode string) (*Profile, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, invalidf("profile name is required")
+	}
+	cpvCode = strings.TrimSpace(cpvCode)
+
+	profile, err := s.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case profile == nil:
+		if _, err := s.exec(`INSERT INTO profiles (name, cpv_code) VALUES (?, ?)`, name, cpvCode); err != nil {
+			return nil, fmt.Errorf("failed to create profile %s: %w", name, err)
+		}
+	case cpvCode != "" && cpvCode != profile.CPVCode:
+		if _, err := s.exec(`UPDATE profiles SET cpv_code = ? WHERE id = ?`, cpvCode, profile.ID); err != nil {
+			return nil, fmt.Errorf("failed to update profile %s: %w", name, err)
+		}
+	default:
+		return profile, nil
+	}
+
+	return s.GetProfile(name)
+}
+
+// GetProfile returns the profile with the given name, or nil if there is none
+func (s *Storage) GetProfile(name string) (*Profile, error) {
+	profiles, err := s.queryProfiles(`WHERE p.name = ?`, strings.TrimSpace(name))
+	if err != nil || len(profiles) == 0 {
+		return nil, err
+	}
+	return &profiles[0], nil
+}
+
+// GetProfiles lists every profile with its number of contracts, the default one first
+func (s *Storage) GetProfiles() ([]Profile, error) {
+	return s.queryProfiles(``)
+}
+
+// queryProfiles reads the profiles matching a WHERE clause (or all of them) with their contract counts
+func (s *Storage) queryProfiles(where string, args ...interface{}) ([]Profile, error) {
+	query := `
+	SELECT p.id, p.name, p.cpv_code, p.created_at,
+		(SELECT COUNT(*) FROM contracts c WHERE c.profile_id = p.id AND c.deleted_at IS NULL)
+	FROM profiles p ` + where + `
+	ORDER BY p.id ASC
+	`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []Profile
+	for rows.Next() {
+		var profile Profile
+		var cpvCode sql.NullString
+		if err := rows.Scan(&profile.ID, &profile.Name, &cpvCode, &profile.CreatedAt, &profile.Contracts); err != nil {
+			return nil, fmt.Errorf("failed to scan profile: %w", err)
+		}
+		profile.CPVCode = cpvCode.String
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// DeleteProfile permanently removes a profile together with its scrape runs and its contracts with
+// their history, tags, notes and watchlist entries, and returns how many contracts were removed.
+// The default profile cannot be deleted. actor is recorded in the audit log.
+func (s *Storage) DeleteProfile(name, actor string) (int64, error) {
+	profile, err := s.GetProfile(name)
+	if err != nil {
+		return 0, err
+	}
+	if profile == nil {
+		return 0, notFoundf("profile %s not found", name)
+	}
+	if profile.ID == defaultProfileID {
+		return 0, invalidf("the %s profile cannot be deleted", DefaultProfile)
+	}
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	deleted, err := deleteContractsWhere(tx, `profile_id = ?`, profile.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete contracts of profile %s: %w", name, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM scrape_runs WHERE profile = ?`, profile.Name); err != nil {
+		return 0, fmt.Errorf("failed to delete scrape runs of profile %s: %w", name, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM profiles WHERE id = ?`, profile.ID); err != nil {
+		return 0, fmt.Errorf("failed to delete profile %s: %w", name, err)
+	}
+
+	if err := recordAudit(tx, actor, AuditDeleteProfile, profile.Name, deleted); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deleted, nil
+}
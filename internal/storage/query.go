@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// This file is the small query layer shared by the storage methods: a cache of prepared statements and
+// the helpers that run a query and scan contracts or status changes. Each row type is read in exactly
+// one place (scanContract, scanStatusChange) and contracts are written in one place (contractValues),
+// so adding a column means touching its column lists and those functions only.
+
+// prepared returns a prepared statement for query, preparing it on first use.
+// Only use it for queries with a fixed text; dynamically built queries would grow the cache.
+func (s *Storage) prepared(query string) (*sql.Stmt, error) {
+	s.stmtsMu.Lock()
+	defer s.stmtsMu.Unlock()
+
+	if stmt, ok := s.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := s.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	if s.stmts == nil {
+		s.stmts = make(map[string]*sql.Stmt)
+	}
+	s.stmts[query] = stmt
+	return stmt, nil
+}
+
+// closeStatements closes every cached prepared statement
+func (s *Storage) closeStatements() {
+	s.stmtsMu.Lock()
+	defer s.stmtsMu.Unlock()
+
+	for query, stmt := range s.stmts {
+		stmt.Close()
+		delete(s.stmts, query)
+	}
+}
+
+// txStmt returns the cached prepared statement for query bound to tx. Close it before the transaction ends.
+func (s *Storage) txStmt(tx *sql.Tx, query string) (*sql.Stmt, error) {
+	stmt, err := s.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Stmt(stmt), nil
+}
+
+// queryContracts runs a fixed query selecting contractColumns and returns the contracts with their
+// tags and watchlist flag. what describes the query in error messages.
+func (s *Storage) queryContracts(what, query string, args ...interface{}) ([]scraper.Contract, error) {
+	stmt, err := s.prepared(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	contracts, err := scanContracts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return contracts, s.attachUserData(contracts)
+}
+
+// requireContract returns an error unless a contract with the given ID exists and is not soft-deleted
+func (s *Storage) requireContract(contractID string) error {
+	stmt, err := s.prepared(`SELECT COUNT(*) FROM contracts WHERE id = ? AND ` + notDeleted)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := stmt.QueryRow(contractID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check contract %s: %w", contractID, err)
+	}
+	if count == 0 {
+		return notFoundf("contract %s not found", contractID)
+	}
+	return nil
+}
+
+// contractUIDOf is the uid of the contract whose id is the query argument, as tables referencing a
+// contract store its uid in contract_uid
+const contractUIDOf = `(SELECT uid FROM contracts WHERE id = ?)`
+
+// contractIDOf selects the id of the contract a row references through its contract_uid
+const contractIDOf = `(SELECT id FROM contracts WHERE uid = contract_uid)`
+
+// statusChangeColumns is the column list read by scanStatusChange
+const statusChangeColumns = `id, ` + contractIDOf + `, old_status, new_status, changed_at, uid, acknowledged_at, acknowledged_by`
+
+// scanStatusChange reads a row selected with statusChangeColumns
+func scanStatusChange(row rowScanner) (StatusChange, error) {
+	var change StatusChange
+	var oldStatus, uid, acknowledgedBy sql.NullString
+	var acknowledgedAt sql.NullTime
+	err := row.Scan(
+		&change.ID,
+		&change.ContractID,
+		&oldStatus,
+		&change.NewStatus,
+		&change.ChangedAt,
+		&uid,
+		&acknowledgedAt,
+		&acknowledgedBy,
+	)
+	change.OldStatus = oldStatus.String
+	change.UID = uid.String
+	if acknowledgedAt.Valid {
+		change.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	change.AcknowledgedBy = acknowledgedBy.String
+	return change, err
+}
+
+// queryStatusChanges runs a fixed query selecting statusChangeColumns. what describes the query in error messages.
+func (s *Storage) queryStatusChanges(what, query string, args ...interface{}) ([]StatusChange, error) {
+	stmt, err := s.prepared(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	var changes []StatusChange
+	for rows.Next() {
+		change, err := scanStatusChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	return changes, nil
+}
+
+// placeholders returns n comma-separated "?" placeholders for an IN clause
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RawPage is the HTML of a contract detail page as it was scraped, kept gzip-compressed so records
+// can be re-parsed when the extraction logic improves without visiting the portal again
+type RawPage struct {
+	ID         int64     `json:"id"`
+	ContractID string    `json:"contract_id"`
+	ScrapedAt  time.Time `json:"scraped_at"`
+	SHA256     string    `json:"sha256"`         // Hex digest of the uncompressed HTML
+	Size       int64     `json:"size"`           // Uncompressed size in bytes
+	HTML       string    `json:"html,omitempty"` // Only filled in by GetRawPage
+}
+
+// rawPageColumns is the column list read by scanRawPage
+const rawPageColumns = `id, ` + contractIDOf + `, scraped_at, sha256, size`
+
+// scanRawPage reads a row selected with rawPageColumns
+func scanRawPage(row rowScanner) (RawPage, error) {
+	var page RawPage
+	err := row.Scan(&page.ID, &page.ContractID, &page.ScrapedAt, &page.SHA256, &page.Size)
+	return page, err
+}
+
+// SaveRawPage stores the HTML of a contract detail page scraped at the given time. A page identical to
+// the latest one stored for the contract is not stored again; the result reports whether it was stored.
+func (s *Storage) SaveRawPage(contractID, html string, scrapedAt time.Time) (bool, error) {
+	if err := s.requireContract(contractID); err != nil {
+		return false, err
+	}
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	stored, err := saveRawPage(tx, contractID, html, scrapedAt)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return stored, nil
+}
+
+// saveRawPage compresses and stores a page within tx unless it matches the latest stored page of the contract
+func saveRawPage(tx *sql.Tx, contractID, html string, scrapedAt time.Time) (bool, error) {
+	sum := sha256.Sum256([]byte(html))
+	digest := hex.EncodeToString(sum[:])
+
+	var latest string
+	err := tx.QueryRow(`SELECT sha256 FROM raw_pages WHERE contract_uid = `+contractUIDOf+` ORDER BY scraped_at DESC, id DESC LIMIT 1`, contractID).Scan(&latest)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to check stored page of contract %s: %w", contractID, err)
+	}
+	if latest == digest {
+		return false, nil
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(html)); err != nil {
+		return false, fmt.Errorf("failed to compress page of contract %s: %w", contractID, err)
+	}
+	if err := zw.Close(); err != nil {
+		return false, fmt.Errorf("failed to compress page of contract %s: %w", contractID, err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO raw_pages (contract_uid, scraped_at, sha256, size, html) VALUES (`+contractUIDOf+`, ?, ?, ?, ?)`,
+		contractID, scrapedAt.UTC().Truncate(time.Second), digest, len(html), compressed.Bytes())
+	if err != nil {
+		return false, fmt.Errorf("failed to save page of contract %s: %w", contractID, err)
+	}
+	return true, nil
+}
+
+// GetRawPages lists the stored pages of a contract without their HTML, newest first
+func (s *Storage) GetRawPages(contractID string) ([]RawPage, error) {
+	stmt, err := s.prepared(`SELECT ` + rawPageColumns + ` FROM raw_pages WHERE contract_uid = ` + contractUIDOf + ` ORDER BY scraped_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pages: %w", err)
+	}
+
+	rows, err := stmt.Query(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []RawPage
+	for rows.Next() {
+		page, err := scanRawPage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+		pages = append(pages, page)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pages: %w", err)
+	}
+	return pages, nil
+}
+
+// GetRawPagesBetween lists the pages stored from from up to to, without their HTML, oldest first,
+// e.g. the pages a scrape run stored
+func (s *Storage) GetRawPagesBetween(from, to time.Time) ([]RawPage, error) {
+	rows, err := s.db.Query(`SELECT `+rawPageColumns+` FROM raw_pages WHERE scraped_at >= ? AND scraped_at <= ? ORDER BY scraped_at, id`,
+		from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []RawPage
+	for rows.Next() {
+		page, err := scanRawPage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+		pages = append(pages, page)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pages: %w", err)
+	}
+	return pages, nil
+}
+
+// GetRawPage returns a stored page with its decompressed HTML, or nil if there is no page with that ID
+func (s *Storage) GetRawPage(id int64) (*RawPage, error) {
+	var page RawPage
+	var compressed []byte
+	err := s.db.QueryRow(`SELECT `+rawPageColumns+`, html FROM raw_pages WHERE id = ?`, id).
+		Scan(&page.ID, &page.ContractID, &page.ScrapedAt, &page.SHA256, &page.Size, &compressed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page %d: %w", id, err)
+	}
+
+	page.HTML, err = decompressPage(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress page %d: %w", id, err)
+	}
+	return &page, nil
+}
+
+// decompressPage returns the HTML of a page stored by saveRawPage
+func decompressPage(compressed []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	html, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(html), nil
+}
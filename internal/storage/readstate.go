@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// MarkContractsSeen clears the unseen flag of the given contracts and returns how many were unseen
+func (s *Storage) MarkContractsSeen(contractIDs []string) (int64, error) {
+	if len(contractIDs) == 0 {
+		return 0, nil
+	}
+
+	placeholders := make([]string, len(contractIDs))
+	args := make([]interface{}, len(contractIDs))
+	for i, id := range contractIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`UPDATE contracts SET seen_at = CURRENT_TIMESTAMP WHERE seen_at IS NULL AND id IN (%s)`, strings.Join(placeholders, ", "))
+	result, err := s.exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark contracts as seen: %w", err)
+	}
+
+	seen, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return seen, nil
+}
+
+// MarkAllContractsSeen clears the unseen flag of every contract and returns how many were unseen
+func (s *Storage) MarkAllContractsSeen() (int64, error) {
+	result, err := s.exec(`UPDATE contracts SET seen_at = CURRENT_TIMESTAMP WHERE seen_at IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark contracts as seen: %w", err)
+	}
+
+	seen, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return seen, nil
+}
+
+// GetUnseenContracts retrieves the active contracts that have not been viewed yet, newest first
+func (s *Storage) GetUnseenContracts() ([]scraper.Contract, error) {
+	return s.queryContracts("unseen contracts",
+		`SELECT `+contractColumns+` FROM contracts WHERE seen_at IS NULL AND `+activeOnly+` ORDER BY first_seen_at DESC`)
+}
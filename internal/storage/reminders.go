@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReminderChannelEmail is the channel of reminders sent by the email notifier
+const ReminderChannelEmail = "email"
+
+// Reminder is a scheduled notification about a contract, kept in the database so pending
+// reminders survive restarts and sent ones are never repeated
+type Reminder struct {
+	ID         int64      `json:"id"`
+	ContractID string     `json:"contract_id"`
+	RemindAt   time.Time  `json:"remind_at"`
+	Channel    string     `json:"channel"`
+	Deadline   *time.Time `json:"deadline,omitempty"` // Deadline the reminder is about, for deadline reminders
+	SentAt     *time.Time `json:"sent_at,omitempty"`  // Set once the reminder was sent
+}
+
+// reminderColumns is the column list read by scanReminder
+const reminderColumns = `id, ` + contractIDOf + `, remind_at, channel, deadline, sent_at`
+
+// scanReminder reads a row selected with reminderColumns
+func scanReminder(row rowScanner) (Reminder, error) {
+	var reminder Reminder
+	var deadline, sentAt sql.NullTime
+	err := row.Scan(&reminder.ID, &reminder.ContractID, &reminder.RemindAt, &reminder.Channel, &deadline, &sentAt)
+	if deadline.Valid {
+		reminder.Deadline = &deadline.Time
+	}
+	if sentAt.Valid {
+		reminder.SentAt = &sentAt.Time
+	}
+	return reminder, err
+}
+
+// ScheduleReminder schedules a notification about a contract on a channel at the given time
+func (s *Storage) ScheduleReminder(contractID string, remindAt time.Time, channel string) (*Reminder, error) {
+	if err := s.requireContract(contractID); err != nil {
+		return nil, err
+	}
+
+	reminder := &Reminder{ContractID: contractID, RemindAt: remindAt.UTC().Truncate(time.Second), Channel: channel}
+	result, err := s.exec(`INSERT INTO reminders (contract_uid, remind_at, channel) VALUES (`+contractUIDOf+`, ?, ?)`,
+		reminder.ContractID, reminder.RemindAt, reminder.Channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule reminder for contract %s: %w", contractID, err)
+	}
+
+	reminder.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder id: %w", err)
+	}
+	return reminder, nil
+}
+
+// ScheduleDeadlineReminders schedules a reminder the given time before the deadline of every active
+// watched contract, once per deadline and channel. Pending reminders for a deadline that has since
+// moved are replaced. It returns how many reminders were scheduled.
+func (s *Storage) ScheduleDeadlineReminders(before time.Duration, channel string) (int, error) {
+	tx, err := s.beginWrite()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	// Drop pending reminders whose deadline moved, or whose contract is no longer watched
+	_, err = tx.Exec(`DELETE FROM reminders WHERE channel = ? AND sent_at IS NULL AND deadline IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM contracts c JOIN watchlist w ON w.contract_uid = c.uid
+			WHERE c.uid = reminders.contract_uid AND c.deadline = reminders.deadline)`, channel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to drop outdated reminders: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT id, deadline FROM contracts
+		WHERE deadline > ? AND `+activeOnly+` AND uid IN (SELECT contract_uid FROM watchlist)
+		AND NOT EXISTS (SELECT 1 FROM reminders r WHERE r.contract_uid = contracts.uid AND r.channel = ? AND r.deadline = contracts.deadline)`,
+		time.Now().UTC(), channel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query deadlines to remind: %w", err)
+	}
+
+	var reminders []Reminder
+	for rows.Next() {
+		var reminder Reminder
+		var deadline time.Time
+		if err := rows.Scan(&reminder.ContractID, &deadline); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan deadline: %w", err)
+		}
+		reminder.Deadline = &deadline
+		reminder.RemindAt = deadline.Add(-before).UTC().Truncate(time.Second)
+		reminders = append(reminders, reminder)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read deadlines: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		_, err := tx.Exec(`INSERT INTO reminders (contract_uid, remind_at, channel, deadline) VALUES (`+contractUIDOf+`, ?, ?, ?)`,
+			reminder.ContractID, reminder.RemindAt, channel, reminder.Deadline.UTC())
+		if err != nil {
+			return 0, fmt.Errorf("failed to schedule reminder for contract %s: %w", reminder.ContractID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(reminders), nil
+}
+
+// GetDueReminders returns the unsent reminders of a channel that are due, for contracts that are
+// still active and deadlines that have not passed, oldest first
+func (s *Storage) GetDueReminders(channel string) ([]Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders
+	WHERE channel = ? AND sent_at IS NULL AND remind_at <= ? AND (deadline IS NULL OR deadline > ?)
+	AND contract_uid IN (SELECT uid FROM contracts WHERE ` + activeOnly + `)
+	ORDER BY remind_at ASC, id ASC`
+
+	now := time.Now().UTC()
+	rows, err := s.db.Query(query, channel, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// MarkRemindersSent records that the given reminders were sent
+func (s *Storage) MarkRemindersSent(reminders []Reminder) error {
+	for _, reminder := range reminders {
+		if _, err := s.exec(`UPDATE reminders SET sent_at = CURRENT_TIMESTAMP WHERE id = ?`, reminder.ID); err != nil {
+			return fmt.Errorf("failed to mark reminder %d as sent: %w", reminder.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RetentionPolicy sets how long each kind of data is kept. A zero duration keeps the data forever.
+type RetentionPolicy struct {
+	StatusChanges     time.Duration // Status change history
+	Revisions         time.Duration // Field-level contract revisions
+	ArchivedContracts time.Duration // Contracts counted from the moment they were archived
+	DeletedContracts  time.Duration // Soft-deleted contracts counted from the moment they were deleted
+	RawPages          time.Duration // Scraped detail page HTML
+}
+
+// DefaultRetentionPolicy keeps history for 180 days, archived contracts for two years,
+// soft-deleted contracts for 30 days and scraped pages for a year
+var DefaultRetentionPolicy = RetentionPolicy{
+	StatusChanges:     180 * 24 * time.Hour,
+	Revisions:         180 * 24 * time.Hour,
+	ArchivedContracts: 2 * 365 * 24 * time.Hour,
+	DeletedContracts:  30 * 24 * time.Hour,
+	RawPages:          365 * 24 * time.Hour,
+}
+
+// PruneResult reports how many rows Prune removed
+type PruneResult struct {
+	StatusChanges     int64
+	Revisions         int64
+	ArchivedContracts int64
+	DeletedContracts  int64
+	RawPages          int64
+}
+
+// Prune removes the data that is older than the retention policy allows. actor is recorded in the audit log.
+func (s *Storage) Prune(policy RetentionPolicy, actor string) (PruneResult, error) {
+	var result PruneResult
+	now := time.Now().UTC()
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	if policy.DeletedContracts > 0 {
+		result.DeletedContracts, err = deleteContractsWhere(tx, `deleted_at IS NOT NULL AND deleted_at < ?`, now.Add(-policy.DeletedContracts))
+		if err != nil {
+			return result, fmt.Errorf("failed to prune deleted contracts: %w", err)
+		}
+	}
+
+	if policy.ArchivedContracts > 0 {
+		result.ArchivedContracts, err = deleteContractsWhere(tx, `archived_at IS NOT NULL AND archived_at < ?`, now.Add(-policy.ArchivedContracts))
+		if err != nil {
+			return result, fmt.Errorf("failed to prune archived contracts: %w", err)
+		}
+	}
+
+	if policy.StatusChanges > 0 {
+		result.StatusChanges, err = deleteRows(tx, `DELETE FROM status_changes WHERE changed_at < ?`, now.Add(-policy.StatusChanges))
+		if err != nil {
+			return result, fmt.Errorf("failed to prune status changes: %w", err)
+		}
+	}
+
+	if policy.Revisions > 0 {
+		result.Revisions, err = deleteRows(tx, `DELETE FROM contract_revisions WHERE changed_at < ?`, now.Add(-policy.Revisions))
+		if err != nil {
+			return result, fmt.Errorf("failed to prune contract revisions: %w", err)
+		}
+	}
+
+	if policy.RawPages > 0 {
+		result.RawPages, err = deleteRows(tx, `DELETE FROM raw_pages WHERE scraped_at < ?`, now.Add(-policy.RawPages))
+		if err != nil {
+			return result, fmt.Errorf("failed to prune raw pages: %w", err)
+		}
+	}
+
+	removed := result.StatusChanges + result.Revisions + result.ArchivedContracts + result.DeletedContracts + result.RawPages
+	if err := recordAudit(tx, actor, AuditPrune, "", removed); err != nil {
+		return result, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Pruned %d status changes, %d revisions, %d archived and %d deleted contracts, %d raw pages",
+		result.StatusChanges, result.Revisions, result.ArchivedContracts, result.DeletedContracts, result.RawPages)
+	return result, nil
+}
+
+// deleteContractsWhere permanently removes the contracts matching condition together with
+// their status changes, revisions, tags, notes, watchlist entries, document records, reminders, raw pages and CPV codes, and
+// returns how many contracts were removed
+func deleteContractsWhere(tx *sql.Tx, condition string, args ...interface{}) (int64, error) {
+	for _, table := range []string{"status_changes", "contract_revisions", "contract_tags", "contract_notes", "watchlist", "documents", "reminders", "raw_pages", "contract_cpvs"} {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE contract_uid IN (SELECT uid FROM contracts WHERE %s)`, table, condition)
+		if _, err := tx.Exec(query, args...); err != nil {
+			return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	return deleteRows(tx, `DELETE FROM contracts WHERE `+condition, args...)
+}
+
+// deleteRows runs a DELETE statement and returns the number of removed rows
+func deleteRows(tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// ContractRevision records a change to a single contract field between two scrapes.
+// Status changes are kept separately in status_changes.
+type ContractRevision struct {
+	ID         int    `json:"id"`
+	UID        string `json:"uid"`
+	ContractID string `json:"contract_id"`
+	Field      string `json:"field"`
+	OldValue   string `json:"old_value"`
+	NewValue   string `json:"new_value"`
+	ChangedAt  string `json:"changed_at"`
+}
+
+// revisionFields maps the tracked contract fields to their column names
+var revisionFields = []struct {
+	name  string
+	field func(c *scraper.Contract) *string
+}{
+	{"description", func(c *scraper.Contract) *string { return &c.Description }},
+	{"contract_type", func(c *scraper.Contract) *string { return &c.ContractType }},
+	{"amount", func(c *scraper.Contract) *string { return &c.Amount }},
+	{"submission_date", func(c *scraper.Contract) *string { return &c.SubmissionDate }},
+	{"contracting_body", func(c *scraper.Contract) *string { return &c.ContractingBody }},
+	{"link", func(c *scraper.Contract) *string { return &c.Link }},
+	{"pliego_link", func(c *scraper.Contract) *string { return &c.PliegoLink }},
+	{"anuncio_link", func(c *scraper.Contract) *string { return &c.AnuncioLink }},
+}
+
+// diffContracts returns a revision for every tracked field that differs between the stored
+// and the freshly scraped contract. Empty scraped values are ignored because they mean the
+// field was not extracted on this run, not that it was removed from the tender.
+func diffContracts(stored, scraped scraper.Contract) []ContractRevision {
+	var revisions []ContractRevision
+	for _, field := range revisionFields {
+		oldValue, newValue := *field.field(&stored), *field.field(&scraped)
+		if newValue == "" || oldValue == newValue {
+			continue
+		}
+		revisions = append(revisions, ContractRevision{
+			ContractID: scraped.ID,
+			Field:      field.name,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+		})
+	}
+	return revisions
+}
+
+// mergeMissingFields keeps stored values for every text field the scrape left empty, so a partial
+// scrape does not wipe previously extracted data such as the status or document links. The typed
+// fields are parsed again from the merged text.
+func mergeMissingFields(stored scraper.Contract, scraped *scraper.Contract) {
+	if scraped.Status == "" {
+		scraped.Status = stored.Status
+	}
+	for _, field := range revisionFields {
+		if value := field.field(scraped); *value == "" {
+			*value = *field.field(&stored)
+		}
+	}
+
+	scraped.AmountValue = 0
+	scraped.Deadline = nil
+	scraped.ParseTypedFields()
+}
+
+// GetContractRevisions retrieves the field-level history of a contract, newest first
+func (s *Storage) GetContractRevisions(contractID string) ([]ContractRevision, error) {
+	query := `
+	SELECT id, ` + contractIDOf + `, field, old_value, new_value, changed_at, uid
+	FROM contract_revisions
+	WHERE contract_uid = ` + contractUIDOf + `
+	ORDER BY changed_at DESC, id DESC
+	`
+
+	rows, err := s.db.Query(query, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contract revisions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRevisions(rows)
+}
+
+// GetRecentRevisions retrieves field changes recorded in the last 24 hours
+func (s *Storage) GetRecentRevisions() ([]ContractRevision, error) {
+	query := fmt.Sprintf(`
+	SELECT id, `+contractIDOf+`, field, old_value, new_value, changed_at, uid
+	FROM contract_revisions
+	WHERE changed_at >= %s
+	ORDER BY changed_at DESC, id DESC
+	`, s.dialect.daysAgo(1))
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent revisions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRevisions(rows)
+}
+
+// GetAllRevisions retrieves every recorded field change, newest first
+func (s *Storage) GetAllRevisions() ([]ContractRevision, error) {
+	query := `
+	SELECT id, ` + contractIDOf + `, field, old_value, new_value, changed_at, uid
+	FROM contract_revisions
+	ORDER BY changed_at DESC, id DESC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contract revisions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRevisions(rows)
+}
+
+// GetWatchedRevisionsSince retrieves the field changes of watched contracts recorded at or after t, oldest first
+func (s *Storage) GetWatchedRevisionsSince(t time.Time) ([]ContractRevision, error) {
+	query := `
+	SELECT id, ` + contractIDOf + `, field, old_value, new_value, changed_at, uid
+	FROM contract_revisions
+	WHERE changed_at >= ? AND contract_uid IN (SELECT contract_uid FROM watchlist)
+	ORDER BY changed_at ASC, id ASC
+	`
+
+	rows, err := s.db.Query(query, timestampParam(t))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched revisions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRevisions(rows)
+}
+
+// scanRevisions reads every contract_revisions row
+func scanRevisions(rows *sql.Rows) ([]ContractRevision, error) {
+	var revisions []ContractRevision
+	for rows.Next() {
+		var revision ContractRevision
+		var oldValue, newValue, uid sql.NullString
+		err := rows.Scan(
+			&revision.ID,
+			&revision.ContractID,
+			&revision.Field,
+			&oldValue,
+			&newValue,
+			&revision.ChangedAt,
+			&uid,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan contract revision: %w", err)
+		}
+		revision.OldValue = oldValue.String
+		revision.NewValue = newValue.String
+		revision.UID = uid.String
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read contract revisions: %w", err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scrape run statuses
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial" // Finished, but some steps reported errors
+	RunStatusFailed  = "failed"
+)
+
+// ScrapeRun records the outcome of one scraping run
+type ScrapeRun struct {
+	ID               int64      `json:"id"`
+	StartedAt        time.Time  `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+	ScraperType      string     `json:"scraper_type"`
+	Profile          string     `json:"profile"`
+	Status           string     `json:"status"`
+	PagesProcessed   int        `json:"pages_processed"`
+	ContractsFound   int        `json:"contracts_found"`
+	ContractsNew     int        `json:"contracts_new"`
+	ContractsChanged int        `json:"contracts_changed"`
+	Errors           []string   `json:"errors"`
+}
+
+// AddError records a non-fatal error that happened during the run
+func (r *ScrapeRun) AddError(err error) {
+	r.Errors = append(r.Errors, err.Error())
+}
+
+// Duration returns how long the run took, or how long it has been running
+func (r *ScrapeRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return time.Since(r.StartedAt)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// StartScrapeRun records the start of a scraping run
+func (s *Storage) StartScrapeRun(scraperType, profile string) (*ScrapeRun, error) {
+	// Whole seconds so the run start compares cleanly with CURRENT_TIMESTAMP columns
+	run := &ScrapeRun{
+		StartedAt:   time.Now().UTC().Truncate(time.Second),
+		ScraperType: scraperType,
+		Profile:     profile,
+		Status:      RunStatusRunning,
+	}
+
+	result, err := s.exec(`INSERT INTO scrape_runs (started_at, scraper_type, profile, status) VALUES (?, ?, ?, ?)`,
+		run.StartedAt, run.ScraperType, run.Profile, run.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record scrape run: %w", err)
+	}
+
+	run.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scrape run id: %w", err)
+	}
+
+	return run, nil
+}
+
+// FinishScrapeRun stores the final counters of a run. A non-nil runErr marks the run as failed;
+// otherwise it succeeded, or partially succeeded if errors were added along the way.
+// The number of changed contracts is computed from the status changes and revisions recorded since the run started.
+func (s *Storage) FinishScrapeRun(run *ScrapeRun, runErr error) error {
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+
+	switch {
+	case runErr != nil:
+		run.AddError(runErr)
+		run.Status = RunStatusFailed
+	case len(run.Errors) > 0:
+		run.Status = RunStatusPartial
+	default:
+		run.Status = RunStatusSuccess
+	}
+
+	changed, err := s.countContractsChangedSince(run.StartedAt)
+	if err != nil {
+		return err
+	}
+	run.ContractsChanged = changed
+
+	_, err = s.exec(`
+	UPDATE scrape_runs
+	SET finished_at = ?, status = ?, pages_processed = ?, contracts_found = ?, contracts_new = ?, contracts_changed = ?, errors = ?
+	WHERE id = ?`,
+		finishedAt, run.Status, run.PagesProcessed, run.ContractsFound, run.ContractsNew, run.ContractsChanged,
+		strings.Join(run.Errors, "\n"), run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update scrape run %d: %w", run.ID, err)
+	}
+
+	return nil
+}
+
+// GetScrapeRuns retrieves the most recent scrape runs, newest first
+func (s *Storage) GetScrapeRuns(limit int) ([]ScrapeRun, error) {
+	query := `
+	SELECT id, started_at, finished_at, scraper_type, profile, status, pages_processed, contracts_found, contracts_new, contracts_changed, errors
+	FROM scrape_runs
+	ORDER BY started_at DESC, id DESC
+	LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scrape runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []ScrapeRun
+	for rows.Next() {
+		var run ScrapeRun
+		var finishedAt sql.NullTime
+		var scraperType, profile, status, errors sql.NullString
+		err := rows.Scan(
+			&run.ID,
+			&run.StartedAt,
+			&finishedAt,
+			&scraperType,
+			&profile,
+			&status,
+			&run.PagesProcessed,
+			&run.ContractsFound,
+			&run.ContractsNew,
+			&run.ContractsChanged,
+			&errors,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scrape run: %w", err)
+		}
+
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		run.ScraperType = scraperType.String
+		run.Profile = profile.String
+		run.Status = status.String
+		if errors.String != "" {
+			run.Errors = strings.Split(errors.String, "\n")
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scrape runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetLastScrapeRun returns the most recent scrape run, or nil if none was recorded
+func (s *Storage) GetLastScrapeRun() (*ScrapeRun, error) {
+	runs, err := s.GetScrapeRuns(1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
+
+// countContractsChangedSince counts the distinct contracts with a status change or revision since t
+func (s *Storage) countContractsChangedSince(t time.Time) (int, error) {
+	query := `
+	SELECT COUNT(DISTINCT contract_uid) FROM (
+		SELECT contract_uid FROM status_changes WHERE changed_at >= ?
+		UNION
+		SELECT contract_uid FROM contract_revisions WHERE changed_at >= ?
+	) changed`
+
+	since := timestampParam(t)
+
+	var count int
+	if err := s.db.QueryRow(query, since, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count changed contracts: %w", err)
+	}
+	return count, nil
+}
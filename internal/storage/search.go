@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"scraper/internal/scraper"
+)
+
+// maxSearchTerms bounds the words of a search, as each one is matched separately
+const maxSearchTerms = 10
+
+// snippetWords is how many words a search snippet shows around the first match
+const snippetWords = 24
+
+// searchFields are the contract fields searched, by weight in the ranking
+var searchFields = []struct {
+	name   string
+	weight float64
+	text   func(scraper.Contract) string
+}{
+	{"id", 3, func(c scraper.Contract) string { return c.ID }},
+	{"description", 2, func(c scraper.Contract) string { return c.Description }},
+	{"contracting_body", 1, func(c scraper.Contract) string { return c.ContractingBody }},
+}
+
+// SearchResult is a contract found by SearchContracts
+type SearchResult struct {
+	Contract scraper.Contract
+	Score    float64       // Relevance; results come highest first
+	Field    string        // Field the snippet is taken from: "description", "contracting_body" or "id"
+	Snippet  []SnippetPart // Words of Field around the first match
+}
+
+// SnippetPart is a piece of a search snippet, Match when it is a word matching the search
+type SnippetPart struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+// SearchContracts returns one page of the contracts matching filter.Search through the full-text
+// index, most relevant first, together with the number of matching contracts. Matches in the ID
+// weigh most, then the description, then the contracting body; whole words weigh more than
+// prefixes. A limit <= 0 returns every match.
+func (s *Storage) SearchContracts(filter ContractFilter, limit, offset int) ([]SearchResult, int, error) {
+	terms := searchTerms(filter.Search)
+	if len(terms) == 0 {
+		return nil, 0, invalidf("search %q has no words to look for", filter.Search)
+	}
+
+	where, args := s.contractWhereClause(filter)
+	rows, err := s.db.Query(`SELECT `+contractColumns+` FROM contracts`+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search contracts: %w", err)
+	}
+	defer rows.Close()
+
+	contracts, err := scanContracts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]SearchResult, len(contracts))
+	for i, contract := range contracts {
+		results[i] = rankContract(contract, terms)
+	}
+	// Ties, e.g. contracts matching only in the contracting body, list the most recent first
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Contract.ScrapedAt.After(results[j].Contract.ScrapedAt)
+	})
+
+	total := len(results)
+	if offset > 0 {
+		if offset > total {
+			offset = total
+		}
+		results = results[offset:]
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	page := make([]scraper.Contract, len(results))
+	for i := range results {
+		page[i] = results[i].Contract
+	}
+	if err := s.attachUserData(page); err != nil {
+		return nil, 0, err
+	}
+	for i := range results {
+		results[i].Contract = page[i]
+	}
+
+	return results, total, nil
+}
+
+// searchTerms splits a search into lower-case words of letters and digits, the only characters the
+// full-text index looks at, so nothing in a search is read as query syntax
+func searchTerms(search string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(search), notWordRune) {
+		if !seen[word] && len(terms) < maxSearchTerms {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// notWordRune reports whether r separates words
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// textWord is a word of a text, by byte offsets
+type textWord struct {
+	start, end int
+	folded     string
+}
+
+// splitWords returns the words of text with their accent-free lower-case form, to compare them with
+// search terms the way the full-text index does
+func splitWords(text string) []textWord {
+	var words []textWord
+	start := -1
+	for i, r := range text {
+		switch {
+		case !notWordRune(r) && start < 0:
+			start = i
+		case notWordRune(r) && start >= 0:
+			words = append(words, textWord{start: start, end: i, folded: foldAccents(text[start:i])})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, textWord{start: start, end: len(text), folded: foldAccents(text[start:])})
+	}
+	return words
+}
+
+// accentFolder removes the accents of the letters used in Spanish, Catalan and Galician
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// foldAccents lower-cases text and removes its accents
+func foldAccents(text string) string {
+	return accentFolder.Replace(strings.ToLower(text))
+}
+
+// snippetFields are the fields a snippet is taken from, in order of preference: the description
+// says the most about a contract
+var snippetFields = []string{"description", "contracting_body", "id"}
+
+// rankContract scores a contract matching the search terms and picks its snippet
+func rankContract(contract scraper.Contract, terms []string) SearchResult {
+	result := SearchResult{Contract: contract}
+	folded := make([]string, len(terms))
+	for i, term := range terms {
+		folded[i] = foldAccents(term)
+	}
+
+	texts := make(map[string]string)
+	fieldWords := make(map[string][]textWord)
+	for _, field := range searchFields {
+		text := field.text(contract)
+		words := splitWords(text)
+		texts[field.name], fieldWords[field.name] = text, words
+		for _, term := range folded {
+			// Saturate repeated matches, so a long text mentioning a word often does not outrank the ID
+			var hits float64
+			for _, word := range words {
+				switch {
+				case word.folded == term:
+					hits++
+				case strings.HasPrefix(word.folded, term):
+					hits += 0.5
+				}
+			}
+			result.Score += field.weight * hits / (hits + 1)
+		}
+	}
+
+	for _, name := range snippetFields {
+		if hasMatch(fieldWords[name], folded) {
+			result.Field = name
+			result.Snippet = snippet(texts[name], fieldWords[name], folded)
+			break
+		}
+	}
+	return result
+}
+
+// hasMatch reports whether one of the words starts with one of the terms
+func hasMatch(words []textWord, terms []string) bool {
+	for _, word := range words {
+		if wordMatches(word, terms) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordMatches reports whether a word starts with one of the terms
+func wordMatches(word textWord, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word.folded, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns up to snippetWords words of text around its first word matching the terms, with
+// the matching words marked and "…" where text was cut
+func snippet(text string, words []textWord, terms []string) []SnippetPart {
+	first := 0
+	for i, word := range words {
+		if wordMatches(word, terms) {
+			first = i
+			break
+		}
+	}
+	from := first - snippetWords/3
+	if from < 0 {
+		from = 0
+	}
+	to := from + snippetWords
+	if to > len(words) {
+		to = len(words)
+	}
+
+	var parts []SnippetPart
+	add := func(text string, match bool) {
+		if text == "" {
+			return
+		}
+		if n := len(parts); n > 0 && parts[n-1].Match == match {
+			parts[n-1].Text += text
+			return
+		}
+		parts = append(parts, SnippetPart{Text: text, Match: match})
+	}
+
+	start := 0
+	if from > 0 {
+		start = words[from].start
+		add("…", false)
+	}
+	position := start
+	for _, word := range words[from:to] {
+		add(text[position:word.start], false)
+		add(text[word.start:word.end], wordMatches(word, terms))
+		position = word.end
+	}
+	if to < len(words) {
+		add("…", false)
+	} else {
+		add(text[position:], false)
+	}
+	return parts
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetUserSetting returns a setting of a dashboard user, such as their theme, or "" if they never
+// changed it
+func (s *Storage) GetUserSetting(user, name string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM user_settings WHERE user_name = ? AND name = ?`, user, name).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting %s of %s: %w", name, user, err)
+	}
+	return value, nil
+}
+
+// SetUserSetting saves a setting of a dashboard user
+func (s *Storage) SetUserSetting(user, name, value string) error {
+	if user == "" || name == "" {
+		return invalidf("a setting needs a user and a name")
+	}
+
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM user_settings WHERE user_name = ? AND name = ?`, user, name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check setting %s of %s: %w", name, user, err)
+	}
+
+	query := `INSERT INTO user_settings (value, updated_at, user_name, name) VALUES (?, ?, ?, ?)`
+	if exists > 0 {
+		query = `UPDATE user_settings SET value = ?, updated_at = ? WHERE user_name = ? AND name = ?`
+	}
+	if _, err := s.exec(query, value, time.Now().UTC(), user, name); err != nil {
+		return fmt.Errorf("failed to save setting %s of %s: %w", name, user, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stats aggregates the stored (not deleted) contracts for charts and digests
+type Stats struct {
+	Total             int         `json:"total"`
+	TotalValue        float64     `json:"total_value"` // Sum of the parsed amounts in euros
+	ByStatus          []StatGroup `json:"by_status"`
+	ByContractingBody []StatGroup `json:"by_contracting_body"`
+	ByMonth           []StatGroup `json:"by_month"` // Keyed "2006-01" by the month the contract was first seen, the closest we have to its publication
+}
+
+// StatGroup is the number and estimated value of the contracts sharing a key
+type StatGroup struct {
+	Key   string  `json:"key"`
+	Count int     `json:"count"`
+	Value float64 `json:"value"`
+}
+
+// GetStats computes the contract counts and estimated value by status, contracting body and month
+// in a single aggregate query. Groups are sorted by count, months chronologically.
+func (s *Storage) GetStats() (*Stats, error) {
+	dimensions := []struct{ name, key string }{
+		{"status", "status"},
+		{"body", "contracting_body"},
+		{"month", s.dialect.monthOf("first_seen_at")},
+	}
+	selects := make([]string, len(dimensions))
+	for i, dimension := range dimensions {
+		key := fmt.Sprintf("COALESCE(%s, '')", dimension.key)
+		selects[i] = fmt.Sprintf(`SELECT '%s', %s, COUNT(*), SUM(amount_value) FROM contracts WHERE %s GROUP BY %s`,
+			dimension.name, key, notDeleted, key)
+	}
+	query := strings.Join(selects, " UNION ALL ")
+
+	stmt, err := s.prepared(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats: %w", err)
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &Stats{ByStatus: []StatGroup{}, ByContractingBody: []StatGroup{}, ByMonth: []StatGroup{}}
+	for rows.Next() {
+		var dimension string
+		var group StatGroup
+		var value sql.NullFloat64
+		if err := rows.Scan(&dimension, &group.Key, &group.Count, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan stats: %w", err)
+		}
+		group.Value = value.Float64
+
+		switch dimension {
+		case "status":
+			stats.ByStatus = append(stats.ByStatus, group)
+			stats.Total += group.Count
+			stats.TotalValue += group.Value
+		case "body":
+			stats.ByContractingBody = append(stats.ByContractingBody, group)
+		case "month":
+			stats.ByMonth = append(stats.ByMonth, group)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	sortByCount(stats.ByStatus)
+	sortByCount(stats.ByContractingBody)
+	sort.Slice(stats.ByMonth, func(i, j int) bool { return stats.ByMonth[i].Key < stats.ByMonth[j].Key })
+	return stats, nil
+}
+
+// sortByCount orders groups by descending count, then by key
+func sortByCount(groups []StatGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+}
+
+// StatusCount is a contract status together with the number of contracts in it
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// GetStatusCounts counts the contracts matching the filter by status, most common first. The
+// Statuses of the filter are ignored, so every status shows up with its count.
+func (s *Storage) GetStatusCounts(filter ContractFilter) ([]StatusCount, error) {
+	filter.Statuses = nil
+	where, args := s.contractWhereClause(filter)
+	query := `SELECT COALESCE(status, ''), COUNT(*) FROM contracts` + where +
+		` GROUP BY COALESCE(status, '') ORDER BY COUNT(*) DESC, COALESCE(status, '') ASC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []StatusCount{}
+	for rows.Next() {
+		var count StatusCount
+		if err := rows.Scan(&count.Status, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan status count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status counts: %w", err)
+	}
+	return counts, nil
+}
@@ -4,29 +4,107 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	_ "github.com/mattn/go-sqlite3"
 	"scraper/internal/scraper"
 )
 
 // Storage handles database operations
 type Storage struct {
-	db *sql.DB
+	db         *sql.DB
+	dialect    dialect
+	passphrase string // SQLCipher key of an encrypted SQLite database, also used for its backups
+
+	stmtsMu sync.Mutex
+	stmts   map[string]*sql.Stmt // Prepared statements by query text, see prepared
+
+	writeMu sync.Mutex // Serializes the writes of this process, see beginWrite
 }
 
-// NewStorage creates a new storage instance
+// sqliteBusyTimeout is how long a SQLite connection waits for another writer before failing with "database is locked"
+const sqliteBusyTimeout = 5 * time.Second
+
+// NewStorage creates a new storage instance backed by a SQLite database file.
+// The database runs in WAL mode so the dashboard can read while a scrape writes.
 func NewStorage(dbPath string) (*Storage, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	return openStorage(sqliteDialect{}, sqliteDSN(dbPath))
+}
+
+// sqliteDSN adds the connection pragmas to a SQLite database path. They are part of the DSN rather than
+// PRAGMA statements so every pooled connection gets them:
+//   - WAL journal mode lets readers run alongside a writer
+//   - synchronous=NORMAL is durable in WAL mode and avoids an fsync per transaction
+//   - busy_timeout waits for a concurrent writer instead of failing immediately
+//   - foreign_keys enforces the status_changes -> contracts reference
+//   - immediate transactions take the write lock up front, so two writers queue on busy_timeout
+//     instead of deadlocking when both try to upgrade a read lock
+func sqliteDSN(dbPath string) string {
+	separator := "?"
+	if strings.Contains(dbPath, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=%d&_foreign_keys=on&_txlock=immediate",
+		dbPath, separator, sqliteBusyTimeout.Milliseconds())
+}
+
+// NewMySQLStorage creates a new storage instance backed by a MySQL or MariaDB server.
+// The DSN uses the go-sql-driver format, e.g. "user:pass@tcp(localhost:3306)/contracts".
+func NewMySQLStorage(dsn string) (*Storage, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL DSN: %w", err)
+	}
+
+	// Scan DATETIME columns into time.Time and keep every timestamp in UTC,
+	// matching what SQLite's CURRENT_TIMESTAMP produces
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	if cfg.Params == nil {
+		cfg.Params = make(map[string]string)
+	}
+	cfg.Params["time_zone"] = "'+00:00'"
+
+	return openStorage(mysqlDialect{}, cfg.FormatDSN())
+}
+
+// Open creates a storage instance for the given driver ("sqlite3" or "mysql")
+func Open(driver, dsn string) (*Storage, error) {
+	switch driver {
+	case "", "sqlite", "sqlite3":
+		return NewStorage(dsn)
+	case "mysql", "mariadb":
+		return NewMySQLStorage(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// openStorage opens the database, verifies the connection and applies pending migrations
+func openStorage(d dialect, dsn string) (*Storage, error) {
+	db, err := sql.Open(d.driverName(), dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	return newStorage(d, db)
+}
+
+// newStorage verifies the connection of an opened database and applies pending migrations
+func newStorage(d dialect, db *sql.DB) (*Storage, error) {
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	storage := &Storage{db: db}
+	d.configurePool(db)
+
+	storage := &Storage{db: db, dialect: d}
 	if err := storage.initTables(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to initialize tables: %w", err)
 	}
 
@@ -35,50 +113,41 @@ func NewStorage(dbPath string) (*Storage, error) {
 
 // Close closes the database connection
 func (s *Storage) Close() error {
+	s.closeStatements()
 	return s.db.Close()
 }
 
+// beginWrite starts a write transaction; end it with endWrite. The writes of one process run one at a
+// time, so a dashboard action and a scrape sharing the process queue here instead of failing with
+// "database is locked" when the other holds the SQLite write lock for longer than sqliteBusyTimeout.
+// Writes from other processes are still covered by the busy timeout.
+func (s *Storage) beginWrite() (*sql.Tx, error) {
+	s.writeMu.Lock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.writeMu.Unlock()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// endWrite rolls back tx unless it was committed and lets the next write start
+func (s *Storage) endWrite(tx *sql.Tx) {
+	tx.Rollback()
+	s.writeMu.Unlock()
+}
+
+// exec runs a single write statement outside a transaction, queued like beginWrite
+func (s *Storage) exec(query string, args ...interface{}) (sql.Result, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.db.Exec(query, args...)
+}
+
 // initTables creates the necessary tables if they don't exist
 func (s *Storage) initTables() error {
-	// Create contracts table
-	contractsQuery := `
-	CREATE TABLE IF NOT EXISTS contracts (
-		id TEXT PRIMARY KEY,
-		description TEXT,
-		contract_type TEXT,
-		status TEXT,
-		amount TEXT,
-		submission_date TEXT,
-		contracting_body TEXT,
-		link TEXT,
-		pliego_link TEXT,
-		anuncio_link TEXT,
-		scraped_at DATETIME,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	_, err := s.db.Exec(contractsQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create contracts table: %w", err)
-	}
-
-	// Create status changes table to track status modifications
-	statusChangesQuery := `
-	CREATE TABLE IF NOT EXISTS status_changes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		contract_id TEXT NOT NULL,
-		old_status TEXT,
-		new_status TEXT NOT NULL,
-		changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (contract_id) REFERENCES contracts (id)
-	);
-	`
-
-	_, err = s.db.Exec(statusChangesQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create status_changes table: %w", err)
+	if err := s.migrate(); err != nil {
+		return err
 	}
 
 	log.Println("Database tables initialized successfully")
@@ -91,72 +160,109 @@ func (s *Storage) SaveContracts(contracts []scraper.Contract) error {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.beginWrite()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback()
+	defer s.endWrite(tx)
 
 	// Prepare statements
-	insertQuery := `
-	INSERT OR REPLACE INTO contracts 
-	(id, description, contract_type, status, amount, submission_date, contracting_body, link, pliego_link, anuncio_link, scraped_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-	`
-
-	insertStmt, err := tx.Prepare(insertQuery)
+	insertStmt, err := s.txStmt(tx, s.upsertContractQuery())
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer insertStmt.Close()
 
-	// Statement to check current status
-	checkStatusQuery := `SELECT status FROM contracts WHERE id = ?`
-	checkStatusStmt, err := tx.Prepare(checkStatusQuery)
+	// Statement to load the stored version of a contract
+	checkStatusQuery := `SELECT ` + contractColumns + ` FROM contracts WHERE id = ?`
+	checkStatusStmt, err := s.txStmt(tx, checkStatusQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare check status statement: %w", err)
 	}
 	defer checkStatusStmt.Close()
 
 	// Statement to insert status change
-	statusChangeQuery := `INSERT INTO status_changes (contract_id, old_status, new_status) VALUES (?, ?, ?)`
-	statusChangeStmt, err := tx.Prepare(statusChangeQuery)
+	statusChangeQuery := `INSERT INTO status_changes (contract_uid, old_status, new_status, uid) VALUES (` + contractUIDOf + `, ?, ?, ?)`
+	statusChangeStmt, err := s.txStmt(tx, statusChangeQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare status change statement: %w", err)
 	}
 	defer statusChangeStmt.Close()
 
+	// Statement to insert field revisions
+	revisionQuery := `INSERT INTO contract_revisions (contract_uid, field, old_value, new_value, uid) VALUES (` + contractUIDOf + `, ?, ?, ?, ?)`
+	revisionStmt, err := s.txStmt(tx, revisionQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare revision statement: %w", err)
+	}
+	defer revisionStmt.Close()
+
 	var statusChanges []string
+	revisionCount, pageCount := 0, 0
+	resolver := newContractResolver(tx)
+	if err := resolver.preload(contracts); err != nil {
+		return err
+	}
 
 	for _, contract := range contracts {
-		// Check if contract exists and get current status
-		var currentStatus string
-		err := checkStatusStmt.QueryRow(contract.ID).Scan(&currentStatus)
+		contract.ParseTypedFields()
+
+		// Find the id the contract is stored under, or a fresh one for new contracts
+		if _, err := resolver.resolve(&contract); err != nil {
+			return err
+		}
+
+		// Check if contract exists and get its stored version
+		stored, err := scanContract(checkStatusStmt.QueryRow(contract.ID))
 		if err != nil && err != sql.ErrNoRows {
 			return fmt.Errorf("failed to check current status for contract %s: %w", contract.ID, err)
 		}
+		currentStatus := stored.Status
+
+		// Keep the first-seen time of contracts we already had
+		if err == sql.ErrNoRows || stored.FirstSeenAt.IsZero() {
+			stored.FirstSeenAt = time.Now().UTC()
+		}
+
+		// Record field-level revisions for contracts we already had
+		if err != sql.ErrNoRows {
+			mergeMissingFields(stored, &contract)
+			for _, revision := range diffContracts(stored, contract) {
+				_, err := revisionStmt.Exec(revision.ContractID, revision.Field, revision.OldValue, revision.NewValue, newULID(time.Now()))
+				if err != nil {
+					return fmt.Errorf("failed to record revision for contract %s: %w", contract.ID, err)
+				}
+				revisionCount++
+			}
+		}
 
 		// Insert or update the contract
-		_, err = insertStmt.Exec(
-			contract.ID,
-			contract.Description,
-			contract.ContractType,
-			contract.Status,
-			contract.Amount,
-			contract.SubmissionDate,
-			contract.ContractingBody,
-			contract.Link,
-			contract.PliegoLink,
-			contract.AnuncioLink,
-			contract.ScrapedAt,
-		)
+		_, err = insertStmt.Exec(contractValues(contract, stored)...)
 		if err != nil {
 			return fmt.Errorf("failed to insert contract %s: %w", contract.ID, err)
 		}
 
+		// A scrape that did not visit the detail page leaves the stored CPV codes alone
+		if len(contract.CPVCodes) > 0 {
+			if err := saveContractCPVs(tx, contract.ID, contract.CPVCodes); err != nil {
+				return err
+			}
+		}
+
+		// Keep the detail page HTML fetched by this scrape for reprocessing
+		if contract.DetailHTML != "" {
+			saved, err := saveRawPage(tx, contract.ID, contract.DetailHTML, time.Now())
+			if err != nil {
+				return err
+			}
+			if saved {
+				pageCount++
+			}
+		}
+
 		// If contract existed and status changed, record the change
 		if err != sql.ErrNoRows && currentStatus != "" && currentStatus != contract.Status {
-			_, err = statusChangeStmt.Exec(contract.ID, currentStatus, contract.Status)
+			_, err = statusChangeStmt.Exec(contract.ID, currentStatus, contract.Status, newULID(time.Now()))
 			if err != nil {
 				return fmt.Errorf("failed to record status change for contract %s: %w", contract.ID, err)
 			}
@@ -172,74 +278,122 @@ func (s *Storage) SaveContracts(contracts []scraper.Contract) error {
 	if len(statusChanges) > 0 {
 		log.Printf("Status changes detected: %v", statusChanges)
 	}
+	if revisionCount > 0 {
+		log.Printf("Recorded %d contract field revisions", revisionCount)
+	}
+	if pageCount > 0 {
+		log.Printf("Stored %d contract detail pages", pageCount)
+	}
 
 	return nil
 }
 
-// CheckAndUpdateStatusChanges checks for status changes in existing contracts
-// This method is called with ALL contracts found on the website to detect status changes
-// for contracts that are already in our database but have different statuses
-func (s *Storage) CheckAndUpdateStatusChanges(allContracts []scraper.Contract) error {
+// CheckAndUpdateChanges checks existing contracts for changes
+// This method is called with ALL contracts found on the website to detect status changes and
+// changes to the tracked fields (amount, deadline, description...) of contracts that are already
+// in our database, recording them in status_changes and contract_revisions
+func (s *Storage) CheckAndUpdateChanges(allContracts []scraper.Contract) error {
 	if len(allContracts) == 0 {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.beginWrite()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback()
+	defer s.endWrite(tx)
 
-	// Statement to check if contract exists and get current status
-	checkQuery := `SELECT status FROM contracts WHERE id = ?`
-	checkStmt, err := tx.Prepare(checkQuery)
+	// Statement to get the stored version of a contract
+	checkQuery := `SELECT ` + contractColumns + ` FROM contracts WHERE id = ?`
+	checkStmt, err := s.txStmt(tx, checkQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare check statement: %w", err)
 	}
 	defer checkStmt.Close()
 
-	// Statement to update contract status
-	updateQuery := `UPDATE contracts SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
-	updateStmt, err := tx.Prepare(updateQuery)
+	// Statement to update the status and tracked fields of a contract
+	var assignments []string
+	for _, field := range revisionFields {
+		assignments = append(assignments, field.name+" = ?")
+	}
+	updateQuery := `UPDATE contracts SET status = ?, ` + strings.Join(assignments, ", ") + `, amount_value = ?, deadline = ?, body_key = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	updateStmt, err := s.txStmt(tx, updateQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare update statement: %w", err)
 	}
 	defer updateStmt.Close()
 
 	// Statement to insert status change
-	statusChangeQuery := `INSERT INTO status_changes (contract_id, old_status, new_status) VALUES (?, ?, ?)`
-	statusChangeStmt, err := tx.Prepare(statusChangeQuery)
+	statusChangeQuery := `INSERT INTO status_changes (contract_uid, old_status, new_status, uid) VALUES (` + contractUIDOf + `, ?, ?, ?)`
+	statusChangeStmt, err := s.txStmt(tx, statusChangeQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare status change statement: %w", err)
 	}
 	defer statusChangeStmt.Close()
 
+	// Statement to insert field revisions
+	revisionQuery := `INSERT INTO contract_revisions (contract_uid, field, old_value, new_value, uid) VALUES (` + contractUIDOf + `, ?, ?, ?, ?)`
+	revisionStmt, err := s.txStmt(tx, revisionQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare revision statement: %w", err)
+	}
+	defer revisionStmt.Close()
+
 	var statusChanges []string
+	revisionCount := 0
+	resolver := newContractResolver(tx)
+	if err := resolver.preload(allContracts); err != nil {
+		return err
+	}
 
 	for _, contract := range allContracts {
 		// Check if contract exists in our database
-		var currentStatus string
-		err := checkStmt.QueryRow(contract.ID).Scan(&currentStatus)
-		if err == sql.ErrNoRows {
+		known, err := resolver.resolve(&contract)
+		if err != nil {
+			return err
+		}
+		if !known {
 			// Contract not in our database, skip (we only track existing contracts)
 			continue
-		} else if err != nil {
+		}
+
+		stored, err := scanContract(checkStmt.QueryRow(contract.ID))
+		if err != nil {
 			return fmt.Errorf("failed to check contract %s: %w", contract.ID, err)
 		}
 
-		// If status changed, update it and record the change
-		if currentStatus != contract.Status {
-			_, err = updateStmt.Exec(contract.Status, contract.ID)
+		// Fields the listing did not show (an empty status or document links) were not extracted, not cleared
+		mergeMissingFields(stored, &contract)
+		revisions := diffContracts(stored, contract)
+		statusChanged := contract.Status != stored.Status
+		if !statusChanged && len(revisions) == 0 {
+			continue
+		}
+
+		args := []interface{}{contract.Status}
+		for _, field := range revisionFields {
+			args = append(args, *field.field(&contract))
+		}
+		args = append(args, nullableAmount(contract), nullableDeadline(contract), normalizeBody(contract.ContractingBody), contract.ID)
+		if _, err := updateStmt.Exec(args...); err != nil {
+			return fmt.Errorf("failed to update contract %s: %w", contract.ID, err)
+		}
+
+		for _, revision := range revisions {
+			_, err := revisionStmt.Exec(revision.ContractID, revision.Field, revision.OldValue, revision.NewValue, newULID(time.Now()))
 			if err != nil {
-				return fmt.Errorf("failed to update status for contract %s: %w", contract.ID, err)
+				return fmt.Errorf("failed to record revision for contract %s: %w", contract.ID, err)
 			}
+			revisionCount++
+		}
 
-			_, err = statusChangeStmt.Exec(contract.ID, currentStatus, contract.Status)
+		if statusChanged {
+			_, err = statusChangeStmt.Exec(contract.ID, stored.Status, contract.Status, newULID(time.Now()))
 			if err != nil {
 				return fmt.Errorf("failed to record status change for contract %s: %w", contract.ID, err)
 			}
 
-			statusChanges = append(statusChanges, fmt.Sprintf("%s: %s → %s", contract.ID, currentStatus, contract.Status))
+			statusChanges = append(statusChanges, fmt.Sprintf("%s: %s → %s", contract.ID, stored.Status, contract.Status))
 		}
 	}
 
@@ -250,51 +404,34 @@ func (s *Storage) CheckAndUpdateStatusChanges(allContracts []scraper.Contract) e
 	if len(statusChanges) > 0 {
 		log.Printf("Status changes detected: %v", statusChanges)
 	}
+	if revisionCount > 0 {
+		log.Printf("Recorded %d contract field revisions", revisionCount)
+	}
 
 	return nil
 }
 
-// GetContracts retrieves all contracts from the database
-func (s *Storage) GetContracts() ([]scraper.Contract, error) {
-	query := `SELECT id, description, contract_type, status, amount, submission_date, contracting_body, link, pliego_link, anuncio_link, scraped_at FROM contracts ORDER BY scraped_at DESC`
-	
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query contracts: %w", err)
-	}
-	defer rows.Close()
+// contractColumns is the column list read by scanContract
+const contractColumns = `id, description, contract_type, status, amount, submission_date, contracting_body, link, pliego_link, anuncio_link, amount_value, deadline, scraped_at, archived_at, deleted_at, first_seen_at, seen_at, expediente, profile_id, uid`
 
-	var contracts []scraper.Contract
-	for rows.Next() {
-		var contract scraper.Contract
-		err := rows.Scan(
-			&contract.ID,
-			&contract.Description,
-			&contract.ContractType,
-			&contract.Status,
-			&contract.Amount,
-			&contract.SubmissionDate,
-			&contract.ContractingBody,
-			&contract.Link,
-			&contract.PliegoLink,
-			&contract.AnuncioLink,
-			&contract.ScrapedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan contract: %w", err)
-		}
-		contracts = append(contracts, contract)
-	}
+// notDeleted restricts a query to contracts that have not been soft-deleted
+const notDeleted = `deleted_at IS NULL`
 
-	return contracts, nil
+// activeOnly restricts a query to contracts that are neither archived nor soft-deleted
+const activeOnly = `archived_at IS NULL AND deleted_at IS NULL`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// GetContractByID retrieves a specific contract by ID
-func (s *Storage) GetContractByID(id string) (*scraper.Contract, error) {
-	query := `SELECT id, description, contract_type, status, amount, submission_date, contracting_body, link, pliego_link, anuncio_link, scraped_at FROM contracts WHERE id = ?`
-	
+// scanContract reads a contract selected with contractColumns
+func scanContract(row rowScanner) (scraper.Contract, error) {
 	var contract scraper.Contract
-	err := s.db.QueryRow(query, id).Scan(
+	var amountValue sql.NullFloat64
+	var deadline, archivedAt, deletedAt, firstSeenAt, seenAt sql.NullTime
+	var expediente, uid sql.NullString
+	err := row.Scan(
 		&contract.ID,
 		&contract.Description,
 		&contract.ContractType,
@@ -305,25 +442,249 @@ func (s *Storage) GetContractByID(id string) (*scraper.Contract, error) {
 		&contract.Link,
 		&contract.PliegoLink,
 		&contract.AnuncioLink,
+		&amountValue,
+		&deadline,
 		&contract.ScrapedAt,
+		&archivedAt,
+		&deletedAt,
+		&firstSeenAt,
+		&seenAt,
+		&expediente,
+		&contract.ProfileID,
+		&uid,
 	)
-	
-	if err == sql.ErrNoRows {
-		return nil, nil
+	if err != nil {
+		return contract, err
+	}
+
+	contract.Expediente = expediente.String
+	contract.UID = uid.String
+	contract.AmountValue = amountValue.Float64
+	if deadline.Valid {
+		contract.Deadline = &deadline.Time
+	}
+	if archivedAt.Valid {
+		contract.ArchivedAt = &archivedAt.Time
+	}
+	if deletedAt.Valid {
+		contract.DeletedAt = &deletedAt.Time
+	}
+	if firstSeenAt.Valid {
+		contract.FirstSeenAt = firstSeenAt.Time
+	}
+	if seenAt.Valid {
+		contract.SeenAt = &seenAt.Time
+	}
+	return contract, nil
+}
+
+// contractWriteColumns are the columns written by SaveContracts, in the order of contractValues
+var contractWriteColumns = []string{"id", "description", "contract_type", "status", "amount", "submission_date", "contracting_body", "link", "pliego_link", "anuncio_link", "amount_value", "deadline", "scraped_at", "archived_at", "deleted_at", "first_seen_at", "seen_at", "expediente", "body_key", "profile_id", "uid"}
+
+// contractValues returns the values of contractWriteColumns for a scraped contract. State that only
+// exists in the database is carried over from the stored row (the zero contract for new ones).
+func contractValues(contract, stored scraper.Contract) []interface{} {
+	return []interface{}{
+		contract.ID,
+		contract.Description,
+		contract.ContractType,
+		contract.Status,
+		contract.Amount,
+		contract.SubmissionDate,
+		contract.ContractingBody,
+		contract.Link,
+		contract.PliegoLink,
+		contract.AnuncioLink,
+		nullableAmount(contract),
+		nullableDeadline(contract),
+		contract.ScrapedAt.UTC(),
+		nullableTime(stored.ArchivedAt), // Re-scraping does not undo archival
+		nullableTime(stored.DeletedAt),  // or a soft delete
+		stored.FirstSeenAt.UTC(),
+		nullableTime(stored.SeenAt), // New contracts start unseen
+		normalizeExpediente(contract.Expediente),
+		normalizeBody(contract.ContractingBody),
+		profileID(contract.ProfileID),
+		contractUID(stored),
+	}
+}
+
+// contractUID returns the uid of a stored contract, or a new one for a contract that is not stored yet
+func contractUID(stored scraper.Contract) string {
+	if stored.UID != "" {
+		return stored.UID
+	}
+	return newULID(stored.FirstSeenAt)
+}
+
+// upsertContractQuery builds the insert-or-update statement for contractWriteColumns. Scraped text
+// columns keep their stored value when the incoming one is empty, so a partial scrape never clobbers
+// data extracted earlier; created_at is not in the column list and is never touched.
+func (s *Storage) upsertContractQuery() string {
+	values := make([]string, len(contractWriteColumns))
+	for i := range values {
+		values[i] = "?"
 	}
+
+	keep := []string{"status"}
+	for _, field := range revisionFields {
+		keep = append(keep, field.name)
+	}
+
+	return s.dialect.replaceQuery("contracts",
+		append(append([]string{}, contractWriteColumns...), "updated_at"),
+		append(values, "CURRENT_TIMESTAMP"),
+		keep)
+}
+
+// scanContracts reads every contract row selected with contractColumns
+func scanContracts(rows *sql.Rows) ([]scraper.Contract, error) {
+	var contracts []scraper.Contract
+	for rows.Next() {
+		contract, err := scanContract(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan contract: %w", err)
+		}
+		contracts = append(contracts, contract)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read contracts: %w", err)
+	}
+
+	return contracts, nil
+}
+
+// attachUserData fills in the tags, CPV codes and watchlist flag of already loaded contracts
+func (s *Storage) attachUserData(contracts []scraper.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+	userData, err := s.loadUserData()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get contract: %w", err)
+		return err
+	}
+	for i := range contracts {
+		userData.attach(&contracts[i])
+	}
+	return nil
+}
+
+// contractUserData holds the tags, CPV codes and watchlist flags of the contracts by uid
+type contractUserData struct {
+	tags     map[string][]string
+	cpvCodes map[string][]string
+	watched  map[string]bool
+}
+
+// loadUserData reads the tags, CPV codes and watchlist flags of every contract
+func (s *Storage) loadUserData() (*contractUserData, error) {
+	var userData contractUserData
+	var err error
+	if userData.tags, err = s.contractTags(); err != nil {
+		return nil, err
+	}
+	if userData.cpvCodes, err = s.contractCPVCodes(); err != nil {
+		return nil, err
+	}
+	if userData.watched, err = s.watchedContracts(); err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
+// attach fills in the tags, CPV codes and watchlist flag of a contract
+func (u *contractUserData) attach(contract *scraper.Contract) {
+	contract.Tags = u.tags[contract.UID]
+	contract.CPVCodes = u.cpvCodes[contract.UID]
+	contract.Watched = u.watched[contract.UID]
+}
+
+// nullableAmount returns the parsed amount or NULL when the amount text could not be parsed
+func nullableAmount(contract scraper.Contract) interface{} {
+	if contract.AmountValue == 0 {
+		return nil
 	}
+	return contract.AmountValue
+}
+
+// nullableDeadline returns the parsed deadline in UTC or NULL when unknown
+func nullableDeadline(contract scraper.Contract) interface{} {
+	return nullableTime(contract.Deadline)
+}
+
+// timestampParam formats t like CURRENT_TIMESTAMP for comparisons against columns it filled.
+// The driver's encoding of a time.Time appends a zone suffix, which would sort after rows written in the same second.
+func timestampParam(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
 
-	return &contract, nil
+// nullableTime returns the time in UTC or NULL when it is not set
+func nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// GetContracts retrieves all active (not archived or deleted) contracts from the database
+func (s *Storage) GetContracts() ([]scraper.Contract, error) {
+	return s.queryContracts("contracts", `SELECT `+contractColumns+` FROM contracts WHERE `+activeOnly+` ORDER BY scraped_at DESC`)
 }
 
-// GetNewContracts returns contracts that don't exist in the database
+// GetContractByID retrieves a specific contract by ID
+func (s *Storage) GetContractByID(id string) (*scraper.Contract, error) {
+	contracts, err := s.queryContracts("contract", `SELECT `+contractColumns+` FROM contracts WHERE id = ? AND `+notDeleted, id)
+	if err != nil || len(contracts) == 0 {
+		return nil, err
+	}
+
+	return &contracts[0], nil
+}
+
+// GetContractsClosingSoon returns contracts whose submission deadline falls within the given
+// window from now, soonest first
+func (s *Storage) GetContractsClosingSoon(within time.Duration) ([]scraper.Contract, error) {
+	now := time.Now().UTC()
+	return s.queryContracts("contracts closing soon",
+		`SELECT `+contractColumns+` FROM contracts WHERE deadline >= ? AND deadline <= ? AND `+activeOnly+` ORDER BY deadline ASC`,
+		now, now.Add(within))
+}
+
+// GetLargestContracts returns the contracts with the highest estimated amount
+func (s *Storage) GetLargestContracts(limit int) ([]scraper.Contract, error) {
+	return s.queryContracts("largest contracts",
+		`SELECT `+contractColumns+` FROM contracts WHERE amount_value IS NOT NULL AND `+notDeleted+` ORDER BY amount_value DESC LIMIT ?`,
+		limit)
+}
+
+// GetContractsFirstSeenSince returns the contracts first saved at or after t, newest first
+func (s *Storage) GetContractsFirstSeenSince(t time.Time) ([]scraper.Contract, error) {
+	return s.queryContracts("contracts first seen since "+t.Format(time.RFC3339),
+		`SELECT `+contractColumns+` FROM contracts WHERE first_seen_at >= ? AND `+notDeleted+` ORDER BY first_seen_at DESC`,
+		t.UTC())
+}
+
+// CountContractsFirstSeenSince counts the contracts first saved at or after t
+func (s *Storage) CountContractsFirstSeenSince(t time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM contracts WHERE first_seen_at >= ? AND `+notDeleted, t.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count contracts first seen since %s: %w", t.Format(time.RFC3339), err)
+	}
+	return count, nil
+}
+
+// GetNewContracts returns contracts that don't exist in the database, with the ids they will be stored under
 func (s *Storage) GetNewContracts(contracts []scraper.Contract) ([]scraper.Contract, error) {
 	var newContracts []scraper.Contract
+	resolver := newContractResolver(s.db)
+	if err := resolver.preload(contracts); err != nil {
+		return nil, fmt.Errorf("failed to check for new contracts: %w", err)
+	}
 
 	for _, contract := range contracts {
-		exists, err := s.contractExists(contract.ID)
+		exists, err := resolver.resolve(&contract)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check if contract exists: %w", err)
 		}
@@ -335,57 +696,177 @@ func (s *Storage) GetNewContracts(contracts []scraper.Contract) ([]scraper.Contr
 	return newContracts, nil
 }
 
-// contractExists checks if a contract with the given ID exists
-func (s *Storage) contractExists(id string) (bool, error) {
-	query := `SELECT COUNT(*) FROM contracts WHERE id = ?`
+// DeleteAllContracts soft-deletes all contracts; they can be brought back with RestoreAllContracts.
+// actor is recorded in the audit log.
+func (s *Storage) DeleteAllContracts(actor string) error {
+	query := `UPDATE contracts SET deleted_at = CURRENT_TIMESTAMP WHERE ` + notDeleted
 	
-	var count int
-	err := s.db.QueryRow(query, id).Scan(&count)
+	deleted, err := s.auditedUpdate(actor, AuditDeleteAll, "", query)
 	if err != nil {
-		return false, fmt.Errorf("failed to check contract existence: %w", err)
+		return fmt.Errorf("failed to delete all contracts: %w", err)
 	}
 
-	return count > 0, nil
+	log.Printf("All %d contracts deleted from database by %s", deleted, actor)
+	return nil
 }
 
-// DeleteAllContracts removes all contracts from the database
-func (s *Storage) DeleteAllContracts() error {
-	query := `DELETE FROM contracts`
+// DeleteContract soft-deletes a specific contract; it can be brought back with RestoreContract.
+// actor is recorded in the audit log.
+func (s *Storage) DeleteContract(contractID, actor string) error {
+	query := `UPDATE contracts SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND ` + notDeleted
 	
-	_, err := s.db.Exec(query)
+	rowsAffected, err := s.auditedUpdate(actor, AuditDeleteContract, contractID, query, contractID)
 	if err != nil {
-		return fmt.Errorf("failed to delete all contracts: %w", err)
+		return fmt.Errorf("failed to delete contract %s: %w", contractID, err)
+	}
+
+	if rowsAffected == 0 {
+		return notFoundf("contract %s not found", contractID)
 	}
 
-	log.Println("All contracts deleted from database")
+	log.Printf("Contract %s deleted from database by %s", contractID, actor)
 	return nil
 }
 
-// DeleteContract removes a specific contract from the database
-func (s *Storage) DeleteContract(contractID string) error {
-	query := `DELETE FROM contracts WHERE id = ?`
-	
-	result, err := s.db.Exec(query, contractID)
+// DeleteContracts soft-deletes the given contracts in one transaction and returns how many were
+// deleted; unknown or already deleted ones are skipped. Each is recorded in the audit log like
+// DeleteContract, so they can be restored one by one.
+func (s *Storage) DeleteContracts(contractIDs []string, actor string) (int64, error) {
+	tx, err := s.beginWrite()
 	if err != nil {
-		return fmt.Errorf("failed to delete contract %s: %w", contractID, err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	var deleted int64
+	for _, contractID := range contractIDs {
+		result, err := tx.Exec(`UPDATE contracts SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND `+notDeleted, contractID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete contract %s: %w", contractID, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if affected == 0 {
+			continue
+		}
+		if err := recordAudit(tx, actor, AuditDeleteContract, contractID, affected); err != nil {
+			return 0, err
+		}
+		deleted += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if deleted > 0 {
+		log.Printf("%d contracts deleted from database by %s", deleted, actor)
+	}
+	return deleted, nil
+}
+
+// RestoreContract undoes the soft delete of a specific contract. actor is recorded in the audit log.
+func (s *Storage) RestoreContract(contractID, actor string) error {
+	query := `UPDATE contracts SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+
+	rowsAffected, err := s.auditedUpdate(actor, AuditRestoreContract, contractID, query, contractID)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to restore contract %s: %w", contractID, err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("contract %s not found", contractID)
+		return notFoundf("deleted contract %s not found", contractID)
 	}
 
-	log.Printf("Contract %s deleted from database", contractID)
+	log.Printf("Contract %s restored by %s", contractID, actor)
 	return nil
 }
 
+// RestoreAllContracts undoes the soft delete of every deleted contract and returns how many were restored.
+// actor is recorded in the audit log.
+func (s *Storage) RestoreAllContracts(actor string) (int64, error) {
+	query := `UPDATE contracts SET deleted_at = NULL WHERE deleted_at IS NOT NULL`
+
+	restored, err := s.auditedUpdate(actor, AuditRestoreAll, "", query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore contracts: %w", err)
+	}
+
+	log.Printf("Restored %d deleted contracts by %s", restored, actor)
+	return restored, nil
+}
+
+// auditedUpdate runs an UPDATE statement and records it in the audit log in one transaction. Nothing
+// is recorded when a single-contract action (one with a target) matches no contract.
+func (s *Storage) auditedUpdate(actor, action, target, query string, args ...interface{}) (int64, error) {
+	tx, err := s.beginWrite()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 && target != "" {
+		return 0, nil
+	}
+
+	if err := recordAudit(tx, actor, action, target, affected); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return affected, nil
+}
+
+// GetDeletedContracts retrieves the soft-deleted contracts, most recently deleted first
+func (s *Storage) GetDeletedContracts() ([]scraper.Contract, error) {
+	return s.queryContracts("deleted contracts",
+		`SELECT `+contractColumns+` FROM contracts WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+}
+
+// PurgeDeletedContracts permanently removes contracts that were soft-deleted more than
+// olderThan ago, together with their status changes and revisions. actor is recorded in the audit log.
+func (s *Storage) PurgeDeletedContracts(olderThan time.Duration, actor string) (int64, error) {
+	cutoff := time.Now().Add(-olderThan).UTC()
+
+	tx, err := s.beginWrite()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer s.endWrite(tx)
+
+	purged, err := deleteContractsWhere(tx, `deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted contracts: %w", err)
+	}
+
+	if err := recordAudit(tx, actor, AuditPurgeDeleted, "deleted before "+cutoff.Format(time.RFC3339), purged); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Purged %d contracts deleted before %s", purged, cutoff.Format(time.RFC3339))
+	return purged, nil
+}
+
 // GetContractCount returns the total number of contracts
 func (s *Storage) GetContractCount() (int, error) {
-	query := `SELECT COUNT(*) FROM contracts`
+	query := `SELECT COUNT(*) FROM contracts WHERE ` + notDeleted
 	
 	var count int
 	err := s.db.QueryRow(query).Scan(&count)
@@ -398,149 +879,92 @@ func (s *Storage) GetContractCount() (int, error) {
 
 // StatusChange represents a status change record
 type StatusChange struct {
-	ID         int    `json:"id"`
-	ContractID string `json:"contract_id"`
-	OldStatus  string `json:"old_status"`
-	NewStatus  string `json:"new_status"`
-	ChangedAt  string `json:"changed_at"`
+	ID             int        `json:"id"`
+	UID            string     `json:"uid"`
+	ContractID     string     `json:"contract_id"`
+	OldStatus      string     `json:"old_status"`
+	NewStatus      string     `json:"new_status"`
+	ChangedAt      string     `json:"changed_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"` // When a dashboard user dismissed the change
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
 }
 
 // GetStatusChanges retrieves all status changes for a specific contract
 func (s *Storage) GetStatusChanges(contractID string) ([]StatusChange, error) {
-	query := `
-	SELECT id, contract_id, old_status, new_status, changed_at 
-	FROM status_changes 
-	WHERE contract_id = ? 
-	ORDER BY changed_at DESC
-	`
-	
-	rows, err := s.db.Query(query, contractID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query status changes: %w", err)
-	}
-	defer rows.Close()
-
-	var changes []StatusChange
-	for rows.Next() {
-		var change StatusChange
-		err := rows.Scan(
-			&change.ID,
-			&change.ContractID,
-			&change.OldStatus,
-			&change.NewStatus,
-			&change.ChangedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan status change: %w", err)
-		}
-		changes = append(changes, change)
-	}
-
-	return changes, nil
+	return s.queryStatusChanges("status changes",
+		`SELECT `+statusChangeColumns+` FROM status_changes WHERE contract_uid = `+contractUIDOf+` ORDER BY changed_at DESC`,
+		contractID)
 }
 
 // GetRecentStatusChanges retrieves recent status changes (last 24 hours)
 func (s *Storage) GetRecentStatusChanges() ([]StatusChange, error) {
-	query := `
-	SELECT id, contract_id, old_status, new_status, changed_at 
-	FROM status_changes 
-	WHERE changed_at >= datetime('now', '-1 day')
-	ORDER BY changed_at DESC
-	`
-	
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query recent status changes: %w", err)
-	}
-	defer rows.Close()
-
-	var changes []StatusChange
-	for rows.Next() {
-		var change StatusChange
-		err := rows.Scan(
-			&change.ID,
-			&change.ContractID,
-			&change.OldStatus,
-			&change.NewStatus,
-			&change.ChangedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan status change: %w", err)
-		}
-		changes = append(changes, change)
-	}
-
-	return changes, nil
+	return s.queryStatusChanges("recent status changes",
+		`SELECT `+statusChangeColumns+` FROM status_changes WHERE changed_at >= `+s.dialect.daysAgo(1)+` ORDER BY changed_at DESC`)
 }
 
 // GetAllStatusChanges retrieves all status changes
 func (s *Storage) GetAllStatusChanges() ([]StatusChange, error) {
-	query := `
-	SELECT id, contract_id, old_status, new_status, changed_at 
-	FROM status_changes 
-	ORDER BY changed_at DESC
-	`
-	
-	rows, err := s.db.Query(query)
+	return s.queryStatusChanges("all status changes",
+		`SELECT `+statusChangeColumns+` FROM status_changes ORDER BY changed_at DESC`)
+}
+
+// AcknowledgeStatusChange marks a status change as dealt with by actor, so the dashboard stops
+// listing it for everyone. Acknowledging it again keeps the first acknowledgement.
+func (s *Storage) AcknowledgeStatusChange(id int64, actor string) error {
+	result, err := s.exec(`UPDATE status_changes SET acknowledged_at = CURRENT_TIMESTAMP, acknowledged_by = ? WHERE id = ? AND acknowledged_at IS NULL`, actor, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query all status changes: %w", err)
+		return fmt.Errorf("failed to acknowledge status change: %w", err)
 	}
-	defer rows.Close()
 
-	var changes []StatusChange
-	for rows.Next() {
-		var change StatusChange
-		err := rows.Scan(
-			&change.ID,
-			&change.ContractID,
-			&change.OldStatus,
-			&change.NewStatus,
-			&change.ChangedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan status change: %w", err)
-		}
-		changes = append(changes, change)
+	acknowledged, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if acknowledged > 0 {
+		return nil
 	}
 
-	return changes, nil
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM status_changes WHERE id = ?`, id).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check status change %d: %w", id, err)
+	}
+	if count == 0 {
+		return notFoundf("status change %d not found", id)
+	}
+	return nil
 }
 
-// GetContractsWithStatusChanges returns contracts that have recent status changes
-func (s *Storage) GetContractsWithStatusChanges() ([]scraper.Contract, error) {
-	query := `
-	SELECT DISTINCT c.id, c.description, c.contract_type, c.status, c.amount, 
-	       c.submission_date, c.contracting_body, c.scraped_at
-	FROM contracts c
-	INNER JOIN status_changes sc ON c.id = sc.contract_id
-	WHERE sc.changed_at >= datetime('now', '-1 day')
-	ORDER BY c.scraped_at DESC
-	`
-	
+// ForEachStatusChange calls fn for every recorded status change in insertion order, reading one
+// row at a time. Iteration stops at the first error returned by fn.
+func (s *Storage) ForEachStatusChange(fn func(change StatusChange) error) error {
+	query := `SELECT ` + statusChangeColumns + ` FROM status_changes ORDER BY id ASC`
+
 	rows, err := s.db.Query(query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query contracts with status changes: %w", err)
+		return fmt.Errorf("failed to query status changes: %w", err)
 	}
 	defer rows.Close()
 
-	var contracts []scraper.Contract
 	for rows.Next() {
-		var contract scraper.Contract
-		err := rows.Scan(
-			&contract.ID,
-			&contract.Description,
-			&contract.ContractType,
-			&contract.Status,
-			&contract.Amount,
-			&contract.SubmissionDate,
-			&contract.ContractingBody,
-			&contract.ScrapedAt,
-		)
+		change, err := scanStatusChange(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan contract: %w", err)
+			return fmt.Errorf("failed to scan status change: %w", err)
+		}
+		if err := fn(change); err != nil {
+			return err
 		}
-		contracts = append(contracts, contract)
 	}
 
-	return contracts, nil
-} 
\ No newline at end of file
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read status changes: %w", err)
+	}
+	return nil
+}
+
+// GetContractsWithStatusChanges returns contracts that have recent status changes
+func (s *Storage) GetContractsWithStatusChanges() ([]scraper.Contract, error) {
+	return s.queryContracts("contracts with status changes",
+		`SELECT `+contractColumns+` FROM contracts
+		WHERE uid IN (SELECT contract_uid FROM status_changes WHERE changed_at >= `+s.dialect.daysAgo(1)+`) AND `+notDeleted+`
+		ORDER BY scraped_at DESC`)
+}
\ No newline at end of file
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// ContractStore persists scraped contracts
+type ContractStore interface {
+	SaveContracts(contracts []scraper.Contract) error
+	GetContracts() ([]scraper.Contract, error)
+	GetContractsPage(filter ContractFilter, sort ContractSort, limit, offset int) ([]scraper.Contract, int, error)
+	SearchContracts(filter ContractFilter, limit, offset int) ([]SearchResult, int, error)
+	GetStatusCounts(filter ContractFilter) ([]StatusCount, error)
+	ForEachContract(filter ContractFilter, sort ContractSort, fn func(contract scraper.Contract) error) error
+	GetContractByID(id string) (*scraper.Contract, error)
+	ResolveContractID(ref string) (string, error)
+	FindStoredContract(contract scraper.Contract) (*scraper.Contract, error)
+	GetContractsClosingSoon(within time.Duration) ([]scraper.Contract, error)
+	GetLargestContracts(limit int) ([]scraper.Contract, error)
+	GetNewContracts(contracts []scraper.Contract) ([]scraper.Contract, error)
+	GetContractCount() (int, error)
+	GetStats() (*Stats, error)
+	GetStatusFunnel() ([]StatusCount, error)
+	GetAdjudicationTimes() (*AdjudicationTimes, error)
+	GetContractsFirstSeenSince(t time.Time) ([]scraper.Contract, error)
+	CountContractsFirstSeenSince(t time.Time) (int, error)
+	MarkContractsSeen(contractIDs []string) (int64, error)
+	MarkAllContractsSeen() (int64, error)
+	GetUnseenContracts() ([]scraper.Contract, error)
+	GetContractsWithStatusChanges() ([]scraper.Contract, error)
+	DeleteAllContracts(actor string) error
+	DeleteContract(contractID, actor string) error
+	DeleteContracts(contractIDs []string, actor string) (int64, error)
+	RestoreContract(contractID, actor string) error
+	RestoreAllContracts(actor string) (int64, error)
+	GetDeletedContracts() ([]scraper.Contract, error)
+	PurgeDeletedContracts(olderThan time.Duration, actor string) (int64, error)
+	ArchiveContracts(policy ArchivePolicy) (int64, error)
+	ArchiveContractsByID(contractIDs []string) (int64, error)
+	UnarchiveContract(contractID string) error
+	GetArchivedContracts() ([]scraper.Contract, error)
+	Prune(policy RetentionPolicy, actor string) (PruneResult, error)
+	GetAuditLog(limit int) ([]AuditEntry, error)
+}
+
+// StatusChangeStore detects and records contract status transitions
+type StatusChangeStore interface {
+	CheckAndUpdateChanges(allContracts []scraper.Contract) error
+	GetStatusChanges(contractID string) ([]StatusChange, error)
+	GetRecentStatusChanges() ([]StatusChange, error)
+	GetAllStatusChanges() ([]StatusChange, error)
+	ForEachStatusChange(fn func(change StatusChange) error) error
+	AcknowledgeStatusChange(id int64, actor string) error
+}
+
+// RevisionStore exposes the field-level history of contracts
+type RevisionStore interface {
+	GetContractRevisions(contractID string) ([]ContractRevision, error)
+	GetRecentRevisions() ([]ContractRevision, error)
+	GetAllRevisions() ([]ContractRevision, error)
+	GetWatchedRevisionsSince(t time.Time) ([]ContractRevision, error)
+}
+
+// ScrapeRunStore records the history and health of scraping runs
+type ScrapeRunStore interface {
+	StartScrapeRun(scraperType, profile string) (*ScrapeRun, error)
+	FinishScrapeRun(run *ScrapeRun, runErr error) error
+	GetScrapeRuns(limit int) ([]ScrapeRun, error)
+	GetLastScrapeRun() (*ScrapeRun, error)
+}
+
+// TagStore labels contracts with user-defined tags
+type TagStore interface {
+	AddTag(contractID, tag string) error
+	TagContracts(contractIDs []string, tag string) (int64, error)
+	RemoveTag(contractID, tag string) error
+	GetContractsByTag(tag string) ([]scraper.Contract, error)
+	GetTags() ([]TagCount, error)
+}
+
+// NoteStore keeps internal comments about contracts
+type NoteStore interface {
+	AddNote(contractID, author, body string) (*ContractNote, error)
+	UpdateNote(noteID int64, body string) error
+	DeleteNote(noteID int64) error
+	GetNotes(contractID string) ([]ContractNote, error)
+}
+
+// WatchlistStore tracks the contracts users want priority notifications for
+type WatchlistStore interface {
+	WatchContract(contractID string) error
+	UnwatchContract(contractID string) error
+	GetWatchedContracts() ([]scraper.Contract, error)
+	GetWatchedStatusChangesSince(t time.Time) ([]StatusChange, error)
+	GetUnwatchedStatusChangesSince(t time.Time) ([]StatusChange, error)
+}
+
+// ReminderStore schedules notifications that must survive restarts, such as deadline reminders
+type ReminderStore interface {
+	ScheduleReminder(contractID string, remindAt time.Time, channel string) (*Reminder, error)
+	ScheduleDeadlineReminders(before time.Duration, channel string) (int, error)
+	GetDueReminders(channel string) ([]Reminder, error)
+	MarkRemindersSent(reminders []Reminder) error
+}
+
+// NotificationLogStore records notification deliveries and queues the failed ones for retry
+type NotificationLogStore interface {
+	RecordNotification(target, event string, payload []byte, sendErr error) error
+	GetDueNotifications() ([]NotificationLogEntry, error)
+	GetNotificationLog(limit int) ([]NotificationLogEntry, error)
+	GetNotification(id int64) (*NotificationLogEntry, error)
+	RecordNotificationRetry(entry NotificationLogEntry, sendErr error) error
+	HoldNotification(target, event string, payload []byte) error
+	GetHeldNotifications() ([]NotificationLogEntry, error)
+	ReleaseHeldNotifications(held []NotificationLogEntry, payload []byte, sendErr error) error
+	CountSentNotifications(target string, since time.Time) (int, error)
+	GetNotifiedKeys(keys []string, since time.Time) (map[string]bool, error)
+	RecordNotifiedKeys(keys []string, expireBefore time.Time) error
+}
+
+// ProfileStore manages the saved searches contracts are partitioned by
+type ProfileStore interface {
+	SaveProfile(name, cpvCode string) (*Profile, error)
+	GetProfile(name string) (*Profile, error)
+	GetProfiles() ([]Profile, error)
+	DeleteProfile(name, actor string) (int64, error)
+}
+
+// DocumentStore records the contract documents downloaded to disk
+type DocumentStore interface {
+	SaveDocument(doc *Document) error
+	GetDocuments(contractID string) ([]Document, error)
+	GetLatestDocument(contractID, docType string) (*Document, error)
+}
+
+// RawPageStore keeps the scraped HTML of contract detail pages for reprocessing
+type RawPageStore interface {
+	SaveRawPage(contractID, html string, scrapedAt time.Time) (bool, error)
+	GetRawPages(contractID string) ([]RawPage, error)
+	GetRawPagesBetween(from, to time.Time) ([]RawPage, error)
+	GetRawPage(id int64) (*RawPage, error)
+}
+
+// CPVStore queries the CPV codes listed by contracts
+type CPVStore interface {
+	GetContractsByCPV(codes []string) ([]scraper.Contract, error)
+	GetCPVCodes() ([]CPVCount, error)
+}
+
+// APITokenStore manages the tokens scripts use to call the dashboard API
+type APITokenStore interface {
+	CreateAPIToken(name string) (string, *APIToken, error)
+	GetAPITokens() ([]APIToken, error)
+	RevokeAPIToken(name string) error
+	AuthenticateAPIToken(token string) (*APIToken, error)
+}
+
+// UserSettingStore keeps the preferences of dashboard users, such as their theme
+type UserSettingStore interface {
+	GetUserSetting(user, name string) (string, error)
+	SetUserSetting(user, name, value string) error
+}
+
+// ConfigStore keeps the runtime configuration edited on the dashboard settings page
+type ConfigStore interface {
+	GetConfig() (map[string]string, error)
+	SetConfig(values map[string]string, actor string) error
+}
+
+// ChannelStore keeps the notification channels added on the dashboard settings page
+type ChannelStore interface {
+	GetNotificationChannels() ([]NotificationChannel, error)
+	AddNotificationChannel(channel *NotificationChannel, actor string) error
+	DeleteNotificationChannel(id int64, actor string) error
+}
+
+// Store is the full storage API consumed by the CLI and the dashboard.
+// *Storage implements it for SQLite and MySQL; tests and alternative backends can provide their own.
+type Store interface {
+	ContractStore
+	StatusChangeStore
+	RevisionStore
+	ScrapeRunStore
+	TagStore
+	NoteStore
+	WatchlistStore
+	ReminderStore
+	NotificationLogStore
+	ProfileStore
+	DocumentStore
+	RawPageStore
+	CPVStore
+	APITokenStore
+	UserSettingStore
+	ConfigStore
+	ChannelStore
+	Close() error
+}
+
+var _ Store = (*Storage)(nil)
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"scraper/internal/scraper"
+)
+
+// Suggested tags for the bidding workflow. Any other tag is accepted as well.
+const (
+	TagToBid  = "to bid"
+	TagWon    = "won"
+	TagIgnore = "ignore"
+)
+
+// maxTagLength keeps tags short enough for an indexed key column on every backend
+const maxTagLength = 64
+
+// TagCount is a tag together with the number of contracts carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTag trims and lower-cases a tag so "To Bid" and "to bid" are the same label
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// AddTag labels a contract. Adding a tag the contract already has is not an error.
+func (s *Storage) AddTag(contractID, tag string) error {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return invalidf("tag is required")
+	}
+	if len(tag) > maxTagLength {
+		return invalidf("tag is longer than %d characters", maxTagLength)
+	}
+
+	if err := s.requireContract(contractID); err != nil {
+		return err
+	}
+
+	var tagged int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM contract_tags WHERE contract_uid = `+contractUIDOf+` AND tag = ?`, contractID, tag).Scan(&tagged); err != nil {
+		return fmt.Errorf("failed to check tags of contract %s: %w", contractID, err)
+	}
+	if tagged > 0 {
+		return nil
+	}
+
+	if _, err := s.exec(`INSERT INTO contract_tags (contract_uid, tag) VALUES (`+contractUIDOf+`, ?)`, contractID, tag); err != nil {
+		return fmt.Errorf("failed to tag contract %s: %w", contractID, err)
+	}
+	return nil
+}
+
+// TagContracts labels the given contracts and returns how many were not tagged yet. Unknown and
+// deleted contracts are skipped.
+func (s *Storage) TagContracts(contractIDs []string, tag string) (int64, error) {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return 0, invalidf("tag is required")
+	}
+	if len(tag) > maxTagLength {
+		return 0, invalidf("tag is longer than %d characters", maxTagLength)
+	}
+	if len(contractIDs) == 0 {
+		return 0, nil
+	}
+
+	args := []interface{}{tag}
+	for _, id := range contractIDs {
+		args = append(args, id)
+	}
+	args = append(args, tag)
+
+	query := fmt.Sprintf(`INSERT INTO contract_tags (contract_uid, tag)
+		SELECT uid, ? FROM contracts WHERE id IN (%s) AND %s
+		AND uid NOT IN (SELECT contract_uid FROM contract_tags WHERE tag = ?)`, placeholders(len(contractIDs)), notDeleted)
+	result, err := s.exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to tag contracts: %w", err)
+	}
+
+	tagged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return tagged, nil
+}
+
+// RemoveTag removes a label from a contract
+func (s *Storage) RemoveTag(contractID, tag string) error {
+	tag = NormalizeTag(tag)
+
+	result, err := s.exec(`DELETE FROM contract_tags WHERE contract_uid = `+contractUIDOf+` AND tag = ?`, contractID, tag)
+	if err != nil {
+		return fmt.Errorf("failed to untag contract %s: %w", contractID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFoundf("contract %s is not tagged %q", contractID, tag)
+	}
+	return nil
+}
+
+// GetContractsByTag returns the contracts carrying a tag, archived ones included, most recently scraped first
+func (s *Storage) GetContractsByTag(tag string) ([]scraper.Contract, error) {
+	contracts, _, err := s.GetContractsPage(ContractFilter{Tags: []string{tag}, Archive: ArchiveInclude}, DefaultContractSort, 0, 0)
+	return contracts, err
+}
+
+// GetTags lists every tag in use with its number of contracts, most used first
+func (s *Storage) GetTags() ([]TagCount, error) {
+	query := `
+	SELECT t.tag, COUNT(*) FROM contract_tags t
+	JOIN contracts c ON c.uid = t.contract_uid
+	WHERE c.deleted_at IS NULL
+	GROUP BY t.tag
+	ORDER BY COUNT(*) DESC, t.tag ASC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	return tags, nil
+}
+
+// contractTags returns the tags of every contract by contract uid
+func (s *Storage) contractTags() (map[string][]string, error) {
+	stmt, err := s.prepared(`SELECT contract_uid, tag FROM contract_tags ORDER BY tag`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contract tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var contractUID, tag string
+		if err := rows.Scan(&contractUID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan contract tag: %w", err)
+		}
+		tags[contractUID] = append(tags[contractUID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read contract tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// tagCondition builds a "uid [NOT] IN (contracts with any of the tags)" condition and its arguments
+func tagCondition(operator string, tags []string) (string, []interface{}) {
+	placeholders := make([]string, len(tags))
+	args := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		placeholders[i] = "?"
+		args[i] = NormalizeTag(tag)
+	}
+	return fmt.Sprintf("uid %s (SELECT contract_uid FROM contract_tags WHERE tag IN (%s))", operator, strings.Join(placeholders, ", ")), args
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// apiTokenPrefix starts every API token, so leaked tokens are easy to recognize
+const apiTokenPrefix = "cdt_"
+
+// apiTokenUseInterval is how often the last use of a token is recorded, to avoid a write per request
+const apiTokenUseInterval = time.Minute
+
+// APIToken gives scripts access to the dashboard API. Only a hash of the token is stored; the token
+// itself is shown once, when it is created.
+type APIToken struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"` // First characters of the token, to tell tokens apart
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// hashAPIToken returns the stored hash of a token
+func hashAPIToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateAPIToken creates a token with a unique name and returns the token, which cannot be read back
+func (s *Storage) CreateAPIToken(name string) (string, *APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, invalidf("token name is required")
+	}
+
+	existing, err := s.queryAPITokens(`WHERE name = ?`, name)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existing) > 0 {
+		return "", nil, fmt.Errorf("a token named %s already exists", name)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := apiTokenPrefix + hex.EncodeToString(secret)
+
+	apiToken := &APIToken{
+		Name:      name,
+		Hint:      token[:len(apiTokenPrefix)+6],
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	result, err := s.exec(`INSERT INTO api_tokens (name, token_hash, hint, created_at) VALUES (?, ?, ?, ?)`,
+		apiToken.Name, hashAPIToken(token), apiToken.Hint, apiToken.CreatedAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create token %s: %w", name, err)
+	}
+
+	apiToken.ID, err = result.LastInsertId()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get token id: %w", err)
+	}
+	return token, apiToken, nil
+}
+
+// GetAPITokens lists the API tokens, oldest first
+func (s *Storage) GetAPITokens() ([]APIToken, error) {
+	return s.queryAPITokens(`ORDER BY created_at ASC, id ASC`)
+}
+
+// RevokeAPIToken deletes the token with the given name, so it no longer gives access
+func (s *Storage) RevokeAPIToken(name string) error {
+	result, err := s.exec(`DELETE FROM api_tokens WHERE name = ?`, strings.TrimSpace(name))
+	if err != nil {
+		return fmt.Errorf("failed to revoke token %s: %w", name, err)
+	}
+
+	return requireRowAffected(result, fmt.Sprintf("token %s not found", name))
+}
+
+// AuthenticateAPIToken returns the API token matching token and records its use, or nil if there is none
+func (s *Storage) AuthenticateAPIToken(token string) (*APIToken, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return nil, nil
+	}
+
+	tokens, err := s.queryAPITokens(`WHERE token_hash = ?`, hashAPIToken(token))
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+
+	apiToken := &tokens[0]
+	now := time.Now().UTC().Truncate(time.Second)
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= apiTokenUseInterval {
+		if _, err := s.exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, now, apiToken.ID); err != nil {
+			return nil, fmt.Errorf("failed to record use of token %s: %w", apiToken.Name, err)
+		}
+		apiToken.LastUsedAt = &now
+	}
+	return apiToken, nil
+}
+
+// queryAPITokens returns the API tokens matching a WHERE/ORDER BY clause
+func (s *Storage) queryAPITokens(where string, args ...interface{}) ([]APIToken, error) {
+	rows, err := s.db.Query(`SELECT id, name, hint, created_at, last_used_at FROM api_tokens `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []APIToken
+	for rows.Next() {
+		var token APIToken
+		var lastUsedAt sql.NullTime
+		if err := rows.Scan(&token.ID, &token.Name, &token.Hint, &token.CreatedAt, &lastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		if lastUsedAt.Valid {
+			token.LastUsedAt = &lastUsedAt.Time
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read API tokens: %w", err)
+	}
+	return tokens, nil
+}
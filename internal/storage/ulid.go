@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Contracts, status changes and revisions carry a ULID in their uid column: a stable internal key,
+// independent of the expediente-based contract id, that API URLs can use so normalizing the
+// human-readable id later does not break links. ULIDs sort by creation time. Status changes,
+// revisions, tags, notes and the other tables about a contract refer to it by its uid too.
+
+// ulidAlphabet is Crockford's base32, the ULID encoding
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLength is the length of an encoded ULID
+const ulidLength = 26
+
+// newULID returns a ULID for an entity created at t: 48 bits of milliseconds followed by 80 random bits
+func newULID(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+
+	// 128 bits in 26 characters of 5 bits, the first one carrying only 3
+	var out [ulidLength]byte
+	var acc uint32
+	bits := 2
+	pos := 0
+	for _, b := range id {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = ulidAlphabet[(acc>>uint(bits))&31]
+			pos++
+		}
+	}
+	return string(out[:])
+}
+
+// isULID reports whether value looks like a ULID, as opposed to an expediente-based contract id
+func isULID(value string) bool {
+	if len(value) != ulidLength {
+		return false
+	}
+	for _, c := range strings.ToUpper(value) {
+		if !strings.ContainsRune(ulidAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveContractID returns the id of the contract referenced by ref, which is either its uid or its
+// id. Unknown references are returned unchanged so the caller reports the contract as not found.
+func (s *Storage) ResolveContractID(ref string) (string, error) {
+	if !isULID(ref) {
+		return ref, nil
+	}
+
+	stmt, err := s.prepared(`SELECT id FROM contracts WHERE uid = ?`)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = stmt.QueryRow(strings.ToUpper(ref)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ref, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve contract %s: %w", ref, err)
+	}
+	return id, nil
+}
+
+// backfillUIDs gives every existing contract, status change and revision a ULID based on when it was created
+func backfillUIDs(tx *sql.Tx) error {
+	tables := []struct{ table, key, created string }{
+		{"contracts", "id", "first_seen_at"},
+		{"status_changes", "id", "changed_at"},
+		{"contract_revisions", "id", "changed_at"},
+	}
+
+	for _, t := range tables {
+		rows, err := tx.Query(fmt.Sprintf(`SELECT %s, %s FROM %s WHERE uid IS NULL`, t.key, t.created, t.table))
+		if err != nil {
+			return fmt.Errorf("failed to query %s: %w", t.table, err)
+		}
+
+		uids := make(map[string]string)
+		for rows.Next() {
+			var key string
+			var created sql.NullTime
+			if err := rows.Scan(&key, &created); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s: %w", t.table, err)
+			}
+			if !created.Valid {
+				created.Time = time.Now()
+			}
+			uids[key] = newULID(created.Time)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", t.table, err)
+		}
+
+		for key, uid := range uids {
+			if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET uid = ? WHERE %s = ?`, t.table, t.key), uid, key); err != nil {
+				return fmt.Errorf("failed to backfill uid of %s %s: %w", t.table, key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"scraper/internal/scraper"
+)
+
+// WatchContract puts a contract on the watchlist. Watching an already watched contract is not an error.
+func (s *Storage) WatchContract(contractID string) error {
+	if err := s.requireContract(contractID); err != nil {
+		return err
+	}
+
+	var watched int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM watchlist WHERE contract_uid = `+contractUIDOf, contractID).Scan(&watched); err != nil {
+		return fmt.Errorf("failed to check watchlist: %w", err)
+	}
+	if watched > 0 {
+		return nil
+	}
+
+	if _, err := s.exec(`INSERT INTO watchlist (contract_uid) VALUES (`+contractUIDOf+`)`, contractID); err != nil {
+		return fmt.Errorf("failed to watch contract %s: %w", contractID, err)
+	}
+	return nil
+}
+
+// UnwatchContract removes a contract from the watchlist
+func (s *Storage) UnwatchContract(contractID string) error {
+	result, err := s.exec(`DELETE FROM watchlist WHERE contract_uid = `+contractUIDOf, contractID)
+	if err != nil {
+		return fmt.Errorf("failed to unwatch contract %s: %w", contractID, err)
+	}
+
+	return requireRowAffected(result, fmt.Sprintf("contract %s is not watched", contractID))
+}
+
+// GetWatchedContracts retrieves the watched contracts, archived ones included, most recently scraped first
+func (s *Storage) GetWatchedContracts() ([]scraper.Contract, error) {
+	return s.queryContracts("watched contracts",
+		`SELECT `+contractColumns+` FROM contracts WHERE `+notDeleted+` AND uid IN (SELECT contract_uid FROM watchlist) ORDER BY scraped_at DESC`)
+}
+
+// GetWatchedStatusChangesSince retrieves the status changes of watched contracts recorded at or after t, oldest first
+func (s *Storage) GetWatchedStatusChangesSince(t time.Time) ([]StatusChange, error) {
+	return s.queryStatusChanges("watched status changes",
+		`SELECT `+statusChangeColumns+` FROM status_changes
+		WHERE changed_at >= ? AND contract_uid IN (SELECT contract_uid FROM watchlist)
+		ORDER BY changed_at ASC, id ASC`,
+		timestampParam(t))
+}
+
+// GetUnwatchedStatusChangesSince retrieves the status changes of contracts that are not watched
+// recorded at or after t, oldest first
+func (s *Storage) GetUnwatchedStatusChangesSince(t time.Time) ([]StatusChange, error) {
+	return s.queryStatusChanges("unwatched status changes",
+		`SELECT `+statusChangeColumns+` FROM status_changes
+		WHERE changed_at >= ? AND contract_uid NOT IN (SELECT contract_uid FROM watchlist)
+		ORDER BY changed_at ASC, id ASC`,
+		timestampParam(t))
+}
+
+// watchedContracts returns the uids of the watched contracts
+func (s *Storage) watchedContracts() (map[string]bool, error) {
+	stmt, err := s.prepared(`SELECT contract_uid FROM watchlist`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	watched := make(map[string]bool)
+	for rows.Next() {
+		var contractUID string
+		if err := rows.Scan(&contractUID); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist: %w", err)
+		}
+		watched[contractUID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read watchlist: %w", err)
+	}
+
+	return watched, nil
+}